@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"dokusho/pkg/client"
+	"dokusho/pkg/config"
+	"dokusho/pkg/database"
+	"dokusho/pkg/http_router"
+	"dokusho/pkg/library"
+	"dokusho/pkg/stats"
+	"dokusho/pkg/trackers"
+	"dokusho/pkg/trackers/tracker_sync"
+
+	"github.com/riverqueue/river"
+)
+
+func main() {
+	cfg, err := config.NewBackendConfig()
+	if err != nil {
+		slog.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	// Workers need the store, which needs the pool, so they are registered on a bundle filled once connected
+	workers := river.NewWorkers()
+	queues := map[string]river.QueueConfig{
+		river.QueueDefault:         {MaxWorkers: 10},
+		tracker_sync.QueueTrackers: {MaxWorkers: 2},
+	}
+
+	pgpool, riverClient, err := database.Connect(*cfg.DatabaseBaseConfig, workers, queues)
+	if err != nil {
+		slog.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer pgpool.Close()
+
+	registry := tracker_sync.NewRegistry(*cfg.TrackerBaseConfig, cfg.BackendAPIURL)
+	tracker_sync.AddWorkers(workers, trackers.NewStore(pgpool), registry)
+	stats.AddWorkers(workers, stats.NewStore(pgpool))
+	stats.RefreshPeriodically(riverClient.PeriodicJobs(), cfg.StatsRefreshInterval)
+
+	sourceClient, err := client.NewHTTPSourceAPIClient(cfg.SourceAPIURL, 0)
+	if err != nil {
+		slog.Error("Invalid source API URL", "error", err)
+		os.Exit(1)
+	}
+	sourceClient.APIKey = cfg.SourceAPIKey
+
+	library.AddWorkers(workers, library.NewStore(pgpool), sourceClient)
+	library.RefreshPeriodically(riverClient.PeriodicJobs(), cfg.ChapterRefreshInterval)
+
+	err = riverClient.Start(context.Background())
+	if err != nil {
+		slog.Error("Failed to start job client", "error", err)
+		os.Exit(1)
+	}
+	defer riverClient.Stop(context.Background())
+
+	backendRouter := http_router.NewBackendRouter(cfg, pgpool, riverClient, registry)
+	mux := backendRouter.SetupMux()
+
+	slog.Info("Starting server", "url", cfg.BackendAPIURL)
+	err = http.ListenAndServe(fmt.Sprintf("%s:%s", cfg.ListenAddr, cfg.Port), mux)
+	if err != nil {
+		slog.Error("Server stopped", "error", err)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"dokusho/pkg/config"
+	"dokusho/pkg/http_router"
+	"dokusho/pkg/http_utils"
+)
+
+func main() {
+	cfg, err := config.NewFileConfig()
+	if err != nil {
+		slog.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+
+	fileRouter := http_router.NewFileRouter(*cfg.FileBaseConfig)
+	mux := fileRouter.SetupMux(http.NewServeMux())
+	handler := http_utils.WhitelistedReverseProxy(cfg.UseWhitelistedReverseProxy, cfg.WhitelistedReverseProxyAddr...)(mux)
+
+	slog.Info("Starting server", "url", cfg.FileServeURL)
+	err = http.ListenAndServe(fmt.Sprintf("%s:%s", cfg.ListenAddr, cfg.Port), handler)
+	if err != nil {
+		slog.Error("Server stopped", "error", err)
+	}
+}
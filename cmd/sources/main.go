@@ -26,7 +26,13 @@ func main() {
 	}
 	slog.Info("Flaresolver is healthy", "url", cfg.SourceFlaresolverURL)
 
-	sourceRouter := http_router.NewSourceRouter(sources.BuildSources(cfg.SourceBaseConfig), cfg)
+	registry, err := sources.BuildSources(cfg.SourceBaseConfig)
+	if err != nil {
+		slog.Error("Failed to build sources", "error", err)
+		os.Exit(1)
+	}
+
+	sourceRouter := http_router.NewSourceRouter(registry, cfg)
 	mux := sourceRouter.SetupMux()
 
 	slog.Info("Starting server", "url", cfg.SourceAPIURL)
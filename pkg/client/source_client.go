@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
@@ -16,7 +17,9 @@ import (
 )
 
 type HTTPSourceAPIClient struct {
-	BaseURL    *url.URL
+	BaseURL *url.URL
+	// Sent as X-API-KEY when the source API requires it
+	APIKey     string
 	httpClient *http.Client
 	logger     *slog.Logger
 }
@@ -41,17 +44,34 @@ func NewHTTPSourceAPIClient(baseURL string, timeout time.Duration) (*HTTPSourceA
 	}, nil
 }
 
+// do sends the request, a non 2xx response is returned as an error
+func (s *HTTPSourceAPIClient) do(req *http.Request) (*http.Response, error) {
+	if s.APIKey != "" {
+		req.Header.Set("X-API-KEY", s.APIKey)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("source api responded %d to %s", resp.StatusCode, req.URL.Path)
+	}
+
+	return resp, nil
+}
+
 func (s *HTTPSourceAPIClient) GetSources(ctx context.Context) ([]source_types.SourceInformation, error) {
 	url := s.BaseURL.JoinPath("/api/v1/sources")
 
-	req, err := http.NewRequest("GET", url.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url.String(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.WithContext(ctx)
-
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -75,14 +95,12 @@ func (s *HTTPSourceAPIClient) GetSources(ctx context.Context) ([]source_types.So
 func (s *HTTPSourceAPIClient) GetSource(ctx context.Context, sourceID source_types.SourceID) (source_types.SourceInformation, error) {
 	url := s.BaseURL.JoinPath("/api/v1/sources", string(sourceID))
 
-	req, err := http.NewRequest("GET", url.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url.String(), nil)
 	if err != nil {
 		return source_types.SourceInformation{}, err
 	}
 
-	req.WithContext(ctx)
-
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return source_types.SourceInformation{}, err
 	}
@@ -110,14 +128,12 @@ func (s *HTTPSourceAPIClient) FetchPopularSeries(ctx context.Context, sourceID s
 	q.Set("page", strconv.Itoa(page))
 	url.RawQuery = q.Encode()
 
-	req, err := http.NewRequest("GET", url.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url.String(), nil)
 	if err != nil {
 		return source_types.SourcePaginatedSmallSerie{}, err
 	}
 
-	req.WithContext(ctx)
-
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return source_types.SourcePaginatedSmallSerie{}, err
 	}
@@ -145,14 +161,12 @@ func (s *HTTPSourceAPIClient) FetchLatestSeries(ctx context.Context, sourceID so
 	q.Set("page", strconv.Itoa(page))
 	url.RawQuery = q.Encode()
 
-	req, err := http.NewRequest("GET", url.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url.String(), nil)
 	if err != nil {
 		return source_types.SourcePaginatedSmallSerie{}, err
 	}
 
-	req.WithContext(ctx)
-
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return source_types.SourcePaginatedSmallSerie{}, err
 	}
@@ -211,14 +225,12 @@ func (s *HTTPSourceAPIClient) FetchSearchSeries(ctx context.Context, sourceID so
 
 	url.RawQuery = q.Encode()
 
-	req, err := http.NewRequest("GET", url.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url.String(), nil)
 	if err != nil {
 		return source_types.SourcePaginatedSmallSerie{}, err
 	}
 
-	req.WithContext(ctx)
-
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return source_types.SourcePaginatedSmallSerie{}, err
 	}
@@ -242,14 +254,12 @@ func (s *HTTPSourceAPIClient) FetchSearchSeries(ctx context.Context, sourceID so
 func (s *HTTPSourceAPIClient) FetchSerieInformation(ctx context.Context, sourceID source_types.SourceID, serieID source_types.SourceSerieID) (source_types.SourceSerie, error) {
 	url := s.BaseURL.JoinPath("/api/v1/sources", string(sourceID), "series", string(serieID))
 
-	req, err := http.NewRequest("GET", url.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url.String(), nil)
 	if err != nil {
 		return source_types.SourceSerie{}, err
 	}
 
-	req.WithContext(ctx)
-
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return source_types.SourceSerie{}, err
 	}
@@ -273,14 +283,12 @@ func (s *HTTPSourceAPIClient) FetchSerieInformation(ctx context.Context, sourceI
 func (s *HTTPSourceAPIClient) FetchSerieSourceUrl(ctx context.Context, sourceID source_types.SourceID, serieID source_types.SourceSerieID) (string, error) {
 	url := s.BaseURL.JoinPath("/api/v1/sources", string(sourceID), "series", string(serieID), "source_url")
 
-	req, err := http.NewRequest("GET", url.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url.String(), nil)
 	if err != nil {
 		return "", err
 	}
 
-	req.WithContext(ctx)
-
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return "", err
 	}
@@ -304,14 +312,12 @@ func (s *HTTPSourceAPIClient) FetchSerieSourceUrl(ctx context.Context, sourceID
 func (s *HTTPSourceAPIClient) FetchSerieChapters(ctx context.Context, sourceID source_types.SourceID, serieID source_types.SourceSerieID, volumeID source_types.SourceSerieVolumeID, chapterID source_types.SourceSerieVolumeChapterID) (source_types.SourceSerieVolumeChapterData, error) {
 	url := s.BaseURL.JoinPath("/api/v1/sources", string(sourceID), "series", string(serieID), string(volumeID), string(chapterID))
 
-	req, err := http.NewRequest("GET", url.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url.String(), nil)
 	if err != nil {
 		return source_types.SourceSerieVolumeChapterData{}, err
 	}
 
-	req.WithContext(ctx)
-
-	resp, err := s.httpClient.Do(req)
+	resp, err := s.do(req)
 	if err != nil {
 		return source_types.SourceSerieVolumeChapterData{}, err
 	}
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"os"
 	"strconv"
+	"time"
 
 	"dokusho/pkg/utils"
 
@@ -28,10 +29,36 @@ var SOURCE_FLARESOLVER_URL = utils.Getenv("SOURCE_FLARESOLVER_URL", "")
 var SOURCE_USE_MOCK = utils.Getenv("SOURCE_USE_MOCK", "true") == "true"
 var SOURCE_USE_API_KEY = utils.Getenv("SOURCE_USE_API_KEY", "false") == "true"
 var SOURCE_API_KEY = utils.Getenv("SOURCE_API_KEY", "")
+var SOURCE_URL_SIGNING_KEY = utils.Getenv("SOURCE_URL_SIGNING_KEY", "")
+var SOURCE_SIGNED_URL_TTL = utils.Getenv("SOURCE_SIGNED_URL_TTL", "6h")
+var SOURCE_SETTINGS_FILE = utils.Getenv("SOURCE_SETTINGS_FILE", "")
+var SOURCE_DEFINITIONS_DIR = utils.Getenv("SOURCE_DEFINITIONS_DIR", "")
+var SOURCE_WASM_DIR = utils.Getenv("SOURCE_WASM_DIR", "")
+var SOURCE_JS_DIR = utils.Getenv("SOURCE_JS_DIR", "")
+var SOURCE_KOMGA_URL = utils.Getenv("SOURCE_KOMGA_URL", "")
+var SOURCE_KOMGA_LIBRARIES = utils.Getenv("SOURCE_KOMGA_LIBRARIES", "")
+var SOURCE_ALLOW_NSFW = utils.Getenv("SOURCE_ALLOW_NSFW", "true") == "true"
 
 var FILE_SERVE_URL = utils.Getenv("FILE_SERVE_URL", fmt.Sprintf("http://%s:%s", LISTEN_ADDR, PORT))
 var FILE_SERVE_MOCK = utils.Getenv("FILE_SERVE_MOCK", "false") == "true"
 var FILE_ROOT_DIR = utils.Getenv("FILE_ROOT_DIR", "/mnt/dokusho")
+var FILE_REQUIRE_SIGNED_URL = utils.Getenv("FILE_REQUIRE_SIGNED_URL", "false") == "true"
+var FILE_URL_SIGNING_KEY = utils.Getenv("FILE_URL_SIGNING_KEY", "")
+var FILE_SIGNED_URL_TTL = utils.Getenv("FILE_SIGNED_URL_TTL", "6h")
+var FILE_USE_API_KEY = utils.Getenv("FILE_USE_API_KEY", "false") == "true"
+var FILE_API_KEY = utils.Getenv("FILE_API_KEY", "")
+
+var BACKEND_API_URL = utils.Getenv("BACKEND_API_URL", fmt.Sprintf("http://%s:%s", LISTEN_ADDR, PORT))
+var BACKEND_USE_API_KEY = utils.Getenv("BACKEND_USE_API_KEY", "true") == "true"
+var BACKEND_API_KEY = utils.Getenv("BACKEND_API_KEY", "")
+
+var STATS_REFRESH_INTERVAL = utils.Getenv("STATS_REFRESH_INTERVAL", "15m")
+var CHAPTER_REFRESH_INTERVAL = utils.Getenv("CHAPTER_REFRESH_INTERVAL", "24h")
+
+var TRACKER_ANILIST_CLIENT_ID = utils.Getenv("TRACKER_ANILIST_CLIENT_ID", "")
+var TRACKER_ANILIST_CLIENT_SECRET = utils.Getenv("TRACKER_ANILIST_CLIENT_SECRET", "")
+var TRACKER_MYANIMELIST_CLIENT_ID = utils.Getenv("TRACKER_MYANIMELIST_CLIENT_ID", "")
+var TRACKER_MYANIMELIST_CLIENT_SECRET = utils.Getenv("TRACKER_MYANIMELIST_CLIENT_SECRET", "")
 
 func init() {
 	slog.SetLogLoggerLevel(utils.NewLogLevel(LOG_LEVEL).SlogLevel())
@@ -46,9 +73,15 @@ type HTTPServerBaseConfig struct {
 }
 
 type FileBaseConfig struct {
-	FileServeURL  string
-	FileServeMock bool
-	FileRootDir   string
+	FileServeURL         string
+	FileServeMock        bool
+	FileRootDir          string
+	FileRequireSignedURL bool
+	FileURLSigningKey    string
+	FileSignedURLTTL     time.Duration
+	// The chapter manifests hand out signed urls, so they require the API key
+	FileUseAPIKey bool
+	FileAPIKey    string
 }
 
 type SourceBaseConfig struct {
@@ -58,6 +91,22 @@ type SourceBaseConfig struct {
 	SourceUseMock        bool
 	SourceUseAPIKey      bool
 	SourceAPIKey         string
+	SourceURLSigningKey  string
+	SourceSignedURLTTL   time.Duration
+	// JSON file with the per source settings, see sources.LoadSettings
+	SourceSettingsFile string
+	// Directory of the JSON and YAML declarative source definitions
+	SourceDefinitionsDir string
+	// Directory of the wasm source modules and their JSON manifests
+	SourceWasmDir string
+	// Directory of the javascript source extensions
+	SourceJSDir string
+	// URL of a self-hosted Komga server, the source is disabled when empty
+	SourceKomgaURL string
+	// IDs of the Komga libraries the source is restricted to
+	SourceKomgaLibraries []string
+	// NSFW sources and the NSFW content ratings are refused when false
+	SourceAllowNSFW bool
 }
 
 type DatabaseBaseConfig struct {
@@ -66,6 +115,33 @@ type DatabaseBaseConfig struct {
 	DatabaseApplyMigrations bool
 }
 
+type TrackerBaseConfig struct {
+	AniListClientID         string
+	AniListClientSecret     string
+	MyAnimeListClientID     string
+	MyAnimeListClientSecret string
+}
+
+type BackendConfig struct {
+	*HTTPServerBaseConfig
+	*DatabaseBaseConfig
+	*TrackerBaseConfig
+	BackendAPIURL string
+	// The user routes are keyed by a user ID trusted from the path, they require the API key
+	BackendUseAPIKey     bool
+	BackendAPIKey        string
+	StatsRefreshInterval time.Duration
+	// Sources API the chapters of the library series are fetched from
+	SourceAPIURL           string
+	SourceAPIKey           string
+	ChapterRefreshInterval time.Duration
+}
+
+type FileConfig struct {
+	*HTTPServerBaseConfig
+	*FileBaseConfig
+}
+
 type SourceConfig struct {
 	*HTTPServerBaseConfig
 	*SourceBaseConfig
@@ -83,6 +159,14 @@ func NewSourceConfig() (*SourceConfig, error) {
 	// TODO: Need convert dns address to ip address, usefull to allow only selected container from inside a compose network
 	whitelistedAddr := utils.SplitAndTrim(WHITELIST_REVERSE_PROXY_ADDR, ",")
 
+	// Already validated
+	signedURLTTL, _ := time.ParseDuration(SOURCE_SIGNED_URL_TTL)
+
+	var komgaLibraries []string
+	if SOURCE_KOMGA_LIBRARIES != "" {
+		komgaLibraries = utils.SplitAndTrim(SOURCE_KOMGA_LIBRARIES, ",")
+	}
+
 	return &SourceConfig{
 		HTTPServerBaseConfig: &HTTPServerBaseConfig{
 			Port:                        PORT,
@@ -98,10 +182,108 @@ func NewSourceConfig() (*SourceConfig, error) {
 			SourceUseMock:        SOURCE_USE_MOCK,
 			SourceUseAPIKey:      SOURCE_USE_API_KEY,
 			SourceAPIKey:         SOURCE_API_KEY,
+			SourceURLSigningKey:  SOURCE_URL_SIGNING_KEY,
+			SourceSignedURLTTL:   signedURLTTL,
+			SourceSettingsFile:   SOURCE_SETTINGS_FILE,
+			SourceDefinitionsDir: SOURCE_DEFINITIONS_DIR,
+			SourceWasmDir:        SOURCE_WASM_DIR,
+			SourceJSDir:          SOURCE_JS_DIR,
+			SourceKomgaURL:       SOURCE_KOMGA_URL,
+			SourceKomgaLibraries: komgaLibraries,
+			SourceAllowNSFW:      SOURCE_ALLOW_NSFW,
+		},
+	}, nil
+}
+
+func NewFileConfig() (*FileConfig, error) {
+	bce := validateHttpServerBaseConfig()
+	fce := validateFileServerConfig()
+
+	err := errors.Join(bce, fce)
+	if err != nil {
+		return nil, err
+	}
+
+	whitelistedAddr := utils.SplitAndTrim(WHITELIST_REVERSE_PROXY_ADDR, ",")
+
+	// Already validated
+	signedURLTTL, _ := time.ParseDuration(FILE_SIGNED_URL_TTL)
+
+	return &FileConfig{
+		HTTPServerBaseConfig: &HTTPServerBaseConfig{
+			Port:                        PORT,
+			ListenAddr:                  LISTEN_ADDR,
+			LogLevel:                    LOG_LEVEL,
+			UseWhitelistedReverseProxy:  USE_WHITELIST_REVERSE_PROXY,
+			WhitelistedReverseProxyAddr: whitelistedAddr,
+		},
+		FileBaseConfig: &FileBaseConfig{
+			FileServeURL:         FILE_SERVE_URL,
+			FileServeMock:        FILE_SERVE_MOCK,
+			FileRootDir:          FILE_ROOT_DIR,
+			FileRequireSignedURL: FILE_REQUIRE_SIGNED_URL,
+			FileURLSigningKey:    FILE_URL_SIGNING_KEY,
+			FileSignedURLTTL:     signedURLTTL,
+			FileUseAPIKey:        FILE_USE_API_KEY,
+			FileAPIKey:           FILE_API_KEY,
 		},
 	}, nil
 }
 
+func NewBackendConfig() (*BackendConfig, error) {
+	bce := validateHttpServerBaseConfig()
+	dce := validateDatabaseConfig()
+	tce := validateTrackerConfig()
+	ace := validateBackendAPIKeyConfig()
+
+	var sce error
+	statsRefreshInterval, err := time.ParseDuration(STATS_REFRESH_INTERVAL)
+	if err != nil || statsRefreshInterval < time.Minute {
+		sce = fmt.Errorf("STATS_REFRESH_INTERVAL must be a duration of at least 1m")
+	}
+
+	var cce error
+	chapterRefreshInterval, err := time.ParseDuration(CHAPTER_REFRESH_INTERVAL)
+	if err != nil || chapterRefreshInterval < time.Hour {
+		cce = fmt.Errorf("CHAPTER_REFRESH_INTERVAL must be a duration of at least 1h")
+	}
+
+	err = errors.Join(bce, dce, tce, ace, sce, cce)
+	if err != nil {
+		return nil, err
+	}
+
+	whitelistedAddr := utils.SplitAndTrim(WHITELIST_REVERSE_PROXY_ADDR, ",")
+
+	return &BackendConfig{
+		HTTPServerBaseConfig: &HTTPServerBaseConfig{
+			Port:                        PORT,
+			ListenAddr:                  LISTEN_ADDR,
+			LogLevel:                    LOG_LEVEL,
+			UseWhitelistedReverseProxy:  USE_WHITELIST_REVERSE_PROXY,
+			WhitelistedReverseProxyAddr: whitelistedAddr,
+		},
+		DatabaseBaseConfig: &DatabaseBaseConfig{
+			DatabaseAppURL:          DATABASE_APP_URL,
+			DatabaseJobsURL:         DATABASE_JOBS_URL,
+			DatabaseApplyMigrations: DATABASE_APPLY_MIGRATIONS,
+		},
+		TrackerBaseConfig: &TrackerBaseConfig{
+			AniListClientID:         TRACKER_ANILIST_CLIENT_ID,
+			AniListClientSecret:     TRACKER_ANILIST_CLIENT_SECRET,
+			MyAnimeListClientID:     TRACKER_MYANIMELIST_CLIENT_ID,
+			MyAnimeListClientSecret: TRACKER_MYANIMELIST_CLIENT_SECRET,
+		},
+		BackendAPIURL:          BACKEND_API_URL,
+		BackendUseAPIKey:       BACKEND_USE_API_KEY,
+		BackendAPIKey:          BACKEND_API_KEY,
+		StatsRefreshInterval:   statsRefreshInterval,
+		SourceAPIURL:           SOURCE_API_URL,
+		SourceAPIKey:           SOURCE_API_KEY,
+		ChapterRefreshInterval: chapterRefreshInterval,
+	}, nil
+}
+
 func validateFileServerConfig() error {
 	if FILE_ROOT_DIR == "" {
 		return fmt.Errorf("FILE_ROOT_DIR is required")
@@ -114,6 +296,25 @@ func validateFileServerConfig() error {
 		slog.Info("File root dir created, or already existing", "root_dir", FILE_ROOT_DIR)
 	}
 
+	if _, err := time.ParseDuration(FILE_SIGNED_URL_TTL); err != nil {
+		return fmt.Errorf("FILE_SIGNED_URL_TTL must be a valid duration: %w", err)
+	}
+
+	if FILE_REQUIRE_SIGNED_URL && FILE_URL_SIGNING_KEY == "" {
+		return fmt.Errorf("FILE_URL_SIGNING_KEY is required when FILE_REQUIRE_SIGNED_URL is true")
+	}
+
+	// Anyone could get signed page urls from the manifest otherwise
+	if FILE_REQUIRE_SIGNED_URL && !FILE_USE_API_KEY {
+		return fmt.Errorf("FILE_USE_API_KEY must be true when FILE_REQUIRE_SIGNED_URL is true")
+	}
+
+	if FILE_USE_API_KEY && FILE_API_KEY == "" {
+		slog.Warn("FILE_API_KEY is required when FILE_USE_API_KEY is true")
+		FILE_API_KEY = uuid.NewString()
+		slog.Info("Generated new FILE_API_KEY, you must set one or it will generated at every restart", "file_api_key", FILE_API_KEY)
+	}
+
 	return nil
 }
 
@@ -122,12 +323,39 @@ func validateSourceServerConfig() error {
 		return fmt.Errorf("SOURCE_FLARESOLVER_URL is required when SOURCE_USE_FLARESOLVER is true")
 	}
 
-	if SOURCE_USE_API_KEY && SOURCE_API_KEY == "" {
-		slog.Warn("SOURCE_API_KEY is required when SOURCE_USE_API_KEY is true")
+	// Enabling and disabling sources always requires the API key
+	if SOURCE_API_KEY == "" {
+		if SOURCE_USE_API_KEY {
+			slog.Warn("SOURCE_API_KEY is required when SOURCE_USE_API_KEY is true")
+		}
 		SOURCE_API_KEY = uuid.NewString()
 		slog.Info("Generated new SOURCE_API_KEY, you must set one or it will generated at every restart", "source_api_key", SOURCE_API_KEY)
 	}
 
+	if _, err := time.ParseDuration(SOURCE_SIGNED_URL_TTL); err != nil {
+		return fmt.Errorf("SOURCE_SIGNED_URL_TTL must be a valid duration: %w", err)
+	}
+
+	if SOURCE_URL_SIGNING_KEY == "" {
+		SOURCE_URL_SIGNING_KEY = uuid.NewString()
+		slog.Info("Generated new SOURCE_URL_SIGNING_KEY, signed urls will be invalidated at every restart unless you set one")
+	}
+
+	return nil
+}
+
+func validateBackendAPIKeyConfig() error {
+	if !BACKEND_USE_API_KEY {
+		slog.Warn("BACKEND_USE_API_KEY is false, anyone reaching the backend can read and write the data of every user")
+		return nil
+	}
+
+	if BACKEND_API_KEY == "" {
+		slog.Warn("BACKEND_API_KEY is required when BACKEND_USE_API_KEY is true")
+		BACKEND_API_KEY = uuid.NewString()
+		slog.Info("Generated new BACKEND_API_KEY, you must set one or it will generated at every restart", "backend_api_key", BACKEND_API_KEY)
+	}
+
 	return nil
 }
 
@@ -143,6 +371,22 @@ func validateDatabaseConfig() error {
 	return nil
 }
 
+func validateTrackerConfig() error {
+	if TRACKER_ANILIST_CLIENT_ID != "" && TRACKER_ANILIST_CLIENT_SECRET == "" {
+		return fmt.Errorf("TRACKER_ANILIST_CLIENT_SECRET is required when TRACKER_ANILIST_CLIENT_ID is set")
+	}
+
+	if TRACKER_ANILIST_CLIENT_ID == "" {
+		slog.Info("TRACKER_ANILIST_CLIENT_ID is not set, AniList sync is disabled")
+	}
+
+	if TRACKER_MYANIMELIST_CLIENT_ID == "" {
+		slog.Info("TRACKER_MYANIMELIST_CLIENT_ID is not set, MyAnimeList sync is disabled")
+	}
+
+	return nil
+}
+
 func validateHttpServerBaseConfig() error {
 	// Check if port is a valid number
 	if _, err := strconv.Atoi(PORT); err != nil {
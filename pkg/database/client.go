@@ -14,7 +14,8 @@ import (
 	"github.com/riverqueue/river/rivermigrate"
 )
 
-func Connect(cfg config.DatabaseBaseConfig) (*pgxpool.Pool, *river.Client[pgx.Tx], error) {
+// Connect opens the app and jobs pools, the river client only works jobs when workers and queues are given
+func Connect(cfg config.DatabaseBaseConfig, workers *river.Workers, queues map[string]river.QueueConfig) (*pgxpool.Pool, *river.Client[pgx.Tx], error) {
 	DBPool, err := pgxpool.New(context.Background(), cfg.DatabaseAppURL)
 	if err != nil {
 		return nil, nil, fmt.Errorf("Error opening database connection: %w", err)
@@ -53,7 +54,13 @@ func Connect(cfg config.DatabaseBaseConfig) (*pgxpool.Pool, *river.Client[pgx.Tx
 	}
 
 	driver := riverpgxv5.New(jobpool)
-	riverDBClient, err := river.NewClient(driver, &river.Config{})
+	riverDBClient, err := river.NewClient(driver, &river.Config{
+		Workers: workers,
+		Queues:  queues,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error creating river client: %w", err)
+	}
 
 	if cfg.DatabaseApplyMigrations {
 		migrator, err := rivermigrate.New(driver, nil)
@@ -0,0 +1,8 @@
+CREATE TABLE library_series (
+	id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+	title text NOT NULL,
+	source_id text NOT NULL,
+	serie_id text NOT NULL,
+	created_at timestamptz NOT NULL DEFAULT now(),
+	CONSTRAINT unique_source_serie UNIQUE (source_id, serie_id)
+);
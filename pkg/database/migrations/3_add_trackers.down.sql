@@ -0,0 +1,2 @@
+DROP TABLE tracker_links;
+DROP TABLE tracker_accounts;
@@ -0,0 +1,19 @@
+CREATE TABLE tracker_accounts (
+	tracker text PRIMARY KEY,
+	access_token text NOT NULL,
+	refresh_token text NOT NULL DEFAULT '',
+	expires_at timestamptz,
+	updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE tracker_links (
+	library_serie_id uuid NOT NULL REFERENCES library_series (id) ON DELETE CASCADE,
+	tracker text NOT NULL,
+	media_id text NOT NULL,
+	status text NOT NULL DEFAULT '',
+	progress integer NOT NULL DEFAULT 0,
+	progress_volumes integer NOT NULL DEFAULT 0,
+	synced_at timestamptz,
+	last_error text NOT NULL DEFAULT '',
+	PRIMARY KEY (library_serie_id, tracker)
+);
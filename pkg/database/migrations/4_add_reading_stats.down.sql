@@ -0,0 +1,8 @@
+DROP MATERIALIZED VIEW read_stats_series;
+DROP MATERIALIZED VIEW read_stats_hourly;
+DROP TABLE read_events;
+
+ALTER TABLE library_series
+	DROP COLUMN genres,
+	DROP COLUMN authors,
+	DROP COLUMN chapter_count;
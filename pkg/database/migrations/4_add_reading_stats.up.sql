@@ -0,0 +1,45 @@
+ALTER TABLE library_series
+	ADD COLUMN genres text[] NOT NULL DEFAULT '{}',
+	ADD COLUMN authors text[] NOT NULL DEFAULT '{}',
+	ADD COLUMN chapter_count integer NOT NULL DEFAULT 0;
+
+CREATE TABLE read_events (
+	id bigserial PRIMARY KEY,
+	user_id text NOT NULL,
+	library_serie_id uuid NOT NULL REFERENCES library_series (id) ON DELETE CASCADE,
+	chapter_id text NOT NULL,
+	pages_read integer NOT NULL DEFAULT 0,
+	chapter_completed boolean NOT NULL DEFAULT false,
+	duration_seconds integer NOT NULL DEFAULT 0,
+	read_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX read_events_user_read_at ON read_events (user_id, read_at);
+
+-- Hourly buckets are kept in UTC, days, weeks and heatmaps are computed from them in the user timezone.
+-- They are per serie so genres and authors can be ranked on any period.
+CREATE MATERIALIZED VIEW read_stats_hourly AS
+SELECT
+	user_id,
+	library_serie_id,
+	date_trunc('hour', read_at) AS bucket,
+	count(DISTINCT chapter_id) FILTER (WHERE chapter_completed) AS chapters_read,
+	sum(pages_read) AS pages_read,
+	sum(duration_seconds) AS duration_seconds
+FROM read_events
+GROUP BY user_id, library_serie_id, date_trunc('hour', read_at);
+
+CREATE UNIQUE INDEX read_stats_hourly_key ON read_stats_hourly (user_id, bucket, library_serie_id);
+
+CREATE MATERIALIZED VIEW read_stats_series AS
+SELECT
+	user_id,
+	library_serie_id,
+	count(DISTINCT chapter_id) FILTER (WHERE chapter_completed) AS chapters_read,
+	sum(pages_read) AS pages_read,
+	min(read_at) AS first_read_at,
+	max(read_at) AS last_read_at
+FROM read_events
+GROUP BY user_id, library_serie_id;
+
+CREATE UNIQUE INDEX read_stats_series_key ON read_stats_series (user_id, library_serie_id);
@@ -0,0 +1,2 @@
+DROP TABLE library_serie_chapters;
+DROP TABLE library_serie_sources;
@@ -0,0 +1,23 @@
+-- Every source the serie is read from, the source it was added from included
+CREATE TABLE library_serie_sources (
+	library_serie_id uuid NOT NULL REFERENCES library_series (id) ON DELETE CASCADE,
+	source_id text NOT NULL,
+	serie_id text NOT NULL,
+	fetched_at timestamptz,
+	last_error text NOT NULL DEFAULT '',
+	PRIMARY KEY (library_serie_id, source_id, serie_id)
+);
+
+INSERT INTO library_serie_sources (library_serie_id, source_id, serie_id)
+SELECT id, source_id, serie_id FROM library_series;
+
+-- Chapters known by each source, replaced on every fetch
+CREATE TABLE library_serie_chapters (
+	library_serie_id uuid NOT NULL,
+	source_id text NOT NULL,
+	serie_id text NOT NULL,
+	chapter_number double precision NOT NULL,
+	language text NOT NULL,
+	PRIMARY KEY (library_serie_id, source_id, serie_id, language, chapter_number),
+	FOREIGN KEY (library_serie_id, source_id, serie_id) REFERENCES library_serie_sources (library_serie_id, source_id, serie_id) ON DELETE CASCADE
+);
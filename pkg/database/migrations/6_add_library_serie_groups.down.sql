@@ -0,0 +1,3 @@
+ALTER TABLE library_series
+	DROP COLUMN preferred_groups,
+	DROP COLUMN blocked_groups;
@@ -0,0 +1,4 @@
+-- Scanlation groups preferences of the serie, matched by ID or name
+ALTER TABLE library_series
+	ADD COLUMN preferred_groups text[] NOT NULL DEFAULT '{}',
+	ADD COLUMN blocked_groups text[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,9 @@
+-- Only the most recently updated account of each tracker is kept
+DELETE FROM tracker_accounts a
+	USING tracker_accounts b
+	WHERE a.tracker = b.tracker AND (a.updated_at, a.user_id) < (b.updated_at, b.user_id);
+
+ALTER TABLE tracker_accounts
+	DROP CONSTRAINT tracker_accounts_pkey,
+	DROP COLUMN user_id,
+	ADD PRIMARY KEY (tracker);
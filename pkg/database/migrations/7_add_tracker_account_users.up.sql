@@ -0,0 +1,7 @@
+-- Tracker accounts belong to a user, the accounts linked before are dropped since their user is unknown
+DELETE FROM tracker_accounts;
+
+ALTER TABLE tracker_accounts
+	DROP CONSTRAINT tracker_accounts_pkey,
+	ADD COLUMN user_id text NOT NULL,
+	ADD PRIMARY KEY (user_id, tracker);
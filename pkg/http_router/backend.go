@@ -1,28 +1,52 @@
 package http_router
 
 import (
+	"encoding/json"
 	"log/slog"
 	"net/http"
+	"sync"
 
 	"dokusho/pkg/config"
+	"dokusho/pkg/http_utils"
+	"dokusho/pkg/library"
+	"dokusho/pkg/stats"
+	"dokusho/pkg/trackers"
+	"dokusho/pkg/trackers/tracker_sync"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/riverqueue/river"
 )
 
 type BackendRouter struct {
-	config config.SourceConfig
-	l      *slog.Logger
-	pgpool *pgxpool.Pool
+	config      *config.BackendConfig
+	l           *slog.Logger
+	pgpool      *pgxpool.Pool
+	riverClient *river.Client[pgx.Tx]
+
+	library  *library.Store
+	trackers *trackers.Store
+	stats    *stats.Store
+	registry *tracker_sync.Registry
+	// Pending OAuth authorizations by state
+	oauthStates sync.Map
 }
 
-func NewBackendRouter(config config.SourceConfig, pgpool *pgxpool.Pool) *BackendRouter {
+func NewBackendRouter(config *config.BackendConfig, pgpool *pgxpool.Pool, riverClient *river.Client[pgx.Tx], registry *tracker_sync.Registry) *BackendRouter {
 	logger := slog.Default().WithGroup("backend_router")
 
 	return &BackendRouter{
-		config: config,
-		l:      logger,
+		config:      config,
+		l:           logger,
+		pgpool:      pgpool,
+		riverClient: riverClient,
+		library:     library.NewStore(pgpool),
+		trackers:    trackers.NewStore(pgpool),
+		stats:       stats.NewStore(pgpool),
+		registry:    registry,
+		oauthStates: sync.Map{},
 	}
 }
 
@@ -35,9 +59,43 @@ func (r *BackendRouter) SetupMux() *chi.Mux {
 	mux.Use(middleware.RealIP)
 	mux.Use(middleware.Logger)
 	mux.Use(middleware.Recoverer)
+	mux.Use(http_utils.WhitelistedReverseProxy(r.config.UseWhitelistedReverseProxy, r.config.WhitelistedReverseProxyAddr...))
 
 	mux.Get("/api/v1/series", r.testHander)
 
+	mux.Route("/api/v1/library", func(mux chi.Router) {
+		mux.Use(http_utils.APIKeyMiddleware(r.config.BackendUseAPIKey, r.config.BackendAPIKey))
+
+		mux.Get("/", r.librarySeriesHandler)
+		mux.Post("/", r.addLibrarySerieHandler)
+		mux.Get("/missing-chapters", r.missingChaptersHandler)
+		mux.Get("/{librarySerieID}/sources", r.serieSourcesHandler)
+		mux.Post("/{librarySerieID}/sources", r.addSerieSourceHandler)
+		mux.Delete("/{librarySerieID}/sources/{sourceID}/{serieID}", r.removeSerieSourceHandler)
+		mux.Put("/{librarySerieID}/groups", r.serieGroupsHandler)
+		mux.Post("/{librarySerieID}/chapters/refresh", r.refreshSerieChaptersHandler)
+		mux.Get("/{librarySerieID}/missing-chapters", r.serieMissingChaptersHandler)
+		mux.Get("/{librarySerieID}/trackers", r.serieTrackerLinksHandler)
+		mux.Put("/{librarySerieID}/trackers/{trackerID}", r.linkSerieTrackerHandler)
+		mux.Delete("/{librarySerieID}/trackers/{trackerID}", r.unlinkSerieTrackerHandler)
+	})
+
+	mux.Route("/api/v1/users/{userID}", func(mux chi.Router) {
+		mux.Use(http_utils.APIKeyMiddleware(r.config.BackendUseAPIKey, r.config.BackendAPIKey))
+
+		mux.Post("/reads", r.recordReadHandler)
+		mux.Get("/stats", r.userStatsHandler)
+		mux.Get("/stats/year/{year}", r.userYearStatsHandler)
+		mux.Post("/library/{librarySerieID}/progress", r.serieProgressHandler)
+		mux.Get("/trackers", r.trackersHandler)
+		mux.Get("/trackers/{trackerID}/authorize", r.trackerAuthorizeHandler)
+		mux.Delete("/trackers/{trackerID}", r.trackerLogoutHandler)
+		mux.Get("/trackers/{trackerID}/search", r.trackerSearchHandler)
+	})
+
+	// The tracker redirects the browser here without the API key, the single use state of the authorization authenticates it
+	mux.Get("/api/v1/trackers/{trackerID}/callback", r.trackerCallbackHandler)
+
 	return mux
 }
 
@@ -46,3 +104,25 @@ func (r *BackendRouter) testHander(w http.ResponseWriter, _ *http.Request) {
 
 	w.Write([]byte("Test handler"))
 }
+
+// extractUserID returns the user of the path, it is trusted since the user routes require the API key
+func (r *BackendRouter) extractUserID(w http.ResponseWriter, req *http.Request) (string, bool) {
+	userID := http_utils.ExtractPathParam(req, "userID", "")
+	if userID == "" {
+		r.l.Error("No user ID provided")
+		w.WriteHeader(http.StatusBadRequest)
+		return "", false
+	}
+
+	return userID, true
+}
+
+func (r *BackendRouter) writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(data)
+	if err != nil {
+		r.l.Error("Error marshalling response", "error", err)
+	}
+}
@@ -0,0 +1,291 @@
+package http_router
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+
+	"dokusho/pkg/http_utils"
+	"dokusho/pkg/library"
+	"dokusho/pkg/sources/source_types"
+	"dokusho/pkg/trackers"
+
+	"github.com/google/uuid"
+)
+
+type LibrarySerieSourceRequest struct {
+	SourceID source_types.SourceID      `json:"sourceId"`
+	SerieID  source_types.SourceSerieID `json:"serieId"`
+}
+
+type AddLibrarySerieRequest struct {
+	Title         string                                `json:"title"`
+	SourceID      source_types.SourceID                 `json:"sourceId"`
+	SerieID       source_types.SourceSerieID            `json:"serieId"`
+	ExternalLinks source_types.SourceSerieExternalLinks `json:"externalLinks"`
+	Genres        []source_types.SourceSerieGenre       `json:"genres"`
+	Authors       []string                              `json:"authors"`
+	ChapterCount  int                                   `json:"chapterCount"`
+}
+
+type LibrarySerieGroupsRequest struct {
+	Preferred []string `json:"preferred"`
+	Blocked   []string `json:"blocked"`
+}
+
+func (r *BackendRouter) librarySeriesHandler(w http.ResponseWriter, req *http.Request) {
+	series, err := r.library.List(req.Context())
+	if err != nil {
+		r.l.Error("Error listing library series", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	r.writeJSON(w, http.StatusOK, series)
+}
+
+// addLibrarySerieHandler adds the serie to the library, tracker links known by the source are prefilled
+func (r *BackendRouter) addLibrarySerieHandler(w http.ResponseWriter, req *http.Request) {
+	var body AddLibrarySerieRequest
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil || body.Title == "" || body.SourceID == "" || body.SerieID == "" {
+		r.l.Error("Invalid library serie", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	genres := make([]string, len(body.Genres))
+	for i, genre := range body.Genres {
+		genres[i] = string(genre)
+	}
+
+	serie, err := r.library.Add(req.Context(), library.LibrarySerie{
+		Title:        body.Title,
+		SourceID:     body.SourceID,
+		SerieID:      body.SerieID,
+		Genres:       genres,
+		Authors:      body.Authors,
+		ChapterCount: body.ChapterCount,
+	})
+	if err != nil {
+		r.l.Error("Error adding library serie", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = library.EnqueueRefresh(req.Context(), r.riverClient, serie.ID)
+	if err != nil {
+		r.l.Warn("Error enqueuing chapters refresh", "library_serie_id", serie.ID, "error", err)
+	}
+
+	for tracker, mediaID := range trackers.LinksFromExternal(body.ExternalLinks) {
+		err = r.trackers.LinkSerieIfMissing(req.Context(), serie.ID, tracker, mediaID)
+		if err != nil {
+			r.l.Warn("Error prefilling tracker link", "tracker", tracker, "error", err)
+		}
+	}
+
+	r.writeJSON(w, http.StatusCreated, serie)
+}
+
+func (r *BackendRouter) serieSourcesHandler(w http.ResponseWriter, req *http.Request) {
+	id, ok := r.extractLibrarySerieID(w, req)
+	if !ok {
+		return
+	}
+
+	sources, err := r.library.ListSources(req.Context(), id)
+	if err != nil {
+		r.l.Error("Error listing serie sources", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	r.writeJSON(w, http.StatusOK, sources)
+}
+
+// addSerieSourceHandler links another source to the serie, its chapters are fetched in the background
+func (r *BackendRouter) addSerieSourceHandler(w http.ResponseWriter, req *http.Request) {
+	id, ok := r.extractLibrarySerieID(w, req)
+	if !ok {
+		return
+	}
+
+	var body LibrarySerieSourceRequest
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil || body.SourceID == "" || body.SerieID == "" {
+		r.l.Error("Invalid serie source", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = r.library.AddSource(req.Context(), id, body.SourceID, body.SerieID)
+	if err != nil {
+		r.l.Error("Error adding serie source", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = library.EnqueueRefresh(req.Context(), r.riverClient, id)
+	if err != nil {
+		r.l.Warn("Error enqueuing chapters refresh", "library_serie_id", id, "error", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *BackendRouter) removeSerieSourceHandler(w http.ResponseWriter, req *http.Request) {
+	id, ok := r.extractLibrarySerieID(w, req)
+	if !ok {
+		return
+	}
+
+	sourceID := source_types.SourceID(http_utils.ExtractPathParam(req, "sourceID", ""))
+	serieID := source_types.SourceSerieID(http_utils.ExtractPathParam(req, "serieID", ""))
+
+	err := r.library.RemoveSource(req.Context(), id, sourceID, serieID)
+	if errors.Is(err, library.ErrSourceNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		r.l.Error("Error removing serie source", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serieGroupsHandler replaces the scanlation groups preferences, the chapters are refreshed in the background to apply them
+func (r *BackendRouter) serieGroupsHandler(w http.ResponseWriter, req *http.Request) {
+	id, ok := r.extractLibrarySerieID(w, req)
+	if !ok {
+		return
+	}
+
+	var body LibrarySerieGroupsRequest
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil {
+		r.l.Error("Invalid serie groups", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	serie, err := r.library.SetGroups(req.Context(), id, body.Preferred, body.Blocked)
+	if err != nil {
+		r.l.Error("Error saving serie groups", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = library.EnqueueRefresh(req.Context(), r.riverClient, id)
+	if err != nil {
+		r.l.Warn("Error enqueuing chapters refresh", "library_serie_id", id, "error", err)
+	}
+
+	r.writeJSON(w, http.StatusOK, serie)
+}
+
+func (r *BackendRouter) refreshSerieChaptersHandler(w http.ResponseWriter, req *http.Request) {
+	id, ok := r.extractLibrarySerieID(w, req)
+	if !ok {
+		return
+	}
+
+	err := library.EnqueueRefresh(req.Context(), r.riverClient, id)
+	if err != nil {
+		r.l.Error("Error enqueuing chapters refresh", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// missingChaptersHandler reports the series of the library with missing chapters, filtered by source and language
+func (r *BackendRouter) missingChaptersHandler(w http.ResponseWriter, req *http.Request) {
+	filter := extractReportFilter(req)
+
+	series, err := r.library.List(req.Context())
+	if err != nil {
+		r.l.Error("Error listing library series", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	chapters, err := r.library.Chapters(req.Context())
+	if err != nil {
+		r.l.Error("Error fetching library chapters", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	reports := []library.SerieReport{}
+	for _, serie := range series {
+		report := library.BuildReport(serie, chapters[serie.ID], filter)
+		if len(report.Gaps) > 0 {
+			reports = append(reports, report)
+		}
+	}
+
+	// Series where the chapters can be found elsewhere first
+	slices.SortStableFunc(reports, func(a, b library.SerieReport) int {
+		return b.Recoverable - a.Recoverable
+	})
+
+	r.writeJSON(w, http.StatusOK, reports)
+}
+
+func (r *BackendRouter) serieMissingChaptersHandler(w http.ResponseWriter, req *http.Request) {
+	id, ok := r.extractLibrarySerieID(w, req)
+	if !ok {
+		return
+	}
+
+	serie, err := r.library.Get(req.Context(), id)
+	if err != nil {
+		r.l.Error("Error fetching library serie", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	chapters, err := r.library.Chapters(req.Context(), id)
+	if err != nil {
+		r.l.Error("Error fetching serie chapters", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	r.writeJSON(w, http.StatusOK, library.BuildReport(serie, chapters[id], extractReportFilter(req)))
+}
+
+func extractReportFilter(req *http.Request) library.ReportFilter {
+	return library.ReportFilter{
+		SourceID: source_types.SourceID(http_utils.ExtractQueryValue(req, "source", "")),
+		Language: source_types.SourceLanguage(http_utils.ExtractQueryValue(req, "language", "")),
+	}
+}
+
+func (r *BackendRouter) extractLibrarySerieID(w http.ResponseWriter, req *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(http_utils.ExtractPathParam(req, "librarySerieID", ""))
+	if err != nil {
+		r.l.Error("Invalid library serie ID", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return uuid.UUID{}, false
+	}
+
+	_, err = r.library.Get(req.Context(), id)
+	if errors.Is(err, library.ErrSerieNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return uuid.UUID{}, false
+	}
+	if err != nil {
+		r.l.Error("Error fetching library serie", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return uuid.UUID{}, false
+	}
+
+	return id, true
+}
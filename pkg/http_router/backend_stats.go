@@ -0,0 +1,154 @@
+package http_router
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dokusho/pkg/http_utils"
+	"dokusho/pkg/library"
+	"dokusho/pkg/stats"
+)
+
+const (
+	statsDefaultPeriod = 30 * 24 * time.Hour
+	statsDefaultLimit  = 10
+	// A year in review is the longest period that makes sense
+	statsMaxPeriod = 366 * 24 * time.Hour
+)
+
+func (r *BackendRouter) recordReadHandler(w http.ResponseWriter, req *http.Request) {
+	userID, ok := r.extractUserID(w, req)
+	if !ok {
+		return
+	}
+
+	var event stats.ReadEvent
+	err := json.NewDecoder(req.Body).Decode(&event)
+	if err != nil || event.ChapterID == "" || event.PagesRead < 0 || event.DurationSeconds < 0 {
+		r.l.Error("Invalid read event", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	_, err = r.library.Get(req.Context(), event.LibrarySerieID)
+	if errors.Is(err, library.ErrSerieNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		r.l.Error("Error fetching library serie", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	event.UserID = userID
+	err = r.stats.RecordRead(req.Context(), event)
+	if err != nil {
+		r.l.Error("Error recording read event", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// userStatsHandler returns the statistics between the from and to days included, the last 30 days by default
+func (r *BackendRouter) userStatsHandler(w http.ResponseWriter, req *http.Request) {
+	loc, ok := r.extractStatsLocation(w, req)
+	if !ok {
+		return
+	}
+
+	to := stats.Date(time.Now(), loc)
+	if value := http_utils.ExtractQueryValue(req, "to", ""); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			r.l.Error("Invalid to date", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		to = parsed
+	}
+
+	from := to.Add(-statsDefaultPeriod)
+	if value := http_utils.ExtractQueryValue(req, "from", ""); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			r.l.Error("Invalid from date", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		from = parsed
+	}
+
+	r.writeStats(w, req, from, to, loc)
+}
+
+// userYearStatsHandler returns the year in review
+func (r *BackendRouter) userYearStatsHandler(w http.ResponseWriter, req *http.Request) {
+	loc, ok := r.extractStatsLocation(w, req)
+	if !ok {
+		return
+	}
+
+	year, err := strconv.Atoi(http_utils.ExtractPathParam(req, "year", ""))
+	if err != nil || year < 1970 || year > 9999 {
+		r.l.Error("Invalid year", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	r.writeStats(w, req, from, to, loc)
+}
+
+func (r *BackendRouter) writeStats(w http.ResponseWriter, req *http.Request, from, to time.Time, loc *time.Location) {
+	userID, ok := r.extractUserID(w, req)
+	if !ok {
+		return
+	}
+
+	if to.Before(from) || to.Sub(from) > statsMaxPeriod {
+		r.l.Error("Invalid stats period", "from", from, "to", to)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	limit, err := strconv.Atoi(http_utils.ExtractQueryValue(req, "limit", strconv.Itoa(statsDefaultLimit)))
+	if err != nil || limit < 1 || limit > 100 {
+		r.l.Error("Invalid limit", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	summary, err := r.stats.Summary(req.Context(), userID, from, to, loc, limit)
+	if err != nil {
+		r.l.Error("Error computing stats", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	r.writeJSON(w, http.StatusOK, summary)
+}
+
+// extractStatsLocation reads the IANA timezone used to split days and hours, UTC by default
+func (r *BackendRouter) extractStatsLocation(w http.ResponseWriter, req *http.Request) (*time.Location, bool) {
+	tz := http_utils.ExtractQueryValue(req, "tz", "UTC")
+
+	// Local depends on the server and is unknown to the database
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		r.l.Error("Invalid timezone", "tz", tz, "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+
+	return loc, true
+}
@@ -0,0 +1,358 @@
+package http_router
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"dokusho/pkg/http_utils"
+	"dokusho/pkg/trackers"
+	"dokusho/pkg/trackers/tracker_sync"
+)
+
+// Time given to the user to accept the authorization on the tracker
+const oauthStateTTL = 10 * time.Minute
+
+type oauthState struct {
+	userID       string
+	tracker      trackers.TrackerID
+	codeVerifier string
+	expiresAt    time.Time
+}
+
+type TrackerStatus struct {
+	ID     trackers.TrackerID `json:"id"`
+	Linked bool               `json:"linked"`
+}
+
+type TrackerAuthorizeResponse struct {
+	URL string `json:"url"`
+}
+
+type LinkSerieTrackerRequest struct {
+	MediaID string `json:"mediaId"`
+}
+
+type SerieProgressRequest struct {
+	Progress        int                    `json:"progress"`
+	ProgressVolumes int                    `json:"progressVolumes"`
+	Status          trackers.ReadingStatus `json:"status,omitempty"`
+}
+
+type SerieProgressResponse struct {
+	Enqueued int `json:"enqueued"`
+}
+
+func (r *BackendRouter) serieTrackerLinksHandler(w http.ResponseWriter, req *http.Request) {
+	serieID, ok := r.extractLibrarySerieID(w, req)
+	if !ok {
+		return
+	}
+
+	links, err := r.trackers.GetLinks(req.Context(), serieID)
+	if err != nil {
+		r.l.Error("Error fetching tracker links", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	r.writeJSON(w, http.StatusOK, links)
+}
+
+func (r *BackendRouter) linkSerieTrackerHandler(w http.ResponseWriter, req *http.Request) {
+	serieID, ok := r.extractLibrarySerieID(w, req)
+	if !ok {
+		return
+	}
+
+	tracker, ok := r.extractTrackerID(w, req)
+	if !ok {
+		return
+	}
+
+	var body LinkSerieTrackerRequest
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil || body.MediaID == "" {
+		r.l.Error("Invalid tracker link", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = r.trackers.LinkSerie(req.Context(), serieID, tracker, body.MediaID)
+	if err != nil {
+		r.l.Error("Error linking serie", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *BackendRouter) unlinkSerieTrackerHandler(w http.ResponseWriter, req *http.Request) {
+	serieID, ok := r.extractLibrarySerieID(w, req)
+	if !ok {
+		return
+	}
+
+	tracker, ok := r.extractTrackerID(w, req)
+	if !ok {
+		return
+	}
+
+	err := r.trackers.UnlinkSerie(req.Context(), serieID, tracker)
+	if err != nil {
+		r.l.Error("Error unlinking serie", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serieProgressHandler enqueues the push of the read progress to every linked tracker
+func (r *BackendRouter) serieProgressHandler(w http.ResponseWriter, req *http.Request) {
+	userID, ok := r.extractUserID(w, req)
+	if !ok {
+		return
+	}
+
+	serieID, ok := r.extractLibrarySerieID(w, req)
+	if !ok {
+		return
+	}
+
+	var body SerieProgressRequest
+	err := json.NewDecoder(req.Body).Decode(&body)
+	if err != nil || body.Progress < 0 || body.ProgressVolumes < 0 {
+		r.l.Error("Invalid progress", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if body.Status != "" {
+		if _, err := trackers.NewReadingStatus(string(body.Status)); err != nil {
+			r.l.Error("Invalid status", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	enqueued, err := tracker_sync.EnqueueSync(req.Context(), r.riverClient, r.trackers, userID, serieID, body.Progress, body.ProgressVolumes, body.Status)
+	if err != nil {
+		r.l.Error("Error enqueuing tracker sync", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	r.writeJSON(w, http.StatusAccepted, SerieProgressResponse{Enqueued: enqueued})
+}
+
+func (r *BackendRouter) trackersHandler(w http.ResponseWriter, req *http.Request) {
+	userID, ok := r.extractUserID(w, req)
+	if !ok {
+		return
+	}
+
+	linked, err := r.trackers.ListAccounts(req.Context(), userID)
+	if err != nil {
+		r.l.Error("Error listing tracker accounts", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	statuses := []TrackerStatus{}
+	for _, id := range r.registry.Enabled() {
+		statuses = append(statuses, TrackerStatus{ID: id, Linked: slices.Contains(linked, id)})
+	}
+
+	r.writeJSON(w, http.StatusOK, statuses)
+}
+
+// trackerAuthorizeHandler returns the url of the tracker the user authorizes the account on, the route requires the API key
+// so browsers can't follow a redirect to it
+func (r *BackendRouter) trackerAuthorizeHandler(w http.ResponseWriter, req *http.Request) {
+	userID, ok := r.extractUserID(w, req)
+	if !ok {
+		return
+	}
+
+	tracker, ok := r.extractTrackerID(w, req)
+	if !ok {
+		return
+	}
+
+	client, err := r.registry.New(tracker, trackers.Token{}, nil)
+	if err != nil {
+		r.l.Error("Tracker not configured", "error", err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		r.l.Error("Error generating oauth state", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	verifier, err := randomToken()
+	if err != nil {
+		r.l.Error("Error generating code verifier", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	r.cleanOAuthStates()
+	r.oauthStates.Store(state, oauthState{userID: userID, tracker: tracker, codeVerifier: verifier, expiresAt: time.Now().Add(oauthStateTTL)})
+
+	r.writeJSON(w, http.StatusOK, TrackerAuthorizeResponse{URL: client.AuthorizeURL(state, verifier)})
+}
+
+func (r *BackendRouter) trackerCallbackHandler(w http.ResponseWriter, req *http.Request) {
+	tracker, ok := r.extractTrackerID(w, req)
+	if !ok {
+		return
+	}
+
+	code := http_utils.ExtractQueryValue(req, "code", "")
+	value, found := r.oauthStates.LoadAndDelete(http_utils.ExtractQueryValue(req, "state", ""))
+	if code == "" || !found {
+		r.l.Error("Invalid oauth callback", "tracker", tracker)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	state := value.(oauthState)
+	if state.tracker != tracker || time.Now().After(state.expiresAt) {
+		r.l.Error("Expired or mismatched oauth state", "tracker", tracker)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	client, err := r.registry.New(tracker, trackers.Token{}, nil)
+	if err != nil {
+		r.l.Error("Tracker not configured", "error", err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	token, err := client.ExchangeCode(req.Context(), code, state.codeVerifier)
+	if err != nil {
+		r.l.Error("Error exchanging code", "tracker", tracker, "error", err)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	err = r.trackers.SaveAccount(req.Context(), state.userID, tracker, token)
+	if err != nil {
+		r.l.Error("Error saving tracker account", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	r.writeJSON(w, http.StatusOK, TrackerStatus{ID: tracker, Linked: true})
+}
+
+func (r *BackendRouter) trackerLogoutHandler(w http.ResponseWriter, req *http.Request) {
+	userID, ok := r.extractUserID(w, req)
+	if !ok {
+		return
+	}
+
+	tracker, ok := r.extractTrackerID(w, req)
+	if !ok {
+		return
+	}
+
+	err := r.trackers.DeleteAccount(req.Context(), userID, tracker)
+	if err != nil {
+		r.l.Error("Error deleting tracker account", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *BackendRouter) trackerSearchHandler(w http.ResponseWriter, req *http.Request) {
+	userID, ok := r.extractUserID(w, req)
+	if !ok {
+		return
+	}
+
+	tracker, ok := r.extractTrackerID(w, req)
+	if !ok {
+		return
+	}
+
+	query := http_utils.ExtractQueryValue(req, "q", "")
+	if query == "" {
+		r.l.Error("No search query provided")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	token, err := r.trackers.GetAccount(req.Context(), userID, tracker)
+	if err != nil && !errors.Is(err, trackers.ErrAccountNotFound) {
+		r.l.Error("Error fetching tracker account", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	client, err := r.registry.New(tracker, token, func(ctx context.Context, token trackers.Token) error {
+		return r.trackers.SaveAccount(ctx, userID, tracker, token)
+	})
+	if err != nil {
+		r.l.Error("Tracker not configured", "error", err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	media, err := client.SearchMedia(req.Context(), query)
+	if err != nil {
+		r.l.Error("Error searching tracker", "tracker", tracker, "error", err)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	r.writeJSON(w, http.StatusOK, media)
+}
+
+func (r *BackendRouter) extractTrackerID(w http.ResponseWriter, req *http.Request) (trackers.TrackerID, bool) {
+	tracker, err := trackers.NewTrackerID(http_utils.ExtractPathParam(req, "trackerID", ""))
+	if err != nil {
+		r.l.Error("Invalid tracker", "error", err)
+		w.WriteHeader(http.StatusNotFound)
+		return "", false
+	}
+
+	return tracker, true
+}
+
+func (r *BackendRouter) cleanOAuthStates() {
+	now := time.Now()
+
+	r.oauthStates.Range(func(key, value any) bool {
+		if now.After(value.(oauthState).expiresAt) {
+			r.oauthStates.Delete(key)
+		}
+
+		return true
+	})
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 48)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -1,41 +1,92 @@
 package http_router
 
 import (
+	"bytes"
 	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"dokusho/pkg/config"
 	"dokusho/pkg/http_utils"
+	"dokusho/pkg/imageutils"
+	"dokusho/pkg/sources/source_types"
 )
 
+// Length of the content hash prefix used in the v query param of page URLs
+const versionLength = 16
+
 type FileRouter struct {
 	config config.FileBaseConfig
 	l      *slog.Logger
+	// ETag cache by file path, only valid while the file modification time and size don't change
+	etags  sync.Map
+	signer *http_utils.URLSigner
+}
+
+type fileETag struct {
+	modTime time.Time
+	size    int64
+	etag    string
 }
 
 //go:embed image.jpg
 var mockImage []byte
 
+var mockImageETag = sync.OnceValue(func() string {
+	etag, _ := http_utils.ContentETag(bytes.NewReader(mockImage))
+	return etag
+})
+
 func NewFileRouter(config config.FileBaseConfig) *FileRouter {
 	logger := slog.Default().WithGroup("backend_router")
 
 	return &FileRouter{
 		config: config,
 		l:      logger,
+		etags:  sync.Map{},
+		signer: http_utils.NewURLSigner(config.FileURLSigningKey, config.FileSignedURLTTL),
 	}
 }
 
 func (fr *FileRouter) SetupMux(mux *http.ServeMux) *http.ServeMux {
 	fr.l.Info("Setting up file api router")
 
-	mux.HandleFunc("GET /files/{serieID}/{volumeID}/{chapterID}/{page}", fr.fileSerieHandler)
-	mux.HandleFunc("GET /files/{serieID}/cover", fr.fileSerieCoverHandler)
+	mux.Handle("GET /files/{serieID}/{volumeID}/{chapterID}/manifest", http_utils.APIKeyMiddleware(fr.config.FileUseAPIKey, fr.config.FileAPIKey)(http.HandlerFunc(fr.chapterManifestHandler)))
+	mux.Handle("GET /files/{serieID}/{volumeID}/{chapterID}/{page}", fr.signed(http_utils.SCOPE_PAGE, fr.fileSerieHandler))
+	mux.Handle("GET /files/{serieID}/{volumeID}/{chapterID}/{page}/thumbnail", fr.signed(http_utils.SCOPE_PAGE, fr.fileSerieThumbnailHandler))
+	mux.Handle("GET /files/{serieID}/cover", fr.signed(http_utils.SCOPE_COVER, fr.fileSerieCoverHandler))
 	mux.HandleFunc("GET /files/{hash}", fr.hashFileHandler)
 
 	return mux
 }
 
+// signed only lets requests with a valid signed URL through when FILE_REQUIRE_SIGNED_URL is set
+func (fr *FileRouter) signed(scope http_utils.SignedURLScope, handler http.HandlerFunc) http.Handler {
+	return http_utils.SignedURLMiddleware(fr.config.FileRequireSignedURL, fr.signer, scope)(handler)
+}
+
+// signURL returns the URL signed for the scope when signed URLs are required, the URL is returned unchanged otherwise
+func (fr *FileRouter) signURL(u *url.URL, scope http_utils.SignedURLScope) *url.URL {
+	if !fr.config.FileRequireSignedURL {
+		return u
+	}
+
+	return fr.signer.Sign(u, scope)
+}
+
 func (fr *FileRouter) fileSerieHandler(w http.ResponseWriter, r *http.Request) {
 	serieID := http_utils.ExtractPathParam(r, "serieID", "")
 	if serieID == "" {
@@ -69,13 +120,386 @@ func (fr *FileRouter) fileSerieHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	useMockImage := http_utils.ExtractQueryValue(r, "mock", "true")
-	if useMockImage == "true" {
-		fr.serveMockImage(w)
+	if fr.useMockImage(r) {
+		fr.serveMockImage(w, r, http_utils.CacheControlRevalidate)
+		return
+	}
+
+	index, err := strconv.Atoi(page)
+	if err != nil {
+		fr.l.Error("Invalid page provided", "page", page, "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	dir, err := fr.chapterDir(serieID, volumeID, chapterID)
+	if err != nil {
+		fr.l.Error("Invalid chapter path", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	path, err := imageutils.PagePath(dir, index)
+	if err != nil {
+		fr.l.Error("Page not found", "error", err)
+		http.NotFound(w, r)
+
+		return
+	}
+
+	crop := http_utils.ExtractQueryValue(r, "crop", "")
+	if crop != "" {
+		rect, err := imageutils.ParseRect(crop)
+		if err != nil {
+			fr.l.Error("Invalid crop provided", "crop", crop, "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		fr.serveCroppedFile(w, r, path, rect)
+		return
+	}
+
+	fr.serveFile(w, r, path)
+}
+
+func (fr *FileRouter) fileSerieThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	serieID := http_utils.ExtractPathParam(r, "serieID", "")
+	if serieID == "" {
+		fr.l.Error("No serieID provided")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	volumeID := http_utils.ExtractPathParam(r, "volumeID", "")
+	if volumeID == "" {
+		fr.l.Error("No volumeID provided")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	chapterID := http_utils.ExtractPathParam(r, "chapterID", "")
+	if chapterID == "" {
+		fr.l.Error("No chapterID provided")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	page := http_utils.ExtractPathParam(r, "page", "")
+	if page == "" {
+		fr.l.Error("No page provided")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	if fr.useMockImage(r) {
+		fr.serveMockImage(w, r, http_utils.CacheControlRevalidate)
+		return
+	}
+
+	index, err := strconv.Atoi(page)
+	if err != nil {
+		fr.l.Error("Invalid page provided", "page", page, "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	dir, err := fr.chapterDir(serieID, volumeID, chapterID)
+	if err != nil {
+		fr.l.Error("Invalid chapter path", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	// Only the requested page is decoded when its thumbnail is not there yet
+	path, err := imageutils.PageThumbnail(dir, index, imageutils.DefaultThumbnailWidth)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			http.NotFound(w, r)
+			return
+		}
+
+		fr.l.Error("Error generating page thumbnail", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	fr.serveFile(w, r, path)
+}
+
+type PageManifestEntry struct {
+	Index        int                    `json:"index"`
+	URL          string                 `json:"url"`
+	ThumbnailURL string                 `json:"thumbnailURL"`
+	Width        int                    `json:"width"`
+	Height       int                    `json:"height"`
+	AspectRatio  float64                `json:"aspectRatio"`
+	Format       imageutils.ImageFormat `json:"format"`
+	Size         int64                  `json:"size"`
+}
+
+type VirtualPageManifestEntry struct {
+	imageutils.VirtualPage
+	URL string `json:"url"`
+}
+
+type ChapterManifest struct {
+	Pages        []PageManifestEntry        `json:"pages"`
+	Options      *imageutils.ProcessOptions `json:"options,omitempty"`
+	VirtualPages []VirtualPageManifestEntry `json:"virtualPages,omitempty"`
+}
+
+func (fr *FileRouter) chapterManifestHandler(w http.ResponseWriter, r *http.Request) {
+	serieID := http_utils.ExtractPathParam(r, "serieID", "")
+	volumeID := http_utils.ExtractPathParam(r, "volumeID", "")
+	chapterID := http_utils.ExtractPathParam(r, "chapterID", "")
+
+	dir, err := fr.chapterDir(serieID, volumeID, chapterID)
+	if err != nil {
+		fr.l.Error("Invalid chapter path", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	pages, err := imageutils.ChapterManifest(dir, imageutils.DefaultThumbnailWidth)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			http.NotFound(w, r)
+			return
+		}
+
+		fr.l.Error("Error generating chapter manifest", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	baseURL, err := url.Parse(fr.config.FileServeURL)
+	if err != nil {
+		fr.l.Error("Error parsing file serve url", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	opts, err := extractProcessOptions(r)
+	if err != nil {
+		fr.l.Error("Invalid process options", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	manifest := ChapterManifest{Pages: make([]PageManifestEntry, len(pages))}
+	for i, page := range pages {
+		pageURL := baseURL.JoinPath("files", serieID, volumeID, chapterID, strconv.Itoa(page.Index))
+		thumbnailURL := pageURL.JoinPath("thumbnail")
+		setVersion(pageURL, page.Hash)
+		setVersion(thumbnailURL, page.Hash)
+		pageURL = fr.signURL(pageURL, http_utils.SCOPE_PAGE)
+		thumbnailURL = fr.signURL(thumbnailURL, http_utils.SCOPE_PAGE)
+
+		manifest.Pages[i] = PageManifestEntry{
+			Index:        page.Index,
+			URL:          pageURL.String(),
+			ThumbnailURL: thumbnailURL.String(),
+			Width:        page.Width,
+			Height:       page.Height,
+			AspectRatio:  page.AspectRatio,
+			Format:       page.Format,
+			Size:         page.Size,
+		}
+	}
+
+	if opts.Enabled() {
+		virtualPages, err := imageutils.PlanVirtualPages(pages, opts, func(page imageutils.ChapterPage) (image.Image, error) {
+			data, err := os.ReadFile(filepath.Join(dir, page.Name))
+			if err != nil {
+				return nil, err
+			}
+
+			img, _, err := imageutils.Decode(data)
+			return img, err
+		})
+		if err != nil {
+			fr.l.Error("Error planning virtual pages", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		manifest.Options = &opts
+		manifest.VirtualPages = make([]VirtualPageManifestEntry, 0, len(virtualPages))
+		for _, vp := range virtualPages {
+			i := slices.IndexFunc(pages, func(page imageutils.ChapterPage) bool { return page.Index == vp.PageIndex })
+			if i == -1 {
+				fr.l.Warn("Virtual page of an unknown page skipped", "page_index", vp.PageIndex)
+				continue
+			}
+
+			pageURL := baseURL.JoinPath("files", serieID, volumeID, chapterID, strconv.Itoa(vp.PageIndex))
+			setVersion(pageURL, pages[i].Hash)
+			q := pageURL.Query()
+			q.Set("crop", vp.Crop.String())
+			pageURL.RawQuery = q.Encode()
+			pageURL = fr.signURL(pageURL, http_utils.SCOPE_PAGE)
+
+			manifest.VirtualPages = append(manifest.VirtualPages, VirtualPageManifestEntry{VirtualPage: vp, URL: pageURL.String()})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(manifest)
+	if err != nil {
+		fr.l.Error("Error marshalling manifest", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+}
 
-	w.Write([]byte("Serie page handler"))
+// setVersion adds the content hash to the URL, so it can be cached forever since it changes with the content
+func setVersion(u *url.URL, hash string) {
+	if len(hash) < versionLength {
+		return
+	}
+
+	q := u.Query()
+	q.Set("v", hash[:versionLength])
+	u.RawQuery = q.Encode()
+}
+
+// extractProcessOptions derives the options from the serie type and genres, split_spreads, rtl and slice_strips override them
+func extractProcessOptions(r *http.Request) (imageutils.ProcessOptions, error) {
+	var genres []source_types.SourceSerieGenre
+	for _, genre := range strings.Split(http_utils.ExtractQueryValue(r, "genres", ""), ",") {
+		if genre != "" {
+			genres = append(genres, source_types.NewSourceSerieGenre(genre))
+		}
+	}
+
+	opts := imageutils.DefaultProcessOptions(source_types.NewSourceSerieType(http_utils.ExtractQueryValue(r, "type", "")), genres)
+	opts.SplitSpreads = extractBoolQuery(r, "split_spreads", opts.SplitSpreads)
+	opts.RightToLeft = extractBoolQuery(r, "rtl", opts.RightToLeft)
+	opts.SliceStrips = extractBoolQuery(r, "slice_strips", opts.SliceStrips)
+
+	segmentHeight := http_utils.ExtractQueryValue(r, "segment_height", "")
+	if segmentHeight != "" {
+		height, err := strconv.Atoi(segmentHeight)
+		if err != nil || height <= 0 {
+			return opts, errors.Join(fmt.Errorf("invalid segment height: %s", segmentHeight), err)
+		}
+
+		opts.SegmentHeight = height
+	}
+
+	return opts, nil
+}
+
+// extractBoolQuery returns defaultValue when the query param is not set
+func extractBoolQuery(r *http.Request, key string, defaultValue bool) bool {
+	value := http_utils.ExtractQueryValue(r, key, "")
+	if value == "" {
+		return defaultValue
+	}
+
+	return value == "true"
+}
+
+// chapterDir builds the chapter directory path and makes sure it stays inside the root dir
+func (fr *FileRouter) chapterDir(serieID, volumeID, chapterID string) (string, error) {
+	for _, segment := range []string{serieID, volumeID, chapterID} {
+		if segment == "" || segment == "." || segment == ".." || strings.ContainsAny(segment, `/\`) {
+			return "", fmt.Errorf("invalid path segment: %q", segment)
+		}
+	}
+
+	root := filepath.Clean(fr.config.FileRootDir)
+	dir := filepath.Join(root, serieID, volumeID, chapterID)
+	if !strings.HasPrefix(dir, root+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside of root dir", dir)
+	}
+
+	return dir, nil
+}
+
+func (fr *FileRouter) serveFile(w http.ResponseWriter, r *http.Request, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			http.NotFound(w, r)
+			return
+		}
+
+		fr.l.Error("Error opening file", "path", path, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		fr.l.Error("Error reading file info", "path", path, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	etag, err := fr.fileETag(path, f, stat)
+	if err != nil {
+		fr.l.Error("Error computing file etag", "path", path, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	http_utils.ServeContent(w, r, f, etag, stat.ModTime(), "", fr.cacheControl(r, etag))
+}
+
+// fileETag returns the ETag of the file, hashing it only when it changed since the last call
+func (fr *FileRouter) fileETag(path string, f *os.File, stat os.FileInfo) (string, error) {
+	if cached, ok := fr.etags.Load(path); ok {
+		c := cached.(fileETag)
+		if c.modTime.Equal(stat.ModTime()) && c.size == stat.Size() {
+			return c.etag, nil
+		}
+	}
+
+	etag, err := http_utils.ContentETag(f)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", fmt.Errorf("Failed to rewind file: %w", err)
+	}
+
+	fr.etags.Store(path, fileETag{modTime: stat.ModTime(), size: stat.Size(), etag: etag})
+
+	return etag, nil
+}
+
+// cacheControl allows clients to cache the response forever when the URL is versioned with the current content hash
+func (fr *FileRouter) cacheControl(r *http.Request, etag string) string {
+	version := http_utils.ExtractQueryValue(r, "v", "")
+	if len(version) >= versionLength && strings.HasPrefix(strings.Trim(etag, `"`), version) {
+		return http_utils.CacheControlImmutable
+	}
+
+	return http_utils.CacheControlRevalidate
 }
 
 func (fr *FileRouter) hashFileHandler(w http.ResponseWriter, r *http.Request) {
@@ -87,10 +511,9 @@ func (fr *FileRouter) hashFileHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	useMockImage := http_utils.ExtractQueryValue(r, "mock", "true")
-
-	if hash == "image.jpg" || useMockImage == "true" {
-		fr.serveMockImage(w)
+	// The mock isn't the content of the hash, caching it would keep it once the real file is served
+	if hash == "image.jpg" || fr.useMockImage(r) {
+		fr.serveMockImage(w, r, http_utils.CacheControlNoStore)
 		return
 	}
 
@@ -106,19 +529,89 @@ func (fr *FileRouter) fileSerieCoverHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	useMockImage := http_utils.ExtractQueryValue(r, "mock", "true")
-	if useMockImage == "true" {
-		fr.serveMockImage(w)
+	if fr.useMockImage(r) {
+		fr.serveMockImage(w, r, http_utils.CacheControlRevalidate)
 		return
 	}
 
 	w.Write([]byte("Serie cover handler"))
 }
 
-func (fr *FileRouter) serveMockImage(w http.ResponseWriter) {
+func (fr *FileRouter) serveCroppedFile(w http.ResponseWriter, r *http.Request, path string, rect imageutils.Rect) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			http.NotFound(w, r)
+			return
+		}
+
+		fr.l.Error("Error reading file info", "path", path, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fr.l.Error("Error reading file", "path", path, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	img, format, err := imageutils.Decode(data)
+	if err != nil {
+		fr.l.Error("Error decoding file", "path", path, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	cropped, err := imageutils.Crop(img, rect)
+	if err != nil {
+		fr.l.Error("Error cropping file", "path", path, "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	// webp can't be encoded and is served as png
+	if format == imageutils.FORMAT_WEBP {
+		format = imageutils.FORMAT_PNG
+	}
+
+	out, err := imageutils.Encode(cropped, format)
+	if err != nil {
+		fr.l.Error("Error encoding file", "path", path, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	etag, err := http_utils.ContentETag(bytes.NewReader(out))
+	if err != nil {
+		fr.l.Error("Error computing file etag", "path", path, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	// The crop is derived from the source page, so the version of the source page is also valid for it
+	cacheControl := http_utils.CacheControlRevalidate
+	if sourceETag, err := http_utils.ContentETag(bytes.NewReader(data)); err == nil {
+		cacheControl = fr.cacheControl(r, sourceETag)
+	}
+
+	http_utils.ServeContent(w, r, bytes.NewReader(out), etag, stat.ModTime(), format.MimeType(), cacheControl)
+}
+
+// useMockImage reports whether the mock image is served instead of the file, FILE_SERVE_MOCK is used when the mock query param is not set
+func (fr *FileRouter) useMockImage(r *http.Request) bool {
+	return http_utils.ExtractQueryValue(r, "mock", strconv.FormatBool(fr.config.FileServeMock)) == "true"
+}
+
+func (fr *FileRouter) serveMockImage(w http.ResponseWriter, r *http.Request, cacheControl string) {
 	fr.l.Info("Serving mock image from embeded file")
 
-	w.Header().Set("Content-Type", "image/jpeg")
-	w.WriteHeader(http.StatusOK)
-	w.Write(mockImage)
+	http_utils.ServeContent(w, r, bytes.NewReader(mockImage), mockImageETag(), time.Time{}, "image/jpeg", cacheControl)
 }
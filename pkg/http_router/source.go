@@ -1,34 +1,75 @@
 package http_router
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"dokusho/pkg/config"
 	"dokusho/pkg/http_utils"
+	"dokusho/pkg/sources"
+	"dokusho/pkg/sources/chapterutils"
 	"dokusho/pkg/sources/source_types"
+	"dokusho/pkg/utils"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
+const (
+	proxyTimeout      = 30 * time.Second
+	proxyMaxRedirects = 3
+	// Upper bound of a proxied image, no legit page should come close to it
+	proxyMaxSize = 50 << 20
+)
+
+type proxyHostsKey struct{}
+
 type SourceRouter struct {
-	sources []source_types.SourceAPI
-	l       *slog.Logger
-	cfg     *config.SourceConfig
+	sources     *sources.Registry
+	l           *slog.Logger
+	cfg         *config.SourceConfig
+	proxyClient *http.Client
+	signer      *http_utils.URLSigner
+	// Cookies of the proxied images, they never appear in the urls
+	cookies *proxyCookies
 }
 
-func NewSourceRouter(sources []source_types.SourceAPI, cfg *config.SourceConfig) *SourceRouter {
+func NewSourceRouter(registry *sources.Registry, cfg *config.SourceConfig) *SourceRouter {
 	logger := slog.Default().WithGroup("sources_router")
 
 	return &SourceRouter{
-		sources: sources,
-		l:       logger,
-		cfg:     cfg,
+		sources:     registry,
+		l:           logger,
+		cfg:         cfg,
+		proxyClient: http_utils.NewSafeHTTPClient(proxyTimeout, checkProxyRedirect),
+		signer:      http_utils.NewURLSigner(cfg.SourceURLSigningKey, cfg.SourceSignedURLTTL),
+		cookies:     newProxyCookies(cfg.SourceSignedURLTTL),
+	}
+}
+
+// checkProxyRedirect only follows redirects to hosts allowed for the source being proxied
+func checkProxyRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= proxyMaxRedirects {
+		return fmt.Errorf("stopped after %d redirects", proxyMaxRedirects)
+	}
+
+	hosts, _ := req.Context().Value(proxyHostsKey{}).([]string)
+	if !http_utils.MatchHost(req.URL.Hostname(), hosts) {
+		return fmt.Errorf("%w: redirect to %s is not allowed", http_utils.ErrForbiddenAddress, req.URL.Hostname())
 	}
+
+	return nil
 }
 
 func (s *SourceRouter) SetupMux() http.Handler {
@@ -43,19 +84,39 @@ func (s *SourceRouter) SetupMux() http.Handler {
 
 	mux.Route("/api/v1/sources", func(r chi.Router) {
 		r.Use(middleware.Heartbeat("/api/v1/sources/health"))
-		r.Use(
-			http_utils.WhitelistedReverseProxy(s.cfg.UseWhitelistedReverseProxy, s.cfg.WhitelistedReverseProxyAddr...),
-			http_utils.APIKeyMiddleware(s.cfg.SourceUseAPIKey, s.cfg.SourceAPIKey),
-		)
-
-		r.Get("/", s.sourcesHandler)
-		r.Get("/{sourceID}", s.sourceHandler)
-		r.Get("/{sourceID}/popular", s.popularSeriesHandler)
-		r.Get("/{sourceID}/latest", s.latestSeriesHandler)
-		r.Get("/{sourceID}/search", s.searchSeriesHandler)
-		r.Get("/{sourceID}/series/{serieID}", s.serieHandler)
-		r.Get("/{sourceID}/series/{serieID}/source_url", s.serieUrlHandler)
-		r.Get("/{sourceID}/series/{serieID}/{volumeID}/{chapterID}", s.chapterHandler)
+		r.Use(http_utils.WhitelistedReverseProxy(s.cfg.UseWhitelistedReverseProxy, s.cfg.WhitelistedReverseProxyAddr...))
+
+		r.Group(func(r chi.Router) {
+			r.Use(http_utils.APIKeyMiddleware(s.cfg.SourceUseAPIKey, s.cfg.SourceAPIKey))
+
+			r.Get("/", s.sourcesHandler)
+			r.Get("/{sourceID}", s.sourceHandler)
+			r.Get("/{sourceID}/popular", s.popularSeriesHandler)
+			r.Get("/{sourceID}/latest", s.latestSeriesHandler)
+			r.Get("/{sourceID}/search", s.searchSeriesHandler)
+			r.Get("/{sourceID}/home", s.homeSectionsHandler)
+			r.Get("/{sourceID}/random", s.randomSerieHandler)
+			r.Get("/{sourceID}/authors/{author}", s.authorSeriesHandler)
+			r.Post("/{sourceID}/login", s.loginHandler)
+			r.Get("/{sourceID}/series/{serieID}", s.serieHandler)
+			r.Get("/{sourceID}/series/{serieID}/metadata", s.serieMetadataHandler)
+			r.Get("/{sourceID}/series/{serieID}/chapters", s.serieChaptersHandler)
+			r.Get("/{sourceID}/series/{serieID}/source_url", s.serieUrlHandler)
+			r.Get("/{sourceID}/series/{serieID}/{volumeID}/{chapterID}", s.chapterHandler)
+			r.Get("/{sourceID}/series/{serieID}/{volumeID}/{chapterID}/source_url", s.chapterUrlHandler)
+		})
+
+		// Changing the sources of everyone requires the API key, even when the other routes don't
+		r.Group(func(r chi.Router) {
+			r.Use(http_utils.APIKeyMiddleware(true, s.cfg.SourceAPIKey))
+
+			r.Post("/{sourceID}/enable", s.enableSourceHandler)
+			r.Post("/{sourceID}/disable", s.disableSourceHandler)
+		})
+
+		// Images are loaded by browsers from <img> tags that can't send the API key, so a signed url is also accepted
+		r.With(http_utils.APIKeyOrSignedURLMiddleware(s.cfg.SourceUseAPIKey, s.cfg.SourceAPIKey, s.signer, http_utils.SCOPE_PROXY)).
+			Get("/{sourceID}/proxy", s.imageProxyHandler)
 	})
 
 	return mux
@@ -66,10 +127,8 @@ type SerieURL struct {
 }
 
 func (s *SourceRouter) serieUrlHandler(w http.ResponseWriter, r *http.Request) {
-	sourceID := http_utils.ExtractPathParam(r, "sourceID", "")
-	if sourceID == "" {
-		s.l.Error("No source ID provided")
-		w.WriteHeader(http.StatusBadRequest)
+	source, ok := s.getSource(w, r)
+	if !ok {
 		return
 	}
 
@@ -80,39 +139,458 @@ func (s *SourceRouter) serieUrlHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for _, source := range s.sources {
-		info := source.GetInformation()
+	url, err := source.SerieUrl(source_types.NewSourceSerieID(serieID))
+	if err != nil {
+		s.l.Error("Error generating serie url", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
-		if string(info.ID) == sourceID {
-			url, err := source.SerieUrl(source_types.NewSourceSerieID(serieID))
-			if err != nil {
-				s.l.Error("Error generating serie url", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+	data := SerieURL{URL: url.String()}
 
-			data := SerieURL{URL: url.String()}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(data)
+	if err != nil {
+		s.l.Error("Error marshalling sources", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
 
-			w.Header().Set("Content-Type", "application/json; charset=utf-8")
-			encoder := json.NewEncoder(w)
-			err = encoder.Encode(data)
-			if err != nil {
-				s.l.Error("Error marshalling sources", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+// SourceLogin is the body of a login, either a username and a password or an API key
+type SourceLogin struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	APIKey   string `json:"apiKey"`
+}
+
+func (s *SourceRouter) loginHandler(w http.ResponseWriter, r *http.Request) {
+	source, ok := s.getSource(w, r)
+	if !ok {
+		return
+	}
+
+	loginSource, ok := source.(source_types.LoginSourceAPI)
+	if !ok {
+		s.l.Warn("Source doesn't support login", "source", source.GetInformation().ID)
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	var body SourceLogin
+	err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&body)
+	if err != nil {
+		s.l.Error("Error decoding login", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = loginSource.Login(r.Context(), source_types.SourceCredentials{
+		Username: body.Username,
+		Password: body.Password,
+		APIKey:   body.APIKey,
+	})
+	if errors.Is(err, source_types.ErrInvalidCredentials) {
+		s.l.Warn("Login refused", "source", source.GetInformation().ID, "error", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		s.l.Error("Error logging in", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *SourceRouter) homeSectionsHandler(w http.ResponseWriter, r *http.Request) {
+	source, ok := s.getSource(w, r)
+	if !ok {
+		return
+	}
+
+	homeSource, ok := source.(source_types.HomeSectionsSourceAPI)
+	if !ok {
+		s.l.Warn("Source doesn't support home sections", "source", source.GetInformation().ID)
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	ctx, ok := s.contentRatingsContext(w, r, source)
+	if !ok {
+		return
+	}
+
+	data, err := homeSource.FetchHomeSections(ctx)
+	if err != nil {
+		s.l.Error("Error fetching home sections", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(data)
+	if err != nil {
+		s.l.Error("Error marshalling sources", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *SourceRouter) randomSerieHandler(w http.ResponseWriter, r *http.Request) {
+	source, ok := s.getSource(w, r)
+	if !ok {
+		return
+	}
+
+	randomSource, ok := source.(source_types.RandomSerieSourceAPI)
+	if !ok {
+		s.l.Warn("Source doesn't support random serie", "source", source.GetInformation().ID)
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	ctx, ok := s.contentRatingsContext(w, r, source)
+	if !ok {
+		return
+	}
+
+	data, err := randomSource.FetchRandomSerie(ctx)
+	if err != nil {
+		s.l.Error("Error fetching random serie", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(data)
+	if err != nil {
+		s.l.Error("Error marshalling sources", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *SourceRouter) authorSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	source, ok := s.getSource(w, r)
+	if !ok {
+		return
+	}
+
+	authorSource, ok := source.(source_types.AuthorSeriesSourceAPI)
+	if !ok {
+		s.l.Warn("Source doesn't support author series", "source", source.GetInformation().ID)
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	author := http_utils.ExtractPathParam(r, "author", "")
+	if author == "" {
+		s.l.Error("No author provided")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	p := http_utils.ExtractQueryValue(r, "page", "1")
+	page, err := strconv.Atoi(p)
+	if err != nil {
+		s.l.Error("Error parsing page", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, ok := s.contentRatingsContext(w, r, source)
+	if !ok {
+		return
+	}
+
+	data, err := authorSource.FetchAuthorSeries(ctx, author, page)
+	if errors.Is(err, source_types.ErrInvalidSearchQuery) {
+		s.l.Warn("Invalid author", "author", author, "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.l.Error("Error fetching author series", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(data)
+	if err != nil {
+		s.l.Error("Error marshalling sources", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *SourceRouter) chapterUrlHandler(w http.ResponseWriter, r *http.Request) {
+	source, ok := s.getSource(w, r)
+	if !ok {
+		return
+	}
+
+	chapterSource, ok := source.(source_types.ChapterURLSourceAPI)
+	if !ok {
+		s.l.Warn("Source doesn't support chapter url", "source", source.GetInformation().ID)
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	serieID := http_utils.ExtractPathParam(r, "serieID", "")
+	volumeID := http_utils.ExtractPathParam(r, "volumeID", "")
+	chapterID := http_utils.ExtractPathParam(r, "chapterID", "")
+	if serieID == "" || volumeID == "" || chapterID == "" {
+		s.l.Error("No serie, volume or chapter ID provided")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	url, err := chapterSource.ChapterUrl(source_types.SourceSerieID(serieID), source_types.SourceSerieVolumeID(volumeID), source_types.SourceSerieVolumeChapterID(chapterID))
+	if err != nil {
+		s.l.Error("Error generating chapter url", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	data := SerieURL{URL: url.String()}
 
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(data)
+	if err != nil {
+		s.l.Error("Error marshalling sources", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// signChapterImages rewrites the chapter images to signed urls of the image proxy, so they can be loaded without the API key
+func (s *SourceRouter) signChapterImages(source source_types.SourceAPI, data source_types.SourceSerieVolumeChapterData) (source_types.SourceSerieVolumeChapterData, error) {
+	baseURL, err := url.Parse(s.cfg.SourceAPIURL)
+	if err != nil {
+		return data, errors.Join(source_types.ErrParsingURL, err)
+	}
+
+	proxyURL := baseURL.JoinPath("/api/v1/sources", string(source.GetInformation().ID), "proxy")
+	hosts := source.GetAPIInformation().ImageHosts
+	_, canDecrypt := source.(source_types.ImageDecrypter)
+
+	images := make([]source_types.SourceSerieVolumeChapterImage, len(data.Images))
+	for i, image := range data.Images {
+		images[i] = image
+
+		imageURL, err := url.Parse(image.URL)
+		if err != nil || !http_utils.MatchHost(imageURL.Hostname(), hosts) {
+			s.l.Warn("Image can't be proxied, keeping original url", "url", image.URL)
+			continue
+		}
+
+		if image.EncryptionKey != "" && !canDecrypt {
+			s.l.Warn("Encrypted image can't be decrypted, keeping original url", "url", image.URL)
+			continue
+		}
+
+		u := *proxyURL
+		q := u.Query()
+		q.Set("url", image.URL)
+		// The key is signed with the url, the proxy serves the decrypted image
+		if image.EncryptionKey != "" {
+			q.Set("key", image.EncryptionKey)
+			images[i].EncryptionKey = ""
+			images[i].NeedsProcessing = false
+		}
+		// The proxy sends the headers of the image, the client doesn't need them anymore
+		if image.Headers.Referer != "" {
+			q.Set("referer", image.Headers.Referer)
+		}
+		if image.Headers.Cookie != "" {
+			q.Set("cookie_id", s.cookies.store(string(source.GetInformation().ID), image.Headers.Cookie))
+		}
+		images[i].Headers = source_types.SourceSerieVolumeChapterImageHeaders{}
+		u.RawQuery = q.Encode()
+
+		images[i].URL = s.signer.Sign(&u, http_utils.SCOPE_PROXY).String()
+	}
+
+	data.Images = images
+
+	return data, nil
+}
+
+// Headers of the upstream response forwarded to the client
+var proxyForwardedHeaders = []string{"Content-Type", "Content-Length", "Content-Range", "Accept-Ranges", "Cache-Control", "ETag", "Last-Modified", "Expires"}
+
+// Headers of the client request forwarded upstream, for conditional and range requests
+var proxyRequestHeaders = []string{"Range", "If-Range", "If-None-Match", "If-Modified-Since"}
+
+func (s *SourceRouter) imageProxyHandler(w http.ResponseWriter, r *http.Request) {
+	source, ok := s.getSource(w, r)
+	if !ok {
+		return
+	}
+
+	rawURL := http_utils.ExtractQueryValue(r, "url", "")
+	if rawURL == "" {
+		s.l.Error("No url provided")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	imageURL, err := url.Parse(rawURL)
+	if err != nil || (imageURL.Scheme != "https" && imageURL.Scheme != "http") || imageURL.User != nil {
+		s.l.Error("Invalid url provided", "url", rawURL, "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	info := source.GetInformation()
+
+	apiInfo := source.GetAPIInformation()
+
+	key := http_utils.ExtractQueryValue(r, "key", "")
+	decrypter, canDecrypt := source.(source_types.ImageDecrypter)
+	if key != "" && !canDecrypt {
+		s.l.Error("Source can't decrypt images", "source", info.ID)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !http_utils.MatchHost(imageURL.Hostname(), apiInfo.ImageHosts) {
+		s.l.Warn("Host not allowed for source", "source", info.ID, "host", imageURL.Hostname())
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), proxyHostsKey{}, apiInfo.ImageHosts)
+	if apiInfo.SelfHosted {
+		ctx = http_utils.WithPrivateAddresses(ctx)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL.String(), nil)
+	if err != nil {
+		s.l.Error("Error building proxy request", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	req.Header = apiInfo.Headers.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+
+	// Headers the source needs for this image, over the headers of the source
+	if headersSource, ok := source.(source_types.ImageHeadersSourceAPI); ok {
+		for name, values := range headersSource.ImageHeaders(imageURL) {
+			req.Header[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+
+	// Headers of the image, signed with the url by signChapterImages, the cookie is kept server side
+	if referer := http_utils.ExtractQueryValue(r, "referer", ""); referer != "" {
+		req.Header.Set("Referer", referer)
+	}
+	if cookieID := http_utils.ExtractQueryValue(r, "cookie_id", ""); cookieID != "" {
+		if cookie := s.cookies.load(string(info.ID), cookieID); cookie != "" {
+			req.Header.Set("Cookie", cookie)
+		}
+	}
+
+	if req.Header.Get("Referer") == "" {
+		req.Header.Set("Referer", info.URL+"/")
+	}
+
+	for _, h := range proxyRequestHeaders {
+		// A range of an encrypted image can't be decrypted without the offset
+		if key != "" && (h == "Range" || h == "If-Range") {
+			continue
+		}
+
+		if v := r.Header.Get(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+
+	resp, err := s.proxyClient.Do(req)
+	if err != nil {
+		if errors.Is(err, http_utils.ErrForbiddenAddress) {
+			s.l.Warn("Proxy request refused", "url", imageURL.String(), "error", err)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		s.l.Error("Error fetching proxied image", "url", imageURL.String(), "error", err)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusNotModified {
+		s.l.Error("Upstream returned an error", "url", imageURL.String(), "status", resp.Status)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	if resp.ContentLength > proxyMaxSize {
+		s.l.Error("Proxied image is too big", "url", imageURL.String(), "size", resp.ContentLength)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	body := io.Reader(io.LimitReader(resp.Body, proxyMaxSize))
+	contentType := resp.Header.Get("Content-Type")
+
+	// Encrypted images are rarely served with an image content type, it is detected once decrypted
+	if key != "" && resp.StatusCode != http.StatusNotModified {
+		decrypted, err := decrypter.DecryptImage(body, key)
+		if err != nil {
+			s.l.Error("Error decrypting proxied image", "url", imageURL.String(), "error", err)
+			w.WriteHeader(http.StatusBadGateway)
 			return
 		}
+
+		buffered := bufio.NewReader(decrypted)
+		head, _ := buffered.Peek(512)
+		contentType = http.DetectContentType(head)
+		body = buffered
+	}
+
+	if resp.StatusCode != http.StatusNotModified && !strings.HasPrefix(contentType, "image/") {
+		s.l.Error("Upstream did not return an image", "url", imageURL.String(), "content_type", contentType)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	for _, h := range proxyForwardedHeaders {
+		// The decrypted image may not have the length of the encrypted one
+		if key != "" && (h == "Content-Length" || h == "Content-Range" || h == "Accept-Ranges") {
+			continue
+		}
+
+		if v := resp.Header.Get(h); v != "" {
+			w.Header().Set(h, v)
+		}
 	}
 
+	if key != "" && resp.StatusCode != http.StatusNotModified {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	w.WriteHeader(resp.StatusCode)
+	_, err = io.Copy(w, body)
+	if err != nil {
+		s.l.Error("Error streaming proxied image", "url", imageURL.String(), "error", err)
+	}
 }
 
 func (s *SourceRouter) chapterHandler(w http.ResponseWriter, r *http.Request) {
-	sourceID := http_utils.ExtractPathParam(r, "sourceID", "")
-	if sourceID == "" {
-		s.l.Error("No source ID provided")
-		w.WriteHeader(http.StatusBadRequest)
+	source, ok := s.getSource(w, r)
+	if !ok {
 		return
 	}
 
@@ -137,39 +615,88 @@ func (s *SourceRouter) chapterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for _, source := range s.sources {
-		info := source.GetInformation()
+	// The source picks its default quality when none is asked
+	quality := source.GetAPIInformation().ImageQuality
+	if q := http_utils.ExtractQueryValue(r, "quality", ""); q != "" {
+		parsed, err := source_types.NewSourceImageQuality(q)
+		if err != nil {
+			s.l.Error("Error parsing quality", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
 
-		if string(info.ID) == sourceID {
-			data, err := source.FetchChapterData(r.Context(), source_types.SourceSerieID(serieID), source_types.SourceSerieVolumeID(volumeID), source_types.SourceSerieVolumeChapterID(chapterID))
-			if err != nil {
-				s.l.Error("Error fetching serie information", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+		quality = parsed
+	}
 
-			w.Header().Set("Content-Type", "application/json; charset=utf-8")
-			encoder := json.NewEncoder(w)
-			err = encoder.Encode(data)
-			if err != nil {
-				s.l.Error("Error marshalling sources", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+	data, err := source_types.FetchChapterDataQuality(r.Context(), source, source_types.SourceSerieID(serieID), source_types.SourceSerieVolumeID(volumeID), source_types.SourceSerieVolumeChapterID(chapterID), quality)
+	if errors.Is(err, source_types.ErrInvalidImageQuality) {
+		s.l.Warn("Quality not supported by the source", "quality", quality, "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.l.Error("Error fetching serie information", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
+	if http_utils.ExtractQueryValue(r, "signed", "") == "true" {
+		data, err = s.signChapterImages(source, data)
+		if err != nil {
+			s.l.Error("Error signing chapter images", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(data)
+	if err != nil {
+		s.l.Error("Error marshalling sources", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (s *SourceRouter) serieHandler(w http.ResponseWriter, r *http.Request) {
-	sourceID := http_utils.ExtractPathParam(r, "sourceID", "")
-	if sourceID == "" {
-		s.l.Error("No source ID provided")
+	source, ok := s.getSource(w, r)
+	if !ok {
+		return
+	}
+
+	serieID := http_utils.ExtractPathParam(r, "serieID", "")
+	if serieID == "" {
+		s.l.Error("No serie ID provided")
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
+	data, err := source.FetchSerieDetail(r.Context(), source_types.SourceSerieID(serieID))
+	if err != nil {
+		s.l.Error("Error fetching serie information", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	data.Volumes = chapterutils.ApplyGroupPreferences(data.Volumes, extractGroupPreferences(r))
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(data)
+	if err != nil {
+		s.l.Error("Error marshalling sources", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *SourceRouter) serieMetadataHandler(w http.ResponseWriter, r *http.Request) {
+	source, ok := s.getSource(w, r)
+	if !ok {
+		return
+	}
+
 	serieID := http_utils.ExtractPathParam(r, "serieID", "")
 	if serieID == "" {
 		s.l.Error("No serie ID provided")
@@ -177,36 +704,98 @@ func (s *SourceRouter) serieHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for _, source := range s.sources {
-		info := source.GetInformation()
+	data, err := source_types.FetchSerieMetadata(r.Context(), source, source_types.SourceSerieID(serieID))
+	if err != nil {
+		s.l.Error("Error fetching serie metadata", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
-		if string(info.ID) == sourceID {
-			data, err := source.FetchSerieDetail(r.Context(), source_types.SourceSerieID(serieID))
-			if err != nil {
-				s.l.Error("Error fetching serie information", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(data)
+	if err != nil {
+		s.l.Error("Error marshalling sources", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
 
-			w.Header().Set("Content-Type", "application/json; charset=utf-8")
-			encoder := json.NewEncoder(w)
-			err = encoder.Encode(data)
-			if err != nil {
-				s.l.Error("Error marshalling sources", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+func (s *SourceRouter) serieChaptersHandler(w http.ResponseWriter, r *http.Request) {
+	source, ok := s.getSource(w, r)
+	if !ok {
+		return
+	}
+
+	serieID := http_utils.ExtractPathParam(r, "serieID", "")
+	if serieID == "" {
+		s.l.Error("No serie ID provided")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
 
+	p := http_utils.ExtractQueryValue(r, "page", "1")
+	page, err := strconv.Atoi(p)
+	if err != nil || page < 1 {
+		s.l.Error("Error parsing page", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var since time.Time
+	if sc := http_utils.ExtractQueryValue(r, "since", ""); sc != "" {
+		since, err = time.Parse(time.RFC3339, sc)
+		if err != nil {
+			s.l.Error("Error parsing since", "error", err)
+			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 	}
+
+	data, err := source_types.FetchSerieChapters(r.Context(), source, source_types.SourceSerieID(serieID), source_types.FetchSerieChaptersFilter{
+		Page:  page,
+		Since: since,
+	})
+	if err != nil {
+		s.l.Error("Error fetching serie chapters", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// The releases of a chapter can be split across two pages, they are only collapsed within a page
+	data.Volumes = chapterutils.ApplyGroupPreferences(data.Volumes, extractGroupPreferences(r))
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(data)
+	if err != nil {
+		s.l.Error("Error marshalling sources", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// extractGroupPreferences reads the scanlation groups preferences of the serie, preferring a group implies collapsing the chapters
+func extractGroupPreferences(r *http.Request) chapterutils.GroupPreferences {
+	groups := func(param string) []string {
+		return slices.DeleteFunc(utils.SplitAndTrim(http_utils.ExtractQueryValue(r, param, ""), ","), func(group string) bool {
+			return group == ""
+		})
+	}
+
+	prefs := chapterutils.GroupPreferences{
+		Preferred: groups("preferred_groups"),
+		Blocked:   groups("blocked_groups"),
+		Collapse:  http_utils.ExtractQueryValue(r, "collapse", "") == "true",
+	}
+	prefs.Collapse = prefs.Collapse || len(prefs.Preferred) > 0
+
+	return prefs
 }
 
 func (s *SourceRouter) searchSeriesHandler(w http.ResponseWriter, r *http.Request) {
-	sourceID := http_utils.ExtractPathParam(r, "sourceID", "")
-	if sourceID == "" {
-		s.l.Error("No source ID provided")
-		w.WriteHeader(http.StatusBadRequest)
+	source, ok := s.getSource(w, r)
+	if !ok {
 		return
 	}
 
@@ -228,14 +817,14 @@ func (s *SourceRouter) searchSeriesHandler(w http.ResponseWriter, r *http.Reques
 
 	a := strings.Trim(http_utils.ExtractQueryValue(r, "artists", ""), "")
 	aa := strings.Split(a, ",")
-	artists := make([]string, len(aa))
+	artists := make([]string, 0, len(aa))
 	for _, artist := range aa {
 		artists = append(artists, artist)
 	}
 
 	a = strings.Trim(http_utils.ExtractQueryValue(r, "authors", ""), "")
 	aa = strings.Split(a, ",")
-	authors := make([]string, len(aa))
+	authors := make([]string, 0, len(aa))
 	for _, author := range aa {
 		authors = append(authors, author)
 	}
@@ -268,52 +857,53 @@ func (s *SourceRouter) searchSeriesHandler(w http.ResponseWriter, r *http.Reques
 		excludeGenres = append(excludeGenres, source_types.NewSourceSerieGenre(genre))
 	}
 
-	s.l.Info("filter", "query", query, "sort", sort, "order", ord, "artists", artists, "authors", authors, "types", types, "statuses", statuses, "include_genres", includeGenres, "exclude_genres", excludeGenres)
-
-	for _, source := range s.sources {
-		info := source.GetInformation()
-
-		if string(info.ID) == sourceID {
-			filter := source_types.FetchSearchSerieFilter{
-				Query:   query,
-				Sort:    sort,
-				Order:   ord,
-				Artists: artists,
-				Authors: authors,
-				Types:   types,
-				Status:  statuses,
-				Genres: source_types.FetchSearchSerieFilterGenres{
-					Include: includeGenres,
-					Exclude: excludeGenres,
-				},
-			}
+	contentRatings, ok := s.contentRatings(w, r, source)
+	if !ok {
+		return
+	}
 
-			data, err := source.FetchSearchSerie(r.Context(), page, filter)
-			if err != nil {
-				s.l.Error("Error fetching search series", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+	s.l.Info("filter", "query", query, "sort", sort, "order", ord, "artists", artists, "authors", authors, "types", types, "statuses", statuses, "include_genres", includeGenres, "exclude_genres", excludeGenres, "content_ratings", contentRatings)
+
+	filter := source_types.FetchSearchSerieFilter{
+		Query:   query,
+		Sort:    sort,
+		Order:   ord,
+		Artists: artists,
+		Authors: authors,
+		Types:   types,
+		Status:  statuses,
+		Genres: source_types.FetchSearchSerieFilterGenres{
+			Include: includeGenres,
+			Exclude: excludeGenres,
+		},
+		ContentRatings: contentRatings,
+	}
 
-			w.Header().Set("Content-Type", "application/json; charset=utf-8")
-			encoder := json.NewEncoder(w)
-			err = encoder.Encode(data)
-			if err != nil {
-				s.l.Error("Error marshalling sources", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+	data, err := source.FetchSearchSerie(r.Context(), page, filter)
+	if errors.Is(err, source_types.ErrInvalidSearchQuery) {
+		s.l.Warn("Invalid search", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.l.Error("Error fetching search series", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
-			return
-		}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(data)
+	if err != nil {
+		s.l.Error("Error marshalling sources", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 }
 
 func (s *SourceRouter) popularSeriesHandler(w http.ResponseWriter, r *http.Request) {
-	sourceID := http_utils.ExtractPathParam(r, "sourceID", "")
-	if sourceID == "" {
-		s.l.Error("No source ID provided")
-		w.WriteHeader(http.StatusBadRequest)
+	source, ok := s.getSource(w, r)
+	if !ok {
 		return
 	}
 
@@ -325,36 +915,31 @@ func (s *SourceRouter) popularSeriesHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	for _, source := range s.sources {
-		info := source.GetInformation()
-
-		if string(info.ID) == sourceID {
-			data, err := source.FetchPopularSerie(r.Context(), page)
-			if err != nil {
-				s.l.Error("Error fetching popular series", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+	ctx, ok := s.contentRatingsContext(w, r, source)
+	if !ok {
+		return
+	}
 
-			w.Header().Set("Content-Type", "application/json; charset=utf-8")
-			encoder := json.NewEncoder(w)
-			err = encoder.Encode(data)
-			if err != nil {
-				s.l.Error("Error marshalling sources", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+	data, err := source.FetchPopularSerie(ctx, page)
+	if err != nil {
+		s.l.Error("Error fetching popular series", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
-			return
-		}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(data)
+	if err != nil {
+		s.l.Error("Error marshalling sources", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 }
 
 func (s *SourceRouter) latestSeriesHandler(w http.ResponseWriter, r *http.Request) {
-	sourceID := http_utils.ExtractPathParam(r, "sourceID", "")
-	if sourceID == "" {
-		s.l.Error("No source ID provided")
-		w.WriteHeader(http.StatusBadRequest)
+	source, ok := s.getSource(w, r)
+	if !ok {
 		return
 	}
 
@@ -366,70 +951,173 @@ func (s *SourceRouter) latestSeriesHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	for _, source := range s.sources {
-		info := source.GetInformation()
-
-		if string(info.ID) == sourceID {
-			data, err := source.FetchLatestUpdates(r.Context(), page)
-			if err != nil {
-				s.l.Error("Error fetching popular series", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+	ctx, ok := s.contentRatingsContext(w, r, source)
+	if !ok {
+		return
+	}
 
-			w.Header().Set("Content-Type", "application/json; charset=utf-8")
-			encoder := json.NewEncoder(w)
-			err = encoder.Encode(data)
-			if err != nil {
-				s.l.Error("Error marshalling sources", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+	data, err := source.FetchLatestUpdates(ctx, page)
+	if err != nil {
+		s.l.Error("Error fetching popular series", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
-			return
-		}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(data)
+	if err != nil {
+		s.l.Error("Error marshalling sources", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 }
 
+// sourceHandler returns the source even when it is disabled, with its status, NSFW sources are refused when they are not allowed
 func (s *SourceRouter) sourceHandler(w http.ResponseWriter, r *http.Request) {
 	sourceID := http_utils.ExtractPathParam(r, "sourceID", "")
-	if sourceID == "" {
-		s.l.Error("No source ID provided")
-		w.WriteHeader(http.StatusBadRequest)
+	status, err := s.sources.Status(source_types.SourceID(sourceID))
+	if errors.Is(err, sources.ErrSourceNotFound) {
+		w.WriteHeader(http.StatusNotFound)
 		return
 	}
 
-	for _, source := range s.sources {
-		info := source.GetInformation()
-
-		if string(info.ID) == sourceID {
-			w.Header().Set("Content-Type", "application/json; charset=utf-8")
-			encoder := json.NewEncoder(w)
-			err := encoder.Encode(source.GetInformation())
-			if err != nil {
-				s.l.Error("Error marshalling sources", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+	if !s.cfg.SourceAllowNSFW && status.NSFW {
+		s.l.Warn("NSFW source refused", "source_id", sourceID)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
 
-			return
-		}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(status)
+	if err != nil {
+		s.l.Error("Error marshalling sources", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 }
 
+// sourcesHandler returns the enabled sources, and the disabled ones too with all=true, NSFW sources are hidden when they are not allowed
 func (s *SourceRouter) sourcesHandler(w http.ResponseWriter, r *http.Request) {
-	var info []source_types.SourceInformation
-
-	for _, source := range s.sources {
-		info = append(info, source.GetInformation())
-	}
+	all := http_utils.ExtractQueryValue(r, "all", "") == "true"
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	encoder := json.NewEncoder(w)
-	err := encoder.Encode(info)
+	statuses := s.sources.Statuses(all)
+	if !s.cfg.SourceAllowNSFW {
+		statuses = slices.DeleteFunc(statuses, func(status sources.SourceStatus) bool { return status.NSFW })
+	}
+
+	err := encoder.Encode(statuses)
 	if err != nil {
 		s.l.Error("Error marshalling sources", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 }
+
+func (s *SourceRouter) enableSourceHandler(w http.ResponseWriter, r *http.Request) {
+	s.setSourceEnabled(w, r, true)
+}
+
+func (s *SourceRouter) disableSourceHandler(w http.ResponseWriter, r *http.Request) {
+	s.setSourceEnabled(w, r, false)
+}
+
+func (s *SourceRouter) setSourceEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	err := s.sources.SetEnabled(source_types.SourceID(http_utils.ExtractPathParam(r, "sourceID", "")), enabled)
+	if errors.Is(err, sources.ErrSourceNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.l.Error("Error toggling source", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getSource returns the source of the request, or writes the error response when it can't be used
+func (s *SourceRouter) getSource(w http.ResponseWriter, r *http.Request) (source_types.SourceAPI, bool) {
+	sourceID := http_utils.ExtractPathParam(r, "sourceID", "")
+	if sourceID == "" {
+		s.l.Error("No source ID provided")
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, false
+	}
+
+	source, err := s.sources.Get(source_types.SourceID(sourceID))
+	switch {
+	case errors.Is(err, sources.ErrSourceNotFound):
+		s.l.Warn("Unknown source", "source_id", sourceID)
+		w.WriteHeader(http.StatusNotFound)
+		return nil, false
+	case errors.Is(err, sources.ErrSourceDisabled):
+		s.l.Warn("Source disabled", "source_id", sourceID)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return nil, false
+	}
+
+	if !s.cfg.SourceAllowNSFW && source.GetInformation().NSFW {
+		s.l.Warn("NSFW source refused", "source_id", sourceID)
+		w.WriteHeader(http.StatusForbidden)
+		return nil, false
+	}
+
+	return source, true
+}
+
+// contentRatings parses the content_ratings of the request, they must be supported by the source.
+// When NSFW is not allowed, NSFW ratings are refused and the request is restricted to the other ratings of the source
+func (s *SourceRouter) contentRatings(w http.ResponseWriter, r *http.Request, source source_types.SourceAPI) ([]source_types.SourceContentRating, bool) {
+	supported := source.GetInformation().SearchFilters.ContentRatings
+
+	var ratings []source_types.SourceContentRating
+	for _, rr := range strings.Split(http_utils.ExtractQueryValue(r, "content_ratings", ""), ",") {
+		if strings.TrimSpace(rr) == "" {
+			continue
+		}
+
+		rating, err := source_types.NewSourceContentRating(rr)
+		if err != nil || !slices.Contains(supported, rating) {
+			s.l.Warn("Invalid content rating", "content_rating", rr, "source", source.GetInformation().ID)
+			w.WriteHeader(http.StatusBadRequest)
+			return nil, false
+		}
+
+		if rating.IsNSFW() && !s.cfg.SourceAllowNSFW {
+			s.l.Warn("NSFW content rating refused", "content_rating", rating)
+			w.WriteHeader(http.StatusForbidden)
+			return nil, false
+		}
+
+		ratings = append(ratings, rating)
+	}
+
+	if len(ratings) == 0 && !s.cfg.SourceAllowNSFW {
+		for _, rating := range supported {
+			if !rating.IsNSFW() {
+				ratings = append(ratings, rating)
+			}
+		}
+	}
+
+	return ratings, true
+}
+
+// contentRatingsContext returns the request context restricted to the content ratings of the request, for the feeds without a search filter
+func (s *SourceRouter) contentRatingsContext(w http.ResponseWriter, r *http.Request, source source_types.SourceAPI) (context.Context, bool) {
+	ratings, ok := s.contentRatings(w, r, source)
+	if !ok {
+		return nil, false
+	}
+
+	if len(ratings) == 0 {
+		return r.Context(), true
+	}
+
+	return source_types.WithContentRatings(r.Context(), ratings), true
+}
@@ -0,0 +1,79 @@
+package http_router
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Upper bound of the cookies kept for the image proxy, expired ones are dropped first
+const proxyCookiesMaxEntries = 10000
+
+// proxyCookies keeps the cookies of the proxied images server side, the signed urls only carry their ID
+type proxyCookies struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]proxyCookie
+}
+
+type proxyCookie struct {
+	sourceID string
+	value    string
+	expires  time.Time
+}
+
+func newProxyCookies(ttl time.Duration) *proxyCookies {
+	return &proxyCookies{
+		ttl:     ttl,
+		entries: map[string]proxyCookie{},
+	}
+}
+
+// store keeps the cookie for as long as the signed urls are valid and returns its ID, the same cookie always gets the same ID
+func (c *proxyCookies) store(sourceID, value string) string {
+	hash := sha256.Sum256([]byte(sourceID + "\x00" + value))
+	id := hex.EncodeToString(hash[:])
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[id]; !ok && len(c.entries) >= proxyCookiesMaxEntries {
+		for key, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+
+		// Still full, any entry makes room, its images will be proxied without the cookie
+		for key := range c.entries {
+			if len(c.entries) < proxyCookiesMaxEntries {
+				break
+			}
+			delete(c.entries, key)
+		}
+	}
+
+	c.entries[id] = proxyCookie{sourceID: sourceID, value: value, expires: now.Add(c.ttl)}
+
+	return id
+}
+
+// load returns the cookie stored for the source, empty when unknown or expired
+func (c *proxyCookies) load(sourceID, id string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[id]
+	if !ok || entry.sourceID != sourceID {
+		return ""
+	}
+
+	if time.Now().After(entry.expires) {
+		delete(c.entries, id)
+		return ""
+	}
+
+	return entry.value
+}
@@ -0,0 +1,51 @@
+package http_utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// CacheControlImmutable is used for URLs that change when their content change, like content hash URLs
+	CacheControlImmutable = "public, max-age=31536000, immutable"
+	// CacheControlRevalidate lets clients keep a copy but forces them to check the ETag before using it
+	CacheControlRevalidate = "public, no-cache"
+	// CacheControlNoStore is used for responses that don't match their URL, like the mock image served for any hash
+	CacheControlNoStore = "no-store"
+)
+
+// ContentETag returns a strong ETag derived from the SHA-256 of the content.
+func ContentETag(content io.Reader) (string, error) {
+	h := sha256.New()
+
+	_, err := io.Copy(h, content)
+	if err != nil {
+		return "", fmt.Errorf("Failed to hash content: %w", err)
+	}
+
+	return fmt.Sprintf(`"%s"`, hex.EncodeToString(h.Sum(nil))), nil
+}
+
+// ServeContent serves the content with caching headers, it handles conditional requests (If-None-Match, If-Modified-Since)
+// and byte ranges. contentType is detected from the content when empty.
+func ServeContent(w http.ResponseWriter, r *http.Request, content io.ReadSeeker, etag string, modTime time.Time, contentType string, cacheControl string) {
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	if cacheControl != "" {
+		w.Header().Set("Cache-Control", cacheControl)
+	}
+
+	w.Header().Set("Accept-Ranges", "bytes")
+
+	http.ServeContent(w, r, "", modTime, content)
+}
@@ -0,0 +1,116 @@
+package http_utils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dokusho/pkg/http_utils"
+)
+
+func TestContentETag(t *testing.T) {
+	t.Parallel()
+
+	a, err := http_utils.ContentETag(strings.NewReader("page"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := http_utils.ContentETag(strings.NewReader("page"))
+	c, _ := http_utils.ContentETag(strings.NewReader("other page"))
+
+	if a != b {
+		t.Errorf("Same content should have the same etag, got %s and %s", a, b)
+	}
+
+	if a == c {
+		t.Error("Different content should have different etags")
+	}
+
+	if !strings.HasPrefix(a, `"`) || strings.HasPrefix(a, `W/`) {
+		t.Errorf("ETag should be strong and quoted, got %s", a)
+	}
+}
+
+func TestServeContent(t *testing.T) {
+	t.Parallel()
+
+	content := "0123456789"
+	etag, _ := http_utils.ContentETag(strings.NewReader(content))
+	modTime := time.Date(2025, time.January, 7, 18, 0, 0, 0, time.UTC)
+
+	serve := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/files/page", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		rec := httptest.NewRecorder()
+		http_utils.ServeContent(rec, req, strings.NewReader(content), etag, modTime, "image/jpeg", http_utils.CacheControlImmutable)
+
+		return rec
+	}
+
+	t.Run("full", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serve(nil)
+		if rec.Code != http.StatusOK || rec.Body.String() != content {
+			t.Errorf("Expected 200 with full content, got %d %q", rec.Code, rec.Body.String())
+		}
+
+		if rec.Header().Get("ETag") != etag {
+			t.Errorf("Expected ETag %s, got %s", etag, rec.Header().Get("ETag"))
+		}
+
+		if rec.Header().Get("Cache-Control") != http_utils.CacheControlImmutable {
+			t.Errorf("Unexpected Cache-Control %s", rec.Header().Get("Cache-Control"))
+		}
+
+		if rec.Header().Get("Last-Modified") == "" {
+			t.Error("Last-Modified should be set")
+		}
+	})
+
+	t.Run("if-none-match", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serve(map[string]string{"If-None-Match": etag})
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("Expected 304 without body, got %d %q", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("if-modified-since", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serve(map[string]string{"If-Modified-Since": modTime.Format(http.TimeFormat)})
+		if rec.Code != http.StatusNotModified {
+			t.Errorf("Expected 304, got %d", rec.Code)
+		}
+	})
+
+	t.Run("range", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serve(map[string]string{"Range": "bytes=2-5"})
+		if rec.Code != http.StatusPartialContent || rec.Body.String() != "2345" {
+			t.Errorf("Expected 206 with bytes 2-5, got %d %q", rec.Code, rec.Body.String())
+		}
+
+		if rec.Header().Get("Content-Range") != "bytes 2-5/10" {
+			t.Errorf("Unexpected Content-Range %s", rec.Header().Get("Content-Range"))
+		}
+	})
+
+	t.Run("stale if-range", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serve(map[string]string{"Range": "bytes=2-5", "If-Range": `"stale"`})
+		if rec.Code != http.StatusOK || rec.Body.String() != content {
+			t.Errorf("Expected 200 with full content when If-Range does not match, got %d %q", rec.Code, rec.Body.String())
+		}
+	})
+}
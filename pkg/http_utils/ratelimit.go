@@ -0,0 +1,270 @@
+package http_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var ErrRateLimited = errors.New("rate limited")
+
+const (
+	// Retries of a request answered with 429 or 503, only idempotent requests are retried
+	rateLimitMaxRetries = 3
+	// Backoff of the first retry when the server doesn't say how long to wait, doubled on each retry
+	rateLimitBaseBackoff = time.Second
+)
+
+// RateLimit allows Requests every Per, up to Burst requests at once
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// tokenBucket refills continuously, a request waiting for a token takes it in advance so waiting requests are spread over time
+type tokenBucket struct {
+	mu           sync.Mutex
+	rate         float64
+	burst        float64
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	burst := max(limit.Burst, 1)
+
+	return &tokenBucket{
+		rate:   float64(limit.Requests) / limit.Per.Seconds(),
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// reserve takes a token and returns how long to wait before using it
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	b.tokens--
+
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+
+	return max(wait, b.blockedUntil.Sub(now))
+}
+
+// cancel gives back a token reserved by a request that gave up waiting
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+// block stops handing out tokens until the time, an earlier time doesn't shorten the current block
+func (b *tokenBucket) block(until time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if until.After(b.blockedUntil) {
+		b.blockedUntil = until
+	}
+}
+
+// RateLimitedTransport limits the requests with a global bucket and a bucket per class of endpoints, and retries the requests
+// answered with 429 or 503 after the delay asked by the server. A single transport must be shared by all the clients of a
+// server for the limits to hold, it is safe for concurrent use.
+type RateLimitedTransport struct {
+	next     http.RoundTripper
+	global   *tokenBucket
+	classes  map[string]*tokenBucket
+	classify func(req *http.Request) string
+	logger   *slog.Logger
+	// Timeout of each attempt, from sending the request to closing the response body, the waits between attempts are not included
+	attemptTimeout time.Duration
+}
+
+// NewRateLimitedTransport sends the requests with next, classify returns the class of a request, a class without limit only uses the global bucket
+func NewRateLimitedTransport(next http.RoundTripper, global RateLimit, classes map[string]RateLimit, classify func(req *http.Request) string) *RateLimitedTransport {
+	buckets := make(map[string]*tokenBucket, len(classes))
+	for class, limit := range classes {
+		buckets[class] = newTokenBucket(limit)
+	}
+
+	return &RateLimitedTransport{
+		next:     next,
+		global:   newTokenBucket(global),
+		classes:  buckets,
+		classify: classify,
+		logger:   slog.Default().WithGroup("rate_limit"),
+	}
+}
+
+// WithAttemptTimeout returns a transport sharing the limits of t, with a timeout applied to each attempt.
+// It replaces http.Client.Timeout, which would also cover the waits asked by the server and give up before retrying.
+func (t *RateLimitedTransport) WithAttemptTimeout(timeout time.Duration) *RateLimitedTransport {
+	c := *t
+	c.attemptTimeout = timeout
+
+	return &c
+}
+
+// SetClientTimeout applies the timeout to each attempt when the client is rate limited, to the whole request otherwise
+func SetClientTimeout(client *http.Client, timeout time.Duration) {
+	if t, ok := client.Transport.(*RateLimitedTransport); ok {
+		client.Timeout = 0
+		client.Transport = t.WithAttemptTimeout(timeout)
+
+		return
+	}
+
+	client.Timeout = timeout
+}
+
+func (t *RateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	buckets := []*tokenBucket{t.global}
+	if bucket, ok := t.classes[t.classify(req)]; ok {
+		buckets = append(buckets, bucket)
+	}
+
+	retryable := req.Method == http.MethodGet || req.Method == http.MethodHead
+
+	for attempt := 0; ; attempt++ {
+		err := t.wait(req.Context(), buckets)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.attempt(req)
+		if err != nil {
+			return nil, err
+		}
+
+		delay, limited := retryDelay(resp, attempt)
+		if delay > 0 {
+			// A 503 is an outage of the whole server, a 429 or an exhausted limit only concerns the endpoints of the class
+			blocked := buckets[len(buckets)-1]
+			if resp.StatusCode == http.StatusServiceUnavailable {
+				blocked = t.global
+			}
+			blocked.block(time.Now().Add(delay))
+		}
+
+		if !limited || !retryable || attempt >= rateLimitMaxRetries {
+			return resp, nil
+		}
+
+		t.logger.Warn("Rate limited, retrying", "url", req.URL.String(), "status", resp.Status, "delay", delay, "attempt", attempt+1)
+
+		resp.Body.Close()
+	}
+}
+
+// attempt sends the request once, within the attempt timeout when there is one
+func (t *RateLimitedTransport) attempt(req *http.Request) (*http.Response, error) {
+	if t.attemptTimeout <= 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.attemptTimeout)
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The body is read after the round trip, the attempt only ends once it is closed
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
+
+// wait reserves a token in every bucket and waits for the last one, the tokens are given back when the request can't wait long enough
+func (t *RateLimitedTransport) wait(ctx context.Context, buckets []*tokenBucket) error {
+	now := time.Now()
+
+	var wait time.Duration
+	for _, bucket := range buckets {
+		wait = max(wait, bucket.reserve(now))
+	}
+
+	if wait <= 0 {
+		return nil
+	}
+
+	cancel := func() {
+		for _, bucket := range buckets {
+			bucket.cancel()
+		}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+		cancel()
+		return errors.Join(ErrRateLimited, fmt.Errorf("waiting %s would exceed the request deadline", wait))
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		cancel()
+		return errors.Join(ErrRateLimited, ctx.Err())
+	}
+}
+
+// retryDelay returns how long the server asks to wait, and whether the request was refused because of it.
+// Retry-After is in seconds or an HTTP date, X-RateLimit-Retry-After is a unix timestamp sent once the limit is exhausted.
+func retryDelay(resp *http.Response, attempt int) (time.Duration, bool) {
+	limited := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+
+	var delay time.Duration
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			delay = time.Duration(seconds) * time.Second
+		} else if date, err := http.ParseTime(retryAfter); err == nil {
+			delay = time.Until(date)
+		}
+	}
+
+	if delay <= 0 && (limited || resp.Header.Get("X-RateLimit-Remaining") == "0") {
+		if timestamp, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Retry-After"), 10, 64); err == nil {
+			delay = time.Until(time.Unix(timestamp, 0))
+		}
+	}
+
+	if delay <= 0 && limited {
+		delay = rateLimitBaseBackoff << attempt
+	}
+
+	return max(delay, 0), limited
+}
@@ -0,0 +1,190 @@
+package http_utils_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"dokusho/pkg/http_utils"
+)
+
+// newLimitedServer answers with the status and headers until it has been called limited times, then with 200
+func newLimitedServer(t *testing.T, limited int32, status int, headers map[string]string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= limited {
+			for name, value := range headers {
+				w.Header().Set(name, value)
+			}
+			w.WriteHeader(status)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &calls
+}
+
+func newRateLimitedClient(global http_utils.RateLimit) *http.Client {
+	return &http.Client{
+		Transport: http_utils.NewRateLimitedTransport(http.DefaultTransport, global, nil, func(req *http.Request) string { return "" }),
+	}
+}
+
+func TestRateLimitedTransportRetries(t *testing.T) {
+	t.Parallel()
+
+	unlimited := http_utils.RateLimit{Requests: 100, Per: time.Second, Burst: 100}
+
+	tests := []struct {
+		name     string
+		method   string
+		status   int
+		headers  map[string]string
+		calls    int32
+		expected int
+		minDelay time.Duration
+	}{
+		{
+			name:     "retry after seconds",
+			method:   http.MethodGet,
+			status:   http.StatusTooManyRequests,
+			headers:  map[string]string{"Retry-After": "1"},
+			calls:    2,
+			expected: http.StatusOK,
+			minDelay: time.Second,
+		},
+		{
+			name:     "backoff when the limit reset is past",
+			method:   http.MethodGet,
+			status:   http.StatusServiceUnavailable,
+			headers:  map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Retry-After": "0"},
+			calls:    2,
+			expected: http.StatusOK,
+			minDelay: time.Second,
+		},
+		{
+			name:     "no retry of a post",
+			method:   http.MethodPost,
+			status:   http.StatusTooManyRequests,
+			headers:  map[string]string{"Retry-After": "1"},
+			calls:    1,
+			expected: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, calls := newLimitedServer(t, 1, tc.status, tc.headers)
+			client := newRateLimitedClient(unlimited)
+
+			req, err := http.NewRequest(tc.method, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Now()
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.expected {
+				t.Errorf("expected status %d, got %d", tc.expected, resp.StatusCode)
+			}
+			if calls.Load() != tc.calls {
+				t.Errorf("expected %d calls, got %d", tc.calls, calls.Load())
+			}
+			if elapsed := time.Since(start); elapsed < tc.minDelay {
+				t.Errorf("expected to wait at least %s, waited %s", tc.minDelay, elapsed)
+			}
+		})
+	}
+}
+
+func TestRateLimitedTransportBucket(t *testing.T) {
+	t.Parallel()
+
+	server, calls := newLimitedServer(t, 0, http.StatusOK, nil)
+	client := newRateLimitedClient(http_utils.RateLimit{Requests: 10, Per: time.Second, Burst: 1})
+
+	start := time.Now()
+	for range 3 {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 calls, got %d", calls.Load())
+	}
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("expected the requests to be spread over 200ms, took %s", elapsed)
+	}
+}
+
+func TestRateLimitedTransportDeadline(t *testing.T) {
+	t.Parallel()
+
+	server, calls := newLimitedServer(t, 1, http.StatusTooManyRequests, map[string]string{"Retry-After": "60"})
+	client := newRateLimitedClient(http_utils.RateLimit{Requests: 100, Per: time.Second, Burst: 100})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	_, err = client.Do(req)
+	if !errors.Is(err, http_utils.ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 call, got %d", calls.Load())
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected to give up without waiting, waited %s", elapsed)
+	}
+}
+
+func TestRateLimitedTransportAttemptTimeout(t *testing.T) {
+	t.Parallel()
+
+	server, calls := newLimitedServer(t, 1, http.StatusTooManyRequests, map[string]string{"Retry-After": "1"})
+	transport := http_utils.NewRateLimitedTransport(http.DefaultTransport, http_utils.RateLimit{Requests: 100, Per: time.Second, Burst: 100}, nil, func(req *http.Request) string { return "" })
+	// The server asks to wait longer than the timeout, only the attempts are bound by it
+	client := &http.Client{Transport: transport.WithAttemptTimeout(500 * time.Millisecond)}
+
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 calls, got %d", calls.Load())
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected to wait at least 1s, waited %s", elapsed)
+	}
+}
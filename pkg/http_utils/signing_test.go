@@ -0,0 +1,107 @@
+package http_utils_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"dokusho/pkg/http_utils"
+)
+
+func TestURLSigner(t *testing.T) {
+	t.Parallel()
+
+	signer := http_utils.NewURLSigner("secret", time.Hour)
+	u, _ := url.Parse("https://files.example.com/files/serie/volume/chapter/1?v=0123456789abcdef")
+	signed := signer.Sign(u, http_utils.SCOPE_PAGE)
+
+	tamper := func(f func(u *url.URL)) *url.URL {
+		c := *signed
+		f(&c)
+		return &c
+	}
+
+	tests := []struct {
+		name     string
+		u        *url.URL
+		scope    http_utils.SignedURLScope
+		signer   *http_utils.URLSigner
+		expected error
+	}{
+		{"valid", signed, http_utils.SCOPE_PAGE, signer, nil},
+		{"missing signature", u, http_utils.SCOPE_PAGE, signer, http_utils.ErrMissingSignature},
+		{"other key", signed, http_utils.SCOPE_PAGE, http_utils.NewURLSigner("other", time.Hour), http_utils.ErrInvalidSignature},
+		{"wrong scope", signed, http_utils.SCOPE_COVER, signer, http_utils.ErrInvalidScope},
+		{"tampered path", tamper(func(u *url.URL) { u.Path = "/files/serie/volume/chapter/2" }), http_utils.SCOPE_PAGE, signer, http_utils.ErrInvalidSignature},
+		{"tampered query", tamper(func(u *url.URL) {
+			q := u.Query()
+			q.Set("crop", "0,0,10,10")
+			u.RawQuery = q.Encode()
+		}), http_utils.SCOPE_PAGE, signer, http_utils.ErrInvalidSignature},
+		{"extended expiry", tamper(func(u *url.URL) {
+			q := u.Query()
+			q.Set("expires", "99999999999")
+			u.RawQuery = q.Encode()
+		}), http_utils.SCOPE_PAGE, signer, http_utils.ErrInvalidSignature},
+		{"expired", signer.SignUntil(u, http_utils.SCOPE_PAGE, time.Now().Add(-time.Minute)), http_utils.SCOPE_PAGE, signer, http_utils.ErrExpiredSignature},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.signer.Verify(tc.u, tc.scope)
+			if tc.expected == nil && err != nil {
+				t.Errorf("Expected valid signature, got %v", err)
+			}
+
+			if tc.expected != nil && !errors.Is(err, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestAPIKeyOrSignedURLMiddleware(t *testing.T) {
+	t.Parallel()
+
+	signer := http_utils.NewURLSigner("secret", time.Hour)
+	handler := http_utils.APIKeyOrSignedURLMiddleware(true, "api-key", signer, http_utils.SCOPE_PROXY)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	target, _ := url.Parse("/api/v1/sources/mangadex/proxy?url=https%3A%2F%2Fuploads.mangadex.org%2Fcovers%2Fcover.jpg")
+
+	tests := []struct {
+		name     string
+		target   string
+		apiKey   string
+		expected int
+	}{
+		{"api key", target.String(), "api-key", http.StatusOK},
+		{"wrong api key", target.String(), "wrong", http.StatusUnauthorized},
+		{"signed url", signer.Sign(target, http_utils.SCOPE_PROXY).String(), "", http.StatusOK},
+		{"signed url for another scope", signer.Sign(target, http_utils.SCOPE_PAGE).String(), "api-key", http.StatusUnauthorized},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.apiKey != "" {
+				req.Header.Set("X-API-KEY", tc.apiKey)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.expected {
+				t.Errorf("Expected %d, got %d", tc.expected, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package http_utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var ErrForbiddenAddress = errors.New("forbidden address")
+
+var nonPublicNetworks = []*net.IPNet{
+	// "This" network
+	mustParseCIDR("0.0.0.0/8"),
+	// Carrier-grade NAT
+	mustParseCIDR("100.64.0.0/10"),
+	// IETF protocol assignments
+	mustParseCIDR("192.0.0.0/24"),
+	// Benchmarking
+	mustParseCIDR("198.18.0.0/15"),
+	// Reserved
+	mustParseCIDR("240.0.0.0/4"),
+	// NAT64 well-known prefix
+	mustParseCIDR("64:ff9b::/96"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
+
+// IsPublicIP reports whether the IP is a public unicast address, private, loopback, link-local and reserved ranges are refused.
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+
+	for _, n := range nonPublicNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// MatchHost reports whether the host is allowed by one of the patterns, "*.example.com" matches every subdomain of example.com but not example.com itself.
+func MatchHost(host string, patterns []string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+
+			continue
+		}
+
+		if host == pattern {
+			return true
+		}
+	}
+
+	return false
+}
+
+type privateAddressesKey struct{}
+
+// WithPrivateAddresses lets the safe clients connect to non public addresses for the requests made with the context,
+// it is only meant for the servers of the local network configured by the operator. The idle connections are shared,
+// a connection opened with the context can be reused by a request to the same host without it.
+func WithPrivateAddresses(ctx context.Context) context.Context {
+	return context.WithValue(ctx, privateAddressesKey{}, true)
+}
+
+// NewSafeHTTPClient returns a client refusing to connect to non public addresses, unless the request context allows them.
+// The check is done on the resolved address right before connecting, so DNS rebinding can't be used to bypass it.
+func NewSafeHTTPClient(timeout time.Duration, checkRedirect func(req *http.Request, via []*http.Request) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		ControlContext: func(ctx context.Context, network, address string, _ syscall.RawConn) error {
+			if allowed, _ := ctx.Value(privateAddressesKey{}).(bool); allowed {
+				return nil
+			}
+
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return errors.Join(ErrForbiddenAddress, err)
+			}
+
+			if !IsPublicIP(net.ParseIP(host)) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+			}
+
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     transport,
+		CheckRedirect: checkRedirect,
+	}
+}
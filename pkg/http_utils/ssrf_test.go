@@ -0,0 +1,122 @@
+package http_utils_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"dokusho/pkg/http_utils"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		ip       string
+		expected bool
+	}{
+		{"1.1.1.1", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.12", false},
+		{"172.16.4.2", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:192.168.1.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.ip, func(t *testing.T) {
+			t.Parallel()
+
+			if result := http_utils.IsPublicIP(net.ParseIP(tc.ip)); result != tc.expected {
+				t.Errorf("IsPublicIP(%s) = %v, expected %v", tc.ip, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	t.Parallel()
+
+	patterns := []string{"*.mangadex.network", "uploads.mangadex.org"}
+
+	tests := []struct {
+		host     string
+		expected bool
+	}{
+		{"cmdxd98sb0x3yprd.mangadex.network", true},
+		{"CMDXD98SB0X3YPRD.MANGADEX.NETWORK", true},
+		{"uploads.mangadex.org", true},
+		{"uploads.mangadex.org.", true},
+		{"mangadex.network", false},
+		{"evilmangadex.network", false},
+		{"mangadex.network.evil.com", false},
+		{"api.mangadex.org", false},
+		{"localhost", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.host, func(t *testing.T) {
+			t.Parallel()
+
+			if result := http_utils.MatchHost(tc.host, patterns); result != tc.expected {
+				t.Errorf("MatchHost(%s) = %v, expected %v", tc.host, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSafeHTTPClientRefusesLoopback(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Request should not reach the server")
+	}))
+	defer server.Close()
+
+	client := http_utils.NewSafeHTTPClient(time.Second, nil)
+
+	_, err := client.Get(server.URL)
+	if !errors.Is(err, http_utils.ErrForbiddenAddress) {
+		t.Errorf("Expected ErrForbiddenAddress, got %v", err)
+	}
+}
+
+func TestSafeHTTPClientWithPrivateAddresses(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := http_utils.NewSafeHTTPClient(time.Second, nil)
+
+	req, err := http.NewRequestWithContext(http_utils.WithPrivateAddresses(context.Background()), http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", resp.StatusCode)
+	}
+}
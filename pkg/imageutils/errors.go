@@ -0,0 +1,13 @@
+package imageutils
+
+import "errors"
+
+var (
+	ErrDecodingImage   = errors.New("error decoding image")
+	ErrEncodingImage   = errors.New("error encoding image")
+	ErrReadingImage    = errors.New("error reading image")
+	ErrWritingImage    = errors.New("error writing image")
+	ErrUnknownFormat   = errors.New("unknown image format")
+	ErrReadingManifest = errors.New("error reading manifest")
+	ErrWritingManifest = errors.New("error writing manifest")
+)
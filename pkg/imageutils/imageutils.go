@@ -0,0 +1,158 @@
+package imageutils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	DefaultThumbnailWidth = 240
+	thumbnailQuality      = 80
+)
+
+type ImageFormat string
+
+const (
+	FORMAT_JPEG    ImageFormat = "jpeg"
+	FORMAT_PNG     ImageFormat = "png"
+	FORMAT_GIF     ImageFormat = "gif"
+	FORMAT_WEBP    ImageFormat = "webp"
+	FORMAT_UNKNOWN ImageFormat = "unknown"
+)
+
+func (f ImageFormat) String() string {
+	return string(f)
+}
+
+func (f ImageFormat) MimeType() string {
+	switch f {
+	case FORMAT_JPEG:
+		return "image/jpeg"
+	case FORMAT_PNG:
+		return "image/png"
+	case FORMAT_GIF:
+		return "image/gif"
+	case FORMAT_WEBP:
+		return "image/webp"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+func NewImageFormat(format string) ImageFormat {
+	switch format {
+	case "jpeg", "jpg":
+		return FORMAT_JPEG
+	case "png":
+		return FORMAT_PNG
+	case "gif":
+		return FORMAT_GIF
+	case "webp":
+		return FORMAT_WEBP
+	default:
+		return FORMAT_UNKNOWN
+	}
+}
+
+type ImageInfo struct {
+	Width       int         `json:"width"`
+	Height      int         `json:"height"`
+	AspectRatio float64     `json:"aspectRatio"`
+	Format      ImageFormat `json:"format"`
+	Size        int64       `json:"size"`
+}
+
+// Inspect reads the image header to extract dimensions and format without decoding the full image.
+func Inspect(data []byte) (ImageInfo, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ImageInfo{}, errors.Join(ErrDecodingImage, err)
+	}
+
+	return ImageInfo{
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		AspectRatio: aspectRatio(cfg.Width, cfg.Height),
+		Format:      NewImageFormat(format),
+		Size:        int64(len(data)),
+	}, nil
+}
+
+// Decode decodes the full image and returns its format.
+func Decode(data []byte) (image.Image, ImageFormat, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, FORMAT_UNKNOWN, errors.Join(ErrDecodingImage, err)
+	}
+
+	return img, NewImageFormat(format), nil
+}
+
+// Thumbnail decodes the image and returns a JPEG scaled down to maxWidth, keeping the aspect ratio.
+// Images already narrower than maxWidth are only re-encoded.
+func Thumbnail(data []byte, maxWidth int) ([]byte, error) {
+	src, _, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return Encode(Resize(src, maxWidth), FORMAT_JPEG)
+}
+
+// Resize scales the image down to maxWidth, keeping the aspect ratio.
+func Resize(src image.Image, maxWidth int) image.Image {
+	bounds := src.Bounds()
+	if maxWidth <= 0 || bounds.Dx() <= maxWidth {
+		return src
+	}
+
+	height := int(math.Round(float64(bounds.Dy()) * float64(maxWidth) / float64(bounds.Dx())))
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, maxWidth, height))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	return dst
+}
+
+// Encode writes the image in the given format, webp is not supported by the encoder and falls back to png.
+func Encode(img image.Image, format ImageFormat) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+
+	switch format {
+	case FORMAT_JPEG:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: thumbnailQuality})
+	case FORMAT_GIF:
+		err = gif.Encode(&buf, img, nil)
+	case FORMAT_PNG, FORMAT_WEBP:
+		err = png.Encode(&buf, img)
+	default:
+		return nil, fmt.Errorf("can't encode %s: %w", format, ErrUnknownFormat)
+	}
+
+	if err != nil {
+		return nil, errors.Join(ErrEncodingImage, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func aspectRatio(width, height int) float64 {
+	if height == 0 {
+		return 0
+	}
+
+	return math.Round(float64(width)/float64(height)*1000) / 1000
+}
@@ -0,0 +1,198 @@
+package imageutils_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"dokusho/pkg/imageutils"
+)
+
+func newPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		for y := range height {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func inspectFile(t *testing.T, path string) imageutils.ImageInfo {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := imageutils.Inspect(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return info
+}
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+
+	data := newPNG(t, 800, 1200)
+
+	info, err := imageutils.Inspect(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Width != 800 || info.Height != 1200 {
+		t.Errorf("Inspect dimensions = %dx%d, expected 800x1200", info.Width, info.Height)
+	}
+
+	if info.Format != imageutils.FORMAT_PNG {
+		t.Errorf("Inspect format = %s, expected png", info.Format)
+	}
+
+	if info.AspectRatio != 0.667 {
+		t.Errorf("Inspect aspect ratio = %f, expected 0.667", info.AspectRatio)
+	}
+
+	if info.Size != int64(len(data)) {
+		t.Errorf("Inspect size = %d, expected %d", info.Size, len(data))
+	}
+
+	_, err = imageutils.Inspect([]byte("not an image"))
+	if err == nil {
+		t.Error("Inspect should fail on invalid data")
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	t.Parallel()
+
+	thumb, err := imageutils.Thumbnail(newPNG(t, 800, 1200), 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := imageutils.Inspect(thumb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Width != 200 || info.Height != 300 {
+		t.Errorf("Thumbnail dimensions = %dx%d, expected 200x300", info.Width, info.Height)
+	}
+
+	if info.Format != imageutils.FORMAT_JPEG {
+		t.Errorf("Thumbnail format = %s, expected jpeg", info.Format)
+	}
+}
+
+func TestChapterManifest(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, size := range map[string][2]int{"002.png": {1600, 1200}, "001.png": {800, 1200}} {
+		if err := os.WriteFile(filepath.Join(dir, name), newPNG(t, size[0], size[1]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pages, err := imageutils.ChapterManifest(dir, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pages) != 2 {
+		t.Fatalf("ChapterManifest returned %d pages, expected 2", len(pages))
+	}
+
+	if pages[0].Name != "001.png" || pages[0].Index != 1 {
+		t.Errorf("First page = %+v, expected 001.png at index 1", pages[0])
+	}
+
+	if pages[1].Width != 1600 || pages[1].AspectRatio != 1.333 {
+		t.Errorf("Second page = %+v, expected a 1600 wide spread", pages[1])
+	}
+
+	for _, page := range pages {
+		if _, err := os.Stat(imageutils.ThumbnailPath(dir, page.Hash, 100)); err != nil {
+			t.Errorf("Thumbnail for page %d missing: %s", page.Index, err)
+		}
+	}
+
+	cached, err := imageutils.ChapterManifest(dir, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cached) != len(pages) {
+		t.Errorf("Cached manifest returned %d pages, expected %d", len(cached), len(pages))
+	}
+}
+
+func TestPageThumbnail(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, size := range map[string][2]int{"001.png": {800, 1200}, "002.png": {1600, 1200}} {
+		if err := os.WriteFile(filepath.Join(dir, name), newPNG(t, size[0], size[1]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path, err := imageutils.PageThumbnail(dir, 2, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info := inspectFile(t, path); info.Width != 100 || info.Height != 75 {
+		t.Errorf("Thumbnail dimensions = %dx%d, expected 100x75", info.Width, info.Height)
+	}
+
+	// Only the requested page is decoded
+	thumbnails, err := os.ReadDir(filepath.Join(dir, ".thumbnails"))
+	if err != nil || len(thumbnails) != 1 {
+		t.Errorf("Only the thumbnail of page 2 should exist, got %d (%v)", len(thumbnails), err)
+	}
+
+	// A replaced page gets a new thumbnail even when its modification time is older than the previous thumbnail
+	page := filepath.Join(dir, "002.png")
+	if err := os.WriteFile(page, newPNG(t, 800, 1600), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(page, time.Unix(0, 0), time.Unix(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	replaced, err := imageutils.PageThumbnail(dir, 2, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if replaced == path {
+		t.Errorf("Replaced page should not reuse the thumbnail %s", path)
+	}
+
+	if info := inspectFile(t, replaced); info.Width != 100 || info.Height != 200 {
+		t.Errorf("Replaced thumbnail dimensions = %dx%d, expected 100x200", info.Width, info.Height)
+	}
+
+	if _, err := imageutils.PageThumbnail(dir, 3, 100); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("PageThumbnail of a missing page should return ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+package imageutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	thumbnailDirName = ".thumbnails"
+	manifestFileName = ".manifest.json"
+)
+
+var pageExtensions = []string{".jpg", ".jpeg", ".png", ".gif", ".webp"}
+
+type ChapterPage struct {
+	Index int    `json:"index"`
+	Name  string `json:"name"`
+	// Hash is the hex encoded SHA-256 of the page content
+	Hash string `json:"hash"`
+	ImageInfo
+}
+
+type chapterManifestCache struct {
+	GeneratedAt    time.Time     `json:"generatedAt"`
+	ThumbnailWidth int           `json:"thumbnailWidth"`
+	Pages          []ChapterPage `json:"pages"`
+}
+
+// ListChapterPages returns the image files of a chapter directory sorted by name, indexed from 1.
+func ListChapterPages(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Join(ErrReadingImage, err, fmt.Errorf("failed to list chapter dir: %s", dir))
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		if !slices.Contains(pageExtensions, strings.ToLower(filepath.Ext(entry.Name()))) {
+			continue
+		}
+
+		names = append(names, entry.Name())
+	}
+
+	slices.Sort(names)
+
+	return names, nil
+}
+
+// PagePath returns the path of the page at the given 1-based index.
+func PagePath(dir string, index int) (string, error) {
+	names, err := ListChapterPages(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if index < 1 || index > len(names) {
+		return "", errors.Join(os.ErrNotExist, fmt.Errorf("page %d not found in %s", index, dir))
+	}
+
+	return filepath.Join(dir, names[index-1]), nil
+}
+
+// ThumbnailPath returns where the thumbnail of a page is stored, it is keyed by the page content hash
+// so a replaced page never gets the thumbnail of the previous one.
+func ThumbnailPath(dir string, hash string, thumbnailWidth int) string {
+	return filepath.Join(dir, thumbnailDirName, fmt.Sprintf("%s-%d.jpg", hash, thumbnailWidth))
+}
+
+// PageThumbnail returns the thumbnail of the page at the given index, only this page is decoded when there is
+// no thumbnail for its content yet.
+func PageThumbnail(dir string, index int, thumbnailWidth int) (string, error) {
+	pagePath, err := PagePath(dir, index)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(pagePath)
+	if err != nil {
+		return "", errors.Join(ErrReadingImage, err, fmt.Errorf("failed to read page: %s", pagePath))
+	}
+
+	hash := sha256.Sum256(data)
+	path := ThumbnailPath(dir, hex.EncodeToString(hash[:]), thumbnailWidth)
+
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	thumb, err := Thumbnail(data, thumbnailWidth)
+	if err != nil {
+		return "", errors.Join(err, fmt.Errorf("failed to generate thumbnail: %s", pagePath))
+	}
+
+	err = os.MkdirAll(filepath.Join(dir, thumbnailDirName), os.ModePerm)
+	if err != nil {
+		return "", errors.Join(ErrWritingImage, err, fmt.Errorf("failed to create thumbnail dir"))
+	}
+
+	err = os.WriteFile(path, thumb, 0o644)
+	if err != nil {
+		return "", errors.Join(ErrWritingImage, err, fmt.Errorf("failed to write thumbnail: %s", pagePath))
+	}
+
+	return path, nil
+}
+
+// ChapterManifest inspects every page of the chapter directory and generates the thumbnails.
+// The result is cached next to the pages and only regenerated when a page is added, removed or modified.
+func ChapterManifest(dir string, thumbnailWidth int) ([]ChapterPage, error) {
+	names, err := ListChapterPages(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if cache, err := readManifestCache(dir); err == nil && cache.isFresh(dir, names, thumbnailWidth) {
+		return cache.Pages, nil
+	}
+
+	generatedAt := time.Now()
+
+	err = os.MkdirAll(filepath.Join(dir, thumbnailDirName), os.ModePerm)
+	if err != nil {
+		return nil, errors.Join(ErrWritingImage, err, fmt.Errorf("failed to create thumbnail dir"))
+	}
+
+	pages := make([]ChapterPage, 0, len(names))
+	for i, name := range names {
+		index := i + 1
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, errors.Join(ErrReadingImage, err, fmt.Errorf("failed to read page: %s", name))
+		}
+
+		info, err := Inspect(data)
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("failed to inspect page: %s", name))
+		}
+
+		thumb, err := Thumbnail(data, thumbnailWidth)
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("failed to generate thumbnail: %s", name))
+		}
+
+		hash := sha256.Sum256(data)
+		page := ChapterPage{Index: index, Name: name, Hash: hex.EncodeToString(hash[:]), ImageInfo: info}
+
+		err = os.WriteFile(ThumbnailPath(dir, page.Hash, thumbnailWidth), thumb, 0o644)
+		if err != nil {
+			return nil, errors.Join(ErrWritingImage, err, fmt.Errorf("failed to write thumbnail: %s", name))
+		}
+
+		pages = append(pages, page)
+	}
+
+	pruneThumbnails(dir, pages, thumbnailWidth)
+
+	err = writeManifestCache(dir, chapterManifestCache{
+		GeneratedAt:    generatedAt,
+		ThumbnailWidth: thumbnailWidth,
+		Pages:          pages,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
+
+// pruneThumbnails removes the thumbnails of pages that were replaced or removed, failing to remove one only wastes space
+func pruneThumbnails(dir string, pages []ChapterPage, thumbnailWidth int) {
+	entries, err := os.ReadDir(filepath.Join(dir, thumbnailDirName))
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, thumbnailDirName, entry.Name())
+		if !slices.ContainsFunc(pages, func(page ChapterPage) bool { return ThumbnailPath(dir, page.Hash, thumbnailWidth) == path }) {
+			os.Remove(path)
+		}
+	}
+}
+
+func (c chapterManifestCache) isFresh(dir string, names []string, thumbnailWidth int) bool {
+	if c.ThumbnailWidth != thumbnailWidth || len(c.Pages) != len(names) {
+		return false
+	}
+
+	for i, name := range names {
+		if c.Pages[i].Name != name {
+			return false
+		}
+
+		stat, err := os.Stat(filepath.Join(dir, name))
+		if err != nil || stat.ModTime().After(c.GeneratedAt) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func readManifestCache(dir string) (chapterManifestCache, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, manifestFileName))
+	if err != nil {
+		return chapterManifestCache{}, errors.Join(ErrReadingManifest, err)
+	}
+
+	var cache chapterManifestCache
+	err = json.Unmarshal(raw, &cache)
+	if err != nil {
+		return chapterManifestCache{}, errors.Join(ErrReadingManifest, err)
+	}
+
+	return cache, nil
+}
+
+func writeManifestCache(dir string, cache chapterManifestCache) error {
+	raw, err := json.Marshal(cache)
+	if err != nil {
+		return errors.Join(ErrWritingManifest, err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, manifestFileName), raw, 0o644)
+	if err != nil {
+		return errors.Join(ErrWritingManifest, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package imageutils
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"slices"
+	"strconv"
+	"strings"
+
+	"dokusho/pkg/sources/source_types"
+)
+
+const (
+	DefaultSegmentHeight = 1600
+	// Rows where every sampled pixel stays within this luminance range are treated as whitespace
+	blankRowTolerance = 12
+	blankRowSampling  = 4
+	// Minimum height/width ratio before a page is considered a long strip
+	longStripRatio = 3
+	// Minimum width/height ratio before a page is considered a spread, single pages scanned a bit wider than tall are kept whole
+	spreadRatio = 1.2
+)
+
+type ProcessOptions struct {
+	SplitSpreads  bool `json:"splitSpreads"`
+	RightToLeft   bool `json:"rightToLeft"`
+	SliceStrips   bool `json:"sliceStrips"`
+	SegmentHeight int  `json:"segmentHeight"`
+}
+
+// DefaultProcessOptions enables strip slicing for webtoons and long strips, and right to left spreads for manga.
+func DefaultProcessOptions(serieType source_types.SourceSerieType, genres []source_types.SourceSerieGenre) ProcessOptions {
+	isLongStrip := serieType == source_types.TYPE_WEBTOON || slices.Contains(genres, source_types.LONG_STRIP)
+
+	return ProcessOptions{
+		SplitSpreads:  !isLongStrip,
+		RightToLeft:   serieType == source_types.TYPE_MANGA,
+		SliceStrips:   isLongStrip,
+		SegmentHeight: DefaultSegmentHeight,
+	}
+}
+
+func (o ProcessOptions) Enabled() bool {
+	return o.SplitSpreads || o.SliceStrips
+}
+
+func (o ProcessOptions) segmentHeight() int {
+	if o.SegmentHeight <= 0 {
+		return DefaultSegmentHeight
+	}
+
+	return o.SegmentHeight
+}
+
+type Rect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+func (r Rect) String() string {
+	return fmt.Sprintf("%d,%d,%d,%d", r.X, r.Y, r.Width, r.Height)
+}
+
+func (r Rect) Rectangle() image.Rectangle {
+	return image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height)
+}
+
+// ParseRect parses a rect in the "x,y,width,height" format produced by Rect.String
+func ParseRect(raw string) (Rect, error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) != 4 {
+		return Rect{}, fmt.Errorf("invalid rect %q, expected x,y,width,height", raw)
+	}
+
+	var values [4]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || v < 0 {
+			return Rect{}, errors.Join(fmt.Errorf("invalid rect value %q", part), err)
+		}
+
+		values[i] = v
+	}
+
+	if values[2] == 0 || values[3] == 0 {
+		return Rect{}, fmt.Errorf("invalid rect %q, empty area", raw)
+	}
+
+	return Rect{X: values[0], Y: values[1], Width: values[2], Height: values[3]}, nil
+}
+
+type VirtualPage struct {
+	Index       int     `json:"index"`
+	PageIndex   int     `json:"pageIndex"`
+	Crop        Rect    `json:"crop"`
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	AspectRatio float64 `json:"aspectRatio"`
+}
+
+// IsSpread reports if the page is a double page spread.
+func IsSpread(info ImageInfo) bool {
+	return info.Height > 0 && float64(info.Width) >= float64(info.Height)*spreadRatio
+}
+
+// IsLongStrip reports if the page is too tall to be displayed at once and should be sliced.
+func IsLongStrip(info ImageInfo, segmentHeight int) bool {
+	return info.Width > 0 && info.Height > segmentHeight && info.Height >= info.Width*longStripRatio
+}
+
+// SplitSpread returns the two halves of a spread in reading order.
+func SplitSpread(info ImageInfo, rightToLeft bool) []Rect {
+	half := info.Width / 2
+
+	left := Rect{X: 0, Y: 0, Width: half, Height: info.Height}
+	right := Rect{X: half, Y: 0, Width: info.Width - half, Height: info.Height}
+
+	if rightToLeft {
+		return []Rect{right, left}
+	}
+
+	return []Rect{left, right}
+}
+
+// SliceStrip cuts a long strip into segments close to segmentHeight, preferring to cut on blank rows
+// (gutters between panels) so that no panel is split in the middle.
+func SliceStrip(img image.Image, segmentHeight int) []Rect {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if segmentHeight <= 0 || height <= segmentHeight {
+		return []Rect{{X: 0, Y: 0, Width: width, Height: height}}
+	}
+
+	// Only look for a gutter in the last quarter of the segment, to keep segments roughly screen sized
+	window := segmentHeight / 4
+
+	var rects []Rect
+	start := 0
+	for start < height {
+		target := start + segmentHeight
+		if target >= height {
+			rects = append(rects, Rect{X: 0, Y: start, Width: width, Height: height - start})
+			break
+		}
+
+		cut := target
+		for y := target; y > target-window; y-- {
+			if isBlankRow(img, bounds.Min.Y+y) {
+				cut = y
+				break
+			}
+		}
+
+		rects = append(rects, Rect{X: 0, Y: start, Width: width, Height: cut - start})
+		start = cut
+	}
+
+	return rects
+}
+
+func isBlankRow(img image.Image, y int) bool {
+	bounds := img.Bounds()
+
+	var min, max uint32 = 0xffff, 0
+	for x := bounds.Min.X; x < bounds.Max.X; x += blankRowSampling {
+		r, g, b, _ := img.At(x, y).RGBA()
+		lum := (299*r + 587*g + 114*b) / 1000
+
+		if lum < min {
+			min = lum
+		}
+
+		if lum > max {
+			max = lum
+		}
+
+		if (max-min)>>8 > blankRowTolerance {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PlanVirtualPages builds the list of pages the reader will display, spreads and strips being split in multiple virtual pages.
+// load is only called for long strips, since finding where to cut needs the pixels.
+func PlanVirtualPages(pages []ChapterPage, opts ProcessOptions, load func(ChapterPage) (image.Image, error)) ([]VirtualPage, error) {
+	var virtualPages []VirtualPage
+
+	add := func(page ChapterPage, rects ...Rect) {
+		for _, rect := range rects {
+			virtualPages = append(virtualPages, VirtualPage{
+				Index:       len(virtualPages) + 1,
+				PageIndex:   page.Index,
+				Crop:        rect,
+				Width:       rect.Width,
+				Height:      rect.Height,
+				AspectRatio: aspectRatio(rect.Width, rect.Height),
+			})
+		}
+	}
+
+	for _, page := range pages {
+		switch {
+		case opts.SplitSpreads && IsSpread(page.ImageInfo):
+			add(page, SplitSpread(page.ImageInfo, opts.RightToLeft)...)
+		case opts.SliceStrips && IsLongStrip(page.ImageInfo, opts.segmentHeight()):
+			img, err := load(page)
+			if err != nil {
+				return nil, errors.Join(err, fmt.Errorf("failed to load page %d", page.Index))
+			}
+
+			add(page, SliceStrip(img, opts.segmentHeight())...)
+		default:
+			add(page, Rect{X: 0, Y: 0, Width: page.Width, Height: page.Height})
+		}
+	}
+
+	return virtualPages, nil
+}
+
+type subImager interface {
+	SubImage(r image.Rectangle) image.Image
+}
+
+// Crop returns the part of the image inside rect, relative to the image origin.
+func Crop(img image.Image, rect Rect) (image.Image, error) {
+	bounds := img.Bounds()
+	r := rect.Rectangle().Add(bounds.Min)
+
+	if !r.In(bounds) {
+		return nil, fmt.Errorf("crop %s is outside of the image %s", rect, bounds)
+	}
+
+	si, ok := img.(subImager)
+	if !ok {
+		return nil, fmt.Errorf("image type %T can't be cropped", img)
+	}
+
+	return si.SubImage(r), nil
+}
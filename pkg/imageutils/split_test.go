@@ -0,0 +1,143 @@
+package imageutils_test
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+
+	"dokusho/pkg/imageutils"
+	"dokusho/pkg/sources/source_types"
+)
+
+func TestSplitSpread(t *testing.T) {
+	t.Parallel()
+
+	info := imageutils.ImageInfo{Width: 1601, Height: 1200}
+
+	ltr := imageutils.SplitSpread(info, false)
+	expected := []imageutils.Rect{{X: 0, Y: 0, Width: 800, Height: 1200}, {X: 800, Y: 0, Width: 801, Height: 1200}}
+	if !reflect.DeepEqual(ltr, expected) {
+		t.Errorf("SplitSpread(ltr) = %v, expected %v", ltr, expected)
+	}
+
+	rtl := imageutils.SplitSpread(info, true)
+	expected = []imageutils.Rect{expected[1], expected[0]}
+	if !reflect.DeepEqual(rtl, expected) {
+		t.Errorf("SplitSpread(rtl) = %v, expected %v", rtl, expected)
+	}
+}
+
+func TestSliceStrip(t *testing.T) {
+	t.Parallel()
+
+	// Striped panels with white gutters at y=900 and y=1850
+	img := image.NewRGBA(image.Rect(0, 0, 100, 2500))
+	for y := range 2500 {
+		isGutter := (y >= 900 && y < 910) || (y >= 1850 && y < 1860)
+
+		for x := range 100 {
+			c := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			if !isGutter && x%8 < 4 {
+				c = color.RGBA{A: 255}
+			}
+
+			img.Set(x, y, c)
+		}
+	}
+
+	rects := imageutils.SliceStrip(img, 1000)
+	expected := []imageutils.Rect{
+		{X: 0, Y: 0, Width: 100, Height: 909},
+		{X: 0, Y: 909, Width: 100, Height: 950},
+		{X: 0, Y: 1859, Width: 100, Height: 641},
+	}
+
+	if !reflect.DeepEqual(rects, expected) {
+		t.Errorf("SliceStrip = %v, expected %v", rects, expected)
+	}
+}
+
+func TestPlanVirtualPages(t *testing.T) {
+	t.Parallel()
+
+	pages := []imageutils.ChapterPage{
+		{Index: 1, ImageInfo: imageutils.ImageInfo{Width: 800, Height: 1200}},
+		{Index: 2, ImageInfo: imageutils.ImageInfo{Width: 1600, Height: 1200}},
+		{Index: 3, ImageInfo: imageutils.ImageInfo{Width: 100, Height: 2500}},
+	}
+
+	strip := image.NewRGBA(image.Rect(0, 0, 100, 2500))
+	opts := imageutils.ProcessOptions{SplitSpreads: true, RightToLeft: true, SliceStrips: true, SegmentHeight: 1000}
+
+	virtualPages, err := imageutils.PlanVirtualPages(pages, opts, func(page imageutils.ChapterPage) (image.Image, error) {
+		if page.Index != 3 {
+			t.Errorf("Only the long strip should be loaded, got page %d", page.Index)
+		}
+
+		return strip, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pageIndexes []int
+	for i, vp := range virtualPages {
+		if vp.Index != i+1 {
+			t.Errorf("Virtual page %d has index %d", i+1, vp.Index)
+		}
+
+		pageIndexes = append(pageIndexes, vp.PageIndex)
+	}
+
+	if expected := []int{1, 2, 2, 3, 3, 3}; !reflect.DeepEqual(pageIndexes, expected) {
+		t.Errorf("Virtual pages come from %v, expected %v", pageIndexes, expected)
+	}
+
+	if virtualPages[1].Crop.X != 800 {
+		t.Errorf("First half of a right to left spread should be the right one, got %v", virtualPages[1].Crop)
+	}
+}
+
+func TestIsSpread(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		info     imageutils.ImageInfo
+		expected bool
+	}{
+		{name: "portrait page", info: imageutils.ImageInfo{Width: 800, Height: 1200}, expected: false},
+		{name: "slightly wide scan", info: imageutils.ImageInfo{Width: 1300, Height: 1200}, expected: false},
+		{name: "threshold", info: imageutils.ImageInfo{Width: 1440, Height: 1200}, expected: true},
+		{name: "spread", info: imageutils.ImageInfo{Width: 1600, Height: 1200}, expected: true},
+		{name: "no height", info: imageutils.ImageInfo{Width: 1600}, expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := imageutils.IsSpread(tc.info); got != tc.expected {
+				t.Errorf("IsSpread(%dx%d) = %v, expected %v", tc.info.Width, tc.info.Height, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestDefaultProcessOptions(t *testing.T) {
+	t.Parallel()
+
+	if !imageutils.DefaultProcessOptions(source_types.TYPE_WEBTOON, nil).SliceStrips {
+		t.Error("Webtoons should be sliced")
+	}
+
+	if !imageutils.DefaultProcessOptions(source_types.TYPE_MANHWA, []source_types.SourceSerieGenre{source_types.LONG_STRIP}).SliceStrips {
+		t.Error("Long strips should be sliced")
+	}
+
+	manga := imageutils.DefaultProcessOptions(source_types.TYPE_MANGA, nil)
+	if manga.SliceStrips || !manga.SplitSpreads || !manga.RightToLeft {
+		t.Errorf("Manga should split right to left spreads, got %+v", manga)
+	}
+}
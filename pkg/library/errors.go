@@ -0,0 +1,10 @@
+package library
+
+import "errors"
+
+var (
+	ErrSerieNotFound  = errors.New("library serie not found")
+	ErrSourceNotFound = errors.New("library serie source not found")
+	ErrDatabaseQuery  = errors.New("database query failed")
+	ErrFetchingSerie  = errors.New("error fetching serie from source")
+)
@@ -0,0 +1,139 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"dokusho/pkg/sources/chapterutils"
+	"dokusho/pkg/sources/source_types"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+)
+
+// SerieFetcher fetches a serie from the sources API, implemented by the sources API client
+type SerieFetcher interface {
+	FetchSerieInformation(ctx context.Context, sourceID source_types.SourceID, serieID source_types.SourceSerieID) (source_types.SourceSerie, error)
+}
+
+// pendingStates makes a job unique until it completes, River requires all of them but retryable
+var pendingStates = []rivertype.JobState{
+	rivertype.JobStateAvailable,
+	rivertype.JobStatePending,
+	rivertype.JobStateRetryable,
+	rivertype.JobStateRunning,
+	rivertype.JobStateScheduled,
+}
+
+// RefreshChaptersArgs fetches the chapters of every source of a library serie
+type RefreshChaptersArgs struct {
+	LibrarySerieID uuid.UUID `json:"library_serie_id"`
+}
+
+func (RefreshChaptersArgs) Kind() string { return "library_refresh_chapters" }
+
+func (RefreshChaptersArgs) InsertOpts() river.InsertOpts {
+	return river.InsertOpts{MaxAttempts: 5, UniqueOpts: river.UniqueOpts{ByArgs: true, ByState: pendingStates}}
+}
+
+type RefreshChaptersWorker struct {
+	river.WorkerDefaults[RefreshChaptersArgs]
+
+	store   *Store
+	fetcher SerieFetcher
+	logger  *slog.Logger
+}
+
+func (w *RefreshChaptersWorker) Work(ctx context.Context, job *river.Job[RefreshChaptersArgs]) error {
+	librarySerie, err := w.store.Get(ctx, job.Args.LibrarySerieID)
+	if err != nil {
+		return err
+	}
+
+	sources, err := w.store.ListSources(ctx, job.Args.LibrarySerieID)
+	if err != nil {
+		return err
+	}
+
+	// A failing source doesn't prevent refreshing the others, the job is retried for all of them
+	var errs []error
+	for _, source := range sources {
+		serie, err := w.fetcher.FetchSerieInformation(ctx, source.SourceID, source.SerieID)
+		if err != nil {
+			w.logger.Warn("Failed to fetch serie", "source_id", source.SourceID, "serie_id", source.SerieID, "attempt", job.Attempt, "error", err)
+
+			err = errors.Join(ErrFetchingSerie, err, fmt.Errorf("failed to fetch %s/%s", source.SourceID, source.SerieID))
+			errs = append(errs, err, w.store.SaveFetchError(ctx, source.LibrarySerieID, source.SourceID, source.SerieID, err))
+			continue
+		}
+
+		volumes := chapterutils.ApplyGroupPreferences(serie.Volumes, librarySerie.GroupPreferences())
+
+		err = w.store.SaveChapters(ctx, source.LibrarySerieID, source.SourceID, source.SerieID, volumes)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RefreshAllChaptersArgs enqueues a chapters refresh for every serie of the library
+type RefreshAllChaptersArgs struct{}
+
+func (RefreshAllChaptersArgs) Kind() string { return "library_refresh_all_chapters" }
+
+func (RefreshAllChaptersArgs) InsertOpts() river.InsertOpts {
+	return river.InsertOpts{UniqueOpts: river.UniqueOpts{ByState: pendingStates}}
+}
+
+type RefreshAllChaptersWorker struct {
+	river.WorkerDefaults[RefreshAllChaptersArgs]
+
+	store *Store
+}
+
+func (w *RefreshAllChaptersWorker) Work(ctx context.Context, _ *river.Job[RefreshAllChaptersArgs]) error {
+	series, err := w.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(series) == 0 {
+		return nil
+	}
+
+	params := make([]river.InsertManyParams, len(series))
+	for i, serie := range series {
+		params[i] = river.InsertManyParams{Args: RefreshChaptersArgs{LibrarySerieID: serie.ID}}
+	}
+
+	_, err = river.ClientFromContext[pgx.Tx](ctx).InsertMany(ctx, params)
+	return err
+}
+
+func AddWorkers(workers *river.Workers, store *Store, fetcher SerieFetcher) {
+	river.AddWorker(workers, &RefreshChaptersWorker{store: store, fetcher: fetcher, logger: slog.Default().WithGroup("library_chapters")})
+	river.AddWorker(workers, &RefreshAllChaptersWorker{store: store})
+}
+
+// RefreshPeriodically refreshes the chapters of the whole library every interval
+func RefreshPeriodically(bundle *river.PeriodicJobBundle, interval time.Duration) {
+	bundle.Add(river.NewPeriodicJob(
+		river.PeriodicInterval(interval),
+		func() (river.JobArgs, *river.InsertOpts) {
+			return RefreshAllChaptersArgs{}, nil
+		},
+		nil,
+	))
+}
+
+func EnqueueRefresh(ctx context.Context, client *river.Client[pgx.Tx], id uuid.UUID) error {
+	_, err := client.Insert(ctx, RefreshChaptersArgs{LibrarySerieID: id}, nil)
+	return err
+}
@@ -0,0 +1,141 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"dokusho/pkg/sources/chapterutils"
+	"dokusho/pkg/sources/source_types"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LibrarySerie struct {
+	ID       uuid.UUID                  `json:"id"`
+	Title    string                     `json:"title"`
+	SourceID source_types.SourceID      `json:"sourceId"`
+	SerieID  source_types.SourceSerieID `json:"serieId"`
+	// Copied from the source serie, used by the reading statistics
+	Genres       []string `json:"genres"`
+	Authors      []string `json:"authors"`
+	ChapterCount int      `json:"chapterCount"`
+	// Scanlation groups, matched by ID or name, the chapters of the blocked ones are ignored
+	PreferredGroups []string  `json:"preferredGroups"`
+	BlockedGroups   []string  `json:"blockedGroups"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// GroupPreferences keeps a single release of each chapter, the chapters are counted once whoever released them
+func (s LibrarySerie) GroupPreferences() chapterutils.GroupPreferences {
+	return chapterutils.GroupPreferences{
+		Preferred: s.PreferredGroups,
+		Blocked:   s.BlockedGroups,
+		Collapse:  true,
+	}
+}
+
+const serieColumns = `id, title, source_id, serie_id, genres, authors, chapter_count, preferred_groups, blocked_groups, created_at`
+
+func scanSerie(row pgx.Row) (LibrarySerie, error) {
+	var serie LibrarySerie
+	err := row.Scan(&serie.ID, &serie.Title, &serie.SourceID, &serie.SerieID, &serie.Genres, &serie.Authors, &serie.ChapterCount, &serie.PreferredGroups, &serie.BlockedGroups, &serie.CreatedAt)
+	return serie, err
+}
+
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{pool: pool}
+}
+
+// Add adds the serie to the library, adding an already present serie updates its metadata and returns it
+func (s *Store) Add(ctx context.Context, serie LibrarySerie) (LibrarySerie, error) {
+	if serie.Genres == nil {
+		serie.Genres = []string{}
+	}
+
+	if serie.Authors == nil {
+		serie.Authors = []string{}
+	}
+
+	added, err := scanSerie(s.pool.QueryRow(ctx, `
+		INSERT INTO library_series (title, source_id, serie_id, genres, authors, chapter_count) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (source_id, serie_id) DO UPDATE SET
+			title = EXCLUDED.title,
+			genres = EXCLUDED.genres,
+			authors = EXCLUDED.authors,
+			chapter_count = EXCLUDED.chapter_count
+		RETURNING `+serieColumns,
+		serie.Title, serie.SourceID, serie.SerieID, serie.Genres, serie.Authors, serie.ChapterCount,
+	))
+	if err != nil {
+		return LibrarySerie{}, errors.Join(ErrDatabaseQuery, err, fmt.Errorf("failed to add serie %s/%s", serie.SourceID, serie.SerieID))
+	}
+
+	err = s.AddSource(ctx, added.ID, added.SourceID, added.SerieID)
+	if err != nil {
+		return LibrarySerie{}, err
+	}
+
+	return added, nil
+}
+
+func (s *Store) Get(ctx context.Context, id uuid.UUID) (LibrarySerie, error) {
+	serie, err := scanSerie(s.pool.QueryRow(ctx, `SELECT `+serieColumns+` FROM library_series WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return LibrarySerie{}, ErrSerieNotFound
+	}
+	if err != nil {
+		return LibrarySerie{}, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return serie, nil
+}
+
+func (s *Store) List(ctx context.Context) ([]LibrarySerie, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+serieColumns+` FROM library_series ORDER BY title`)
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	series, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (LibrarySerie, error) {
+		return scanSerie(row)
+	})
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return series, nil
+}
+
+// SetGroups replaces the scanlation groups preferences of the serie
+func (s *Store) SetGroups(ctx context.Context, id uuid.UUID, preferred []string, blocked []string) (LibrarySerie, error) {
+	if preferred == nil {
+		preferred = []string{}
+	}
+
+	if blocked == nil {
+		blocked = []string{}
+	}
+
+	serie, err := scanSerie(s.pool.QueryRow(ctx, `
+		UPDATE library_series SET preferred_groups = $2, blocked_groups = $3
+		WHERE id = $1
+		RETURNING `+serieColumns,
+		id, preferred, blocked,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return LibrarySerie{}, ErrSerieNotFound
+	}
+	if err != nil {
+		return LibrarySerie{}, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return serie, nil
+}
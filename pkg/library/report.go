@@ -0,0 +1,125 @@
+package library
+
+import (
+	"math"
+	"slices"
+
+	"dokusho/pkg/sources/chapterutils"
+	"dokusho/pkg/sources/source_types"
+
+	"github.com/google/uuid"
+)
+
+// SourceChapters are the chapter numbers a source has for a serie in one language, all volumes together
+type SourceChapters struct {
+	SourceID source_types.SourceID       `json:"sourceId"`
+	SerieID  source_types.SourceSerieID  `json:"serieId"`
+	Language source_types.SourceLanguage `json:"language"`
+	Chapters []float64                   `json:"-"`
+}
+
+// SourceRef is a source serie having a chapter another source misses
+type SourceRef struct {
+	SourceID source_types.SourceID      `json:"sourceId"`
+	SerieID  source_types.SourceSerieID `json:"serieId"`
+}
+
+type MissingChapter struct {
+	Number float64 `json:"number"`
+	// Other sources of the serie having the chapter in the same language
+	AvailableIn []SourceRef `json:"availableIn"`
+}
+
+type SourceGap struct {
+	SourceChapters
+	LastChapter float64          `json:"lastChapter"`
+	Missing     []MissingChapter `json:"missing"`
+}
+
+type SerieReport struct {
+	LibrarySerieID uuid.UUID   `json:"librarySerieId"`
+	Title          string      `json:"title"`
+	Gaps           []SourceGap `json:"gaps"`
+	// Missing chapters another source has, the ones to look at first
+	Recoverable int `json:"recoverable"`
+}
+
+type ReportFilter struct {
+	// Only report the gaps of this source, other sources are still used to find where the chapters are
+	SourceID source_types.SourceID
+	Language source_types.SourceLanguage
+}
+
+// BuildReport aggregates the gaps of every source of a serie across all its volumes, so gaps between volumes are found too.
+// A chapter is missing when it is inside the range of the source, or after its last chapter while another source has it.
+func BuildReport(serie LibrarySerie, chapters []SourceChapters, filter ReportFilter) SerieReport {
+	report := SerieReport{
+		LibrarySerieID: serie.ID,
+		Title:          serie.Title,
+		Gaps:           []SourceGap{},
+	}
+
+	for _, sc := range chapters {
+		if len(sc.Chapters) == 0 {
+			continue
+		}
+		if filter.SourceID != "" && sc.SourceID != filter.SourceID {
+			continue
+		}
+		if filter.Language != "" && sc.Language != filter.Language {
+			continue
+		}
+
+		last := slices.Max(sc.Chapters)
+		missing := chapterutils.CalculateMissingChapters(sc.Chapters)
+
+		// Chapters other sources have after the last one of this source
+		for _, other := range chapters {
+			if other.Language != sc.Language || other.SourceID == sc.SourceID && other.SerieID == sc.SerieID {
+				continue
+			}
+
+			for _, number := range other.Chapters {
+				// Supplementary chapters (X.5) don't make the source behind, as in CalculateMissingChapters
+				whole := math.Trunc(number)
+				if whole > last && number-whole != 0.5 && !slices.Contains(missing, whole) {
+					missing = append(missing, whole)
+				}
+			}
+		}
+
+		if len(missing) == 0 {
+			continue
+		}
+		slices.Sort(missing)
+
+		gap := SourceGap{SourceChapters: sc, LastChapter: last, Missing: make([]MissingChapter, len(missing))}
+		for i, number := range missing {
+			gap.Missing[i] = MissingChapter{Number: number, AvailableIn: availableIn(chapters, sc, number)}
+			if len(gap.Missing[i].AvailableIn) > 0 {
+				report.Recoverable++
+			}
+		}
+
+		report.Gaps = append(report.Gaps, gap)
+	}
+
+	return report
+}
+
+func availableIn(chapters []SourceChapters, missing SourceChapters, number float64) []SourceRef {
+	refs := []SourceRef{}
+
+	for _, other := range chapters {
+		if other.Language != missing.Language || other.SourceID == missing.SourceID && other.SerieID == missing.SerieID {
+			continue
+		}
+
+		// Any part of the chapter counts, a chapter split in 12.1 and 12.2 is still chapter 12
+		if slices.ContainsFunc(other.Chapters, func(n float64) bool { return math.Trunc(n) == number }) {
+			refs = append(refs, SourceRef{SourceID: other.SourceID, SerieID: other.SerieID})
+		}
+	}
+
+	return refs
+}
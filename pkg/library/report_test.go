@@ -0,0 +1,111 @@
+package library_test
+
+import (
+	"reflect"
+	"testing"
+
+	"dokusho/pkg/library"
+	"dokusho/pkg/sources/source_types"
+)
+
+func TestBuildReport(t *testing.T) {
+	t.Parallel()
+
+	serie := library.LibrarySerie{Title: "Serie"}
+
+	mangadex := library.SourceRef{SourceID: "mangadex", SerieID: "md-1"}
+	weebcentral := library.SourceRef{SourceID: "weebcentral", SerieID: "wc-1"}
+
+	chapters := []library.SourceChapters{
+		// Gap between volumes, 4 is the last chapter of volume 1 and 7 the first of volume 2
+		{SourceID: "mangadex", SerieID: "md-1", Language: source_types.EN, Chapters: []float64{1, 2, 3, 4, 7, 8}},
+		{SourceID: "mangadex", SerieID: "md-1", Language: source_types.FR, Chapters: []float64{1, 3}},
+		{SourceID: "weebcentral", SerieID: "wc-1", Language: source_types.EN, Chapters: []float64{1, 2, 5.1, 5.2, 6, 8, 9, 10.5}},
+	}
+
+	tests := []struct {
+		name                string
+		filter              library.ReportFilter
+		expected            []library.SourceGap
+		expectedRecoverable int
+	}{
+		{
+			name:   "all sources and languages",
+			filter: library.ReportFilter{},
+			expected: []library.SourceGap{
+				{
+					SourceChapters: chapters[0],
+					LastChapter:    8,
+					Missing: []library.MissingChapter{
+						{Number: 5, AvailableIn: []library.SourceRef{weebcentral}},
+						{Number: 6, AvailableIn: []library.SourceRef{weebcentral}},
+						{Number: 9, AvailableIn: []library.SourceRef{weebcentral}},
+					},
+				},
+				{
+					SourceChapters: chapters[1],
+					LastChapter:    3,
+					Missing:        []library.MissingChapter{{Number: 2, AvailableIn: []library.SourceRef{}}},
+				},
+				{
+					SourceChapters: chapters[2],
+					LastChapter:    10.5,
+					Missing: []library.MissingChapter{
+						{Number: 3, AvailableIn: []library.SourceRef{mangadex}},
+						{Number: 4, AvailableIn: []library.SourceRef{mangadex}},
+						{Number: 7, AvailableIn: []library.SourceRef{mangadex}},
+					},
+				},
+			},
+			expectedRecoverable: 6,
+		},
+		{
+			name:   "filtered by source",
+			filter: library.ReportFilter{SourceID: "weebcentral"},
+			expected: []library.SourceGap{
+				{
+					SourceChapters: chapters[2],
+					LastChapter:    10.5,
+					Missing: []library.MissingChapter{
+						{Number: 3, AvailableIn: []library.SourceRef{mangadex}},
+						{Number: 4, AvailableIn: []library.SourceRef{mangadex}},
+						{Number: 7, AvailableIn: []library.SourceRef{mangadex}},
+					},
+				},
+			},
+			expectedRecoverable: 3,
+		},
+		{
+			name:   "filtered by language",
+			filter: library.ReportFilter{Language: source_types.FR},
+			expected: []library.SourceGap{
+				{
+					SourceChapters: chapters[1],
+					LastChapter:    3,
+					Missing:        []library.MissingChapter{{Number: 2, AvailableIn: []library.SourceRef{}}},
+				},
+			},
+			expectedRecoverable: 0,
+		},
+		{
+			name:                "unknown source",
+			filter:              library.ReportFilter{SourceID: "unknown"},
+			expected:            []library.SourceGap{},
+			expectedRecoverable: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			report := library.BuildReport(serie, chapters, tc.filter)
+			if !reflect.DeepEqual(report.Gaps, tc.expected) {
+				t.Errorf("BuildReport() gaps = %+v, expected %+v", report.Gaps, tc.expected)
+			}
+			if report.Recoverable != tc.expectedRecoverable {
+				t.Errorf("BuildReport() recoverable = %d, expected %d", report.Recoverable, tc.expectedRecoverable)
+			}
+		})
+	}
+}
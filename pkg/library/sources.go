@@ -0,0 +1,194 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"dokusho/pkg/sources/source_types"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// SerieSource is a source the library serie is read from
+type SerieSource struct {
+	LibrarySerieID uuid.UUID                  `json:"librarySerieId"`
+	SourceID       source_types.SourceID      `json:"sourceId"`
+	SerieID        source_types.SourceSerieID `json:"serieId"`
+	FetchedAt      *time.Time                 `json:"fetchedAt"`
+	LastError      string                     `json:"lastError,omitempty"`
+}
+
+// AddSource links another source to the serie, linking an already linked source does nothing
+func (s *Store) AddSource(ctx context.Context, id uuid.UUID, sourceID source_types.SourceID, serieID source_types.SourceSerieID) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO library_serie_sources (library_serie_id, source_id, serie_id) VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`,
+		id, sourceID, serieID,
+	)
+	if err != nil {
+		return errors.Join(ErrDatabaseQuery, err, fmt.Errorf("failed to link source %s/%s", sourceID, serieID))
+	}
+
+	return nil
+}
+
+// RemoveSource unlinks the source and forgets its chapters, the source the serie was added from can't be removed
+func (s *Store) RemoveSource(ctx context.Context, id uuid.UUID, sourceID source_types.SourceID, serieID source_types.SourceSerieID) error {
+	tag, err := s.pool.Exec(ctx, `
+		DELETE FROM library_serie_sources ss
+		USING library_series l
+		WHERE ss.library_serie_id = l.id AND ss.library_serie_id = $1 AND ss.source_id = $2 AND ss.serie_id = $3
+			AND NOT (l.source_id = ss.source_id AND l.serie_id = ss.serie_id)`,
+		id, sourceID, serieID,
+	)
+	if err != nil {
+		return errors.Join(ErrDatabaseQuery, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrSourceNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) ListSources(ctx context.Context, id uuid.UUID) ([]SerieSource, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT library_serie_id, source_id, serie_id, fetched_at, last_error
+		FROM library_serie_sources
+		WHERE library_serie_id = $1
+		ORDER BY source_id, serie_id`,
+		id,
+	)
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	sources, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (SerieSource, error) {
+		var source SerieSource
+		err := row.Scan(&source.LibrarySerieID, &source.SourceID, &source.SerieID, &source.FetchedAt, &source.LastError)
+		return source, err
+	})
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return sources, nil
+}
+
+// SaveChapters replaces the chapters known for the source with the ones of the fetched volumes
+func (s *Store) SaveChapters(ctx context.Context, id uuid.UUID, sourceID source_types.SourceID, serieID source_types.SourceSerieID, volumes []source_types.SourceSerieVolume) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return errors.Join(ErrDatabaseQuery, err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM library_serie_chapters WHERE library_serie_id = $1 AND source_id = $2 AND serie_id = $3`, id, sourceID, serieID)
+	if err != nil {
+		return errors.Join(ErrDatabaseQuery, err)
+	}
+
+	// Several scanlation groups often release the same chapter
+	type key struct {
+		number   float64
+		language source_types.SourceLanguage
+	}
+	seen := map[key]bool{}
+
+	var rows [][]any
+	for _, volume := range volumes {
+		for _, chapter := range volume.Chapters {
+			k := key{chapter.ChapterNumber, chapter.Language}
+			if seen[k] || math.IsNaN(chapter.ChapterNumber) {
+				continue
+			}
+			seen[k] = true
+
+			rows = append(rows, []any{id, string(sourceID), string(serieID), chapter.ChapterNumber, string(chapter.Language)})
+		}
+	}
+
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"library_serie_chapters"},
+		[]string{"library_serie_id", "source_id", "serie_id", "chapter_number", "language"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return errors.Join(ErrDatabaseQuery, err, fmt.Errorf("failed to save chapters of %s/%s", sourceID, serieID))
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE library_serie_sources SET fetched_at = now(), last_error = ''
+		WHERE library_serie_id = $1 AND source_id = $2 AND serie_id = $3`,
+		id, sourceID, serieID,
+	)
+	if err != nil {
+		return errors.Join(ErrDatabaseQuery, err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return nil
+}
+
+// SaveFetchError keeps the chapters of the last successful fetch, a source can be down for a while
+func (s *Store) SaveFetchError(ctx context.Context, id uuid.UUID, sourceID source_types.SourceID, serieID source_types.SourceSerieID, fetchErr error) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE library_serie_sources SET last_error = $4
+		WHERE library_serie_id = $1 AND source_id = $2 AND serie_id = $3`,
+		id, sourceID, serieID, fetchErr.Error(),
+	)
+	if err != nil {
+		return errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return nil
+}
+
+// Chapters returns the chapters of every source of the series by library serie, all series when ids is empty
+func (s *Store) Chapters(ctx context.Context, ids ...uuid.UUID) (map[uuid.UUID][]SourceChapters, error) {
+	if ids == nil {
+		ids = []uuid.UUID{}
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT library_serie_id, source_id, serie_id, language, array_agg(chapter_number ORDER BY chapter_number)
+		FROM library_serie_chapters
+		WHERE cardinality($1::uuid[]) = 0 OR library_serie_id = ANY($1)
+		GROUP BY library_serie_id, source_id, serie_id, language
+		ORDER BY library_serie_id, source_id, serie_id, language`,
+		ids,
+	)
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	type serieChapters struct {
+		id uuid.UUID
+		SourceChapters
+	}
+
+	all, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (serieChapters, error) {
+		var sc serieChapters
+		err := row.Scan(&sc.id, &sc.SourceID, &sc.SerieID, &sc.Language, &sc.Chapters)
+		return sc, err
+	})
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	chapters := map[uuid.UUID][]SourceChapters{}
+	for _, sc := range all {
+		chapters[sc.id] = append(chapters[sc.id], sc.SourceChapters)
+	}
+
+	return chapters, nil
+}
@@ -0,0 +1,110 @@
+package chapterutils
+
+import (
+	"math"
+	"slices"
+	"strings"
+
+	"dokusho/pkg/sources/source_types"
+)
+
+// GroupPreferences chooses between the releases of the scanlation groups of a serie, groups are matched by ID or case-insensitive name
+type GroupPreferences struct {
+	// From the most to the least preferred, the groups not listed come after them
+	Preferred []string `json:"preferred"`
+	// Chapters released by one of these groups are dropped
+	Blocked []string `json:"blocked"`
+	// Keep a single release of each chapter number and language
+	Collapse bool `json:"collapse"`
+}
+
+// IsZero reports whether the preferences keep the chapters as they are
+func (p GroupPreferences) IsZero() bool {
+	return len(p.Preferred) == 0 && len(p.Blocked) == 0 && !p.Collapse
+}
+
+// ApplyGroupPreferences drops the chapters of the blocked groups and, when collapsing, keeps the release of the most preferred group
+// for each chapter number, the first release wins a tie. Volumes left empty are dropped and the missing chapters of the others are computed again.
+func ApplyGroupPreferences(volumes []source_types.SourceSerieVolume, prefs GroupPreferences) []source_types.SourceSerieVolume {
+	if prefs.IsZero() {
+		return volumes
+	}
+
+	// A chapter number can be released in several volumes when the groups disagree on the volume
+	type key struct {
+		number   float64
+		language source_types.SourceLanguage
+	}
+	kept := map[key]source_types.SourceSerieVolumeChapterID{}
+	ranks := map[key]int{}
+
+	if prefs.Collapse {
+		for _, volume := range volumes {
+			for _, chapter := range volume.Chapters {
+				if math.IsNaN(chapter.ChapterNumber) || matchGroups(chapter.Groups, prefs.Blocked) >= 0 {
+					continue
+				}
+
+				k := key{chapter.ChapterNumber, chapter.Language}
+				rank := matchGroups(chapter.Groups, prefs.Preferred)
+				if rank < 0 {
+					rank = len(prefs.Preferred)
+				}
+
+				if current, ok := ranks[k]; !ok || rank < current {
+					kept[k] = chapter.ID
+					ranks[k] = rank
+				}
+			}
+		}
+	}
+
+	filtered := []source_types.SourceSerieVolume{}
+	for _, volume := range volumes {
+		var chapters []source_types.SourceSerieVolumeChapter
+		for _, chapter := range volume.Chapters {
+			if matchGroups(chapter.Groups, prefs.Blocked) >= 0 {
+				continue
+			}
+
+			if prefs.Collapse && !math.IsNaN(chapter.ChapterNumber) && kept[key{chapter.ChapterNumber, chapter.Language}] != chapter.ID {
+				continue
+			}
+
+			chapters = append(chapters, chapter)
+		}
+
+		if len(chapters) == 0 {
+			continue
+		}
+
+		// Some sources know better which chapters are missing, the untouched volumes keep them
+		if len(chapters) != len(volume.Chapters) {
+			numbers := make([]float64, len(chapters))
+			for i, chapter := range chapters {
+				numbers[i] = chapter.ChapterNumber
+			}
+
+			volume.Chapters = chapters
+			volume.MissingChapters = CalculateMissingChapters(numbers)
+		}
+
+		filtered = append(filtered, volume)
+	}
+
+	return filtered
+}
+
+// matchGroups returns the index of the first of names matching one of the groups, -1 when none matches
+func matchGroups(groups []source_types.SourceScanlationGroup, names []string) int {
+	for i, name := range names {
+		matched := slices.ContainsFunc(groups, func(group source_types.SourceScanlationGroup) bool {
+			return group.ID == name || (group.Name != "" && strings.EqualFold(group.Name, name))
+		})
+		if matched {
+			return i
+		}
+	}
+
+	return -1
+}
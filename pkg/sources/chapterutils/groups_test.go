@@ -0,0 +1,99 @@
+package chapterutils
+
+import (
+	"reflect"
+	"testing"
+
+	"dokusho/pkg/sources/source_types"
+)
+
+func TestApplyGroupPreferences(t *testing.T) {
+	alpha := []source_types.SourceScanlationGroup{{ID: "a", Name: "Alpha Scans"}}
+	beta := []source_types.SourceScanlationGroup{{ID: "b", Name: "Beta"}}
+	joint := []source_types.SourceScanlationGroup{{ID: "a", Name: "Alpha Scans"}, {ID: "c"}}
+
+	volumes := []source_types.SourceSerieVolume{
+		{
+			ID:              "volume-2",
+			MissingChapters: []float64{},
+			Chapters: []source_types.SourceSerieVolumeChapter{
+				{ID: "12-beta", ChapterNumber: 12, Language: source_types.EN, Groups: beta},
+				{ID: "12-alpha", ChapterNumber: 12, Language: source_types.EN, Groups: alpha},
+				{ID: "12-fr", ChapterNumber: 12, Language: source_types.FR, Groups: beta},
+				{ID: "11-joint", ChapterNumber: 11, Language: source_types.EN, Groups: joint},
+				{ID: "10-beta", ChapterNumber: 10, Language: source_types.EN, Groups: beta},
+			},
+		},
+		{
+			ID:              "volume-1",
+			MissingChapters: []float64{},
+			Chapters: []source_types.SourceSerieVolumeChapter{
+				{ID: "1-alpha", ChapterNumber: 1, Language: source_types.EN, Groups: alpha},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		prefs    GroupPreferences
+		expected map[source_types.SourceSerieVolumeID][]source_types.SourceSerieVolumeChapterID
+		missing  []float64
+	}{
+		{
+			name:  "no preferences",
+			prefs: GroupPreferences{},
+			expected: map[source_types.SourceSerieVolumeID][]source_types.SourceSerieVolumeChapterID{
+				"volume-2": {"12-beta", "12-alpha", "12-fr", "11-joint", "10-beta"},
+				"volume-1": {"1-alpha"},
+			},
+			missing: []float64{},
+		},
+		{
+			name:  "collapse keeps the first release",
+			prefs: GroupPreferences{Collapse: true},
+			expected: map[source_types.SourceSerieVolumeID][]source_types.SourceSerieVolumeChapterID{
+				"volume-2": {"12-beta", "12-fr", "11-joint", "10-beta"},
+				"volume-1": {"1-alpha"},
+			},
+			missing: []float64{},
+		},
+		{
+			name:  "collapse keeps the preferred group matched by name",
+			prefs: GroupPreferences{Preferred: []string{"alpha scans"}, Collapse: true},
+			expected: map[source_types.SourceSerieVolumeID][]source_types.SourceSerieVolumeChapterID{
+				"volume-2": {"12-alpha", "12-fr", "11-joint", "10-beta"},
+				"volume-1": {"1-alpha"},
+			},
+			missing: []float64{},
+		},
+		{
+			name:  "blocked groups drop their chapters and empty volumes",
+			prefs: GroupPreferences{Blocked: []string{"a"}},
+			expected: map[source_types.SourceSerieVolumeID][]source_types.SourceSerieVolumeChapterID{
+				"volume-2": {"12-beta", "12-fr", "10-beta"},
+			},
+			missing: []float64{11},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := ApplyGroupPreferences(volumes, tc.prefs)
+
+			got := map[source_types.SourceSerieVolumeID][]source_types.SourceSerieVolumeChapterID{}
+			for _, volume := range result {
+				for _, chapter := range volume.Chapters {
+					got[volume.ID] = append(got[volume.ID], chapter.ID)
+				}
+			}
+
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected chapters %v, got %v", tc.expected, got)
+			}
+
+			if !reflect.DeepEqual(result[0].MissingChapters, tc.missing) {
+				t.Errorf("expected missing chapters %v, got %v", tc.missing, result[0].MissingChapters)
+			}
+		})
+	}
+}
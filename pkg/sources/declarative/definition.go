@@ -0,0 +1,356 @@
+package declarative
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"dokusho/pkg/sources/source_types"
+
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+)
+
+// Definition describes an HTML source, URLs are text/template templates rendered with URLData
+type Definition struct {
+	ID         source_types.SourceID `json:"id" yaml:"id"`
+	Name       string                `json:"name" yaml:"name"`
+	URL        string                `json:"url" yaml:"url"`
+	Icon       string                `json:"icon" yaml:"icon"`
+	Version    string                `json:"version" yaml:"version"`
+	Languages  []string              `json:"languages" yaml:"languages"`
+	NSFW       bool                  `json:"nsfw" yaml:"nsfw"`
+	Timeout    string                `json:"timeout" yaml:"timeout"`
+	Headers    map[string]string     `json:"headers" yaml:"headers"`
+	ImageHosts []string              `json:"imageHosts" yaml:"imageHosts"`
+
+	Search *ListDefinition `json:"search" yaml:"search"`
+	// Searches sorted by popularity when missing
+	Popular *ListDefinition `json:"popular" yaml:"popular"`
+	// Searches sorted by latest when missing
+	Latest  *ListDefinition   `json:"latest" yaml:"latest"`
+	Serie   SerieDefinition   `json:"serie" yaml:"serie"`
+	Chapter ChapterDefinition `json:"chapter" yaml:"chapter"`
+	Filters FiltersDefinition `json:"filters" yaml:"filters"`
+
+	// Modification time of the definition file
+	UpdatedAt time.Time `json:"-" yaml:"-"`
+}
+
+// ListDefinition describes a paginated list of series
+type ListDefinition struct {
+	URL string `json:"url" yaml:"url"`
+	// Used to compute URLData.Offset
+	PageSize int `json:"pageSize" yaml:"pageSize"`
+	// Selector of the series in the page
+	Items string    `json:"items" yaml:"items"`
+	ID    Extractor `json:"id" yaml:"id"`
+	Title Extractor `json:"title" yaml:"title"`
+	Cover Extractor `json:"cover" yaml:"cover"`
+	// Extracted from the whole page, a non empty value means there is a next page
+	HasNextPage Extractor `json:"hasNextPage" yaml:"hasNextPage"`
+}
+
+// SerieDefinition describes the serie page, genres, status and type values must be mapped to their dokusho value
+type SerieDefinition struct {
+	URL               string             `json:"url" yaml:"url"`
+	Title             Extractor          `json:"title" yaml:"title"`
+	Cover             Extractor          `json:"cover" yaml:"cover"`
+	Synopsis          Extractor          `json:"synopsis" yaml:"synopsis"`
+	AlternativeTitles Extractor          `json:"alternativeTitles" yaml:"alternativeTitles"`
+	Authors           Extractor          `json:"authors" yaml:"authors"`
+	Artists           Extractor          `json:"artists" yaml:"artists"`
+	Genres            Extractor          `json:"genres" yaml:"genres"`
+	Status            Extractor          `json:"status" yaml:"status"`
+	Type              Extractor          `json:"type" yaml:"type"`
+	Chapters          ChaptersDefinition `json:"chapters" yaml:"chapters"`
+}
+
+// ChaptersDefinition describes the chapters list, read from the serie page when URL is empty
+type ChaptersDefinition struct {
+	URL    string    `json:"url" yaml:"url"`
+	Items  string    `json:"items" yaml:"items"`
+	ID     Extractor `json:"id" yaml:"id"`
+	Name   Extractor `json:"name" yaml:"name"`
+	Number Extractor `json:"number" yaml:"number"`
+	Date   Extractor `json:"date" yaml:"date"`
+	// Go time layout of the date, RFC3339 by default
+	DateLayout string `json:"dateLayout" yaml:"dateLayout"`
+	// Chapters are in volume 1 when missing
+	Volume Extractor `json:"volume" yaml:"volume"`
+	// The first language of the source when missing
+	Language Extractor `json:"language" yaml:"language"`
+}
+
+type ChapterDefinition struct {
+	URL    string    `json:"url" yaml:"url"`
+	Images Extractor `json:"images" yaml:"images"`
+}
+
+// FiltersDefinition maps the dokusho search filters to the values of the site, filters missing from it aren't supported
+type FiltersDefinition struct {
+	Sorts          map[string]string `json:"sorts" yaml:"sorts"`
+	Orders         map[string]string `json:"orders" yaml:"orders"`
+	Genres         map[string]string `json:"genres" yaml:"genres"`
+	ExcludedGenres bool              `json:"excludedGenres" yaml:"excludedGenres"`
+	Types          map[string]string `json:"types" yaml:"types"`
+	Status         map[string]string `json:"status" yaml:"status"`
+	Authors        bool              `json:"authors" yaml:"authors"`
+	Artists        bool              `json:"artists" yaml:"artists"`
+}
+
+// URLData is given to the URL templates, filters hold the values of the site
+type URLData struct {
+	BaseURL string
+	Page    int
+	// Number of series in the previous pages
+	Offset         int
+	Query          string
+	Sort           string
+	Order          string
+	Genres         []string
+	ExcludedGenres []string
+	Types          []string
+	Status         []string
+	Authors        []string
+	Artists        []string
+	SerieID        string
+	VolumeID       string
+	ChapterID      string
+}
+
+var templateFuncs = template.FuncMap{
+	"query": url.QueryEscape,
+	"path":  url.PathEscape,
+}
+
+// LoadFile reads a JSON or YAML definition
+func LoadFile(path string) (Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Definition{}, errors.Join(ErrReadingDefinition, err, fmt.Errorf("failed to read definition %s", path))
+	}
+
+	var definition Definition
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &definition)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &definition)
+	default:
+		return Definition{}, errors.Join(ErrReadingDefinition, fmt.Errorf("unknown definition format: %s", path))
+	}
+	if err != nil {
+		return Definition{}, errors.Join(ErrInvalidDefinition, err, fmt.Errorf("failed to decode definition %s", path))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Definition{}, errors.Join(ErrReadingDefinition, err, fmt.Errorf("failed to stat definition %s", path))
+	}
+	definition.UpdatedAt = info.ModTime().UTC()
+
+	return definition, nil
+}
+
+// LoadDirectory builds a source for every JSON and YAML definition of the directory, ordered by file name
+func LoadDirectory(dir string) ([]source_types.SourceAPI, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Join(ErrReadingDefinition, err, fmt.Errorf("failed to read definitions directory %s", dir))
+	}
+
+	apis := []source_types.SourceAPI{}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || !slices.Contains([]string{".json", ".yaml", ".yml"}, ext) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		definition, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		api, err := NewSource(definition)
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("failed to build source from %s", path))
+		}
+
+		apis = append(apis, api)
+	}
+
+	return apis, nil
+}
+
+func (d *Definition) validate() error {
+	if d.ID == "" || d.Name == "" {
+		return errors.Join(ErrInvalidDefinition, fmt.Errorf("id and name are required"))
+	}
+
+	u, err := url.Parse(d.URL)
+	if err != nil || !u.IsAbs() {
+		return errors.Join(ErrInvalidDefinition, err, fmt.Errorf("url must be absolute: %q", d.URL))
+	}
+
+	if len(d.Languages) == 0 {
+		return errors.Join(ErrInvalidDefinition, fmt.Errorf("at least one language is required"))
+	}
+
+	for _, language := range d.Languages {
+		if source_types.NewSourceLanguage(language).String() != language {
+			return errors.Join(ErrInvalidDefinition, source_types.ErrInvalidLanguage, fmt.Errorf("unknown language: %s", language))
+		}
+	}
+
+	if d.Serie.URL == "" || d.Chapter.URL == "" {
+		return errors.Join(ErrInvalidDefinition, fmt.Errorf("serie and chapter urls are required"))
+	}
+
+	if d.Serie.Chapters.Items == "" || d.Serie.Chapters.ID.IsZero() || d.Chapter.Images.IsZero() {
+		return errors.Join(ErrInvalidDefinition, fmt.Errorf("chapters items, chapters id and chapter images are required"))
+	}
+
+	for name, list := range map[string]*ListDefinition{"search": d.Search, "popular": d.Popular, "latest": d.Latest} {
+		if list != nil && (list.URL == "" || list.Items == "" || list.ID.IsZero()) {
+			return errors.Join(ErrInvalidDefinition, fmt.Errorf("%s url, items and id are required", name))
+		}
+	}
+
+	for sort := range d.Filters.Sorts {
+		if source_types.NewFetchSearchSerieFilterSort(sort).String() != sort {
+			return errors.Join(ErrInvalidDefinition, source_types.ErrInvalidSearchSort, fmt.Errorf("unknown sort: %s", sort))
+		}
+	}
+
+	for order := range d.Filters.Orders {
+		if source_types.NewFetchSearchSerieFilterOrder(order).String() != order {
+			return errors.Join(ErrInvalidDefinition, source_types.ErrInvalidSearchOrder, fmt.Errorf("unknown order: %s", order))
+		}
+	}
+
+	for genre := range d.Filters.Genres {
+		if source_types.NewSourceSerieGenre(genre).String() != genre {
+			return errors.Join(ErrInvalidDefinition, source_types.ErrInvalidSearchGenres, fmt.Errorf("unknown genre: %s", genre))
+		}
+	}
+
+	for t := range d.Filters.Types {
+		if source_types.NewSourceSerieType(t).String() != t {
+			return errors.Join(ErrInvalidDefinition, source_types.ErrInvalidSearchTypes, fmt.Errorf("unknown type: %s", t))
+		}
+	}
+
+	for status := range d.Filters.Status {
+		if source_types.NewSourceSerieStatus(status).String() != status {
+			return errors.Join(ErrInvalidDefinition, source_types.ErrInvalidSearchStatus, fmt.Errorf("unknown status: %s", status))
+		}
+	}
+
+	return nil
+}
+
+func (d *Definition) compileExtractors() error {
+	extractors := map[string]*Extractor{
+		"serie.title":             &d.Serie.Title,
+		"serie.cover":             &d.Serie.Cover,
+		"serie.synopsis":          &d.Serie.Synopsis,
+		"serie.alternativeTitles": &d.Serie.AlternativeTitles,
+		"serie.authors":           &d.Serie.Authors,
+		"serie.artists":           &d.Serie.Artists,
+		"serie.genres":            &d.Serie.Genres,
+		"serie.status":            &d.Serie.Status,
+		"serie.type":              &d.Serie.Type,
+		"serie.chapters.id":       &d.Serie.Chapters.ID,
+		"serie.chapters.name":     &d.Serie.Chapters.Name,
+		"serie.chapters.number":   &d.Serie.Chapters.Number,
+		"serie.chapters.date":     &d.Serie.Chapters.Date,
+		"serie.chapters.volume":   &d.Serie.Chapters.Volume,
+		"serie.chapters.language": &d.Serie.Chapters.Language,
+		"chapter.images":          &d.Chapter.Images,
+	}
+
+	selectors := map[string]string{"serie.chapters.items": d.Serie.Chapters.Items}
+
+	for name, list := range map[string]*ListDefinition{"search": d.Search, "popular": d.Popular, "latest": d.Latest} {
+		if list == nil {
+			continue
+		}
+
+		extractors[name+".id"] = &list.ID
+		extractors[name+".title"] = &list.Title
+		extractors[name+".cover"] = &list.Cover
+		extractors[name+".hasNextPage"] = &list.HasNextPage
+		selectors[name+".items"] = list.Items
+	}
+
+	for name, selector := range selectors {
+		_, err := cascadia.Compile(selector)
+		if err != nil {
+			return errors.Join(ErrInvalidDefinition, err, fmt.Errorf("invalid selector for %s: %q", name, selector))
+		}
+	}
+
+	for name, extractor := range extractors {
+		err := extractor.compile(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Definition) compileTemplates() (map[string]*template.Template, error) {
+	urls := map[string]string{
+		"serie":   d.Serie.URL,
+		"chapter": d.Chapter.URL,
+	}
+	if d.Serie.Chapters.URL != "" {
+		urls["chapters"] = d.Serie.Chapters.URL
+	}
+	for name, list := range map[string]*ListDefinition{"search": d.Search, "popular": d.Popular, "latest": d.Latest} {
+		if list != nil {
+			urls[name] = list.URL
+		}
+	}
+
+	templates := make(map[string]*template.Template, len(urls))
+	for name, raw := range urls {
+		tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(raw)
+		if err != nil {
+			return nil, errors.Join(ErrInvalidDefinition, err, fmt.Errorf("invalid %s url template", name))
+		}
+
+		templates[name] = tmpl
+	}
+
+	return templates, nil
+}
+
+// renderURL renders the template, relative URLs are resolved against the base URL
+func renderURL(base *url.URL, tmpl *template.Template, data URLData) (*url.URL, error) {
+	data.BaseURL = strings.TrimSuffix(base.String(), "/")
+
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, data)
+	if err != nil {
+		return nil, errors.Join(ErrRenderingURL, err, fmt.Errorf("failed to render %s url", tmpl.Name()))
+	}
+
+	u, err := url.Parse(strings.TrimSpace(buf.String()))
+	if err != nil {
+		return nil, errors.Join(source_types.ErrBuildingURL, err, fmt.Errorf("invalid %s url: %s", tmpl.Name(), buf.String()))
+	}
+
+	return base.ResolveReference(u), nil
+}
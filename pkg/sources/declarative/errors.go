@@ -0,0 +1,10 @@
+package declarative
+
+import "errors"
+
+var (
+	ErrReadingDefinition = errors.New("error reading source definition")
+	ErrInvalidDefinition = errors.New("invalid source definition")
+	ErrNotSupported      = errors.New("not supported by the source definition")
+	ErrRenderingURL      = errors.New("error rendering url template")
+)
@@ -0,0 +1,154 @@
+package declarative
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+)
+
+// Extractor extracts a value from the elements matched by Selector, relative to the current element.
+// A definition can give only the selector as a string, the text of the element is then extracted.
+type Extractor struct {
+	// Matches the current element when empty
+	Selector string `json:"selector" yaml:"selector"`
+	// The text of the element is extracted when empty
+	Attr string `json:"attr" yaml:"attr"`
+	// Keeps the first capture group, or the whole match without group, use (?:) for other groups. No match gives an empty value
+	Regex string `json:"regex" yaml:"regex"`
+	// Replaces the value, case insensitively. Values missing from the mapping are kept as is
+	Mapping map[string]string `json:"mapping" yaml:"mapping"`
+	// Used when the value is empty
+	Default string `json:"default" yaml:"default"`
+
+	regex   *regexp.Regexp
+	mapping map[string]string
+}
+
+func (e *Extractor) UnmarshalJSON(data []byte) error {
+	var selector string
+	if json.Unmarshal(data, &selector) == nil {
+		*e = Extractor{Selector: selector}
+		return nil
+	}
+
+	// Avoids calling UnmarshalJSON again
+	type extractor Extractor
+	return json.Unmarshal(data, (*extractor)(e))
+}
+
+func (e *Extractor) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*e = Extractor{Selector: value.Value}
+		return nil
+	}
+
+	type extractor Extractor
+	return value.Decode((*extractor)(e))
+}
+
+// IsZero reports whether the extractor is missing from the definition
+func (e *Extractor) IsZero() bool {
+	return e.Selector == "" && e.Attr == "" && e.Regex == "" && len(e.Mapping) == 0 && e.Default == ""
+}
+
+func (e *Extractor) compile(name string) error {
+	if e.Selector != "" {
+		_, err := cascadia.Compile(e.Selector)
+		if err != nil {
+			return errors.Join(ErrInvalidDefinition, err, fmt.Errorf("invalid selector for %s: %q", name, e.Selector))
+		}
+	}
+
+	if e.Regex != "" {
+		regex, err := regexp.Compile(e.Regex)
+		if err != nil {
+			return errors.Join(ErrInvalidDefinition, err, fmt.Errorf("invalid regex for %s: %q", name, e.Regex))
+		}
+
+		e.regex = regex
+	}
+
+	e.mapping = make(map[string]string, len(e.Mapping))
+	for raw, value := range e.Mapping {
+		e.mapping[strings.ToLower(strings.TrimSpace(raw))] = value
+	}
+
+	return nil
+}
+
+// First extracts the value of the first element matched
+func (e *Extractor) First(s *goquery.Selection) string {
+	if e.IsZero() {
+		return ""
+	}
+
+	target := s
+	if e.Selector != "" {
+		target = s.Find(e.Selector).First()
+	}
+
+	if target.Length() == 0 {
+		return e.Default
+	}
+
+	return e.value(target)
+}
+
+// All extracts the values of every element matched, empty values are skipped
+func (e *Extractor) All(s *goquery.Selection) []string {
+	values := []string{}
+	if e.IsZero() {
+		return values
+	}
+
+	target := s
+	if e.Selector != "" {
+		target = s.Find(e.Selector)
+	}
+
+	target.Each(func(_ int, elem *goquery.Selection) {
+		if value := e.value(elem); value != "" {
+			values = append(values, value)
+		}
+	})
+
+	return values
+}
+
+func (e *Extractor) value(elem *goquery.Selection) string {
+	var raw string
+	if e.Attr != "" {
+		raw = elem.AttrOr(e.Attr, "")
+	} else {
+		raw = elem.Text()
+	}
+	raw = strings.TrimSpace(raw)
+
+	if e.regex != nil {
+		match := e.regex.FindStringSubmatch(raw)
+		switch {
+		case match == nil:
+			raw = ""
+		case len(match) > 1:
+			raw = match[1]
+		default:
+			raw = match[0]
+		}
+	}
+
+	if mapped, ok := e.mapping[strings.ToLower(raw)]; ok {
+		raw = mapped
+	}
+
+	if raw == "" {
+		return e.Default
+	}
+
+	return raw
+}
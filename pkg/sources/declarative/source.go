@@ -0,0 +1,530 @@
+package declarative
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"dokusho/pkg/sources/chapterutils"
+	sources "dokusho/pkg/sources/source_types"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type declarativeSource struct {
+	sources.Source
+
+	definition Definition
+	templates  map[string]*template.Template
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// NewSource validates the definition and builds the source it describes
+func NewSource(definition Definition) (*declarativeSource, error) {
+	err := definition.validate()
+	if err != nil {
+		return nil, errors.Join(err, fmt.Errorf("invalid definition %s", definition.ID))
+	}
+
+	err = definition.compileExtractors()
+	if err != nil {
+		return nil, errors.Join(err, fmt.Errorf("invalid definition %s", definition.ID))
+	}
+
+	templates, err := definition.compileTemplates()
+	if err != nil {
+		return nil, errors.Join(err, fmt.Errorf("invalid definition %s", definition.ID))
+	}
+
+	timeout := 10 * time.Second
+	if definition.Timeout != "" {
+		timeout, err = time.ParseDuration(definition.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, errors.Join(ErrInvalidDefinition, err, fmt.Errorf("invalid timeout for %s: %q", definition.ID, definition.Timeout))
+		}
+	}
+
+	apiURL, _ := url.Parse(definition.URL)
+
+	headers := http.Header{}
+	for name, value := range definition.Headers {
+		headers.Set(name, value)
+	}
+
+	languages := make([]sources.SourceLanguage, len(definition.Languages))
+	for i, language := range definition.Languages {
+		languages[i] = sources.NewSourceLanguage(language)
+	}
+
+	version := definition.Version
+	if version == "" {
+		version = "1.0.0"
+	}
+
+	client := &http.Client{Timeout: timeout}
+
+	return &declarativeSource{
+		definition: definition,
+		templates:  templates,
+		httpClient: client,
+		logger:     slog.Default().WithGroup(string(definition.ID)),
+		Source: sources.Source{
+			HTTPClient: client,
+			SourceInformation: sources.SourceInformation{
+				ID:            definition.ID,
+				Name:          definition.Name,
+				URL:           definition.URL,
+				Icon:          definition.Icon,
+				Version:       version,
+				Languages:     languages,
+				UpdatedAt:     definition.UpdatedAt,
+				NSFW:          definition.NSFW,
+				SearchFilters: definition.supportedFilters(),
+			},
+			SourceAPIInformation: sources.SourceAPIInformation{
+				APIURL:                apiURL,
+				MinimumUpdateInterval: 5 * time.Minute,
+				Timeout:               timeout,
+				Headers:               headers,
+				CanBlockScraping:      true,
+				ImageHosts:            definition.ImageHosts,
+			},
+		},
+	}, nil
+}
+
+func (d *Definition) supportedFilters() sources.SupportedFilters {
+	filters := sources.SupportedFilters{
+		Query:   d.Search != nil,
+		Artists: d.Search != nil && d.Filters.Artists,
+		Authors: d.Search != nil && d.Filters.Authors,
+		Orders:  []sources.FetchSearchSerieFilterOrder{},
+		Sorts:   []sources.FetchSearchSerieFilterSort{},
+		Types:   []sources.SourceSerieType{},
+		Status:  []sources.SourceSerieStatus{},
+		Genres: sources.SupportedFiltersGenres{
+			PossibleValues: []sources.SourceSerieGenre{},
+		},
+	}
+
+	if d.Search == nil {
+		return filters
+	}
+
+	for _, order := range slices.Sorted(maps.Keys(d.Filters.Orders)) {
+		filters.Orders = append(filters.Orders, sources.NewFetchSearchSerieFilterOrder(order))
+	}
+
+	for _, sort := range slices.Sorted(maps.Keys(d.Filters.Sorts)) {
+		filters.Sorts = append(filters.Sorts, sources.NewFetchSearchSerieFilterSort(sort))
+	}
+
+	for _, t := range slices.Sorted(maps.Keys(d.Filters.Types)) {
+		filters.Types = append(filters.Types, sources.NewSourceSerieType(t))
+	}
+
+	for _, status := range slices.Sorted(maps.Keys(d.Filters.Status)) {
+		filters.Status = append(filters.Status, sources.NewSourceSerieStatus(status))
+	}
+
+	for _, genre := range slices.Sorted(maps.Keys(d.Filters.Genres)) {
+		filters.Genres.PossibleValues = append(filters.Genres.PossibleValues, sources.NewSourceSerieGenre(genre))
+	}
+	filters.Genres.Included = len(filters.Genres.PossibleValues) > 0
+	filters.Genres.Excluded = filters.Genres.Included && d.Filters.ExcludedGenres
+
+	return filters
+}
+
+func (s *declarativeSource) GetInformation() sources.SourceInformation {
+	return s.Source.SourceInformation
+}
+
+func (s *declarativeSource) GetAPIInformation() sources.SourceAPIInformation {
+	return s.Source.SourceAPIInformation
+}
+
+func (s *declarativeSource) FetchPopularSerie(ctx context.Context, page int) (sources.SourcePaginatedSmallSerie, error) {
+	if s.definition.Popular != nil {
+		return s.fetchList(ctx, "popular", s.definition.Popular, page, URLData{})
+	}
+
+	return s.FetchSearchSerie(ctx, page, sources.FetchSearchSerieFilter{Sort: sources.POPULARITY, Order: sources.DESC})
+}
+
+func (s *declarativeSource) FetchLatestUpdates(ctx context.Context, page int) (sources.SourcePaginatedSmallSerie, error) {
+	if s.definition.Latest != nil {
+		return s.fetchList(ctx, "latest", s.definition.Latest, page, URLData{})
+	}
+
+	return s.FetchSearchSerie(ctx, page, sources.FetchSearchSerieFilter{Sort: sources.LATEST, Order: sources.DESC})
+}
+
+func (s *declarativeSource) FetchSearchSerie(ctx context.Context, page int, filter sources.FetchSearchSerieFilter) (sources.SourcePaginatedSmallSerie, error) {
+	if s.definition.Search == nil {
+		return sources.SourcePaginatedSmallSerie{}, errors.Join(ErrNotSupported, fmt.Errorf("search isn't defined for %s", s.SourceInformation.ID))
+	}
+
+	data, err := s.searchData(filter)
+	if err != nil {
+		return sources.SourcePaginatedSmallSerie{}, err
+	}
+
+	return s.fetchList(ctx, "search", s.definition.Search, page, data)
+}
+
+// searchData converts the filter to the values of the site
+func (s *declarativeSource) searchData(filter sources.FetchSearchSerieFilter) (URLData, error) {
+	filters := s.definition.Filters
+	data := URLData{Query: filter.Query}
+
+	if filter.Sort != "" {
+		sort, ok := filters.Sorts[filter.Sort.String()]
+		if !ok {
+			return URLData{}, errors.Join(sources.ErrInvalidSearchSort, fmt.Errorf("invalid sort: %s", filter.Sort))
+		}
+		data.Sort = sort
+	}
+
+	if filter.Order != "" {
+		order, ok := filters.Orders[filter.Order.String()]
+		if !ok {
+			return URLData{}, errors.Join(sources.ErrInvalidSearchOrder, fmt.Errorf("invalid order: %s", filter.Order))
+		}
+		data.Order = order
+	}
+
+	var err error
+	data.Types, err = convertFilter(filters.Types, filter.Types, sources.ErrInvalidSearchTypes)
+	if err != nil {
+		return URLData{}, err
+	}
+
+	data.Status, err = convertFilter(filters.Status, filter.Status, sources.ErrInvalidSearchStatus)
+	if err != nil {
+		return URLData{}, err
+	}
+
+	data.Genres, err = convertFilter(filters.Genres, filter.Genres.Include, sources.ErrInvalidSearchGenres)
+	if err != nil {
+		return URLData{}, err
+	}
+
+	if len(filter.Genres.Exclude) > 0 && !filters.ExcludedGenres {
+		return URLData{}, errors.Join(sources.ErrInvalidSearchGenres, fmt.Errorf("excluding genres isn't supported"))
+	}
+	data.ExcludedGenres, err = convertFilter(filters.Genres, filter.Genres.Exclude, sources.ErrInvalidSearchGenres)
+	if err != nil {
+		return URLData{}, err
+	}
+
+	if filters.Authors {
+		data.Authors = filter.Authors
+	}
+
+	if filters.Artists {
+		data.Artists = filter.Artists
+	}
+
+	return data, nil
+}
+
+func convertFilter[T ~string](mapping map[string]string, values []T, errInvalid error) ([]string, error) {
+	converted := make([]string, len(values))
+	for i, value := range values {
+		c, ok := mapping[string(value)]
+		if !ok {
+			return nil, errors.Join(errInvalid, fmt.Errorf("unsupported value: %s", value))
+		}
+		converted[i] = c
+	}
+
+	return converted, nil
+}
+
+func (s *declarativeSource) fetchList(ctx context.Context, name string, list *ListDefinition, page int, data URLData) (sources.SourcePaginatedSmallSerie, error) {
+	if page < 1 {
+		page = 1
+	}
+	data.Page = page
+	data.Offset = (page - 1) * list.PageSize
+
+	listURL, err := renderURL(s.SourceAPIInformation.APIURL, s.templates[name], data)
+	if err != nil {
+		return sources.SourcePaginatedSmallSerie{}, err
+	}
+
+	doc, err := s.fetchDocument(ctx, listURL)
+	if err != nil {
+		return sources.SourcePaginatedSmallSerie{}, err
+	}
+
+	series := []sources.SourceSmallSerie{}
+	doc.Find(list.Items).Each(func(_ int, item *goquery.Selection) {
+		id := list.ID.First(item)
+		if id == "" {
+			s.logger.Warn("Skipping serie without id", "list", name)
+			return
+		}
+
+		series = append(series, sources.SourceSmallSerie{
+			ID:    sources.SourceSerieID(id),
+			Title: s.multiLanguage(list.Title.First(item)),
+			Cover: resolveURL(listURL, list.Cover.First(item)),
+		})
+	})
+
+	return sources.SourcePaginatedSmallSerie{
+		HasNextPage: list.HasNextPage.First(doc.Selection) != "",
+		Series:      series,
+	}, nil
+}
+
+func (s *declarativeSource) FetchSerieDetail(ctx context.Context, serieID sources.SourceSerieID) (sources.SourceSerie, error) {
+	serieURL, err := s.SerieUrl(serieID)
+	if err != nil {
+		return sources.SourceSerie{}, err
+	}
+
+	serieDoc, err := s.fetchDocument(ctx, serieURL)
+	if err != nil {
+		return sources.SourceSerie{}, err
+	}
+
+	chaptersDoc := serieDoc
+	if tmpl, ok := s.templates["chapters"]; ok {
+		chaptersURL, err := renderURL(s.SourceAPIInformation.APIURL, tmpl, URLData{SerieID: string(serieID)})
+		if err != nil {
+			return sources.SourceSerie{}, err
+		}
+
+		chaptersDoc, err = s.fetchDocument(ctx, chaptersURL)
+		if err != nil {
+			return sources.SourceSerie{}, err
+		}
+	}
+
+	def := s.definition.Serie
+	page := serieDoc.Selection
+
+	alternativeTitles := []sources.MultiLanguageString{}
+	for _, title := range def.AlternativeTitles.All(page) {
+		alternativeTitles = append(alternativeTitles, s.multiLanguage(title))
+	}
+
+	genres := []sources.SourceSerieGenre{}
+	for _, raw := range def.Genres.All(page) {
+		genre := sources.NewSourceSerieGenre(raw)
+		if genre == sources.UNKNOWN {
+			s.logger.Warn("Unknown genre, add it to the genres mapping", "raw_genre", raw)
+			continue
+		}
+		genres = append(genres, genre)
+	}
+
+	status := []sources.SourceSerieStatus{}
+	for _, raw := range def.Status.All(page) {
+		status = append(status, sources.NewSourceSerieStatus(raw))
+	}
+
+	return sources.SourceSerie{
+		ID:                serieID,
+		Title:             s.multiLanguage(def.Title.First(page)),
+		AlternativeTitles: alternativeTitles,
+		Cover:             resolveURL(serieURL, def.Cover.First(page)),
+		Synopsis:          s.multiLanguage(def.Synopsis.First(page)),
+		Type:              sources.NewSourceSerieType(def.Type.First(page)),
+		Genres:            genres,
+		Status:            status,
+		Authors:           def.Authors.All(page),
+		Artists:           def.Artists.All(page),
+		Volumes:           s.parseVolumes(chaptersDoc),
+	}, nil
+}
+
+// parseVolumes groups the chapters by volume, ordered by volume number
+func (s *declarativeSource) parseVolumes(doc *goquery.Document) []sources.SourceSerieVolume {
+	def := s.definition.Serie.Chapters
+
+	layout := def.DateLayout
+	if layout == "" {
+		layout = time.RFC3339
+	}
+
+	volumes := map[float64]*sources.SourceSerieVolume{}
+	doc.Find(def.Items).Each(func(_ int, item *goquery.Selection) {
+		id := def.ID.First(item)
+		if id == "" {
+			s.logger.Warn("Skipping chapter without id")
+			return
+		}
+
+		name := def.Name.First(item)
+
+		number, err := strconv.ParseFloat(def.Number.First(item), 64)
+		if err != nil {
+			s.logger.Warn("Failed to parse chapter number", "chapter_id", id, "error", err)
+		}
+
+		var dateUpload time.Time
+		if rawDate := def.Date.First(item); rawDate != "" {
+			dateUpload, err = time.Parse(layout, rawDate)
+			if err != nil {
+				s.logger.Warn("Failed to parse date", "chapter_id", id, "raw_date", rawDate, "error", err)
+			}
+		}
+
+		language := s.SourceInformation.Languages[0]
+		if rawLanguage := def.Language.First(item); rawLanguage != "" {
+			language = sources.NewSourceLanguage(rawLanguage)
+		}
+
+		volumeNumber := 1.0
+		if rawVolume := def.Volume.First(item); rawVolume != "" {
+			volumeNumber, err = strconv.ParseFloat(rawVolume, 64)
+			if err != nil {
+				s.logger.Warn("Failed to parse volume number", "chapter_id", id, "raw_volume", rawVolume, "error", err)
+				volumeNumber = 1
+			}
+		}
+
+		volume, ok := volumes[volumeNumber]
+		if !ok {
+			volume = &sources.SourceSerieVolume{
+				ID:           sources.SourceSerieVolumeID(fmt.Sprintf("volume-%g", volumeNumber)),
+				Name:         fmt.Sprintf("Volume %g", volumeNumber),
+				VolumeNumber: volumeNumber,
+				Chapters:     []sources.SourceSerieVolumeChapter{},
+			}
+			volumes[volumeNumber] = volume
+		}
+
+		volume.Chapters = append(volume.Chapters, sources.SourceSerieVolumeChapter{
+			ID:            sources.SourceSerieVolumeChapterID(id),
+			Name:          name,
+			ChapterNumber: math.Trunc(number*1000) / 1000,
+			Language:      language,
+			DateUpload:    dateUpload,
+		})
+	})
+
+	result := []sources.SourceSerieVolume{}
+	for _, number := range slices.Sorted(maps.Keys(volumes)) {
+		volume := volumes[number]
+
+		chapterNumbers := make([]float64, len(volume.Chapters))
+		for i, chapter := range volume.Chapters {
+			chapterNumbers[i] = chapter.ChapterNumber
+		}
+		volume.MissingChapters = chapterutils.CalculateMissingChapters(chapterNumbers)
+
+		result = append(result, *volume)
+	}
+
+	return result
+}
+
+func (s *declarativeSource) FetchChapterData(ctx context.Context, serieID sources.SourceSerieID, volumeID sources.SourceSerieVolumeID, chapterID sources.SourceSerieVolumeChapterID) (sources.SourceSerieVolumeChapterData, error) {
+	chapterURL, err := renderURL(s.SourceAPIInformation.APIURL, s.templates["chapter"], URLData{
+		SerieID:   string(serieID),
+		VolumeID:  string(volumeID),
+		ChapterID: string(chapterID),
+	})
+	if err != nil {
+		return sources.SourceSerieVolumeChapterData{}, err
+	}
+
+	doc, err := s.fetchDocument(ctx, chapterURL)
+	if err != nil {
+		return sources.SourceSerieVolumeChapterData{}, err
+	}
+
+	images := []sources.SourceSerieVolumeChapterImage{}
+	for i, raw := range s.definition.Chapter.Images.All(doc.Selection) {
+		images = append(images, sources.SourceSerieVolumeChapterImage{
+			Index: i + 1,
+			URL:   resolveURL(chapterURL, raw),
+		})
+	}
+
+	return sources.SourceSerieVolumeChapterData{Type: sources.IMAGE, Images: images}, nil
+}
+
+func (s *declarativeSource) SerieUrl(serieID sources.SourceSerieID) (*url.URL, error) {
+	return renderURL(s.SourceAPIInformation.APIURL, s.templates["serie"], URLData{SerieID: string(serieID)})
+}
+
+func (s *declarativeSource) fetchDocument(ctx context.Context, u *url.URL) (*goquery.Document, error) {
+	s.logger.Info("Fetching page", "url", u.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Join(sources.ErrBuildingRequest, err, fmt.Errorf("failed to build request: %s", u))
+	}
+	req.Header = s.SourceAPIInformation.Headers.Clone()
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Join(sources.ErrHTTPRequestFailed, err, fmt.Errorf("failed to fetch %s", u))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Join(sources.ErrHTTPRequestFailed, fmt.Errorf("unexpected status %s for %s", resp.Status, u))
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, errors.Join(sources.ErrParsingHTML, err, fmt.Errorf("failed to parse %s", u))
+	}
+
+	return doc, nil
+}
+
+// multiLanguage stores the value in the first language of the source
+func (s *declarativeSource) multiLanguage(value string) sources.MultiLanguageString {
+	var m sources.MultiLanguageString
+
+	switch s.SourceInformation.Languages[0] {
+	case sources.JP:
+		m.JP = value
+	case sources.FR:
+		m.FR = value
+	case sources.KO:
+		m.KO = value
+	case sources.ZH:
+		m.ZH = value
+	case sources.ZH_HK:
+		m.ZH_HK = value
+	default:
+		m.EN = value
+	}
+
+	return m
+}
+
+// resolveURL resolves a relative URL against the page it was found in
+func resolveURL(page *url.URL, raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	return page.ResolveReference(u).String()
+}
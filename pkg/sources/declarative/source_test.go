@@ -0,0 +1,346 @@
+package declarative_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"dokusho/pkg/sources/declarative"
+	"dokusho/pkg/sources/source_types"
+)
+
+const fixtures = "../scrapers/weebcentral/fixtures"
+
+// newWeebCentral serves the WeebCentral fixtures and returns the declarative WeebCentral source pointing to them
+func newWeebCentral(t *testing.T) (source_types.SourceAPI, func() url.Values) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var searchQuery url.Values
+
+	mux := http.NewServeMux()
+	serve := func(pattern, fixture string) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("User-Agent") == "" {
+				t.Error("expected the definition headers to be sent")
+			}
+
+			if fixture == "search.html" {
+				mu.Lock()
+				searchQuery = r.URL.Query()
+				mu.Unlock()
+			}
+
+			http.ServeFile(w, r, filepath.Join(fixtures, fixture))
+		})
+	}
+	serve("GET /search/data", "search.html")
+	serve("GET /series/{id}", "serie.html")
+	serve("GET /series/{id}/full-chapter-list", "chapters_list.html")
+	serve("GET /chapters/{id}/images", "chapter_images.html")
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	definition, err := declarative.LoadFile("testdata/weebcentral.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	definition.URL = server.URL
+
+	source, err := declarative.NewSource(definition)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return source, func() url.Values {
+		mu.Lock()
+		defer mu.Unlock()
+		return searchQuery
+	}
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	source, searchQuery := newWeebCentral(t)
+
+	result, err := source.FetchSearchSerie(context.Background(), 2, source_types.FetchSearchSerieFilter{
+		Query:   "sono munou",
+		Sort:    source_types.POPULARITY,
+		Order:   source_types.DESC,
+		Types:   []source_types.SourceSerieType{source_types.TYPE_MANGA},
+		Genres:  source_types.FetchSearchSerieFilterGenres{Include: []source_types.SourceSerieGenre{source_types.SCI_FI}},
+		Authors: []string{"AONO Hakuto"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedQuery := url.Values{
+		"limit":         {"24"},
+		"offset":        {"24"},
+		"official":      {"Any"},
+		"display_mode":  {"Full Display"},
+		"text":          {"sono munou"},
+		"sort":          {"Popularity"},
+		"order":         {"Descending"},
+		"included_type": {"Manga"},
+		"included_tag":  {"Sci-fi"},
+		"author":        {"AONO Hakuto"},
+	}
+	if query := searchQuery(); query.Encode() != expectedQuery.Encode() {
+		t.Errorf("expected query %s, got %s", expectedQuery.Encode(), query.Encode())
+	}
+
+	if !result.HasNextPage {
+		t.Error("expected a next page")
+	}
+
+	if len(result.Series) == 0 {
+		t.Fatal("expected series")
+	}
+
+	expected := source_types.SourceSmallSerie{
+		ID:    "01J76XY7E2VCSR0ZCC21KGXS1K",
+		Title: source_types.MultiLanguageString{EN: "Kobato."},
+		Cover: "https://temp.compsci88.com/cover/normal/01J76XY7E2VCSR0ZCC21KGXS1K.webp",
+	}
+	if result.Series[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, result.Series[0])
+	}
+}
+
+func TestSearchInvalidFilter(t *testing.T) {
+	t.Parallel()
+
+	source, _ := newWeebCentral(t)
+
+	tests := []struct {
+		name     string
+		filter   source_types.FetchSearchSerieFilter
+		expected error
+	}{
+		{
+			name:     "unmapped type",
+			filter:   source_types.FetchSearchSerieFilter{Types: []source_types.SourceSerieType{source_types.TYPE_NOVEL}},
+			expected: source_types.ErrInvalidSearchTypes,
+		},
+		{
+			name:     "unmapped genre",
+			filter:   source_types.FetchSearchSerieFilter{Genres: source_types.FetchSearchSerieFilterGenres{Exclude: []source_types.SourceSerieGenre{source_types.GORE}}},
+			expected: source_types.ErrInvalidSearchGenres,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := source.FetchSearchSerie(context.Background(), 1, tc.filter)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestPopularFallsBackToSearch(t *testing.T) {
+	t.Parallel()
+
+	source, searchQuery := newWeebCentral(t)
+
+	_, err := source.FetchPopularSerie(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := searchQuery()
+	if query.Get("sort") != "Popularity" || query.Get("order") != "Descending" || query.Get("offset") != "0" {
+		t.Errorf("expected a search by popularity, got %s", query.Encode())
+	}
+}
+
+func TestFetchSerieDetail(t *testing.T) {
+	t.Parallel()
+
+	source, _ := newWeebCentral(t)
+
+	serie, err := source.FetchSerieDetail(context.Background(), "01J76XYGC5B3EH5D5XDR7M490Q")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if serie.Title.EN != "Sono Munou, Jitsu wa Sekai Saikyou no Mahoutsukai" {
+		t.Errorf("unexpected title %q", serie.Title.EN)
+	}
+
+	if serie.Cover != "https://temp.compsci88.com/cover/fallback/01J76XYGC5B3EH5D5XDR7M490Q.jpg" {
+		t.Errorf("unexpected cover %q", serie.Cover)
+	}
+
+	if serie.Synopsis.EN == "" {
+		t.Error("expected a synopsis")
+	}
+
+	if serie.Type != source_types.TYPE_MANGA {
+		t.Errorf("expected type manga, got %s", serie.Type)
+	}
+
+	if !slices.Equal(serie.Status, []source_types.SourceSerieStatus{source_types.STATUS_COMPLETED}) {
+		t.Errorf("expected status completed, got %v", serie.Status)
+	}
+
+	if !slices.Equal(serie.Authors, []string{"AONO Hakuto", "MITSUKAWA San"}) {
+		t.Errorf("unexpected authors %v", serie.Authors)
+	}
+
+	expectedGenres := []source_types.SourceSerieGenre{
+		source_types.ACTION, source_types.FANTASY, source_types.ROMANCE, source_types.SEINEN, source_types.SLICE_OF_LIFE,
+	}
+	if !slices.Equal(serie.Genres, expectedGenres) {
+		t.Errorf("expected genres %v, got %v", expectedGenres, serie.Genres)
+	}
+
+	if len(serie.Volumes) != 1 || serie.Volumes[0].ID != "volume-1" {
+		t.Fatalf("expected a single volume, got %d", len(serie.Volumes))
+	}
+
+	chapters := serie.Volumes[0].Chapters
+	if len(chapters) != 201 {
+		t.Fatalf("expected 201 chapters, got %d", len(chapters))
+	}
+
+	first := chapters[0]
+	if first.ID != "01J76XZ666GREP4DQDKEP1YDZG" || first.Name != "Chapter 200" || first.ChapterNumber != 200 || first.Language != source_types.EN {
+		t.Errorf("unexpected first chapter %+v", first)
+	}
+
+	if first.DateUpload.IsZero() {
+		t.Error("expected the upload date to be parsed")
+	}
+}
+
+func TestFetchChapterData(t *testing.T) {
+	t.Parallel()
+
+	source, _ := newWeebCentral(t)
+
+	data, err := source.FetchChapterData(context.Background(), "serie", "volume-1", "01JG1M2AKPQBCWH1J9G52K48JB")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Type != source_types.IMAGE || len(data.Images) != 18 {
+		t.Fatalf("expected 18 images, got %d", len(data.Images))
+	}
+
+	expected := source_types.SourceSerieVolumeChapterImage{
+		Index: 1,
+		URL:   "https://scans.lastation.us/manga/Sono-Munou-Jitsuha-Sekai-Saikyou-No-Mahoutsukai/0064-001.png",
+	}
+	if data.Images[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, data.Images[0])
+	}
+}
+
+func TestLoadDirectory(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	weebcentral, err := os.ReadFile("testdata/weebcentral.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"weebcentral.yaml": string(weebcentral),
+		"README.md":        "ignored",
+		"minimal.json": `{
+			"id": "minimal",
+			"name": "Minimal",
+			"url": "https://example.com",
+			"languages": ["fr"],
+			"serie": {"url": "/serie/{{.SerieID}}", "chapters": {"items": "li > a", "id": {"attr": "href"}}},
+			"chapter": {"url": "/chapter/{{.ChapterID}}", "images": {"selector": "img", "attr": "data-src"}}
+		}`,
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	apis, err := declarative.LoadDirectory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(apis) != 2 || apis[0].GetInformation().ID != "minimal" || apis[1].GetInformation().ID != "weebcentral_declarative" {
+		t.Fatalf("expected minimal and weebcentral_declarative, got %d sources", len(apis))
+	}
+
+	minimal := apis[0]
+	if minimal.GetInformation().SearchFilters.Query {
+		t.Error("expected search not to be supported")
+	}
+
+	_, err = minimal.FetchLatestUpdates(context.Background(), 1)
+	if !errors.Is(err, declarative.ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+
+	serieURL, err := minimal.SerieUrl("one piece")
+	if err != nil || serieURL.String() != "https://example.com/serie/one%20piece" {
+		t.Errorf("unexpected serie url %v, %v", serieURL, err)
+	}
+}
+
+func TestInvalidDefinition(t *testing.T) {
+	t.Parallel()
+
+	valid := func() declarative.Definition {
+		definition, err := declarative.LoadFile("testdata/weebcentral.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return definition
+	}
+
+	tests := []struct {
+		name   string
+		modify func(d *declarative.Definition)
+	}{
+		{name: "missing id", modify: func(d *declarative.Definition) { d.ID = "" }},
+		{name: "relative url", modify: func(d *declarative.Definition) { d.URL = "/weebcentral" }},
+		{name: "unknown language", modify: func(d *declarative.Definition) { d.Languages = []string{"de"} }},
+		{name: "invalid selector", modify: func(d *declarative.Definition) { d.Serie.Title.Selector = "li:has(" }},
+		{name: "invalid regex", modify: func(d *declarative.Definition) { d.Serie.Chapters.Number.Regex = "(" }},
+		{name: "invalid template", modify: func(d *declarative.Definition) { d.Chapter.URL = "{{.ChapterID" }},
+		{name: "unknown genre", modify: func(d *declarative.Definition) { d.Filters.Genres["Space Opera"] = "Space" }},
+		{name: "invalid timeout", modify: func(d *declarative.Definition) { d.Timeout = "soon" }},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			definition := valid()
+			tc.modify(&definition)
+
+			_, err := declarative.NewSource(definition)
+			if !errors.Is(err, declarative.ErrInvalidDefinition) {
+				t.Errorf("expected ErrInvalidDefinition, got %v", err)
+			}
+		})
+	}
+}
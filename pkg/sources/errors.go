@@ -0,0 +1,12 @@
+package sources
+
+import "errors"
+
+var (
+	ErrSourceNotFound    = errors.New("source not found")
+	ErrSourceDisabled    = errors.New("source disabled")
+	ErrDuplicateSource   = errors.New("duplicate source id")
+	ErrInvalidSettings   = errors.New("invalid source settings")
+	ErrReadingSettings   = errors.New("error reading source settings")
+	ErrConfiguringSource = errors.New("error configuring source")
+)
@@ -0,0 +1,9 @@
+package extension
+
+import "errors"
+
+var (
+	ErrReadingManifest = errors.New("error reading extension manifest")
+	ErrInvalidManifest = errors.New("invalid extension manifest")
+	ErrHostNotAllowed  = errors.New("host not allowed for the extension")
+)
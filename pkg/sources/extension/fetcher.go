@@ -0,0 +1,113 @@
+package extension
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"dokusho/pkg/http_utils"
+	"dokusho/pkg/sources/source_types"
+)
+
+// Maximum size of a fetched body
+const MaxBodySize = 10 << 20
+
+type FetchRequest struct {
+	// GET when empty
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+type FetchResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// Fetcher sends the requests of an extension, only to the hosts allowed by its manifest and spaced by its rate limit
+type Fetcher struct {
+	// Set its timeout with http_utils.SetClientTimeout, it is rate limited when the manifest has a rate limit
+	Client *http.Client
+
+	hosts  []string
+	logger *slog.Logger
+}
+
+func NewFetcher(manifest Manifest) *Fetcher {
+	u, _ := url.Parse(manifest.URL)
+
+	client := &http.Client{}
+	if manifest.RequestsPerSecond > 0 {
+		interval := time.Duration(float64(time.Second) / manifest.RequestsPerSecond)
+		client.Transport = http_utils.NewRateLimitedTransport(
+			http.DefaultTransport,
+			http_utils.RateLimit{Requests: 1, Per: interval, Burst: 1},
+			nil,
+			func(req *http.Request) string { return "" },
+		)
+	}
+	http_utils.SetClientTimeout(client, manifest.CallTimeout())
+
+	return &Fetcher{
+		Client: client,
+		hosts:  append([]string{u.Hostname()}, manifest.Hosts...),
+		logger: slog.Default().WithGroup(string(manifest.ID)),
+	}
+}
+
+// Fetch sends the request with the headers of the source, the headers of the request replace them
+func (f *Fetcher) Fetch(ctx context.Context, headers http.Header, req FetchRequest) (FetchResponse, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return FetchResponse{}, errors.Join(source_types.ErrBuildingURL, err, fmt.Errorf("invalid url: %q", req.URL))
+	}
+
+	if !http_utils.MatchHost(u.Hostname(), f.hosts) {
+		return FetchResponse{}, errors.Join(ErrHostNotAllowed, fmt.Errorf("host not allowed: %s", u.Hostname()))
+	}
+
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, u.String(), strings.NewReader(req.Body))
+	if err != nil {
+		return FetchResponse{}, errors.Join(source_types.ErrBuildingRequest, err, fmt.Errorf("failed to build request: %s", u))
+	}
+	httpReq.Header = headers.Clone()
+	if httpReq.Header == nil {
+		httpReq.Header = http.Header{}
+	}
+	for name, value := range req.Headers {
+		httpReq.Header.Set(name, value)
+	}
+
+	f.logger.Info("Extension fetching", "method", method, "url", u.String())
+
+	resp, err := f.Client.Do(httpReq)
+	if err != nil {
+		return FetchResponse{}, errors.Join(source_types.ErrHTTPRequestFailed, err, fmt.Errorf("failed to fetch %s", u))
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxBodySize))
+	if err != nil {
+		return FetchResponse{}, errors.Join(source_types.ErrHTTPRequestFailed, err, fmt.Errorf("failed to read %s", u))
+	}
+
+	respHeaders := make(map[string]string, len(resp.Header))
+	for name := range resp.Header {
+		respHeaders[name] = resp.Header.Get(name)
+	}
+
+	return FetchResponse{Status: resp.StatusCode, Headers: respHeaders, Body: string(body)}, nil
+}
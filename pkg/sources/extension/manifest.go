@@ -0,0 +1,226 @@
+package extension
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"dokusho/pkg/sources/source_types"
+)
+
+// Manifest describes an extension source, it maps to the information of the source and to the limits of its runtime
+type Manifest struct {
+	ID         source_types.SourceID `json:"id"`
+	Name       string                `json:"name"`
+	URL        string                `json:"url"`
+	Icon       string                `json:"icon"`
+	Version    string                `json:"version"`
+	Languages  []string              `json:"languages"`
+	NSFW       bool                  `json:"nsfw"`
+	Headers    map[string]string     `json:"headers"`
+	ImageHosts []string              `json:"imageHosts"`
+	// Hosts the extension can fetch besides the host of URL, "*.example.com" matches every subdomain
+	Hosts []string `json:"hosts"`
+	// Maximum duration of a call, 10s by default
+	Timeout string `json:"timeout"`
+	// Spacing between the requests of the extension, unlimited when 0
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	// Maximum memory of a call in MiB, 64 by default
+	MemoryLimit uint32          `json:"memoryLimit"`
+	Filters     ManifestFilters `json:"filters"`
+
+	// Modification time of the extension file
+	UpdatedAt time.Time `json:"-"`
+}
+
+// ManifestFilters maps to SupportedFilters, the extension receives the dokusho values
+type ManifestFilters struct {
+	Query   bool     `json:"query"`
+	Artists bool     `json:"artists"`
+	Authors bool     `json:"authors"`
+	Orders  []string `json:"orders"`
+	Sorts   []string `json:"sorts"`
+	Types   []string `json:"types"`
+	Status  []string `json:"status"`
+	Genres  struct {
+		Included bool     `json:"included"`
+		Excluded bool     `json:"excluded"`
+		Values   []string `json:"values"`
+	} `json:"genres"`
+}
+
+// LoadManifest reads and validates a JSON manifest
+func LoadManifest(path string) (Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, errors.Join(ErrReadingManifest, err, fmt.Errorf("failed to read manifest %s", path))
+	}
+
+	var manifest Manifest
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return Manifest{}, errors.Join(ErrInvalidManifest, err, fmt.Errorf("failed to decode manifest %s", path))
+	}
+
+	return manifest, manifest.Validate()
+}
+
+// Validate checks the manifest maps to valid source information
+func (m *Manifest) Validate() error {
+	if m.ID == "" || m.Name == "" {
+		return errors.Join(ErrInvalidManifest, fmt.Errorf("id and name are required"))
+	}
+
+	u, err := url.Parse(m.URL)
+	if err != nil || !u.IsAbs() {
+		return errors.Join(ErrInvalidManifest, err, fmt.Errorf("url must be absolute: %q", m.URL))
+	}
+
+	if len(m.Languages) == 0 {
+		return errors.Join(ErrInvalidManifest, fmt.Errorf("at least one language is required"))
+	}
+
+	for _, language := range m.Languages {
+		if source_types.NewSourceLanguage(language).String() != language {
+			return errors.Join(ErrInvalidManifest, source_types.ErrInvalidLanguage, fmt.Errorf("unknown language: %s", language))
+		}
+	}
+
+	if m.Timeout != "" {
+		timeout, err := time.ParseDuration(m.Timeout)
+		if err != nil || timeout <= 0 {
+			return errors.Join(ErrInvalidManifest, err, fmt.Errorf("invalid timeout: %q", m.Timeout))
+		}
+	}
+
+	if m.RequestsPerSecond < 0 {
+		return errors.Join(ErrInvalidManifest, fmt.Errorf("requests per second can't be negative"))
+	}
+
+	for _, order := range m.Filters.Orders {
+		if source_types.NewFetchSearchSerieFilterOrder(order).String() != order {
+			return errors.Join(ErrInvalidManifest, source_types.ErrInvalidSearchOrder, fmt.Errorf("unknown order: %s", order))
+		}
+	}
+
+	for _, sort := range m.Filters.Sorts {
+		if source_types.NewFetchSearchSerieFilterSort(sort).String() != sort {
+			return errors.Join(ErrInvalidManifest, source_types.ErrInvalidSearchSort, fmt.Errorf("unknown sort: %s", sort))
+		}
+	}
+
+	for _, t := range m.Filters.Types {
+		if source_types.NewSourceSerieType(t).String() != t {
+			return errors.Join(ErrInvalidManifest, source_types.ErrInvalidSearchTypes, fmt.Errorf("unknown type: %s", t))
+		}
+	}
+
+	for _, status := range m.Filters.Status {
+		if source_types.NewSourceSerieStatus(status).String() != status {
+			return errors.Join(ErrInvalidManifest, source_types.ErrInvalidSearchStatus, fmt.Errorf("unknown status: %s", status))
+		}
+	}
+
+	for _, genre := range m.Filters.Genres.Values {
+		if source_types.NewSourceSerieGenre(genre).String() != genre {
+			return errors.Join(ErrInvalidManifest, source_types.ErrInvalidSearchGenres, fmt.Errorf("unknown genre: %s", genre))
+		}
+	}
+
+	return nil
+}
+
+// Source builds the information of the source described by the manifest
+func (m *Manifest) Source() source_types.Source {
+	languages := make([]source_types.SourceLanguage, len(m.Languages))
+	for i, language := range m.Languages {
+		languages[i] = source_types.NewSourceLanguage(language)
+	}
+
+	filters := source_types.SupportedFilters{
+		Query:   m.Filters.Query,
+		Artists: m.Filters.Artists,
+		Authors: m.Filters.Authors,
+		Orders:  make([]source_types.FetchSearchSerieFilterOrder, len(m.Filters.Orders)),
+		Sorts:   make([]source_types.FetchSearchSerieFilterSort, len(m.Filters.Sorts)),
+		Types:   make([]source_types.SourceSerieType, len(m.Filters.Types)),
+		Status:  make([]source_types.SourceSerieStatus, len(m.Filters.Status)),
+		Genres: source_types.SupportedFiltersGenres{
+			Included:       m.Filters.Genres.Included,
+			Excluded:       m.Filters.Genres.Excluded,
+			PossibleValues: make([]source_types.SourceSerieGenre, len(m.Filters.Genres.Values)),
+		},
+	}
+	for i, order := range m.Filters.Orders {
+		filters.Orders[i] = source_types.NewFetchSearchSerieFilterOrder(order)
+	}
+	for i, sort := range m.Filters.Sorts {
+		filters.Sorts[i] = source_types.NewFetchSearchSerieFilterSort(sort)
+	}
+	for i, t := range m.Filters.Types {
+		filters.Types[i] = source_types.NewSourceSerieType(t)
+	}
+	for i, status := range m.Filters.Status {
+		filters.Status[i] = source_types.NewSourceSerieStatus(status)
+	}
+	for i, genre := range m.Filters.Genres.Values {
+		filters.Genres.PossibleValues[i] = source_types.NewSourceSerieGenre(genre)
+	}
+
+	version := m.Version
+	if version == "" {
+		version = "1.0.0"
+	}
+
+	apiURL, _ := url.Parse(m.URL)
+
+	headers := http.Header{}
+	for name, value := range m.Headers {
+		headers.Set(name, value)
+	}
+
+	return source_types.Source{
+		SourceInformation: source_types.SourceInformation{
+			ID:            m.ID,
+			Name:          m.Name,
+			URL:           m.URL,
+			Icon:          m.Icon,
+			Languages:     languages,
+			UpdatedAt:     m.UpdatedAt,
+			Version:       version,
+			NSFW:          m.NSFW,
+			SearchFilters: filters,
+		},
+		SourceAPIInformation: source_types.SourceAPIInformation{
+			APIURL:                apiURL,
+			Headers:               headers,
+			MinimumUpdateInterval: 5 * time.Minute,
+			Timeout:               m.CallTimeout(),
+			CanBlockScraping:      true,
+			ImageHosts:            m.ImageHosts,
+		},
+	}
+}
+
+// CallTimeout is the maximum duration of a call, 10s by default
+func (m *Manifest) CallTimeout() time.Duration {
+	timeout, err := time.ParseDuration(m.Timeout)
+	if err != nil || timeout <= 0 {
+		return 10 * time.Second
+	}
+
+	return timeout
+}
+
+// MemoryLimitBytes is the maximum memory of a call, 64 MiB by default
+func (m *Manifest) MemoryLimitBytes() uint64 {
+	if m.MemoryLimit == 0 {
+		return 64 << 20
+	}
+
+	return uint64(m.MemoryLimit) << 20
+}
@@ -0,0 +1,10 @@
+package javascript
+
+import "errors"
+
+var (
+	ErrReadingScript = errors.New("error reading javascript extension")
+	ErrInvalidScript = errors.New("invalid javascript extension")
+	ErrCallingScript = errors.New("error calling javascript extension")
+	ErrMemoryLimit   = errors.New("javascript extension memory limit exceeded")
+)
@@ -0,0 +1,288 @@
+package javascript_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"dokusho/pkg/sources/extension"
+	"dokusho/pkg/sources/javascript"
+	"dokusho/pkg/sources/source_types"
+)
+
+var (
+	script string
+	server *httptest.Server
+	source source_types.SourceAPI
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /popular", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Source") != "example" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		fmt.Fprintf(w, `<html><body>
+			<div class="serie" data-id="one-piece"><img src="/covers/one-piece.jpg"> One Piece</div>
+			<div class="serie" data-id="frieren"><img src="/covers/frieren.jpg"> Frieren</div>
+			%s
+		</body></html>`, map[bool]string{true: `<a class="next" href="/popular?page=2">Next</a>`}[r.URL.Query().Get("page") == "1"])
+	})
+	mux.HandleFunc("GET /search", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data": [{"id": "frieren", "attributes": {"title": %q}}]}`, "Frieren: "+r.URL.Query().Get("q"))
+	})
+	mux.HandleFunc("GET /series/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id": %q, "title": {"en": "Frieren"}, "type": "manga", "volumes": [{"id": "volume-1", "chapters": [{"id": "1", "chapterNumber": 1}]}]}`, r.PathValue("id"))
+	})
+	mux.HandleFunc("GET /chapters/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><img src="https://cdn.example.com/1.png"><img src="https://cdn.example.com/2.png"></body></html>`)
+	})
+	mux.HandleFunc("GET /large", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data": %q}`, strings.Repeat("a", 9<<20))
+	})
+
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	data, err := os.ReadFile("testdata/example.js")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	script = strings.ReplaceAll(string(data), "{{baseURL}}", server.URL)
+
+	source, err = javascript.NewSource("example.js", script)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return m.Run()
+}
+
+func TestInformation(t *testing.T) {
+	t.Parallel()
+
+	info := source.GetInformation()
+	if info.ID != "example" || !info.SearchFilters.Query || len(info.SearchFilters.Sorts) != 2 || len(info.SearchFilters.Genres.PossibleValues) != 2 {
+		t.Errorf("unexpected information %+v", info)
+	}
+}
+
+func TestFetchPopularSerie(t *testing.T) {
+	t.Parallel()
+
+	result, err := source.FetchPopularSerie(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.HasNextPage || len(result.Series) != 2 {
+		t.Fatalf("expected 2 series and a next page, got %+v", result)
+	}
+
+	expected := source_types.SourceSmallSerie{
+		ID:    "one-piece",
+		Title: source_types.MultiLanguageString{EN: "One Piece"},
+		Cover: server.URL + "/covers/one-piece.jpg",
+	}
+	if result.Series[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, result.Series[0])
+	}
+}
+
+func TestFetchSearchSerie(t *testing.T) {
+	t.Parallel()
+
+	result, err := source.FetchSearchSerie(context.Background(), 1, source_types.FetchSearchSerieFilter{Query: "beyond journey's end"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Series) != 1 || result.Series[0].ID != "frieren" || result.Series[0].Title.EN != "Frieren: beyond journey's end" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestFetchSerieDetail(t *testing.T) {
+	t.Parallel()
+
+	serie, err := source.FetchSerieDetail(context.Background(), "frieren")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if serie.ID != "frieren" || serie.Type != source_types.TYPE_MANGA || len(serie.Volumes) != 1 || len(serie.Volumes[0].Chapters) != 1 {
+		t.Errorf("unexpected serie %+v", serie)
+	}
+}
+
+func TestFetchChapterData(t *testing.T) {
+	t.Parallel()
+
+	data, err := source.FetchChapterData(context.Background(), "frieren", "volume-1", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Type != source_types.IMAGE || len(data.Images) != 2 || data.Images[1].URL != "https://cdn.example.com/2.png" {
+		t.Errorf("unexpected chapter data %+v", data)
+	}
+}
+
+func TestSerieUrl(t *testing.T) {
+	t.Parallel()
+
+	u, err := source.SerieUrl("frieren")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.String() != server.URL+"/series/frieren" {
+		t.Errorf("unexpected serie url %s", u)
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		page     int
+		expected error
+	}{
+		{name: "script error", page: 1, expected: javascript.ErrCallingScript},
+		{name: "timeout", page: 2, expected: source_types.ErrTimeout},
+		{name: "host not allowed", page: 3, expected: extension.ErrHostNotAllowed},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := source.FetchLatestUpdates(context.Background(), tc.page)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+// Not parallel, the heap is the one of the process and the calls running at the same time would count in it
+func TestMemoryLimit(t *testing.T) {
+	tests := []struct {
+		name string
+		page int
+	}{
+		{name: "fetched body", page: 4},
+		{name: "allocations", page: 5},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := source.FetchLatestUpdates(context.Background(), tc.page)
+			if !errors.Is(err, javascript.ErrMemoryLimit) {
+				t.Errorf("expected %v, got %v", javascript.ErrMemoryLimit, err)
+			}
+		})
+	}
+}
+
+func TestInvalidScript(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		script   func() string
+		expected error
+	}{
+		{
+			name:     "syntax error",
+			script:   func() string { return "module.exports = {" },
+			expected: javascript.ErrInvalidScript,
+		},
+		{
+			name:     "throws when loaded",
+			script:   func() string { return `throw new Error("broken")` },
+			expected: javascript.ErrInvalidScript,
+		},
+		{
+			name:     "fetches when loaded",
+			script:   func() string { return `fetch("https://example.com")` },
+			expected: javascript.ErrInvalidScript,
+		},
+		{
+			name:     "missing functions",
+			script:   func() string { return strings.Replace(script, "serieUrl(serieID) {", "serieLink(serieID) {", 1) },
+			expected: javascript.ErrInvalidScript,
+		},
+		{
+			name:     "unknown genre",
+			script:   func() string { return strings.Replace(script, `"Fantasy"`, `"Space Opera"`, 1) },
+			expected: extension.ErrInvalidManifest,
+		},
+		{
+			name:     "unknown language",
+			script:   func() string { return strings.Replace(script, `languages: ["en"]`, `languages: ["de"]`, 1) },
+			expected: source_types.ErrInvalidLanguage,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := javascript.NewSource("invalid.js", tc.script())
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestLoadDirectory(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"example.js": script,
+		"README.md":  "ignored",
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	apis, err := javascript.LoadDirectory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(apis) != 1 || apis[0].GetInformation().ID != "example" || apis[0].GetInformation().UpdatedAt.IsZero() {
+		t.Fatalf("expected the example source, got %d sources", len(apis))
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "broken.js"), []byte("module.exports = {"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = javascript.LoadDirectory(dir)
+	if !errors.Is(err, javascript.ErrInvalidScript) {
+		t.Errorf("expected ErrInvalidScript with a broken script, got %v", err)
+	}
+}
@@ -0,0 +1,320 @@
+package javascript
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/metrics"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"dokusho/pkg/sources/extension"
+	"dokusho/pkg/sources/source_types"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/dop251/goja"
+)
+
+// The SDK given to the scripts:
+//   - fetch(url, {method, headers, body}) is synchronous and returns {status, ok, headers, text(), json()}
+//   - load(html) returns a cheerio like $, selections have find, children, parent, first, last, eq, attr,
+//     text, html, each, map and toArray. map returns an array
+//   - console.debug, log, info, warn and error
+
+// Interval of the checks of the heap by budget.watch
+const heapCheckInterval = 10 * time.Millisecond
+
+// budget bounds the memory of a call. goja can't limit the allocations of a runtime, so the data given to the
+// script (fetched bodies and loaded documents) is counted, and the live heap of the process is watched while the call runs
+type budget struct {
+	limit     int64
+	remaining atomic.Int64
+	done      chan struct{}
+	stopOnce  sync.Once
+}
+
+func newBudget(limit uint64) *budget {
+	b := &budget{limit: int64(limit), done: make(chan struct{})}
+	b.remaining.Store(int64(limit))
+
+	return b
+}
+
+func (b *budget) charge(vm *goja.Runtime, size int) {
+	if b.remaining.Add(-int64(size)) < 0 {
+		// Interrupting can't be caught by the script
+		vm.Interrupt(ErrMemoryLimit)
+		panic(vm.NewGoError(ErrMemoryLimit))
+	}
+}
+
+// watch interrupts the runtime once the live heap grew by more than the limit since the call started, until stop is called.
+// The heap is the one of the process, the calls running at the same time share it so the limit is only approximate
+func (b *budget) watch(vm *goja.Runtime) {
+	baseline := liveHeap()
+
+	go func() {
+		ticker := time.NewTicker(heapCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-b.done:
+				return
+			case <-ticker.C:
+				if int64(liveHeap())-int64(baseline) > b.limit {
+					vm.Interrupt(ErrMemoryLimit)
+					return
+				}
+			}
+		}
+	}()
+}
+
+func (b *budget) stop() {
+	b.stopOnce.Do(func() { close(b.done) })
+}
+
+// liveHeap returns the heap still in use after the last garbage collection, it is cheap enough to be read often
+func liveHeap() uint64 {
+	sample := []metrics.Sample{{Name: "/gc/heap/live:bytes"}}
+	metrics.Read(sample)
+
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+
+	return sample[0].Value.Uint64()
+}
+
+type sdk struct {
+	ctx    context.Context
+	vm     *goja.Runtime
+	source *jsSource
+	budget *budget
+}
+
+func (s *sdk) install() error {
+	console := s.vm.NewObject()
+	for _, level := range []string{"debug", "log", "info", "warn", "error"} {
+		err := console.Set(level, s.log(level))
+		if err != nil {
+			return err
+		}
+	}
+
+	return errors.Join(
+		s.vm.Set("console", console),
+		s.vm.Set("fetch", s.fetch),
+		s.vm.Set("load", s.load),
+	)
+}
+
+func (s *sdk) log(level string) func(call goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		parts := make([]string, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			parts[i] = arg.String()
+		}
+		message := strings.Join(parts, " ")
+
+		switch level {
+		case "debug":
+			s.source.logger.Debug(message)
+		case "warn":
+			s.source.logger.Warn(message)
+		case "error":
+			s.source.logger.Error(message)
+		default:
+			s.source.logger.Info(message)
+		}
+
+		return goja.Undefined()
+	}
+}
+
+func (s *sdk) fetch(url string, options *struct {
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}) goja.Value {
+	if s.source.fetcher == nil {
+		panic(s.vm.NewGoError(errors.New("fetch isn't available while loading the script")))
+	}
+
+	req := extension.FetchRequest{URL: url}
+	if options != nil {
+		req.Method = options.Method
+		req.Headers = options.Headers
+		req.Body = options.Body
+	}
+
+	res, err := s.source.fetcher.Fetch(s.ctx, s.source.SourceAPIInformation.Headers, req)
+	if err != nil {
+		panic(s.vm.NewGoError(err))
+	}
+	s.budget.charge(s.vm, len(res.Body))
+
+	response := s.vm.NewObject()
+	err = errors.Join(
+		response.Set("status", res.Status),
+		response.Set("ok", res.Status >= 200 && res.Status < 300),
+		response.Set("headers", res.Headers),
+		response.Set("text", func() string { return res.Body }),
+		response.Set("json", func() goja.Value {
+			var v any
+			err := json.Unmarshal([]byte(res.Body), &v)
+			if err != nil {
+				panic(s.vm.NewGoError(errors.Join(source_types.ErrParsingJSON, err)))
+			}
+
+			return s.vm.ToValue(v)
+		}),
+	)
+	if err != nil {
+		panic(s.vm.NewGoError(err))
+	}
+
+	return response
+}
+
+// load parses the document and returns $, $(selector) searches the document and $(selection) returns it
+func (s *sdk) load(html string) goja.Value {
+	s.budget.charge(s.vm, len(html))
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		panic(s.vm.NewGoError(errors.Join(source_types.ErrParsingHTML, err)))
+	}
+
+	return s.vm.ToValue(func(call goja.FunctionCall) goja.Value {
+		arg := call.Argument(0)
+		if sel, ok := arg.Export().(*selection); ok {
+			return s.vm.ToValue(sel)
+		}
+
+		return s.vm.ToValue(s.find(doc.Selection, arg.String()))
+	})
+}
+
+func (s *sdk) find(from *goquery.Selection, selector string) *selection {
+	matcher, err := cascadia.Compile(selector)
+	if err != nil {
+		panic(s.vm.NewGoError(errors.Join(source_types.ErrParsingHTML, err, fmt.Errorf("invalid selector %q", selector))))
+	}
+
+	return s.wrap(from.FindMatcher(matcher))
+}
+
+func (s *sdk) wrap(sel *goquery.Selection) *selection {
+	return &selection{Length: sel.Length(), sdk: s, sel: sel}
+}
+
+// selection is the cheerio like selection given to the scripts
+type selection struct {
+	Length int
+
+	sdk *sdk
+	sel *goquery.Selection
+}
+
+func (s *selection) Find(selector string) *selection {
+	return s.sdk.find(s.sel, selector)
+}
+
+func (s *selection) Children(selector string) *selection {
+	if selector == "" {
+		return s.sdk.wrap(s.sel.Children())
+	}
+
+	return s.sdk.wrap(s.sel.ChildrenFiltered(selector))
+}
+
+func (s *selection) Parent() *selection {
+	return s.sdk.wrap(s.sel.Parent())
+}
+
+func (s *selection) First() *selection {
+	return s.sdk.wrap(s.sel.First())
+}
+
+func (s *selection) Last() *selection {
+	return s.sdk.wrap(s.sel.Last())
+}
+
+func (s *selection) Eq(i int) *selection {
+	return s.sdk.wrap(s.sel.Eq(i))
+}
+
+// Attr returns undefined when the attribute is missing
+func (s *selection) Attr(name string) goja.Value {
+	value, ok := s.sel.Attr(name)
+	if !ok {
+		return goja.Undefined()
+	}
+
+	return s.sdk.vm.ToValue(value)
+}
+
+func (s *selection) Text() string {
+	return s.sel.Text()
+}
+
+func (s *selection) Html() string {
+	html, _ := s.sel.Html()
+	return html
+}
+
+// Each calls fn with the index and the selection of every element, returning false stops the iteration
+func (s *selection) Each(fn goja.Value) *selection {
+	callback, ok := goja.AssertFunction(fn)
+	if !ok {
+		panic(s.sdk.vm.NewTypeError("each expects a function"))
+	}
+
+	s.sel.EachWithBreak(func(i int, elem *goquery.Selection) bool {
+		res, err := callback(goja.Undefined(), s.sdk.vm.ToValue(i), s.sdk.vm.ToValue(s.sdk.wrap(elem)))
+		if err != nil {
+			panic(err)
+		}
+
+		return res.Export() != false
+	})
+
+	return s
+}
+
+// Map returns the results of fn, undefined and null results are skipped
+func (s *selection) Map(fn goja.Value) []any {
+	callback, ok := goja.AssertFunction(fn)
+	if !ok {
+		panic(s.sdk.vm.NewTypeError("map expects a function"))
+	}
+
+	results := []any{}
+	for i := range s.sel.Nodes {
+		res, err := callback(goja.Undefined(), s.sdk.vm.ToValue(i), s.sdk.vm.ToValue(s.sdk.wrap(s.sel.Eq(i))))
+		if err != nil {
+			panic(err)
+		}
+
+		if !goja.IsUndefined(res) && !goja.IsNull(res) {
+			results = append(results, res)
+		}
+	}
+
+	return results
+}
+
+func (s *selection) ToArray() []*selection {
+	elements := make([]*selection, len(s.sel.Nodes))
+	for i := range s.sel.Nodes {
+		elements[i] = s.sdk.wrap(s.sel.Eq(i))
+	}
+
+	return elements
+}
@@ -0,0 +1,299 @@
+package javascript
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"dokusho/pkg/sources/extension"
+	"dokusho/pkg/sources/source_types"
+
+	"github.com/dop251/goja"
+)
+
+// Functions exported by the scripts, one per SourceAPI method. They can be async, the promises are
+// settled before the call returns because fetch is synchronous
+var functions = []string{
+	"fetchPopularSerie",
+	"fetchLatestUpdates",
+	"fetchSearchSerie",
+	"fetchSerieDetail",
+	"fetchChapterData",
+	"serieUrl",
+}
+
+// Maximum depth of the javascript call stack
+const maxCallStackSize = 1024
+
+type jsSource struct {
+	source_types.Source
+
+	program  *goja.Program
+	manifest extension.Manifest
+	fetcher  *extension.Fetcher
+	logger   *slog.Logger
+}
+
+// NewSource compiles the script and checks its manifest and functions, every call runs in a new runtime.
+// The script is a CommonJS module exporting the manifest and the functions:
+//
+//	module.exports = { manifest: {...}, fetchPopularSerie(page) {...}, ... }
+func NewSource(name string, script string) (*jsSource, error) {
+	program, err := goja.Compile(name, script, true)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidScript, err, fmt.Errorf("failed to compile %s", name))
+	}
+
+	s := &jsSource{program: program, logger: slog.Default().WithGroup(name)}
+
+	// The manifest is read without fetcher, the scripts can't fetch when loaded
+	var defaults extension.Manifest
+	b := newBudget(defaults.MemoryLimitBytes())
+	defer b.stop()
+
+	_, exports, err := s.load(context.Background(), b)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidScript, err, fmt.Errorf("failed to run %s", name))
+	}
+
+	raw, err := json.Marshal(exports.Get("manifest").Export())
+	if err != nil {
+		return nil, errors.Join(ErrInvalidScript, err, fmt.Errorf("invalid manifest in %s", name))
+	}
+
+	err = json.Unmarshal(raw, &s.manifest)
+	if err != nil {
+		return nil, errors.Join(extension.ErrInvalidManifest, err, fmt.Errorf("invalid manifest in %s", name))
+	}
+
+	err = s.manifest.Validate()
+	if err != nil {
+		return nil, errors.Join(err, fmt.Errorf("invalid manifest in %s", name))
+	}
+
+	for _, function := range functions {
+		if _, ok := goja.AssertFunction(exports.Get(function)); !ok {
+			return nil, errors.Join(ErrInvalidScript, fmt.Errorf("%s isn't exported by %s", function, name))
+		}
+	}
+
+	s.Source = s.manifest.Source()
+	s.fetcher = extension.NewFetcher(s.manifest)
+	s.HTTPClient = s.fetcher.Client
+	s.logger = slog.Default().WithGroup(string(s.manifest.ID))
+
+	return s, nil
+}
+
+// load runs the script in a new runtime watched by the budget and returns its exports
+func (s *jsSource) load(ctx context.Context, b *budget) (*goja.Runtime, *goja.Object, error) {
+	vm := goja.New()
+	vm.SetFieldNameMapper(goja.UncapFieldNameMapper())
+	vm.SetMaxCallStackSize(maxCallStackSize)
+	b.watch(vm)
+
+	sdk := &sdk{ctx: ctx, vm: vm, source: s, budget: b}
+	err := sdk.install()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	module := vm.NewObject()
+	exports := vm.NewObject()
+	err = errors.Join(
+		module.Set("exports", exports),
+		vm.Set("module", module),
+		vm.Set("exports", exports),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = vm.RunProgram(s.program)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exported := module.Get("exports")
+	if exported == nil || goja.IsUndefined(exported) || goja.IsNull(exported) {
+		return nil, nil, fmt.Errorf("module.exports is empty")
+	}
+
+	return vm, exported.ToObject(vm), nil
+}
+
+// call runs the function in a new runtime, the runtime is interrupted when the call times out or
+// exceeds its memory budget
+func (s *jsSource) call(ctx context.Context, function string, result any, args ...any) error {
+	ctx, cancel := context.WithTimeout(ctx, s.SourceAPIInformation.Timeout)
+	defer cancel()
+
+	b := newBudget(s.manifest.MemoryLimitBytes())
+	defer b.stop()
+
+	vm, exports, err := s.load(ctx, b)
+	if err != nil {
+		return s.callError(ctx, function, err)
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		vm.Interrupt(source_types.ErrTimeout)
+	})
+	defer stop()
+
+	fn, _ := goja.AssertFunction(exports.Get(function))
+
+	values := make([]goja.Value, len(args))
+	for i, arg := range args {
+		values[i] = vm.ToValue(arg)
+	}
+
+	value, err := fn(exports, values...)
+	if err != nil {
+		return s.callError(ctx, function, err)
+	}
+
+	if promise, ok := value.Export().(*goja.Promise); ok {
+		switch promise.State() {
+		case goja.PromiseStateFulfilled:
+			value = promise.Result()
+		case goja.PromiseStateRejected:
+			return errors.Join(ErrCallingScript, fmt.Errorf("%s rejected: %s", function, promise.Result()))
+		default:
+			return errors.Join(ErrCallingScript, fmt.Errorf("%s returned a promise that never settles", function))
+		}
+	}
+
+	raw, err := json.Marshal(value.Export())
+	if err == nil {
+		err = json.Unmarshal(raw, result)
+	}
+	if err != nil {
+		return errors.Join(ErrCallingScript, source_types.ErrParsingJSON, err, fmt.Errorf("invalid result for %s", function))
+	}
+
+	return nil
+}
+
+func (s *jsSource) callError(ctx context.Context, function string, err error) error {
+	if errors.Is(err, ErrMemoryLimit) {
+		return errors.Join(ErrMemoryLimit, ErrCallingScript, err, fmt.Errorf("%s exceeded the memory limit", function))
+	}
+
+	if ctx.Err() != nil {
+		return errors.Join(source_types.ErrTimeout, ErrCallingScript, err, fmt.Errorf("%s timed out", function))
+	}
+
+	return errors.Join(ErrCallingScript, err, fmt.Errorf("%s failed", function))
+}
+
+func (s *jsSource) GetInformation() source_types.SourceInformation {
+	return s.Source.SourceInformation
+}
+
+func (s *jsSource) GetAPIInformation() source_types.SourceAPIInformation {
+	return s.Source.SourceAPIInformation
+}
+
+func (s *jsSource) FetchPopularSerie(ctx context.Context, page int) (source_types.SourcePaginatedSmallSerie, error) {
+	var result source_types.SourcePaginatedSmallSerie
+	err := s.call(ctx, "fetchPopularSerie", &result, page)
+
+	return result, err
+}
+
+func (s *jsSource) FetchLatestUpdates(ctx context.Context, page int) (source_types.SourcePaginatedSmallSerie, error) {
+	var result source_types.SourcePaginatedSmallSerie
+	err := s.call(ctx, "fetchLatestUpdates", &result, page)
+
+	return result, err
+}
+
+func (s *jsSource) FetchSearchSerie(ctx context.Context, page int, filter source_types.FetchSearchSerieFilter) (source_types.SourcePaginatedSmallSerie, error) {
+	// The script receives the JSON form of the filter
+	raw, err := json.Marshal(filter)
+	if err != nil {
+		return source_types.SourcePaginatedSmallSerie{}, errors.Join(source_types.ErrParsingJSON, err)
+	}
+
+	var jsFilter map[string]any
+	err = json.Unmarshal(raw, &jsFilter)
+	if err != nil {
+		return source_types.SourcePaginatedSmallSerie{}, errors.Join(source_types.ErrParsingJSON, err)
+	}
+
+	var result source_types.SourcePaginatedSmallSerie
+	err = s.call(ctx, "fetchSearchSerie", &result, page, jsFilter)
+
+	return result, err
+}
+
+func (s *jsSource) FetchSerieDetail(ctx context.Context, serieID source_types.SourceSerieID) (source_types.SourceSerie, error) {
+	var result source_types.SourceSerie
+	err := s.call(ctx, "fetchSerieDetail", &result, string(serieID))
+
+	return result, err
+}
+
+func (s *jsSource) FetchChapterData(ctx context.Context, serieID source_types.SourceSerieID, volumeID source_types.SourceSerieVolumeID, chapterID source_types.SourceSerieVolumeChapterID) (source_types.SourceSerieVolumeChapterData, error) {
+	var result source_types.SourceSerieVolumeChapterData
+	err := s.call(ctx, "fetchChapterData", &result, string(serieID), string(volumeID), string(chapterID))
+
+	return result, err
+}
+
+func (s *jsSource) SerieUrl(serieID source_types.SourceSerieID) (*url.URL, error) {
+	var raw string
+	err := s.call(context.Background(), "serieUrl", &raw, string(serieID))
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, errors.Join(source_types.ErrBuildingURL, err, fmt.Errorf("invalid serie url: %s", raw))
+	}
+
+	return u, nil
+}
+
+// LoadDirectory builds a source for every script of the directory
+func LoadDirectory(dir string) ([]source_types.SourceAPI, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Join(ErrReadingScript, err, fmt.Errorf("failed to read scripts directory %s", dir))
+	}
+
+	apis := []source_types.SourceAPI{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".js" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		script, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Join(ErrReadingScript, err, fmt.Errorf("failed to read script %s", path))
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, errors.Join(ErrReadingScript, err, fmt.Errorf("failed to stat script %s", path))
+		}
+
+		api, err := NewSource(entry.Name(), string(script))
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("failed to build source from %s", path))
+		}
+		api.SourceInformation.UpdatedAt = info.ModTime().UTC()
+
+		apis = append(apis, api)
+	}
+
+	return apis, nil
+}
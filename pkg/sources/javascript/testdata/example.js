@@ -0,0 +1,86 @@
+// Extension used by the tests, the base url is replaced by the url of the test server
+const baseURL = "{{baseURL}}";
+
+module.exports = {
+  manifest: {
+    id: "example",
+    name: "Example",
+    url: baseURL,
+    languages: ["en"],
+    headers: { "X-Source": "example" },
+    timeout: "2s",
+    memoryLimit: 8,
+    filters: {
+      query: true,
+      sorts: ["Latest", "Popularity"],
+      genres: { included: true, values: ["Action", "Fantasy"] },
+    },
+  },
+
+  fetchPopularSerie(page) {
+    const $ = load(fetch(`${baseURL}/popular?page=${page}`).text());
+
+    return {
+      hasNextPage: $("a.next").length > 0,
+      series: $("div.serie").map((i, el) => ({
+        id: el.attr("data-id"),
+        title: { en: el.text().trim() },
+        cover: baseURL + el.find("img").attr("src"),
+      })),
+    };
+  },
+
+  // Page 1 throws, page 2 never returns, page 3 fetches a host that isn't allowed, page 4 fetches more than the memory limit
+  // and page 5 allocates until it exceeds it
+  fetchLatestUpdates(page) {
+    switch (page) {
+      case 2:
+        for (;;) {}
+      case 3:
+        return fetch("https://forbidden.example.com/latest").json();
+      case 4:
+        return fetch(`${baseURL}/large`).json();
+      case 5: {
+        const a = [];
+        for (;;) a.push("x".repeat(1e6));
+      }
+      default:
+        throw new Error("latest updates aren't available");
+    }
+  },
+
+  fetchSearchSerie(page, filter) {
+    const res = fetch(`${baseURL}/search?q=${encodeURIComponent(filter.query)}`, {
+      headers: { Accept: "application/json" },
+    });
+    if (!res.ok) {
+      throw new Error(`unexpected status ${res.status}`);
+    }
+
+    const body = res.json();
+    console.debug("found", body.data.length);
+
+    return {
+      hasNextPage: false,
+      series: body.data.map((serie) => ({ id: serie.id, title: { en: serie.attributes.title } })),
+    };
+  },
+
+  async fetchSerieDetail(serieID) {
+    // The site already returns the dokusho format
+    return fetch(`${baseURL}/series/${encodeURIComponent(serieID)}`).json();
+  },
+
+  fetchChapterData(serieID, volumeID, chapterID) {
+    const $ = load(fetch(`${baseURL}/chapters/${encodeURIComponent(chapterID)}`).text());
+
+    return {
+      type: "image",
+      images: $("img").toArray().map((img, i) => ({ index: i + 1, url: img.attr("src") })),
+    };
+  },
+
+  serieUrl(serieID) {
+    return `${baseURL}/series/${encodeURIComponent(serieID)}`;
+  },
+};
@@ -5,6 +5,7 @@ import (
 	"dokusho/pkg/sources/source_types"
 	"errors"
 	"fmt"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
 	"time"
@@ -267,3 +268,42 @@ func (w *mockSource) SerieUrl(serieID source_types.SourceSerieID) (*url.URL, err
 
 	return url.JoinPath("series", string(serieID)), nil
 }
+
+func (w *mockSource) ChapterUrl(serieID source_types.SourceSerieID, volumeID source_types.SourceSerieVolumeID, chapterID source_types.SourceSerieVolumeChapterID) (*url.URL, error) {
+	url, err := w.SerieUrl(serieID)
+	if err != nil {
+		return nil, err
+	}
+
+	return url.JoinPath(string(volumeID), string(chapterID)), nil
+}
+
+func (w *mockSource) FetchHomeSections(context context.Context) ([]source_types.SourceHomeSection, error) {
+	popular, err := w.FetchPopularSerie(context, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := w.FetchLatestUpdates(context, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return []source_types.SourceHomeSection{
+		{ID: "popular", Title: "Popular", Series: popular.Series},
+		{ID: "latest", Title: "Latest updates", Series: latest.Series},
+	}, nil
+}
+
+func (w *mockSource) FetchAuthorSeries(context context.Context, author string, page int) (source_types.SourcePaginatedSmallSerie, error) {
+	return w.FetchSearchSerie(context, page, source_types.FetchSearchSerieFilter{Authors: []string{author}})
+}
+
+func (w *mockSource) FetchRandomSerie(context context.Context) (source_types.SourceSmallSerie, error) {
+	popular, err := w.FetchPopularSerie(context, 1)
+	if err != nil {
+		return source_types.SourceSmallSerie{}, err
+	}
+
+	return popular.Series[rand.IntN(len(popular.Series))], nil
+}
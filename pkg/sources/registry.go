@@ -0,0 +1,157 @@
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"dokusho/pkg/sources/source_types"
+)
+
+// SourceStatus is the information of a source and whether it is enabled
+type SourceStatus struct {
+	source_types.SourceInformation
+	Enabled bool `json:"enabled"`
+}
+
+type registeredSource struct {
+	api          source_types.SourceAPI
+	enabled      bool
+	capabilities []source_types.SourceCapability
+}
+
+func (s *registeredSource) status() SourceStatus {
+	info := s.api.GetInformation()
+	info.Capabilities = s.capabilities
+
+	return SourceStatus{SourceInformation: info, Enabled: s.enabled}
+}
+
+// Registry indexes the sources by ID, sources can be enabled or disabled at runtime.
+// Enabling or disabling a source isn't persisted, the settings apply again on restart.
+type Registry struct {
+	mu      sync.RWMutex
+	ids     []source_types.SourceID
+	sources map[source_types.SourceID]*registeredSource
+	logger  *slog.Logger
+}
+
+// NewRegistry configures the sources with their settings, sources are enabled unless their settings disable them
+func NewRegistry(settings map[source_types.SourceID]source_types.SourceSettings, apis ...source_types.SourceAPI) (*Registry, error) {
+	r := &Registry{
+		sources: make(map[source_types.SourceID]*registeredSource, len(apis)),
+		logger:  slog.Default().WithGroup("sources_registry"),
+	}
+
+	for _, api := range apis {
+		id := api.GetInformation().ID
+		if _, ok := r.sources[id]; ok {
+			return nil, errors.Join(ErrDuplicateSource, fmt.Errorf("source %s is registered twice", id))
+		}
+
+		s, ok := settings[id]
+		if ok {
+			configurable, isConfigurable := api.(source_types.ConfigurableSourceAPI)
+			if !isConfigurable {
+				return nil, errors.Join(ErrConfiguringSource, fmt.Errorf("source %s doesn't accept settings", id))
+			}
+
+			err := configurable.Configure(s)
+			if err != nil {
+				return nil, errors.Join(ErrConfiguringSource, err, fmt.Errorf("failed to configure source %s", id))
+			}
+		}
+
+		r.ids = append(r.ids, id)
+		r.sources[id] = &registeredSource{
+			api:          api,
+			enabled:      s.Enabled == nil || *s.Enabled,
+			capabilities: source_types.DetectCapabilities(api),
+		}
+	}
+
+	for id := range settings {
+		if _, ok := r.sources[id]; !ok {
+			r.logger.Warn("Settings for an unknown source are ignored", "source_id", id)
+		}
+	}
+
+	return r, nil
+}
+
+// Get returns the source, ErrSourceNotFound when it doesn't exist and ErrSourceDisabled when it is disabled
+func (r *Registry) Get(id source_types.SourceID) (source_types.SourceAPI, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.sources[id]
+	if !ok {
+		return nil, ErrSourceNotFound
+	}
+
+	if !s.enabled {
+		return nil, ErrSourceDisabled
+	}
+
+	return s.api, nil
+}
+
+// Status returns the status of the source, enabled or not
+func (r *Registry) Status(id source_types.SourceID) (SourceStatus, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.sources[id]
+	if !ok {
+		return SourceStatus{}, ErrSourceNotFound
+	}
+
+	return s.status(), nil
+}
+
+// Statuses returns the sources in registration order, disabled ones only when all is set
+func (r *Registry) Statuses(all bool) []SourceStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statuses := make([]SourceStatus, 0, len(r.ids))
+	for _, id := range r.ids {
+		s := r.sources[id]
+		if s.enabled || all {
+			statuses = append(statuses, s.status())
+		}
+	}
+
+	return statuses
+}
+
+// All returns every source in registration order, enabled or not
+func (r *Registry) All() []source_types.SourceAPI {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	apis := make([]source_types.SourceAPI, len(r.ids))
+	for i, id := range r.ids {
+		apis[i] = r.sources[id].api
+	}
+
+	return apis
+}
+
+func (r *Registry) SetEnabled(id source_types.SourceID, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sources[id]
+	if !ok {
+		return ErrSourceNotFound
+	}
+
+	if s.enabled != enabled {
+		r.logger.Info("Source toggled", "source_id", id, "enabled", enabled)
+	}
+	s.enabled = enabled
+
+	return nil
+}
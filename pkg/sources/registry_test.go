@@ -0,0 +1,239 @@
+package sources_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"dokusho/pkg/sources"
+	"dokusho/pkg/sources/mock"
+	"dokusho/pkg/sources/source_types"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	disabled := false
+	registry, err := sources.NewRegistry(
+		map[source_types.SourceID]source_types.SourceSettings{"mock_source": {Enabled: &disabled}},
+		mock.NewMockSource(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = registry.Get("unknown")
+	if !errors.Is(err, sources.ErrSourceNotFound) {
+		t.Errorf("expected ErrSourceNotFound, got %v", err)
+	}
+
+	_, err = registry.Get("mock_source")
+	if !errors.Is(err, sources.ErrSourceDisabled) {
+		t.Errorf("expected ErrSourceDisabled, got %v", err)
+	}
+
+	if len(registry.Statuses(false)) != 0 {
+		t.Errorf("expected no enabled source, got %d", len(registry.Statuses(false)))
+	}
+
+	if statuses := registry.Statuses(true); len(statuses) != 1 || statuses[0].Enabled {
+		t.Errorf("expected the disabled source, got %+v", statuses)
+	}
+
+	err = registry.SetEnabled("mock_source", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := registry.Get("mock_source")
+	if err != nil || source.GetInformation().ID != "mock_source" {
+		t.Errorf("expected mock_source, got %v, %v", source, err)
+	}
+
+	err = registry.SetEnabled("unknown", true)
+	if !errors.Is(err, sources.ErrSourceNotFound) {
+		t.Errorf("expected ErrSourceNotFound, got %v", err)
+	}
+}
+
+func TestRegistrySettings(t *testing.T) {
+	t.Parallel()
+
+	nsfw := false
+	registry, err := sources.NewRegistry(
+		map[source_types.SourceID]source_types.SourceSettings{"mock_source": {
+			Timeout:   7 * time.Second,
+			Headers:   map[string][]string{"User-Agent": {"dokusho"}, "X-Custom": {"value"}},
+			Languages: []source_types.SourceLanguage{source_types.FR},
+			NSFW:      &nsfw,
+		}},
+		mock.NewMockSource(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := registry.Get("mock_source")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := source.GetInformation()
+	if len(info.Languages) != 1 || info.Languages[0] != source_types.FR {
+		t.Errorf("expected languages [fr], got %v", info.Languages)
+	}
+	if info.NSFW {
+		t.Error("expected NSFW to be overridden")
+	}
+
+	apiInfo := source.GetAPIInformation()
+	if apiInfo.Timeout != 7*time.Second {
+		t.Errorf("expected timeout 7s, got %s", apiInfo.Timeout)
+	}
+	if apiInfo.Headers.Get("User-Agent") != "dokusho" || apiInfo.Headers.Get("X-Custom") != "value" {
+		t.Errorf("expected merged headers, got %v", apiInfo.Headers)
+	}
+}
+
+func TestRegistryErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		settings map[source_types.SourceID]source_types.SourceSettings
+		apis     []source_types.SourceAPI
+		expected error
+	}{
+		{
+			name:     "duplicate source",
+			apis:     []source_types.SourceAPI{mock.NewMockSource(), mock.NewMockSource()},
+			expected: sources.ErrDuplicateSource,
+		},
+		{
+			name: "unsupported language",
+			settings: map[source_types.SourceID]source_types.SourceSettings{"mock_source": {
+				Languages: []source_types.SourceLanguage{"de"},
+			}},
+			apis:     []source_types.SourceAPI{mock.NewMockSource()},
+			expected: source_types.ErrInvalidLanguage,
+		},
+		{
+			name: "unsupported image quality",
+			settings: map[source_types.SourceID]source_types.SourceSettings{"mock_source": {
+				ImageQuality: source_types.QUALITY_DATA_SAVER,
+			}},
+			apis:     []source_types.SourceAPI{mock.NewMockSource()},
+			expected: source_types.ErrInvalidImageQuality,
+		},
+		{
+			name: "unsupported content rating",
+			settings: map[source_types.SourceID]source_types.SourceSettings{"mock_source": {
+				ContentRatings: []source_types.SourceContentRating{source_types.CONTENT_RATING_SAFE},
+			}},
+			apis:     []source_types.SourceAPI{mock.NewMockSource()},
+			expected: source_types.ErrInvalidContentRating,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := sources.NewRegistry(tc.settings, tc.apis...)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "sources.json")
+	err := os.WriteFile(path, []byte(`{
+		"weebcentral": {"enabled": false},
+		"mangadex": {"timeout": "15s", "languages": ["en", "fr"], "headers": {"user-agent": "dokusho"}, "imageQuality": "data_saver", "contentRatings": ["safe"]},
+		"komga": {"credentials": {"username": "reader", "password": "secret"}}
+	}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := sources.LoadSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if enabled := settings["weebcentral"].Enabled; enabled == nil || *enabled {
+		t.Errorf("expected weebcentral to be disabled, got %v", enabled)
+	}
+
+	mangadex := settings["mangadex"]
+	if mangadex.Timeout != 15*time.Second || len(mangadex.Languages) != 2 || mangadex.Headers.Get("User-Agent") != "dokusho" || mangadex.ImageQuality != source_types.QUALITY_DATA_SAVER ||
+		!slices.Equal(mangadex.ContentRatings, []source_types.SourceContentRating{source_types.CONTENT_RATING_SAFE}) {
+		t.Errorf("unexpected mangadex settings %+v", mangadex)
+	}
+
+	if credentials := settings["komga"].Credentials; credentials.Username != "reader" || credentials.Password != "secret" || credentials.APIKey != "" {
+		t.Errorf("unexpected komga credentials %+v", credentials)
+	}
+
+	err = os.WriteFile(path, []byte(`{"mangadex": {"timeout": "soon"}}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = sources.LoadSettings(path)
+	if !errors.Is(err, sources.ErrInvalidSettings) {
+		t.Errorf("expected ErrInvalidSettings, got %v", err)
+	}
+
+	err = os.WriteFile(path, []byte(`{"mangadex": {"imageQuality": "low"}}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = sources.LoadSettings(path)
+	if !errors.Is(err, source_types.ErrInvalidImageQuality) {
+		t.Errorf("expected ErrInvalidImageQuality, got %v", err)
+	}
+
+	err = os.WriteFile(path, []byte(`{"mangadex": {"contentRatings": ["safe", "adult"]}}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = sources.LoadSettings(path)
+	if !errors.Is(err, source_types.ErrInvalidContentRating) {
+		t.Errorf("expected ErrInvalidContentRating, got %v", err)
+	}
+}
+
+func TestRegistryCapabilities(t *testing.T) {
+	t.Parallel()
+
+	registry, err := sources.NewRegistry(nil, mock.NewMockSource())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := registry.Status("mock_source")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []source_types.SourceCapability{
+		source_types.CAPABILITY_SETTINGS,
+		source_types.CAPABILITY_CHAPTER_URL,
+		source_types.CAPABILITY_HOME_SECTIONS,
+		source_types.CAPABILITY_AUTHOR_SERIES,
+		source_types.CAPABILITY_RANDOM_SERIE,
+	}
+	if !slices.Equal(status.Capabilities, expected) {
+		t.Errorf("expected capabilities %v, got %v", expected, status.Capabilities)
+	}
+}
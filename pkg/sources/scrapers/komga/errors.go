@@ -0,0 +1,10 @@
+package komga
+
+import "errors"
+
+var (
+	ErrInvalidConfig = errors.New("invalid komga config")
+	ErrInvalidStatus = errors.New("invalid status")
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrUnauthorized  = errors.New("komga refused the credentials")
+)
@@ -0,0 +1,20 @@
+package komga_test
+
+import (
+	_ "embed"
+)
+
+//go:embed fixtures/series.json
+var seriesJSON string
+
+//go:embed fixtures/serie.json
+var serieJSON string
+
+//go:embed fixtures/books.json
+var booksJSON string
+
+//go:embed fixtures/book.json
+var bookJSON string
+
+//go:embed fixtures/pages.json
+var pagesJSON string
@@ -0,0 +1,551 @@
+package komga
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dokusho/pkg/sources/chapterutils"
+	"dokusho/pkg/sources/source_types"
+)
+
+const pageSize = 20
+
+// Config of a Komga server, the credentials are given by the settings of the source
+type Config struct {
+	// Base URL of the server, "http://komga.local:25600"
+	URL string
+	// IDs of the libraries the source is restricted to, every library when empty
+	Libraries []string
+}
+
+type komga struct {
+	source_types.Source
+
+	httpClient *http.Client
+	logger     *slog.Logger
+	libraries  []string
+	genres     map[string]source_types.SourceSerieGenre
+	// Guards the headers of the source, they are replaced on login
+	mu sync.RWMutex
+}
+
+func NewKomga(cfg Config) (*komga, error) {
+	baseURL, err := url.Parse(strings.TrimSuffix(cfg.URL, "/"))
+	if err != nil || !baseURL.IsAbs() {
+		return nil, errors.Join(ErrInvalidConfig, err, fmt.Errorf("url must be absolute: %q", cfg.URL))
+	}
+
+	genres := make(map[string]source_types.SourceSerieGenre, len(source_types.ALL_GENRES))
+	for _, genre := range source_types.ALL_GENRES {
+		genres[strings.ToLower(string(genre))] = genre
+	}
+
+	timeout := 10 * time.Second
+
+	client := &http.Client{Timeout: timeout}
+
+	return &komga{
+		httpClient: client,
+		logger:     slog.Default().WithGroup("komga"),
+		libraries:  cfg.Libraries,
+		genres:     genres,
+		Source: source_types.Source{
+			HTTPClient: client,
+			SourceInformation: source_types.SourceInformation{
+				ID:        "komga",
+				Name:      "Komga",
+				URL:       baseURL.String(),
+				Icon:      baseURL.JoinPath("favicon.ico").String(),
+				Version:   "1.0.0",
+				Languages: []source_types.SourceLanguage{source_types.EN},
+				UpdatedAt: time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC),
+				NSFW:      false,
+				SearchFilters: source_types.SupportedFilters{
+					Query:   true,
+					Artists: true,
+					Authors: true,
+					Orders:  GetSearchableOrders(),
+					Sorts:   GetSearchableSorts(),
+					Types:   []source_types.SourceSerieType{},
+					Status:  GetSearchableStatus(),
+					Genres: source_types.SupportedFiltersGenres{
+						Included:       true,
+						Excluded:       false,
+						PossibleValues: source_types.ALL_GENRES,
+					},
+				},
+			},
+			SourceAPIInformation: source_types.SourceAPIInformation{
+				APIURL:                baseURL.JoinPath("api", "v1"),
+				MinimumUpdateInterval: 5 * time.Minute,
+				Timeout:               timeout,
+				Headers: http.Header{
+					"Accept": []string{"application/json"},
+				},
+				CanBlockScraping: false,
+				ImageHosts:       []string{baseURL.Hostname()},
+				SelfHosted:       true,
+			},
+		},
+	}, nil
+}
+
+// Configure also sends the credentials with every request,
+// the image proxy sends them too since it uses the headers of the source
+func (k *komga) Configure(settings source_types.SourceSettings) error {
+	err := k.Source.Configure(settings)
+	if err != nil {
+		return err
+	}
+
+	credentials := settings.Credentials
+	if credentials.APIKey == "" && credentials.Username == "" {
+		return nil
+	}
+
+	headers := k.credentialHeaders(credentials)
+
+	k.mu.Lock()
+	k.SourceAPIInformation.Headers = headers
+	k.mu.Unlock()
+
+	return nil
+}
+
+// Login checks the credentials against the server before using them instead of the credentials of the settings
+func (k *komga) Login(ctx context.Context, credentials source_types.SourceCredentials) error {
+	if credentials.APIKey == "" && credentials.Username == "" {
+		return errors.Join(source_types.ErrInvalidCredentials, fmt.Errorf("an api key or a username is required"))
+	}
+
+	headers := k.credentialHeaders(credentials)
+
+	meURL, err := url.Parse(k.SourceInformation.URL)
+	if err != nil {
+		return errors.Join(source_types.ErrBuildingURL, err, fmt.Errorf("failed to build URL: %s", k.SourceInformation.URL))
+	}
+
+	var me struct {
+		ID string `json:"id"`
+	}
+	err = k.getWithHeaders(ctx, meURL.JoinPath("api", "v2", "users", "me"), headers, &me)
+	if errors.Is(err, ErrUnauthorized) {
+		return errors.Join(source_types.ErrInvalidCredentials, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.SourceAPIInformation.Headers = headers
+	k.mu.Unlock()
+
+	return nil
+}
+
+// credentialHeaders returns the headers of the source authenticating with the credentials, the api key wins over the password
+func (k *komga) credentialHeaders(credentials source_types.SourceCredentials) http.Header {
+	headers := k.headers()
+	headers.Del("X-API-Key")
+	headers.Del("Authorization")
+
+	if credentials.APIKey != "" {
+		headers.Set("X-API-Key", credentials.APIKey)
+	} else {
+		headers.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials.Username+":"+credentials.Password)))
+	}
+
+	return headers
+}
+
+// headers returns a copy of the headers of the source, they change on login
+func (k *komga) headers() http.Header {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.SourceAPIInformation.Headers.Clone()
+}
+
+func (k *komga) GetInformation() source_types.SourceInformation {
+	return k.Source.SourceInformation
+}
+
+func (k *komga) GetAPIInformation() source_types.SourceAPIInformation {
+	info := k.Source.SourceAPIInformation
+	info.Headers = k.headers()
+
+	return info
+}
+
+// FetchPopularSerie lists every serie by title, Komga doesn't know the popularity of a serie
+func (k *komga) FetchPopularSerie(ctx context.Context, page int) (source_types.SourcePaginatedSmallSerie, error) {
+	return k.FetchSearchSerie(ctx, page, source_types.FetchSearchSerieFilter{Sort: source_types.ALPHABETIC, Order: source_types.ASC})
+}
+
+func (k *komga) FetchLatestUpdates(ctx context.Context, page int) (source_types.SourcePaginatedSmallSerie, error) {
+	u := k.SourceAPIInformation.APIURL.JoinPath("series", "latest")
+	q := k.pageQuery(page)
+	u.RawQuery = q.Encode()
+
+	var result pageResponse[seriesDto]
+	err := k.get(ctx, u, &result)
+	if err != nil {
+		return source_types.SourcePaginatedSmallSerie{}, errors.Join(err, fmt.Errorf("failed to fetch latest series"))
+	}
+
+	return k.convertSeriesPage(result), nil
+}
+
+func (k *komga) FetchSearchSerie(ctx context.Context, page int, filter source_types.FetchSearchSerieFilter) (source_types.SourcePaginatedSmallSerie, error) {
+	u := k.SourceAPIInformation.APIURL.JoinPath("series")
+	q := k.pageQuery(page)
+
+	if filter.Query != "" {
+		q.Set("search", filter.Query)
+	}
+
+	if filter.Sort != "" {
+		sort, err := ConvertSourceSerieSort(filter.Sort)
+		if err != nil {
+			return source_types.SourcePaginatedSmallSerie{}, errors.Join(source_types.ErrInvalidSearchSort, err, fmt.Errorf("invalid sort: %s", filter.Sort))
+		}
+
+		if sort != SORT_RELEVANCE {
+			order := filter.Order
+			if order == "" {
+				order = source_types.ASC
+			}
+
+			q.Set("sort", string(sort)+","+string(order))
+		}
+	}
+
+	if filter.Status != nil {
+		status, err := ConvertSourceSerieStatuses(filter.Status)
+		if err != nil {
+			return source_types.SourcePaginatedSmallSerie{}, errors.Join(source_types.ErrInvalidSearchStatus, err, fmt.Errorf("invalid status: %s", filter.Status))
+		}
+
+		for _, s := range status {
+			q.Add("status", string(s))
+		}
+	}
+
+	if len(filter.Genres.Exclude) > 0 {
+		return source_types.SourcePaginatedSmallSerie{}, errors.Join(source_types.ErrInvalidSearchGenres, fmt.Errorf("excluding genres isn't supported"))
+	}
+
+	// Komga stores the genres in lower case
+	for _, genre := range filter.Genres.Include {
+		q.Add("genre", strings.ToLower(string(genre)))
+	}
+
+	for _, author := range filter.Authors {
+		q.Add("author", author+",writer")
+	}
+
+	for _, artist := range filter.Artists {
+		q.Add("author", artist+",penciller")
+	}
+
+	u.RawQuery = q.Encode()
+
+	var result pageResponse[seriesDto]
+	err := k.get(ctx, u, &result)
+	if err != nil {
+		return source_types.SourcePaginatedSmallSerie{}, errors.Join(err, fmt.Errorf("failed to search series"))
+	}
+
+	return k.convertSeriesPage(result), nil
+}
+
+// pageQuery returns the pagination of the page, Komga pages start at 0
+func (k *komga) pageQuery(page int) url.Values {
+	q := url.Values{}
+	q.Set("page", strconv.Itoa(max(page-1, 0)))
+	q.Set("size", strconv.Itoa(pageSize))
+
+	for _, library := range k.libraries {
+		q.Add("library_id", library)
+	}
+
+	return q
+}
+
+func (k *komga) convertSeriesPage(result pageResponse[seriesDto]) source_types.SourcePaginatedSmallSerie {
+	series := make([]source_types.SourceSmallSerie, len(result.Content))
+	for i, serie := range result.Content {
+		series[i] = source_types.SourceSmallSerie{
+			ID:    source_types.SourceSerieID(serie.ID),
+			Title: source_types.MultiLanguageString{EN: serieTitle(serie)},
+			Cover: k.coverURL(serie.ID),
+		}
+	}
+
+	return source_types.SourcePaginatedSmallSerie{HasNextPage: !result.Last, Series: series}
+}
+
+func (k *komga) FetchSerieDetail(ctx context.Context, serieID source_types.SourceSerieID) (source_types.SourceSerie, error) {
+	serieURL := k.SourceAPIInformation.APIURL.JoinPath("series", string(serieID))
+
+	var serie seriesDto
+	err := k.get(ctx, serieURL, &serie)
+	if err != nil {
+		return source_types.SourceSerie{}, errors.Join(err, fmt.Errorf("failed to fetch serie %s", serieID))
+	}
+
+	if len(k.libraries) > 0 && !slices.Contains(k.libraries, serie.LibraryID) {
+		return source_types.SourceSerie{}, errors.Join(source_types.ErrInvalidSerieID, fmt.Errorf("serie %s isn't in the libraries of the source", serieID))
+	}
+
+	booksURL := serieURL.JoinPath("books")
+	q := booksURL.Query()
+	q.Set("unpaged", "true")
+	q.Set("sort", "metadata.numberSort,asc")
+	booksURL.RawQuery = q.Encode()
+
+	var books pageResponse[bookDto]
+	err = k.get(ctx, booksURL, &books)
+	if err != nil {
+		return source_types.SourceSerie{}, errors.Join(err, fmt.Errorf("failed to fetch books of serie %s", serieID))
+	}
+
+	return k.convertSerie(serie, books.Content), nil
+}
+
+// convertSerie maps the books to the chapters of a single volume, the books are often volumes but Komga can't tell
+func (k *komga) convertSerie(serie seriesDto, books []bookDto) source_types.SourceSerie {
+	status := []source_types.SourceSerieStatus{}
+	if serie.Metadata.Status != "" {
+		s, err := ConvertKomgaStatus(serie.Metadata.Status)
+		if err != nil {
+			k.logger.Warn("Failed to parse status", "raw_status", serie.Metadata.Status, "error", err)
+		}
+		status = append(status, s)
+	}
+
+	genres := []source_types.SourceSerieGenre{}
+	for _, raw := range slices.Concat(serie.Metadata.Genres, serie.Metadata.Tags) {
+		genre, ok := k.genres[strings.ToLower(raw)]
+		if ok && !slices.Contains(genres, genre) {
+			genres = append(genres, genre)
+		}
+	}
+
+	authors := []string{}
+	artists := []string{}
+	for _, author := range serie.BooksMetadata.Authors {
+		switch strings.ToLower(author.Role) {
+		case "writer":
+			if !slices.Contains(authors, author.Name) {
+				authors = append(authors, author.Name)
+			}
+		case "penciller", "inker", "colorist", "cover":
+			if !slices.Contains(artists, author.Name) {
+				artists = append(artists, author.Name)
+			}
+		}
+	}
+
+	altTitles := []source_types.MultiLanguageString{}
+	for _, altTitle := range serie.Metadata.AlternateTitles {
+		altTitles = append(altTitles, source_types.MultiLanguageString{EN: altTitle.Title})
+	}
+
+	var links source_types.SourceSerieExternalLinks
+	for _, link := range serie.Metadata.Links {
+		u, err := url.Parse(link.URL)
+		if err != nil {
+			continue
+		}
+
+		// https://anilist.co/manga/30013 and https://myanimelist.net/manga/13/One_Piece
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		if len(parts) < 2 || parts[0] != "manga" {
+			continue
+		}
+
+		switch strings.TrimPrefix(u.Hostname(), "www.") {
+		case "anilist.co":
+			links.AniList = parts[1]
+		case "myanimelist.net":
+			links.MyAnimeList = parts[1]
+		}
+	}
+
+	synopsis := serie.Metadata.Summary
+	if synopsis == "" {
+		synopsis = serie.BooksMetadata.Summary
+	}
+
+	chapters := make([]source_types.SourceSerieVolumeChapter, len(books))
+	chapterNumbers := make([]float64, len(books))
+	for i, book := range books {
+		name := book.Metadata.Title
+		if name == "" {
+			name = book.Name
+		}
+
+		rawDate := book.Metadata.ReleaseDate
+		dateUpload, err := time.Parse(time.DateOnly, rawDate)
+		if rawDate == "" || err != nil {
+			dateUpload, _ = time.Parse(time.RFC3339, book.Created)
+		}
+
+		chapters[i] = source_types.SourceSerieVolumeChapter{
+			ID:            source_types.SourceSerieVolumeChapterID(book.ID),
+			Name:          name,
+			ChapterNumber: book.Metadata.NumberSort,
+			Language:      source_types.EN,
+			DateUpload:    dateUpload,
+		}
+		chapterNumbers[i] = book.Metadata.NumberSort
+	}
+
+	volume := source_types.SourceSerieVolume{
+		ID:              "volume-1",
+		Name:            "Volume 1",
+		VolumeNumber:    1,
+		Chapters:        chapters,
+		MissingChapters: chapterutils.CalculateMissingChapters(chapterNumbers),
+	}
+
+	return source_types.SourceSerie{
+		ID:                source_types.SourceSerieID(serie.ID),
+		Title:             source_types.MultiLanguageString{EN: serieTitle(serie)},
+		AlternativeTitles: altTitles,
+		Cover:             k.coverURL(serie.ID),
+		Synopsis:          source_types.MultiLanguageString{EN: synopsis},
+		Type:              source_types.TYPE_UNKNOWN,
+		Genres:            genres,
+		Status:            status,
+		Authors:           authors,
+		Artists:           artists,
+		Volumes:           []source_types.SourceSerieVolume{volume},
+		ExternalLinks:     links,
+	}
+}
+
+func (k *komga) FetchChapterData(ctx context.Context, serieID source_types.SourceSerieID, volumeID source_types.SourceSerieVolumeID, chapterID source_types.SourceSerieVolumeChapterID) (source_types.SourceSerieVolumeChapterData, error) {
+	bookURL := k.SourceAPIInformation.APIURL.JoinPath("books", string(chapterID))
+
+	var book bookDto
+	err := k.get(ctx, bookURL, &book)
+	if err != nil {
+		return source_types.SourceSerieVolumeChapterData{}, errors.Join(err, fmt.Errorf("failed to fetch book %s", chapterID))
+	}
+
+	if book.SeriesID != string(serieID) {
+		return source_types.SourceSerieVolumeChapterData{}, errors.Join(source_types.ErrInvalidSerieID, fmt.Errorf("book %s isn't in serie %s", chapterID, serieID))
+	}
+
+	var pages []pageDto
+	err = k.get(ctx, bookURL.JoinPath("pages"), &pages)
+	if err != nil {
+		return source_types.SourceSerieVolumeChapterData{}, errors.Join(err, fmt.Errorf("failed to fetch pages of book %s", chapterID))
+	}
+
+	images := make([]source_types.SourceSerieVolumeChapterImage, len(pages))
+	for i, p := range pages {
+		images[i] = source_types.SourceSerieVolumeChapterImage{
+			ID:       source_types.SourceSerieVolumeChapterImageID(p.FileName),
+			Index:    p.Number,
+			URL:      bookURL.JoinPath("pages", strconv.Itoa(p.Number)).String(),
+			Width:    p.Width,
+			Height:   p.Height,
+			MimeType: p.MediaType,
+		}
+	}
+
+	return source_types.SourceSerieVolumeChapterData{Images: images, Type: source_types.IMAGE}, nil
+}
+
+// SerieUrl returns the page of the serie in the Komga web reader
+func (k *komga) SerieUrl(serieID source_types.SourceSerieID) (*url.URL, error) {
+	u, err := url.Parse(k.SourceInformation.URL)
+	if err != nil {
+		return nil, errors.Join(source_types.ErrBuildingURL, err, fmt.Errorf("failed to build URL: %s", k.SourceInformation.URL))
+	}
+
+	return u.JoinPath("series", string(serieID)), nil
+}
+
+// ChapterUrl returns the page of the book in the Komga web reader
+func (k *komga) ChapterUrl(serieID source_types.SourceSerieID, volumeID source_types.SourceSerieVolumeID, chapterID source_types.SourceSerieVolumeChapterID) (*url.URL, error) {
+	u, err := url.Parse(k.SourceInformation.URL)
+	if err != nil {
+		return nil, errors.Join(source_types.ErrBuildingURL, err, fmt.Errorf("failed to build URL: %s", k.SourceInformation.URL))
+	}
+
+	return u.JoinPath("book", string(chapterID)), nil
+}
+
+// FetchAuthorSeries searches the series by author, Komga matches the name exactly
+func (k *komga) FetchAuthorSeries(ctx context.Context, author string, page int) (source_types.SourcePaginatedSmallSerie, error) {
+	return k.FetchSearchSerie(ctx, page, source_types.FetchSearchSerieFilter{Authors: []string{author}})
+}
+
+func (k *komga) coverURL(serieID string) string {
+	return k.SourceAPIInformation.APIURL.JoinPath("series", serieID, "thumbnail").String()
+}
+
+// get decodes the JSON response of the API
+func (k *komga) get(ctx context.Context, u *url.URL, result any) error {
+	return k.getWithHeaders(ctx, u, k.headers(), result)
+}
+
+func (k *komga) getWithHeaders(ctx context.Context, u *url.URL, headers http.Header, result any) error {
+	k.logger.Info("Fetching komga api", "url", u.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return errors.Join(source_types.ErrBuildingRequest, err, fmt.Errorf("failed to build request: %s", u))
+	}
+	req.Header = headers
+
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return errors.Join(source_types.ErrHTTPRequestFailed, err, fmt.Errorf("failed to fetch %s", u))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return errors.Join(source_types.ErrHTTPRequestFailed, ErrUnauthorized, fmt.Errorf("unexpected status %s for %s", resp.Status, u))
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Join(source_types.ErrHTTPRequestFailed, fmt.Errorf("unexpected status %s for %s", resp.Status, u))
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Join(source_types.ErrHTTPRequestFailed, err, fmt.Errorf("failed to read %s", u))
+	}
+
+	err = json.Unmarshal(data, result)
+	if err != nil {
+		return errors.Join(source_types.ErrParsingJSON, err, fmt.Errorf("failed to decode %s", u))
+	}
+
+	return nil
+}
+
+func serieTitle(serie seriesDto) string {
+	if serie.Metadata.Title != "" {
+		return serie.Metadata.Title
+	}
+
+	return serie.Name
+}
@@ -0,0 +1,467 @@
+package komga_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"dokusho/pkg/sources/scrapers/komga"
+	"dokusho/pkg/sources/source_types"
+)
+
+const apiKey = "0123456789abcdef"
+
+// fakeKomga serves the fixtures like a Komga server and records the query of the last series search
+type fakeKomga struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	query url.Values
+}
+
+func newFakeKomga(t *testing.T) *fakeKomga {
+	t.Helper()
+
+	fake := &fakeKomga{}
+	serve := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			username, password, ok := r.BasicAuth()
+			if r.Header.Get("X-API-Key") != apiKey && (!ok || username != "admin@example.com" || password != "secret") {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/series", func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		fake.query = r.URL.Query()
+		fake.mu.Unlock()
+
+		serve(seriesJSON)(w, r)
+	})
+	mux.HandleFunc("GET /api/v1/series/latest", serve(seriesJSON))
+	mux.HandleFunc("GET /api/v1/series/0B4P7XA8JS4BX", serve(serieJSON))
+	mux.HandleFunc("GET /api/v1/series/0B4P7XA8JS4BX/books", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("unpaged") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		serve(booksJSON)(w, r)
+	})
+	mux.HandleFunc("GET /api/v1/books/0B4P7XC3V1T5A", serve(bookJSON))
+	mux.HandleFunc("GET /api/v1/books/0B4P7XC3V1T5A/pages", serve(pagesJSON))
+	mux.HandleFunc("GET /api/v2/users/me", serve(`{"id":"0B4P7W5X0N8KA","email":"admin@example.com"}`))
+
+	fake.Server = httptest.NewServer(mux)
+	t.Cleanup(fake.Close)
+
+	return fake
+}
+
+func (f *fakeKomga) lastQuery() url.Values {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.query
+}
+
+func newKomga(t *testing.T, fake *fakeKomga, libraries []string, credentials source_types.SourceCredentials) source_types.SourceAPI {
+	t.Helper()
+
+	source, err := komga.NewKomga(komga.Config{URL: fake.URL, Libraries: libraries})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = source.Configure(source_types.SourceSettings{Credentials: credentials})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return source
+}
+
+func TestNewKomga(t *testing.T) {
+	t.Parallel()
+
+	_, err := komga.NewKomga(komga.Config{URL: "komga.local"})
+	if !errors.Is(err, komga.ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig with a relative url, got %v", err)
+	}
+
+	source, err := komga.NewKomga(komga.Config{URL: "http://komga.local:25600/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiInfo := source.GetAPIInformation()
+	if apiInfo.APIURL.String() != "http://komga.local:25600/api/v1" || !apiInfo.SelfHosted || !reflect.DeepEqual(apiInfo.ImageHosts, []string{"komga.local"}) {
+		t.Errorf("unexpected api information %+v", apiInfo)
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeKomga(t)
+
+	tests := []struct {
+		name        string
+		credentials source_types.SourceCredentials
+		expected    error
+	}{
+		{name: "api key", credentials: source_types.SourceCredentials{APIKey: apiKey}},
+		{name: "basic auth", credentials: source_types.SourceCredentials{Username: "admin@example.com", Password: "secret"}},
+		{name: "wrong password", credentials: source_types.SourceCredentials{Username: "admin@example.com", Password: "wrong"}, expected: komga.ErrUnauthorized},
+		{name: "no credentials", expected: komga.ErrUnauthorized},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			source := newKomga(t, fake, nil, tc.credentials)
+
+			_, err := source.FetchLatestUpdates(context.Background(), 1)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeKomga(t)
+
+	tests := []struct {
+		name        string
+		credentials source_types.SourceCredentials
+		expected    error
+	}{
+		{name: "api key", credentials: source_types.SourceCredentials{APIKey: apiKey}},
+		{name: "basic auth", credentials: source_types.SourceCredentials{Username: "admin@example.com", Password: "secret"}},
+		{name: "wrong password", credentials: source_types.SourceCredentials{Username: "admin@example.com", Password: "wrong"}, expected: source_types.ErrInvalidCredentials},
+		{name: "no credentials", expected: source_types.ErrInvalidCredentials},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			source := newKomga(t, fake, nil, source_types.SourceCredentials{})
+
+			err := source.(source_types.LoginSourceAPI).Login(context.Background(), tc.credentials)
+			if !errors.Is(err, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, err)
+			}
+
+			// A refused login keeps the previous credentials
+			expected := tc.expected
+			if expected != nil {
+				expected = komga.ErrUnauthorized
+			}
+
+			_, err = source.FetchLatestUpdates(context.Background(), 1)
+			if !errors.Is(err, expected) {
+				t.Errorf("expected %v after login, got %v", expected, err)
+			}
+		})
+	}
+}
+
+func TestFetchLatestUpdates(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeKomga(t)
+	source := newKomga(t, fake, nil, source_types.SourceCredentials{APIKey: apiKey})
+
+	result, err := source.FetchLatestUpdates(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.HasNextPage || len(result.Series) != 2 {
+		t.Fatalf("expected 2 series and a next page, got %+v", result)
+	}
+
+	expected := []source_types.SourceSmallSerie{
+		{
+			ID:    "0B4P7XA8JS4BX",
+			Title: source_types.MultiLanguageString{EN: "Frieren: Beyond Journey's End"},
+			Cover: fake.URL + "/api/v1/series/0B4P7XA8JS4BX/thumbnail",
+		},
+		{
+			ID:    "0B4P7XB1K9QZ2",
+			Title: source_types.MultiLanguageString{EN: "Vinland Saga"},
+			Cover: fake.URL + "/api/v1/series/0B4P7XB1K9QZ2/thumbnail",
+		},
+	}
+	if !reflect.DeepEqual(result.Series, expected) {
+		t.Errorf("expected %+v, got %+v", expected, result.Series)
+	}
+}
+
+func TestFetchSearchSerie(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		page      int
+		libraries []string
+		filter    source_types.FetchSearchSerieFilter
+		expected  url.Values
+	}{
+		{
+			name:     "popular",
+			page:     1,
+			filter:   source_types.FetchSearchSerieFilter{Sort: source_types.ALPHABETIC, Order: source_types.ASC},
+			expected: url.Values{"page": {"0"}, "size": {"20"}, "sort": {"metadata.titleSort,asc"}},
+		},
+		{
+			name:      "every filter",
+			page:      3,
+			libraries: []string{"0B4P7W8C2S0Y1", "0B4P7W8C2S0Y2"},
+			filter: source_types.FetchSearchSerieFilter{
+				Query:   "frieren",
+				Sort:    source_types.LATEST,
+				Order:   source_types.DESC,
+				Status:  []source_types.SourceSerieStatus{source_types.STATUS_ONGOING, source_types.STATUS_HIATUS},
+				Genres:  source_types.FetchSearchSerieFilterGenres{Include: []source_types.SourceSerieGenre{source_types.SLICE_OF_LIFE}},
+				Authors: []string{"Kanehito Yamada"},
+				Artists: []string{"Tsukasa Abe"},
+			},
+			expected: url.Values{
+				"page":       {"2"},
+				"size":       {"20"},
+				"library_id": {"0B4P7W8C2S0Y1", "0B4P7W8C2S0Y2"},
+				"search":     {"frieren"},
+				"sort":       {"lastModified,desc"},
+				"status":     {"ONGOING", "HIATUS"},
+				"genre":      {"slice of life"},
+				"author":     {"Kanehito Yamada,writer", "Tsukasa Abe,penciller"},
+			},
+		},
+		{
+			name:     "relevance",
+			page:     1,
+			filter:   source_types.FetchSearchSerieFilter{Query: "vinland", Sort: source_types.RELEVANCE},
+			expected: url.Values{"page": {"0"}, "size": {"20"}, "search": {"vinland"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := newFakeKomga(t)
+			source := newKomga(t, fake, tc.libraries, source_types.SourceCredentials{APIKey: apiKey})
+
+			result, err := source.FetchSearchSerie(context.Background(), tc.page, tc.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(result.Series) != 2 {
+				t.Errorf("expected 2 series, got %d", len(result.Series))
+			}
+
+			if query := fake.lastQuery(); !reflect.DeepEqual(query, tc.expected) {
+				t.Errorf("expected query %v, got %v", tc.expected, query)
+			}
+		})
+	}
+}
+
+func TestFetchSearchSerieInvalidFilter(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeKomga(t)
+	source := newKomga(t, fake, nil, source_types.SourceCredentials{APIKey: apiKey})
+
+	tests := []struct {
+		name     string
+		filter   source_types.FetchSearchSerieFilter
+		expected error
+	}{
+		{
+			name:     "sort",
+			filter:   source_types.FetchSearchSerieFilter{Sort: "popularity"},
+			expected: source_types.ErrInvalidSearchSort,
+		},
+		{
+			name:     "status",
+			filter:   source_types.FetchSearchSerieFilter{Status: []source_types.SourceSerieStatus{source_types.STATUS_UNKNOWN}},
+			expected: source_types.ErrInvalidSearchStatus,
+		},
+		{
+			name:     "excluded genres",
+			filter:   source_types.FetchSearchSerieFilter{Genres: source_types.FetchSearchSerieFilterGenres{Exclude: []source_types.SourceSerieGenre{source_types.DRAMA}}},
+			expected: source_types.ErrInvalidSearchGenres,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := source.FetchSearchSerie(context.Background(), 1, tc.filter)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestFetchSerieDetail(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeKomga(t)
+	source := newKomga(t, fake, nil, source_types.SourceCredentials{Username: "admin@example.com", Password: "secret"})
+
+	serie, err := source.FetchSerieDetail(context.Background(), "0B4P7XA8JS4BX")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if serie.ID != "0B4P7XA8JS4BX" || serie.Title.EN != "Frieren: Beyond Journey's End" || serie.Cover != fake.URL+"/api/v1/series/0B4P7XA8JS4BX/thumbnail" {
+		t.Errorf("unexpected serie %+v", serie)
+	}
+
+	if !strings.HasPrefix(serie.Synopsis.EN, "The adventure is over") {
+		t.Errorf("unexpected synopsis %q", serie.Synopsis.EN)
+	}
+
+	if !reflect.DeepEqual(serie.Status, []source_types.SourceSerieStatus{source_types.STATUS_ONGOING}) {
+		t.Errorf("unexpected status %v", serie.Status)
+	}
+
+	expectedGenres := []source_types.SourceSerieGenre{source_types.ADVENTURE, source_types.DRAMA, source_types.FANTASY, source_types.SLICE_OF_LIFE}
+	if !reflect.DeepEqual(serie.Genres, expectedGenres) {
+		t.Errorf("expected genres %v, got %v", expectedGenres, serie.Genres)
+	}
+
+	if !reflect.DeepEqual(serie.Authors, []string{"Kanehito Yamada"}) || !reflect.DeepEqual(serie.Artists, []string{"Tsukasa Abe"}) {
+		t.Errorf("unexpected authors %v and artists %v", serie.Authors, serie.Artists)
+	}
+
+	if len(serie.AlternativeTitles) != 2 || serie.AlternativeTitles[1].EN != "Sousou no Frieren" {
+		t.Errorf("unexpected alternative titles %v", serie.AlternativeTitles)
+	}
+
+	if serie.ExternalLinks.AniList != "118586" || serie.ExternalLinks.MyAnimeList != "126287" {
+		t.Errorf("unexpected external links %+v", serie.ExternalLinks)
+	}
+
+	if len(serie.Volumes) != 1 || len(serie.Volumes[0].Chapters) != 3 {
+		t.Fatalf("expected 1 volume with 3 chapters, got %+v", serie.Volumes)
+	}
+
+	volume := serie.Volumes[0]
+	if !reflect.DeepEqual(volume.MissingChapters, []float64{3}) {
+		t.Errorf("expected chapter 3 to be missing, got %v", volume.MissingChapters)
+	}
+
+	expected := []source_types.SourceSerieVolumeChapter{
+		{ID: "0B4P7XC3V1T5A", Name: "Volume 1", ChapterNumber: 1, Language: source_types.EN, DateUpload: time.Date(2021, time.November, 9, 0, 0, 0, 0, time.UTC)},
+		{ID: "0B4P7XC3V1T5B", Name: "Frieren v02", ChapterNumber: 2, Language: source_types.EN, DateUpload: time.Date(2024, time.February, 11, 10, 21, 44, 0, time.UTC)},
+		{ID: "0B4P7XC3V1T5D", Name: "Volume 4", ChapterNumber: 4, Language: source_types.EN, DateUpload: time.Date(2022, time.May, 10, 0, 0, 0, 0, time.UTC)},
+	}
+	for i, chapter := range volume.Chapters {
+		if chapter.ID != expected[i].ID || chapter.Name != expected[i].Name || chapter.ChapterNumber != expected[i].ChapterNumber || chapter.Language != expected[i].Language || !chapter.DateUpload.Equal(expected[i].DateUpload) {
+			t.Errorf("expected chapter %+v, got %+v", expected[i], chapter)
+		}
+	}
+}
+
+func TestFetchSerieDetailLibraries(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeKomga(t)
+
+	tests := []struct {
+		name      string
+		libraries []string
+		expected  error
+	}{
+		{name: "every library"},
+		{name: "library of the serie", libraries: []string{"0B4P7W8C2S0Y1"}},
+		{name: "other library", libraries: []string{"0B4P7W8C2S0Y2"}, expected: source_types.ErrInvalidSerieID},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			source := newKomga(t, fake, tc.libraries, source_types.SourceCredentials{APIKey: apiKey})
+
+			_, err := source.FetchSerieDetail(context.Background(), "0B4P7XA8JS4BX")
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestFetchChapterData(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeKomga(t)
+	source := newKomga(t, fake, nil, source_types.SourceCredentials{APIKey: apiKey})
+
+	data, err := source.FetchChapterData(context.Background(), "0B4P7XA8JS4BX", "volume-1", "0B4P7XC3V1T5A")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Type != source_types.IMAGE || len(data.Images) != 3 {
+		t.Fatalf("expected 3 images, got %+v", data)
+	}
+
+	expected := source_types.SourceSerieVolumeChapterImage{
+		ID:       "Frieren v01 - p002.png",
+		Index:    3,
+		URL:      fake.URL + "/api/v1/books/0B4P7XC3V1T5A/pages/3",
+		Width:    1200,
+		Height:   1800,
+		MimeType: "image/png",
+	}
+	if data.Images[2] != expected {
+		t.Errorf("expected %+v, got %+v", expected, data.Images[2])
+	}
+
+	_, err = source.FetchChapterData(context.Background(), "0B4P7XB1K9QZ2", "volume-1", "0B4P7XC3V1T5A")
+	if !errors.Is(err, source_types.ErrInvalidSerieID) {
+		t.Errorf("expected ErrInvalidSerieID when the book isn't in the serie, got %v", err)
+	}
+}
+
+func TestSerieUrl(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeKomga(t)
+	source := newKomga(t, fake, nil, source_types.SourceCredentials{})
+
+	u, err := source.SerieUrl("0B4P7XA8JS4BX")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.String() != fake.URL+"/series/0B4P7XA8JS4BX" {
+		t.Errorf("unexpected serie url %s", u)
+	}
+}
@@ -0,0 +1,68 @@
+package komga
+
+// Schemas of the Komga REST API, only the used fields are decoded
+
+type pageResponse[T any] struct {
+	Content    []T  `json:"content"`
+	Number     int  `json:"number"`
+	TotalPages int  `json:"totalPages"`
+	Last       bool `json:"last"`
+}
+
+type seriesDto struct {
+	ID         string `json:"id"`
+	LibraryID  string `json:"libraryId"`
+	Name       string `json:"name"`
+	BooksCount int    `json:"booksCount"`
+	Metadata   struct {
+		Status          KomgaStatus `json:"status"`
+		Title           string      `json:"title"`
+		Summary         string      `json:"summary"`
+		Language        string      `json:"language"`
+		Genres          []string    `json:"genres"`
+		Tags            []string    `json:"tags"`
+		AlternateTitles []struct {
+			Label string `json:"label"`
+			Title string `json:"title"`
+		} `json:"alternateTitles"`
+		Links []struct {
+			Label string `json:"label"`
+			URL   string `json:"url"`
+		} `json:"links"`
+	} `json:"metadata"`
+	BooksMetadata struct {
+		Authors []authorDto `json:"authors"`
+		Summary string      `json:"summary"`
+	} `json:"booksMetadata"`
+}
+
+type authorDto struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type bookDto struct {
+	ID       string `json:"id"`
+	SeriesID string `json:"seriesId"`
+	Name     string `json:"name"`
+	Number   int    `json:"number"`
+	Created  string `json:"created"`
+	Metadata struct {
+		Title       string  `json:"title"`
+		Number      string  `json:"number"`
+		NumberSort  float64 `json:"numberSort"`
+		ReleaseDate string  `json:"releaseDate"`
+	} `json:"metadata"`
+	Media struct {
+		Status     string `json:"status"`
+		PagesCount int    `json:"pagesCount"`
+	} `json:"media"`
+}
+
+type pageDto struct {
+	Number    int    `json:"number"`
+	FileName  string `json:"fileName"`
+	MediaType string `json:"mediaType"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+}
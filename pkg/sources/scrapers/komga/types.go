@@ -0,0 +1,90 @@
+package komga
+
+import (
+	"errors"
+	"fmt"
+
+	"dokusho/pkg/sources/source_types"
+)
+
+type KomgaStatus string
+
+const (
+	ONGOING   KomgaStatus = "ONGOING"
+	ENDED     KomgaStatus = "ENDED"
+	ABANDONED KomgaStatus = "ABANDONED"
+	HIATUS    KomgaStatus = "HIATUS"
+)
+
+var KOMGA_TO_SOURCE_SERIE_STATUS = map[KomgaStatus]source_types.SourceSerieStatus{
+	ONGOING:   source_types.STATUS_ONGOING,
+	ENDED:     source_types.STATUS_COMPLETED,
+	ABANDONED: source_types.STATUS_CANCELED,
+	HIATUS:    source_types.STATUS_HIATUS,
+}
+
+var SOURCE_SERIE_STATUS_TO_KOMGA = map[source_types.SourceSerieStatus]KomgaStatus{
+	source_types.STATUS_ONGOING:   ONGOING,
+	source_types.STATUS_COMPLETED: ENDED,
+	source_types.STATUS_CANCELED:  ABANDONED,
+	source_types.STATUS_HIATUS:    HIATUS,
+}
+
+func GetSearchableStatus() []source_types.SourceSerieStatus {
+	return []source_types.SourceSerieStatus{source_types.STATUS_ONGOING, source_types.STATUS_COMPLETED, source_types.STATUS_CANCELED, source_types.STATUS_HIATUS}
+}
+
+func ConvertKomgaStatus(status KomgaStatus) (source_types.SourceSerieStatus, error) {
+	s, ok := KOMGA_TO_SOURCE_SERIE_STATUS[status]
+	if !ok {
+		return source_types.STATUS_UNKNOWN, errors.Join(ErrInvalidStatus, fmt.Errorf("unknown status: %s", status))
+	}
+
+	return s, nil
+}
+
+func ConvertSourceSerieStatuses(statuses []source_types.SourceSerieStatus) ([]KomgaStatus, error) {
+	converted := make([]KomgaStatus, len(statuses))
+	for i, status := range statuses {
+		s, ok := SOURCE_SERIE_STATUS_TO_KOMGA[status]
+		if !ok {
+			return nil, errors.Join(ErrInvalidStatus, fmt.Errorf("status not supported: %s", status))
+		}
+
+		converted[i] = s
+	}
+
+	return converted, nil
+}
+
+// KomgaSort is the property of the sort parameter, the direction is added by the order
+type KomgaSort string
+
+const (
+	SORT_RELEVANCE KomgaSort = ""
+	SORT_TITLE     KomgaSort = "metadata.titleSort"
+	SORT_MODIFIED  KomgaSort = "lastModified"
+)
+
+var SOURCE_SERIE_SORT_TO_KOMGA = map[source_types.FetchSearchSerieFilterSort]KomgaSort{
+	source_types.RELEVANCE:  SORT_RELEVANCE,
+	source_types.ALPHABETIC: SORT_TITLE,
+	source_types.LATEST:     SORT_MODIFIED,
+}
+
+func GetSearchableSorts() []source_types.FetchSearchSerieFilterSort {
+	return []source_types.FetchSearchSerieFilterSort{source_types.RELEVANCE, source_types.ALPHABETIC, source_types.LATEST}
+}
+
+func GetSearchableOrders() []source_types.FetchSearchSerieFilterOrder {
+	return []source_types.FetchSearchSerieFilterOrder{source_types.ASC, source_types.DESC}
+}
+
+func ConvertSourceSerieSort(sort source_types.FetchSearchSerieFilterSort) (KomgaSort, error) {
+	s, ok := SOURCE_SERIE_SORT_TO_KOMGA[sort]
+	if !ok {
+		return "", errors.Join(ErrInvalidSort, fmt.Errorf("sort not supported: %s", sort))
+	}
+
+	return s, nil
+}
@@ -0,0 +1,11 @@
+package madara
+
+import "errors"
+
+var (
+	ErrInvalidConfig = errors.New("invalid madara config")
+	ErrInvalidStatus = errors.New("invalid status")
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidGenre  = errors.New("invalid genre")
+	ErrMissingPostID = errors.New("missing manga post id")
+)
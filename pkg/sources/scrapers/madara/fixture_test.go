@@ -0,0 +1,17 @@
+package madara_test
+
+import (
+	_ "embed"
+)
+
+//go:embed fixtures/search.html
+var searchHTML string
+
+//go:embed fixtures/serie.html
+var serieHTML string
+
+//go:embed fixtures/chapters.html
+var chaptersHTML string
+
+//go:embed fixtures/chapter.html
+var chapterHTML string
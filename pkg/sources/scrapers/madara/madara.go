@@ -0,0 +1,749 @@
+package madara
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"dokusho/pkg/sources/chapterutils"
+	sources "dokusho/pkg/sources/source_types"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+var (
+	chapterNumberRegex = regexp.MustCompile(`(?i)ch(?:apter)?\.?\s*(\d+(?:\.\d+)?)`)
+	numberRegex        = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	relativeDateRegex  = regexp.MustCompile(`(?i)(\d+)\s*(sec|min|hour|day|week|month|year)\w*\s+ago`)
+)
+
+// Config describes a site running the Madara WordPress theme, only the ID, name, URL and language are required
+type Config struct {
+	ID        sources.SourceID
+	Name      string
+	URL       string
+	Icon      string
+	Language  sources.SourceLanguage
+	NSFW      bool
+	UpdatedAt time.Time
+	// Path of the serie pages, "manga" by default
+	SeriePath string
+	// Layout of the chapter dates, "January 2, 2006" by default
+	DateLayout string
+	// Genres offered by the search, the genres of the theme by default
+	Genres     []sources.SourceSerieGenre
+	ImageHosts []string
+	Selectors  Selectors
+}
+
+// Selectors of the theme, the empty selectors keep the default ones
+type Selectors struct {
+	SearchItem   string
+	SearchTitle  string
+	SearchCover  string
+	NextPage     string
+	Title        string
+	Cover        string
+	Synopsis     string
+	AltTitles    string
+	Authors      string
+	Artists      string
+	Genres       string
+	Status       string
+	Type         string
+	PostID       string
+	Chapter      string
+	ChapterDate  string
+	ChapterImage string
+}
+
+var defaultSelectors = Selectors{
+	SearchItem:   "div.c-tabs-item__content, div.page-item-detail",
+	SearchTitle:  "div.post-title a",
+	SearchCover:  "img",
+	NextPage:     "div.nav-previous a, a.nextpostslink",
+	Title:        "div.post-title h1, div.post-title h3",
+	Cover:        "div.summary_image img",
+	Synopsis:     "div.description-summary div.summary__content, div.manga-excerpt",
+	AltTitles:    "div.post-content_item:has(h5:contains(Alternative)) div.summary-content",
+	Authors:      "div.author-content a",
+	Artists:      "div.artist-content a",
+	Genres:       "div.genres-content a",
+	Status:       "div.post-content_item:has(h5:contains(Status)) div.summary-content",
+	Type:         "div.post-content_item:has(h5:contains(Type)) div.summary-content",
+	PostID:       "#manga-chapters-holder[data-id], input.rating-post-id",
+	Chapter:      "li.wp-manga-chapter",
+	ChapterDate:  "span.chapter-release-date",
+	ChapterImage: "div.page-break img",
+}
+
+type matchers struct {
+	searchItem, searchTitle, searchCover, nextPage                cascadia.Selector
+	title, cover, synopsis, altTitles, authors, artists, genres   cascadia.Selector
+	status, serieType, postID, chapter, chapterDate, chapterImage cascadia.Selector
+}
+
+type madara struct {
+	sources.Source
+
+	httpClient *http.Client
+	logger     *slog.Logger
+	seriePath  string
+	dateLayout string
+	genres     map[string]sources.SourceSerieGenre
+	matchers   matchers
+}
+
+// NewMadara builds the source of a Madara site, the selectors are checked once here
+func NewMadara(cfg Config) (*madara, error) {
+	if cfg.ID == "" || cfg.Name == "" {
+		return nil, errors.Join(ErrInvalidConfig, fmt.Errorf("id and name are required"))
+	}
+
+	baseURL, err := url.Parse(strings.TrimSuffix(cfg.URL, "/"))
+	if err != nil || !baseURL.IsAbs() {
+		return nil, errors.Join(ErrInvalidConfig, err, fmt.Errorf("url must be absolute: %q", cfg.URL))
+	}
+
+	if cfg.Language == "" {
+		cfg.Language = sources.EN
+	}
+	if cfg.SeriePath == "" {
+		cfg.SeriePath = "manga"
+	}
+	if cfg.DateLayout == "" {
+		cfg.DateLayout = "January 2, 2006"
+	}
+	if cfg.Genres == nil {
+		cfg.Genres = GetSearchableGenres()
+	}
+	if cfg.ImageHosts == nil {
+		cfg.ImageHosts = []string{}
+	}
+
+	m, err := compileSelectors(cfg.Selectors)
+	if err != nil {
+		return nil, errors.Join(err, fmt.Errorf("invalid selectors for %s", cfg.ID))
+	}
+
+	genres := make(map[string]sources.SourceSerieGenre, len(cfg.Genres))
+	for _, genre := range cfg.Genres {
+		genres[strings.ToLower(string(genre))] = genre
+	}
+
+	timeout := 10 * time.Second
+
+	client := &http.Client{Timeout: timeout}
+
+	return &madara{
+		httpClient: client,
+		logger:     slog.Default().WithGroup(string(cfg.ID)),
+		seriePath:  strings.Trim(cfg.SeriePath, "/"),
+		dateLayout: cfg.DateLayout,
+		genres:     genres,
+		matchers:   m,
+		Source: sources.Source{
+			HTTPClient: client,
+			SourceInformation: sources.SourceInformation{
+				ID:        cfg.ID,
+				Name:      cfg.Name,
+				URL:       baseURL.String(),
+				Icon:      cfg.Icon,
+				Version:   "1.0.0",
+				Languages: []sources.SourceLanguage{cfg.Language},
+				UpdatedAt: cfg.UpdatedAt,
+				NSFW:      cfg.NSFW,
+				SearchFilters: sources.SupportedFilters{
+					Query:   true,
+					Artists: true,
+					Authors: true,
+					Orders:  []sources.FetchSearchSerieFilterOrder{},
+					Sorts:   GetSearchableSorts(),
+					Types:   []sources.SourceSerieType{},
+					Status:  GetSearchableStatus(),
+					Genres: sources.SupportedFiltersGenres{
+						Included:       true,
+						Excluded:       false,
+						PossibleValues: cfg.Genres,
+					},
+				},
+			},
+			SourceAPIInformation: sources.SourceAPIInformation{
+				APIURL:                baseURL,
+				MinimumUpdateInterval: 5 * time.Minute,
+				Timeout:               timeout,
+				Headers: http.Header{
+					"User-Agent": []string{"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:71.0) Gecko/20100101 Firefox/77.0"},
+					// The image hosts of the theme check the referer
+					"Referer": []string{baseURL.String() + "/"},
+				},
+				CanBlockScraping: true,
+				ImageHosts:       cfg.ImageHosts,
+			},
+		},
+	}, nil
+}
+
+func compileSelectors(overrides Selectors) (matchers, error) {
+	var m matchers
+	var err error
+
+	compile := func(name, override, fallback string) cascadia.Selector {
+		selector := override
+		if selector == "" {
+			selector = fallback
+		}
+
+		matcher, compileErr := cascadia.Compile(selector)
+		if compileErr != nil {
+			err = errors.Join(err, ErrInvalidConfig, compileErr, fmt.Errorf("invalid %s selector: %q", name, selector))
+		}
+
+		return matcher
+	}
+
+	d := defaultSelectors
+	m.searchItem = compile("search item", overrides.SearchItem, d.SearchItem)
+	m.searchTitle = compile("search title", overrides.SearchTitle, d.SearchTitle)
+	m.searchCover = compile("search cover", overrides.SearchCover, d.SearchCover)
+	m.nextPage = compile("next page", overrides.NextPage, d.NextPage)
+	m.title = compile("title", overrides.Title, d.Title)
+	m.cover = compile("cover", overrides.Cover, d.Cover)
+	m.synopsis = compile("synopsis", overrides.Synopsis, d.Synopsis)
+	m.altTitles = compile("alternative titles", overrides.AltTitles, d.AltTitles)
+	m.authors = compile("authors", overrides.Authors, d.Authors)
+	m.artists = compile("artists", overrides.Artists, d.Artists)
+	m.genres = compile("genres", overrides.Genres, d.Genres)
+	m.status = compile("status", overrides.Status, d.Status)
+	m.serieType = compile("type", overrides.Type, d.Type)
+	m.postID = compile("post id", overrides.PostID, d.PostID)
+	m.chapter = compile("chapter", overrides.Chapter, d.Chapter)
+	m.chapterDate = compile("chapter date", overrides.ChapterDate, d.ChapterDate)
+	m.chapterImage = compile("chapter image", overrides.ChapterImage, d.ChapterImage)
+
+	return m, err
+}
+
+func (m *madara) GetInformation() sources.SourceInformation {
+	return m.Source.SourceInformation
+}
+
+func (m *madara) GetAPIInformation() sources.SourceAPIInformation {
+	return m.Source.SourceAPIInformation
+}
+
+func (m *madara) FetchPopularSerie(ctx context.Context, page int) (sources.SourcePaginatedSmallSerie, error) {
+	return m.FetchSearchSerie(ctx, page, sources.FetchSearchSerieFilter{Sort: sources.POPULARITY})
+}
+
+func (m *madara) FetchLatestUpdates(ctx context.Context, page int) (sources.SourcePaginatedSmallSerie, error) {
+	return m.FetchSearchSerie(ctx, page, sources.FetchSearchSerieFilter{Sort: sources.LATEST})
+}
+
+func (m *madara) FetchSearchSerie(ctx context.Context, page int, filter sources.FetchSearchSerieFilter) (sources.SourcePaginatedSmallSerie, error) {
+	searchURL := m.SourceAPIInformation.APIURL.JoinPath("/")
+	if page > 1 {
+		searchURL = searchURL.JoinPath("page", strconv.Itoa(page), "/")
+	}
+
+	q := searchURL.Query()
+	q.Set("s", filter.Query)
+	q.Set("post_type", "wp-manga")
+
+	if filter.Sort != "" {
+		sort, err := ConvertSourceSerieSort(filter.Sort)
+		if err != nil {
+			return sources.SourcePaginatedSmallSerie{}, errors.Join(sources.ErrInvalidSearchSort, err, fmt.Errorf("invalid sort: %s", filter.Sort))
+		}
+
+		if sort != SORT_RELEVANCE {
+			q.Set("m_orderby", string(sort))
+		}
+	}
+
+	if filter.Status != nil {
+		status, err := ConvertSourceSerieStatuses(filter.Status)
+		if err != nil {
+			return sources.SourcePaginatedSmallSerie{}, errors.Join(sources.ErrInvalidSearchStatus, err, fmt.Errorf("invalid status: %s", filter.Status))
+		}
+
+		for _, s := range status {
+			q.Add("status[]", string(s))
+		}
+	}
+
+	if len(filter.Genres.Exclude) > 0 {
+		return sources.SourcePaginatedSmallSerie{}, errors.Join(sources.ErrInvalidSearchGenres, fmt.Errorf("excluding genres isn't supported"))
+	}
+
+	for _, genre := range filter.Genres.Include {
+		if _, ok := m.genres[strings.ToLower(string(genre))]; !ok {
+			return sources.SourcePaginatedSmallSerie{}, errors.Join(sources.ErrInvalidSearchGenres, ErrInvalidGenre, fmt.Errorf("genre not supported: %s", genre))
+		}
+
+		q.Add("genre[]", ConvertSourceSerieGenre(genre))
+	}
+	if len(filter.Genres.Include) > 1 {
+		// Series having all the genres
+		q.Set("op", "1")
+	}
+
+	if len(filter.Authors) > 0 {
+		q.Set("author", strings.Join(filter.Authors, ","))
+	}
+
+	if len(filter.Artists) > 0 {
+		q.Set("artist", strings.Join(filter.Artists, ","))
+	}
+
+	searchURL.RawQuery = q.Encode()
+
+	m.logger.Info("Fetching search url", "url", searchURL.String())
+
+	resp, err := m.do(ctx, http.MethodGet, searchURL, nil)
+	if err != nil {
+		return sources.SourcePaginatedSmallSerie{}, errors.Join(err, fmt.Errorf("failed to fetch search data"))
+	}
+	defer resp.Body.Close()
+
+	return m.ParseFetchSearchSerie(resp.Body)
+}
+
+func (m *madara) ParseFetchSearchSerie(html io.Reader) (sources.SourcePaginatedSmallSerie, error) {
+	doc, err := goquery.NewDocumentFromReader(html)
+	if err != nil {
+		return sources.SourcePaginatedSmallSerie{}, errors.Join(sources.ErrParsingHTML, err, fmt.Errorf("failed to parse search html"))
+	}
+
+	items := doc.FindMatcher(m.matchers.searchItem)
+	m.logger.Debug("Found series in search html", "count", items.Length())
+
+	series := []sources.SourceSmallSerie{}
+	for i := range items.Nodes {
+		item := items.Eq(i)
+
+		link := item.FindMatcher(m.matchers.searchTitle).First()
+		id := m.serieIDFromURL(link.AttrOr("href", ""))
+		if id == "" {
+			return sources.SourcePaginatedSmallSerie{}, errors.Join(sources.ErrInvalidSerieID, fmt.Errorf("serie id can't be empty: %q", link.AttrOr("href", "")))
+		}
+
+		cover := imageURL(item.FindMatcher(m.matchers.searchCover).First())
+		if cover == "" {
+			m.logger.Warn("Empty cover URL", "serie_id", id)
+		}
+
+		series = append(series, sources.SourceSmallSerie{
+			ID:    id,
+			Title: m.multiLanguage(strings.TrimSpace(link.Text())),
+			Cover: cover,
+		})
+	}
+
+	return sources.SourcePaginatedSmallSerie{
+		HasNextPage: doc.FindMatcher(m.matchers.nextPage).Length() > 0,
+		Series:      series,
+	}, nil
+}
+
+func (m *madara) FetchSerieDetail(ctx context.Context, serieID sources.SourceSerieID) (sources.SourceSerie, error) {
+	serieURL, err := m.SerieUrl(serieID)
+	if err != nil {
+		return sources.SourceSerie{}, err
+	}
+
+	m.logger.Info("Fetching serie detail", "serie_url", serieURL.String())
+
+	resp, err := m.do(ctx, http.MethodGet, serieURL, nil)
+	if err != nil {
+		return sources.SourceSerie{}, errors.Join(err, fmt.Errorf("failed to fetch serie detail data"))
+	}
+	defer resp.Body.Close()
+
+	serieHTML, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return sources.SourceSerie{}, errors.Join(sources.ErrHTTPRequestFailed, err, fmt.Errorf("failed to read serie detail data"))
+	}
+
+	serieDoc, err := goquery.NewDocumentFromReader(strings.NewReader(string(serieHTML)))
+	if err != nil {
+		return sources.SourceSerie{}, errors.Join(sources.ErrParsingHTML, err, fmt.Errorf("failed to parse serie detail html"))
+	}
+
+	// Older versions of the theme render the chapters in the serie page
+	chaptersHTML := string(serieHTML)
+	if serieDoc.FindMatcher(m.matchers.chapter).Length() == 0 {
+		chaptersHTML, err = m.fetchChapters(ctx, serieURL, serieDoc)
+		if err != nil {
+			return sources.SourceSerie{}, err
+		}
+	}
+
+	return m.ParseFetchSerieDetail(serieID, strings.NewReader(chaptersHTML), strings.NewReader(string(serieHTML)))
+}
+
+// fetchChapters loads the chapters list with the AJAX endpoint of the serie, the sites still running the
+// old theme versions only have the admin-ajax.php action
+func (m *madara) fetchChapters(ctx context.Context, serieURL *url.URL, serieDoc *goquery.Document) (string, error) {
+	chaptersURL := serieURL.JoinPath("ajax", "chapters", "/")
+
+	m.logger.Info("Fetching chapters list", "url", chaptersURL.String())
+
+	resp, err := m.do(ctx, http.MethodPost, chaptersURL, nil)
+	if err == nil {
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", errors.Join(sources.ErrHTTPRequestFailed, err, fmt.Errorf("failed to read chapters list"))
+		}
+
+		return string(body), nil
+	}
+	m.logger.Debug("Chapters endpoint failed, falling back to admin-ajax.php", "error", err)
+
+	postIDElem := serieDoc.FindMatcher(m.matchers.postID).First()
+	postID := postIDElem.AttrOr("data-id", postIDElem.AttrOr("value", ""))
+	if postID == "" {
+		return "", errors.Join(ErrMissingPostID, err, fmt.Errorf("no post id in %s", serieURL))
+	}
+
+	ajaxURL := m.SourceAPIInformation.APIURL.JoinPath("wp-admin", "admin-ajax.php")
+	form := url.Values{"action": {"manga_get_chapters"}, "manga": {postID}}
+
+	m.logger.Info("Fetching chapters list", "url", ajaxURL.String(), "post_id", postID)
+
+	resp, err = m.do(ctx, http.MethodPost, ajaxURL, form)
+	if err != nil {
+		return "", errors.Join(err, fmt.Errorf("failed to fetch chapters list"))
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Join(sources.ErrHTTPRequestFailed, err, fmt.Errorf("failed to read chapters list"))
+	}
+
+	return string(body), nil
+}
+
+func (m *madara) ParseFetchSerieDetail(serieID sources.SourceSerieID, chaptersHTML io.Reader, serieHTML io.Reader) (sources.SourceSerie, error) {
+	chaptersDoc, err := goquery.NewDocumentFromReader(chaptersHTML)
+	if err != nil {
+		return sources.SourceSerie{}, errors.Join(sources.ErrParsingHTML, err, fmt.Errorf("failed to parse chapters list html"))
+	}
+
+	now := time.Now()
+	language := m.SourceInformation.Languages[0]
+
+	cm := chaptersDoc.FindMatcher(m.matchers.chapter)
+	m.logger.Debug("Found chapters", "count", cm.Length())
+
+	chapters := []sources.SourceSerieVolumeChapter{}
+	chapterNumbers := []float64{}
+	for i := range cm.Nodes {
+		elem := cm.Eq(i)
+		link := elem.Find("a").First()
+
+		href := link.AttrOr("href", "")
+		chapterID := lastPathSegment(href)
+		if chapterID == "" {
+			m.logger.Warn("Empty chapter URL", "index", i)
+			continue
+		}
+
+		name := strings.TrimSpace(link.Text())
+
+		rawNumber := numberRegex.FindString(name)
+		if match := chapterNumberRegex.FindStringSubmatch(name); match != nil {
+			rawNumber = match[1]
+		}
+		number, err := strconv.ParseFloat(rawNumber, 64)
+		if err != nil {
+			m.logger.Warn("Failed to parse chapter number", "name", name, "error", err)
+		}
+
+		date := elem.FindMatcher(m.matchers.chapterDate).First()
+		// The recent chapters show a relative date in the title of a link
+		rawDate := strings.TrimSpace(date.Find("a").AttrOr("title", date.Text()))
+		dateUpload, err := parseDate(rawDate, m.dateLayout, now)
+		if err != nil {
+			m.logger.Warn("Failed to parse date", "raw_date", rawDate, "error", err)
+		}
+
+		chapters = append(chapters, sources.SourceSerieVolumeChapter{
+			ID:            sources.SourceSerieVolumeChapterID(chapterID),
+			Name:          name,
+			DateUpload:    dateUpload,
+			ChapterNumber: number,
+			Language:      language,
+		})
+		chapterNumbers = append(chapterNumbers, number)
+	}
+
+	serieDoc, err := goquery.NewDocumentFromReader(serieHTML)
+	if err != nil {
+		return sources.SourceSerie{}, errors.Join(sources.ErrParsingHTML, err, fmt.Errorf("failed to parse serie detail html"))
+	}
+
+	// The title contains badges like "HOT" or "NEW"
+	titleElem := serieDoc.FindMatcher(m.matchers.title).First().Clone()
+	titleElem.Find("span").Remove()
+	title := strings.TrimSpace(titleElem.Text())
+	if title == "" {
+		m.logger.Warn("Empty title", "serie_id", serieID)
+	}
+
+	status := []sources.SourceSerieStatus{}
+	rawStatus := strings.TrimSpace(serieDoc.FindMatcher(m.matchers.status).First().Text())
+	if rawStatus != "" {
+		s, err := ConvertMadaraStatus(rawStatus)
+		if err != nil {
+			m.logger.Warn("Failed to parse status", "raw_status", rawStatus, "error", err)
+		}
+		status = append(status, s)
+	}
+
+	rawType := strings.TrimSpace(serieDoc.FindMatcher(m.matchers.serieType).First().Text())
+	serieType := sources.NewSourceSerieType(strings.ToLower(rawType))
+	if rawType != "" && serieType == sources.TYPE_UNKNOWN {
+		m.logger.Warn("Failed to parse type", "raw_type", rawType)
+	}
+
+	genres := []sources.SourceSerieGenre{}
+	for _, rawGenre := range texts(serieDoc.FindMatcher(m.matchers.genres)) {
+		genre, ok := m.genres[strings.ToLower(rawGenre)]
+		if !ok {
+			m.logger.Warn("Unknown genre, add it to the genres of the config", "raw_genre", rawGenre)
+			continue
+		}
+
+		genres = append(genres, genre)
+	}
+
+	altTitles := []sources.MultiLanguageString{}
+	rawAltTitles := serieDoc.FindMatcher(m.matchers.altTitles).First().Text()
+	for _, altTitle := range strings.FieldsFunc(rawAltTitles, func(r rune) bool { return r == ',' || r == ';' }) {
+		if altTitle = strings.TrimSpace(altTitle); altTitle != "" {
+			altTitles = append(altTitles, m.multiLanguage(altTitle))
+		}
+	}
+
+	synopsis := strings.TrimSpace(serieDoc.FindMatcher(m.matchers.synopsis).First().Text())
+
+	// The theme has no volumes, every chapter is in a single volume
+	volume := sources.SourceSerieVolume{
+		ID:              "volume-1",
+		Name:            "Volume 1",
+		VolumeNumber:    1,
+		Chapters:        chapters,
+		MissingChapters: chapterutils.CalculateMissingChapters(chapterNumbers),
+	}
+
+	return sources.SourceSerie{
+		ID:                serieID,
+		Title:             m.multiLanguage(title),
+		AlternativeTitles: altTitles,
+		Cover:             imageURL(serieDoc.FindMatcher(m.matchers.cover).First()),
+		Synopsis:          m.multiLanguage(synopsis),
+		Type:              serieType,
+		Status:            status,
+		Authors:           texts(serieDoc.FindMatcher(m.matchers.authors)),
+		Artists:           texts(serieDoc.FindMatcher(m.matchers.artists)),
+		Genres:            genres,
+		Volumes:           []sources.SourceSerieVolume{volume},
+	}, nil
+}
+
+func (m *madara) FetchChapterData(ctx context.Context, serieID sources.SourceSerieID, volumeID sources.SourceSerieVolumeID, chapterID sources.SourceSerieVolumeChapterID) (sources.SourceSerieVolumeChapterData, error) {
+	serieURL, err := m.SerieUrl(serieID)
+	if err != nil {
+		return sources.SourceSerieVolumeChapterData{}, err
+	}
+
+	chapterURL := serieURL.JoinPath(string(chapterID), "/")
+	q := chapterURL.Query()
+	q.Set("style", "list")
+	chapterURL.RawQuery = q.Encode()
+
+	m.logger.Info("Fetching chapter data", "url", chapterURL.String())
+
+	resp, err := m.do(ctx, http.MethodGet, chapterURL, nil)
+	if err != nil {
+		return sources.SourceSerieVolumeChapterData{}, errors.Join(err, fmt.Errorf("failed to fetch chapter images data"))
+	}
+	defer resp.Body.Close()
+
+	return m.ParseFetchChapterData(resp.Body)
+}
+
+func (m *madara) ParseFetchChapterData(html io.Reader) (sources.SourceSerieVolumeChapterData, error) {
+	doc, err := goquery.NewDocumentFromReader(html)
+	if err != nil {
+		return sources.SourceSerieVolumeChapterData{}, errors.Join(sources.ErrParsingHTML, err, fmt.Errorf("failed to parse chapter data html"))
+	}
+
+	images := []sources.SourceSerieVolumeChapterImage{}
+	doc.FindMatcher(m.matchers.chapterImage).Each(func(i int, s *goquery.Selection) {
+		u := imageURL(s)
+		if u == "" {
+			m.logger.Warn("Empty image URL", "index", i)
+			return
+		}
+
+		images = append(images, sources.SourceSerieVolumeChapterImage{
+			Index: len(images) + 1,
+			URL:   u,
+		})
+	})
+
+	return sources.SourceSerieVolumeChapterData{Images: images, Type: sources.IMAGE}, nil
+}
+
+func (m *madara) SerieUrl(serieID sources.SourceSerieID) (*url.URL, error) {
+	if serieID == "" || strings.Contains(string(serieID), "/") {
+		return nil, errors.Join(sources.ErrBuildingURL, sources.ErrInvalidSerieID, fmt.Errorf("invalid serie id: %q", serieID))
+	}
+
+	return m.SourceAPIInformation.APIURL.JoinPath(m.seriePath, string(serieID), "/"), nil
+}
+
+// do sends the request with the headers of the source, form is sent as the body of POST requests
+func (m *madara) do(ctx context.Context, method string, u *url.URL, form url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errors.Join(sources.ErrBuildingRequest, err, fmt.Errorf("failed to build request: %s", u))
+	}
+
+	req.Header = m.SourceAPIInformation.Headers.Clone()
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Requested-With", "XMLHttpRequest")
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Join(sources.ErrHTTPRequestFailed, err, fmt.Errorf("failed to fetch %s", u))
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Join(sources.ErrHTTPRequestFailed, fmt.Errorf("unexpected status %s for %s", resp.Status, u))
+	}
+
+	return resp, nil
+}
+
+// serieIDFromURL returns the slug of a serie URL, https://example.com/manga/slug/ is slug
+func (m *madara) serieIDFromURL(raw string) sources.SourceSerieID {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, part := range parts {
+		if part == m.seriePath && i+1 < len(parts) {
+			return sources.SourceSerieID(parts[i+1])
+		}
+	}
+
+	return sources.SourceSerieID(lastPathSegment(raw))
+}
+
+// multiLanguage stores the value in the language of the site
+func (m *madara) multiLanguage(value string) sources.MultiLanguageString {
+	var s sources.MultiLanguageString
+
+	switch m.SourceInformation.Languages[0] {
+	case sources.JP:
+		s.JP = value
+	case sources.FR:
+		s.FR = value
+	case sources.KO:
+		s.KO = value
+	case sources.ZH:
+		s.ZH = value
+	case sources.ZH_HK:
+		s.ZH_HK = value
+	default:
+		s.EN = value
+	}
+
+	return s
+}
+
+func lastPathSegment(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return ""
+	}
+
+	path := strings.Trim(u.Path, "/")
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// imageURL returns the URL of a lazy loaded image, the theme keeps it in data-src or srcset
+func imageURL(img *goquery.Selection) string {
+	for _, attr := range []string{"data-src", "data-lazy-src", "data-cfsrc", "src"} {
+		if v := strings.TrimSpace(img.AttrOr(attr, "")); v != "" && !strings.HasPrefix(v, "data:") {
+			return v
+		}
+	}
+
+	if srcset := strings.Fields(img.AttrOr("srcset", "")); len(srcset) > 0 {
+		return srcset[0]
+	}
+
+	return ""
+}
+
+func texts(s *goquery.Selection) []string {
+	values := []string{}
+	s.Each(func(i int, elem *goquery.Selection) {
+		if text := strings.TrimSpace(elem.Text()); text != "" {
+			values = append(values, text)
+		}
+	})
+
+	return values
+}
+
+// parseDate parses the absolute dates with the layout of the site and the relative dates like "2 days ago"
+func parseDate(raw, layout string, now time.Time) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+
+	match := relativeDateRegex.FindStringSubmatch(raw)
+	if match == nil {
+		return time.Parse(layout, raw)
+	}
+
+	n, _ := strconv.Atoi(match[1])
+	switch strings.ToLower(match[2]) {
+	case "sec":
+		return now.Add(-time.Duration(n) * time.Second), nil
+	case "min":
+		return now.Add(-time.Duration(n) * time.Minute), nil
+	case "hour":
+		return now.Add(-time.Duration(n) * time.Hour), nil
+	case "day":
+		return now.AddDate(0, 0, -n), nil
+	case "week":
+		return now.AddDate(0, 0, -7*n), nil
+	case "month":
+		return now.AddDate(0, -n, 0), nil
+	default:
+		return now.AddDate(-n, 0, 0), nil
+	}
+}
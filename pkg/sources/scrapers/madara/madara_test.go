@@ -0,0 +1,355 @@
+package madara_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"dokusho/pkg/sources/scrapers/madara"
+	"dokusho/pkg/sources/source_types"
+)
+
+func newSource(t *testing.T, url string) source_types.SourceAPI {
+	t.Helper()
+
+	source, err := madara.NewMadara(madara.Config{
+		ID:       "madara_scans",
+		Name:     "Madara Scans",
+		URL:      url,
+		Language: source_types.EN,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return source
+}
+
+func TestMadaraParseFetchSearchSerie(t *testing.T) {
+	t.Parallel()
+
+	source, _ := madara.NewMadara(madara.Config{ID: "madara_scans", Name: "Madara Scans", URL: "https://madara.example.com"})
+
+	series, err := source.ParseFetchSearchSerie(strings.NewReader(searchHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !series.HasNextPage {
+		t.Error("HasNextPage should be true")
+	}
+
+	expected := []source_types.SourceSmallSerie{
+		{
+			ID:    "the-last-swordmaster",
+			Title: source_types.MultiLanguageString{EN: "The Last Swordmaster"},
+			Cover: "https://madara.example.com/wp-content/uploads/2024/01/swordmaster-193x278.jpg",
+		},
+		{
+			ID:    "moonlit-garden",
+			Title: source_types.MultiLanguageString{EN: "Moonlit Garden"},
+			Cover: "https://madara.example.com/wp-content/uploads/2023/11/moonlit-193x278.jpg",
+		},
+		{
+			ID:    "iron-chef-returns",
+			Title: source_types.MultiLanguageString{EN: "Iron Chef Returns"},
+			Cover: "https://madara.example.com/wp-content/uploads/2022/05/iron-chef-193x278.png",
+		},
+	}
+	if !slices.Equal(series.Series, expected) {
+		t.Errorf("expected %+v, got %+v", expected, series.Series)
+	}
+}
+
+func TestMadaraParseFetchSerieDetail(t *testing.T) {
+	t.Parallel()
+
+	source, _ := madara.NewMadara(madara.Config{ID: "madara_scans", Name: "Madara Scans", URL: "https://madara.example.com"})
+
+	serie, err := source.ParseFetchSerieDetail("the-last-swordmaster", strings.NewReader(chaptersHTML), strings.NewReader(serieHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if serie.Title.EN != "The Last Swordmaster" {
+		t.Errorf("unexpected title %q", serie.Title.EN)
+	}
+
+	if !strings.HasPrefix(serie.Synopsis.EN, "The greatest swordmaster") {
+		t.Errorf("unexpected synopsis %q", serie.Synopsis.EN)
+	}
+
+	if serie.Cover != "https://madara.example.com/wp-content/uploads/2024/01/swordmaster.jpg" {
+		t.Errorf("unexpected cover %q", serie.Cover)
+	}
+
+	if serie.Type != source_types.TYPE_MANHWA {
+		t.Errorf("unexpected type %q", serie.Type)
+	}
+
+	if !slices.Equal(serie.Status, []source_types.SourceSerieStatus{source_types.STATUS_ONGOING}) {
+		t.Errorf("unexpected status %v", serie.Status)
+	}
+
+	// Regression isn't a genre of the theme
+	if !slices.Equal(serie.Genres, []source_types.SourceSerieGenre{source_types.ACTION, source_types.MARTIAL_ARTS, source_types.SCI_FI}) {
+		t.Errorf("unexpected genres %v", serie.Genres)
+	}
+
+	if !slices.Equal(serie.Authors, []string{"Han Seol"}) || !slices.Equal(serie.Artists, []string{"Studio Lune", "Park Ji"}) {
+		t.Errorf("unexpected authors %v and artists %v", serie.Authors, serie.Artists)
+	}
+
+	if len(serie.AlternativeTitles) != 3 || serie.AlternativeTitles[1].EN != "The Final Blade" {
+		t.Errorf("unexpected alternative titles %v", serie.AlternativeTitles)
+	}
+
+	if len(serie.Volumes) != 1 {
+		t.Fatalf("expected a single volume, got %d", len(serie.Volumes))
+	}
+	volume := serie.Volumes[0]
+
+	var ids []source_types.SourceSerieVolumeChapterID
+	var numbers []float64
+	for _, chapter := range volume.Chapters {
+		ids = append(ids, chapter.ID)
+		numbers = append(numbers, chapter.ChapterNumber)
+	}
+
+	if !slices.Equal(ids, []source_types.SourceSerieVolumeChapterID{"chapter-5", "chapter-4-5", "chapter-4", "chapter-2", "chapter-1"}) {
+		t.Errorf("unexpected chapter ids %v", ids)
+	}
+
+	if !slices.Equal(numbers, []float64{5, 4.5, 4, 2, 1}) {
+		t.Errorf("unexpected chapter numbers %v", numbers)
+	}
+
+	if !slices.Equal(volume.MissingChapters, []float64{3}) {
+		t.Errorf("unexpected missing chapters %v", volume.MissingChapters)
+	}
+
+	if volume.Chapters[0].Name != "Chapter 5 - The Return" {
+		t.Errorf("unexpected chapter name %q", volume.Chapters[0].Name)
+	}
+
+	// "2 days ago"
+	if age := time.Since(volume.Chapters[0].DateUpload); age < 47*time.Hour || age > 49*time.Hour {
+		t.Errorf("unexpected relative date %v", volume.Chapters[0].DateUpload)
+	}
+
+	if !volume.Chapters[2].DateUpload.Equal(time.Date(2024, time.February, 25, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date %v", volume.Chapters[2].DateUpload)
+	}
+}
+
+func TestMadaraParseFetchChapterData(t *testing.T) {
+	t.Parallel()
+
+	source, _ := madara.NewMadara(madara.Config{ID: "madara_scans", Name: "Madara Scans", URL: "https://madara.example.com"})
+
+	chapter, err := source.ParseFetchChapterData(strings.NewReader(chapterHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if chapter.Type != source_types.IMAGE {
+		t.Error("Chapter for this source should only have type Image")
+	}
+
+	expected := []source_types.SourceSerieVolumeChapterImage{
+		{Index: 1, URL: "https://cdn.madara.example.com/manga_4821/chapter-4/01.jpg"},
+		{Index: 2, URL: "https://cdn.madara.example.com/manga_4821/chapter-4/02.jpg"},
+		{Index: 3, URL: "https://cdn.madara.example.com/manga_4821/chapter-4/03.webp"},
+	}
+	if !slices.Equal(chapter.Images, expected) {
+		t.Errorf("expected %+v, got %+v", expected, chapter.Images)
+	}
+}
+
+func TestMadaraFetchSerieDetail(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		// Serves the chapters with the endpoint of the recent versions of the theme
+		chaptersEndpoint bool
+		// Serves the chapters with admin-ajax.php
+		adminAjax bool
+		// Renders the chapters in the serie page
+		inline bool
+	}{
+		{name: "chapters endpoint", chaptersEndpoint: true},
+		{name: "admin-ajax fallback", adminAjax: true},
+		{name: "chapters in the serie page", inline: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /manga/the-last-swordmaster/", func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Referer") == "" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+
+				if tc.inline {
+					fmt.Fprint(w, strings.Replace(serieHTML, `<div id="manga-chapters-holder" data-id="4821"></div>`, chaptersHTML, 1))
+					return
+				}
+
+				fmt.Fprint(w, serieHTML)
+			})
+			mux.HandleFunc("POST /manga/the-last-swordmaster/ajax/chapters/", func(w http.ResponseWriter, r *http.Request) {
+				if !tc.chaptersEndpoint {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				fmt.Fprint(w, chaptersHTML)
+			})
+			mux.HandleFunc("POST /wp-admin/admin-ajax.php", func(w http.ResponseWriter, r *http.Request) {
+				if !tc.adminAjax || r.FormValue("action") != "manga_get_chapters" || r.FormValue("manga") != "4821" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				fmt.Fprint(w, chaptersHTML)
+			})
+
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			serie, err := newSource(t, server.URL).FetchSerieDetail(context.Background(), "the-last-swordmaster")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if serie.Title.EN != "The Last Swordmaster" || len(serie.Volumes) != 1 || len(serie.Volumes[0].Chapters) != 5 {
+				t.Errorf("unexpected serie %+v", serie)
+			}
+		})
+	}
+}
+
+func TestMadaraFetchSearchSerie(t *testing.T) {
+	t.Parallel()
+
+	var query string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /page/2/", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		fmt.Fprint(w, searchHTML)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	source := newSource(t, server.URL)
+
+	result, err := source.FetchSearchSerie(context.Background(), 2, source_types.FetchSearchSerieFilter{
+		Query:  "sword",
+		Sort:   source_types.POPULARITY,
+		Status: []source_types.SourceSerieStatus{source_types.STATUS_ONGOING},
+		Genres: source_types.FetchSearchSerieFilterGenres{
+			Include: []source_types.SourceSerieGenre{source_types.MARTIAL_ARTS, source_types.SLICE_OF_LIFE},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Series) != 3 {
+		t.Errorf("expected 3 series, got %d", len(result.Series))
+	}
+
+	for _, param := range []string{"s=sword", "post_type=wp-manga", "m_orderby=views", "status%5B%5D=on-going", "genre%5B%5D=martial-arts", "genre%5B%5D=slice-of-life", "op=1"} {
+		if !strings.Contains(query, param) {
+			t.Errorf("expected %s in the query %s", param, query)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		filter   source_types.FetchSearchSerieFilter
+		expected error
+	}{
+		{
+			name:     "excluded genres",
+			filter:   source_types.FetchSearchSerieFilter{Genres: source_types.FetchSearchSerieFilterGenres{Exclude: []source_types.SourceSerieGenre{source_types.ACTION}}},
+			expected: source_types.ErrInvalidSearchGenres,
+		},
+		{
+			name:     "unknown genre",
+			filter:   source_types.FetchSearchSerieFilter{Genres: source_types.FetchSearchSerieFilterGenres{Include: []source_types.SourceSerieGenre{source_types.ZOMBIES}}},
+			expected: source_types.ErrInvalidSearchGenres,
+		},
+		{
+			name:     "unknown status",
+			filter:   source_types.FetchSearchSerieFilter{Status: []source_types.SourceSerieStatus{source_types.STATUS_SCANLATED}},
+			expected: source_types.ErrInvalidSearchStatus,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := source.FetchSearchSerie(context.Background(), 1, tc.filter)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestMadaraSerieUrl(t *testing.T) {
+	t.Parallel()
+
+	source := newSource(t, "https://madara.example.com/")
+
+	u, err := source.SerieUrl("the-last-swordmaster")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.String() != "https://madara.example.com/manga/the-last-swordmaster/" {
+		t.Errorf("unexpected serie url %s", u)
+	}
+
+	_, err = source.SerieUrl("the-last-swordmaster/chapter-1")
+	if !errors.Is(err, source_types.ErrInvalidSerieID) {
+		t.Errorf("expected ErrInvalidSerieID, got %v", err)
+	}
+}
+
+func TestNewMadaraInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		config madara.Config
+	}{
+		{name: "missing id", config: madara.Config{Name: "Madara Scans", URL: "https://madara.example.com"}},
+		{name: "relative url", config: madara.Config{ID: "madara_scans", Name: "Madara Scans", URL: "madara.example.com"}},
+		{name: "invalid selector", config: madara.Config{ID: "madara_scans", Name: "Madara Scans", URL: "https://madara.example.com", Selectors: madara.Selectors{Chapter: "li[class="}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := madara.NewMadara(tc.config)
+			if !errors.Is(err, madara.ErrInvalidConfig) {
+				t.Errorf("expected ErrInvalidConfig, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package madara
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	sources "dokusho/pkg/sources/source_types"
+)
+
+// MadaraSort is the m_orderby parameter of the search
+type MadaraSort string
+
+const (
+	SORT_RELEVANCE MadaraSort = ""
+	SORT_LATEST    MadaraSort = "latest"
+	SORT_ALPHABET  MadaraSort = "alphabet"
+	SORT_VIEWS     MadaraSort = "views"
+)
+
+var SOURCE_SERIE_SORT_TO_MADARA = map[sources.FetchSearchSerieFilterSort]MadaraSort{
+	sources.RELEVANCE:  SORT_RELEVANCE,
+	sources.LATEST:     SORT_LATEST,
+	sources.ALPHABETIC: SORT_ALPHABET,
+	sources.POPULARITY: SORT_VIEWS,
+}
+
+func GetSearchableSorts() []sources.FetchSearchSerieFilterSort {
+	return []sources.FetchSearchSerieFilterSort{sources.RELEVANCE, sources.LATEST, sources.ALPHABETIC, sources.POPULARITY}
+}
+
+func ConvertSourceSerieSort(sort sources.FetchSearchSerieFilterSort) (MadaraSort, error) {
+	s, ok := SOURCE_SERIE_SORT_TO_MADARA[sort]
+	if !ok {
+		return "", errors.Join(ErrInvalidSort, fmt.Errorf("sort not supported: %s", sort))
+	}
+
+	return s, nil
+}
+
+// MadaraStatus is the status[] parameter of the search
+type MadaraStatus string
+
+const (
+	STATUS_ONGOING   MadaraStatus = "on-going"
+	STATUS_COMPLETED MadaraStatus = "end"
+	STATUS_CANCELED  MadaraStatus = "canceled"
+	STATUS_ON_HOLD   MadaraStatus = "on-hold"
+)
+
+var SOURCE_SERIE_STATUS_TO_MADARA = map[sources.SourceSerieStatus]MadaraStatus{
+	sources.STATUS_ONGOING:   STATUS_ONGOING,
+	sources.STATUS_COMPLETED: STATUS_COMPLETED,
+	sources.STATUS_CANCELED:  STATUS_CANCELED,
+	sources.STATUS_HIATUS:    STATUS_ON_HOLD,
+}
+
+// Status displayed on the serie pages, lower cased and without spaces
+var MADARA_LABEL_TO_SOURCE_SERIE_STATUS = map[string]sources.SourceSerieStatus{
+	"ongoing":   sources.STATUS_ONGOING,
+	"completed": sources.STATUS_COMPLETED,
+	"end":       sources.STATUS_COMPLETED,
+	"canceled":  sources.STATUS_CANCELED,
+	"cancelled": sources.STATUS_CANCELED,
+	"onhold":    sources.STATUS_HIATUS,
+	"hiatus":    sources.STATUS_HIATUS,
+}
+
+func GetSearchableStatus() []sources.SourceSerieStatus {
+	return []sources.SourceSerieStatus{sources.STATUS_ONGOING, sources.STATUS_COMPLETED, sources.STATUS_CANCELED, sources.STATUS_HIATUS}
+}
+
+func ConvertSourceSerieStatuses(statuses []sources.SourceSerieStatus) ([]MadaraStatus, error) {
+	converted := make([]MadaraStatus, len(statuses))
+	for i, status := range statuses {
+		s, ok := SOURCE_SERIE_STATUS_TO_MADARA[status]
+		if !ok {
+			return nil, errors.Join(ErrInvalidStatus, fmt.Errorf("status not supported: %s", status))
+		}
+
+		converted[i] = s
+	}
+
+	return converted, nil
+}
+
+func ConvertMadaraStatus(label string) (sources.SourceSerieStatus, error) {
+	key := strings.ToLower(strings.Join(strings.Fields(label), ""))
+	status, ok := MADARA_LABEL_TO_SOURCE_SERIE_STATUS[key]
+	if !ok {
+		return sources.STATUS_UNKNOWN, errors.Join(ErrInvalidStatus, fmt.Errorf("unknown status: %s", label))
+	}
+
+	return status, nil
+}
+
+// Genres of the theme, sites can replace them in their config
+func GetSearchableGenres() []sources.SourceSerieGenre {
+	return []sources.SourceSerieGenre{
+		sources.ACTION,
+		sources.ADVENTURE,
+		sources.COMEDY,
+		sources.DRAMA,
+		sources.FANTASY,
+		sources.HAREM,
+		sources.HISTORICAL,
+		sources.HORROR,
+		sources.ISEKAI,
+		sources.JOSEI,
+		sources.MARTIAL_ARTS,
+		sources.MATURE,
+		sources.MYSTERY,
+		sources.PSYCHOLOGICAL,
+		sources.ROMANCE,
+		sources.SCHOOL_LIFE,
+		sources.SCI_FI,
+		sources.SEINEN,
+		sources.SHOUJO,
+		sources.SHOUNEN,
+		sources.SLICE_OF_LIFE,
+		sources.SPORTS,
+		sources.SUPERNATURAL,
+		sources.TRAGEDY,
+	}
+}
+
+// ConvertSourceSerieGenre returns the slug of the genre, "Slice of Life" is "slice-of-life"
+func ConvertSourceSerieGenre(genre sources.SourceSerieGenre) string {
+	slug := strings.ToLower(strings.ReplaceAll(string(genre), "'", ""))
+
+	return strings.Join(strings.Fields(slug), "-")
+}
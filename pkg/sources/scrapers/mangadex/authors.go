@@ -0,0 +1,224 @@
+package mangadex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"dokusho/pkg/sources/source_types"
+)
+
+const (
+	// Resolved names are kept for a day, authors are rarely renamed
+	authorCacheTTL = 24 * time.Hour
+	// Unknown names are kept for a short time, the author can be added on MangaDex in the meantime
+	authorMissCacheTTL = 10 * time.Minute
+	// Names kept at most, the entries closest to expiring make room for the new ones
+	authorCacheMaxEntries = 1000
+	authorLimit           = 100
+)
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+type authorCacheEntry struct {
+	id        string
+	expiresAt time.Time
+}
+
+// authorCache maps the lowercased names to the author IDs, it is shared by the concurrent searches.
+// Unknown names are cached with an empty ID
+type authorCache struct {
+	mu      sync.Mutex
+	entries map[string]authorCacheEntry
+}
+
+func (c *authorCache) get(name string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[name]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return "", false
+	}
+
+	return entry.id, true
+}
+
+func (c *authorCache) set(name string, id string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]authorCacheEntry)
+	}
+
+	now := time.Now()
+	if _, ok := c.entries[name]; !ok && len(c.entries) >= authorCacheMaxEntries {
+		c.evict(now)
+	}
+
+	c.entries[name] = authorCacheEntry{id: id, expiresAt: now.Add(ttl)}
+}
+
+// evict drops the expired entries, or the one closest to expiring when none expired
+func (c *authorCache) evict(now time.Time) {
+	var oldest string
+	for name, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, name)
+			continue
+		}
+
+		if oldest == "" || entry.expiresAt.Before(c.entries[oldest].expiresAt) {
+			oldest = name
+		}
+	}
+
+	if len(c.entries) >= authorCacheMaxEntries {
+		delete(c.entries, oldest)
+	}
+}
+
+// resolveAuthor returns the ID of an author or an artist by name, MangaDex only filters by ID.
+// An ID is returned as is, and "" with no error when no author has the name
+func (m *mangadex) resolveAuthor(context context.Context, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if uuidRegex.MatchString(strings.ToLower(name)) {
+		return strings.ToLower(name), nil
+	}
+
+	key := strings.ToLower(name)
+	if id, ok := m.authors.get(key); ok {
+		return id, nil
+	}
+
+	authorURL := m.Source.SourceAPIInformation.APIURL.JoinPath("author")
+
+	q := authorURL.Query()
+	q.Set("name", name)
+	q.Set("limit", fmt.Sprint(authorLimit))
+	authorURL.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(context, http.MethodGet, authorURL.String(), nil)
+	if err != nil {
+		return "", errors.Join(source_types.ErrBuildingRequest, err, fmt.Errorf("failed to build request: %s", authorURL.String()))
+	}
+
+	req.Header = m.SourceAPIInformation.Headers.Clone()
+
+	m.logger.Info("Fetching author", "url", authorURL.String())
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return "", errors.Join(source_types.ErrHTTPRequestFailed, err, fmt.Errorf("failed to fetch data: %s", authorURL.String()))
+	}
+	defer resp.Body.Close()
+
+	m.logger.Debug("Fetched author", "status", resp.Status, "header", resp.Header)
+
+	id, err := m.ParseResolveAuthor(resp.Body, name)
+	if errors.Is(err, ErrUnknownAuthor) {
+		m.authors.set(key, "", authorMissCacheTTL)
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	m.authors.set(key, id, authorCacheTTL)
+
+	return id, nil
+}
+
+// ParseResolveAuthor picks the author matching the name in a list of authors, ignoring case.
+// Only a single exact match is picked, homonyms and partial matches are ambiguous and the error lists them
+func (m *mangadex) ParseResolveAuthor(html io.Reader, name string) (string, error) {
+	data, err := io.ReadAll(html)
+	if err != nil {
+		return "", errors.Join(source_types.ErrParsingHTML, err, fmt.Errorf("failed to read response"))
+	}
+
+	var ar authorListResponse
+	err = json.Unmarshal(data, &ar)
+	if err != nil {
+		return "", errors.Join(source_types.ErrParsingJSON, err, fmt.Errorf("failed to parse response"))
+	}
+
+	if ar.Result != "ok" {
+		return "", errors.Join(source_types.ErrHTTPRequestFailed, fmt.Errorf("response not ok"))
+	}
+
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	var exact, partial []authorResponse
+	for _, author := range ar.Data {
+		authorName := strings.ToLower(strings.TrimSpace(author.Attributes.Name))
+		switch {
+		case authorName == name:
+			exact = append(exact, author)
+		case strings.Contains(authorName, name):
+			partial = append(partial, author)
+		}
+	}
+
+	if len(exact) == 1 {
+		return exact[0].ID, nil
+	}
+
+	candidates := exact
+	if len(candidates) == 0 {
+		candidates = partial
+	}
+
+	if len(candidates) == 0 {
+		return "", errors.Join(ErrUnknownAuthor, fmt.Errorf("no author named %q", name))
+	}
+
+	names := make([]string, len(candidates))
+	for i, author := range candidates {
+		names[i] = fmt.Sprintf("%s (%s)", author.Attributes.Name, author.ID)
+	}
+
+	return "", errors.Join(source_types.ErrInvalidSearchQuery, ErrAmbiguousAuthor, fmt.Errorf("%q matches %s", name, strings.Join(names, ", ")))
+}
+
+// resolveAuthors resolves every name, ok is false when a name matches no author so no serie can match
+func (m *mangadex) resolveAuthors(context context.Context, names []string) (ids []string, ok bool, err error) {
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+
+		id, err := m.resolveAuthor(context, name)
+		if err != nil {
+			return nil, false, err
+		}
+		if id == "" {
+			return nil, false, nil
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, true, nil
+}
+
+// FetchAuthorSeries lists the series the author wrote or drew
+func (m *mangadex) FetchAuthorSeries(context context.Context, author string, page int) (source_types.SourcePaginatedSmallSerie, error) {
+	id, err := m.resolveAuthor(context, author)
+	if err != nil {
+		return source_types.SourcePaginatedSmallSerie{}, err
+	}
+	if id == "" {
+		return source_types.SourcePaginatedSmallSerie{Series: []source_types.SourceSmallSerie{}}, nil
+	}
+
+	return m.fetchSearchSerie(context, page, source_types.FetchSearchSerieFilter{}, searchAuthors{authorOrArtist: id})
+}
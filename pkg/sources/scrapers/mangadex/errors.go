@@ -8,4 +8,9 @@ var (
 	ErrInvalidType   = errors.New("invalid type")
 	ErrInvalidGenre  = errors.New("invalid genre")
 	ErrInvalidOrder  = errors.New("invalid order")
+
+	ErrInvalidContentRating = errors.New("invalid content rating")
+
+	ErrUnknownAuthor   = errors.New("unknown author")
+	ErrAmbiguousAuthor = errors.New("ambiguous author")
 )
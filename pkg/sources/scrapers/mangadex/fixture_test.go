@@ -15,3 +15,6 @@ var serieVolume string
 
 //go:embed fixtures/search_serie.json
 var searchSerie string
+
+//go:embed fixtures/author_list.json
+var authorList string
@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"mime"
 	"net/http"
 	"net/url"
+	"path"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -20,6 +23,10 @@ import (
 
 const (
 	noImageURL = "https://i.imgur.com/6TrIues.jpeg"
+	// Maximum number of chapters returned by a feed request
+	feedLimit = 500
+	// Date format of the feed filters, without timezone
+	feedDateFormat = "2006-01-02T15:04:05"
 )
 
 type mangadex struct {
@@ -27,18 +34,21 @@ type mangadex struct {
 
 	httpClient *http.Client
 	logger     *slog.Logger
+	authors    authorCache
 }
 
 func NewMangadex() *mangadex {
 	timeout := 5 * time.Second
 
-	client := http.Client{Timeout: timeout}
+	// The timeout is applied to each attempt, the client would otherwise give up while waiting before a retry
+	client := http.Client{Transport: transport.WithAttemptTimeout(timeout)}
 	logger := slog.Default().WithGroup("mangadex")
 
 	return &mangadex{
 		httpClient: &client,
 		logger:     logger,
 		Source: source_types.Source{
+			HTTPClient: &client,
 			SourceInformation: source_types.SourceInformation{
 				ID:        "mangadex",
 				Name:      "MangaDex",
@@ -49,19 +59,21 @@ func NewMangadex() *mangadex {
 				UpdatedAt: time.Date(2025, time.January, 07, 18, 0, 0, 0, time.UTC),
 				NSFW:      false,
 				SearchFilters: source_types.SupportedFilters{
-					Query:   true,
-					Artists: false,
-					Authors: false,
-					Orders:  GetSearchableOrders(),
-					Sorts:   GetSearchableSorts(),
-					Types:   []source_types.SourceSerieType{},
-					Status:  GetSearchableStatus(),
+					Query:          true,
+					Artists:        true,
+					Authors:        true,
+					Orders:         GetSearchableOrders(),
+					Sorts:          GetSearchableSorts(),
+					Types:          []source_types.SourceSerieType{},
+					Status:         GetSearchableStatus(),
+					ContentRatings: GetSearchableContentRatings(),
 					Genres: source_types.SupportedFiltersGenres{
 						Included:       true,
 						Excluded:       true,
 						PossibleValues: GetSearchableGenres(),
 					},
 				},
+				ImageQualities: []source_types.SourceImageQuality{source_types.QUALITY_ORIGINAL, source_types.QUALITY_DATA_SAVER},
 			},
 			SourceAPIInformation: source_types.SourceAPIInformation{
 				APIURL:                &url.URL{Scheme: "https", Host: "api.mangadex.org"},
@@ -71,6 +83,13 @@ func NewMangadex() *mangadex {
 					"User-Agent": []string{"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:71.0) Gecko/20100101 Firefox/77.0"},
 				},
 				CanBlockScraping: true,
+				ImageHosts:       []string{"*.mangadex.network", "uploads.mangadex.org"},
+				ImageQuality:     source_types.QUALITY_ORIGINAL,
+				ContentRatings: []source_types.SourceContentRating{
+					source_types.CONTENT_RATING_SAFE,
+					source_types.CONTENT_RATING_SUGGESTIVE,
+					source_types.CONTENT_RATING_EROTICA,
+				},
 			},
 		},
 	}
@@ -92,7 +111,35 @@ func (m *mangadex) FetchLatestUpdates(context context.Context, page int) (source
 	return m.FetchSearchSerie(context, page, source_types.FetchSearchSerieFilter{Sort: source_types.LATEST, Order: source_types.DESC})
 }
 
+// FetchSearchSerie resolves the names of the authors and artists first, a name matching no one matches no serie
 func (m *mangadex) FetchSearchSerie(context context.Context, page int, filter source_types.FetchSearchSerieFilter) (source_types.SourcePaginatedSmallSerie, error) {
+	authors, ok, err := m.resolveAuthors(context, filter.Authors)
+	if err != nil {
+		return source_types.SourcePaginatedSmallSerie{}, errors.Join(err, fmt.Errorf("failed to resolve authors"))
+	}
+	if !ok {
+		return source_types.SourcePaginatedSmallSerie{Series: []source_types.SourceSmallSerie{}}, nil
+	}
+
+	artists, ok, err := m.resolveAuthors(context, filter.Artists)
+	if err != nil {
+		return source_types.SourcePaginatedSmallSerie{}, errors.Join(err, fmt.Errorf("failed to resolve artists"))
+	}
+	if !ok {
+		return source_types.SourcePaginatedSmallSerie{Series: []source_types.SourceSmallSerie{}}, nil
+	}
+
+	return m.fetchSearchSerie(context, page, filter, searchAuthors{authors: authors, artists: artists})
+}
+
+// searchAuthors are the IDs of the authors and artists of a search
+type searchAuthors struct {
+	authors        []string
+	artists        []string
+	authorOrArtist string
+}
+
+func (m *mangadex) fetchSearchSerie(context context.Context, page int, filter source_types.FetchSearchSerieFilter, people searchAuthors) (source_types.SourcePaginatedSmallSerie, error) {
 	url := m.Source.SourceAPIInformation.APIURL.JoinPath("manga")
 
 	limit := 20
@@ -103,16 +150,17 @@ func (m *mangadex) FetchSearchSerie(context context.Context, page int, filter so
 	q.Set("offset", strconv.Itoa(offset))
 	q.Set("includedTagsMode", "AND")
 	q.Set("excludedTagsMode", "OR")
-	q.Add("contentRating[]", "safe")
-	q.Add("contentRating[]", "suggestive")
-	q.Add("contentRating[]", "erotica")
 
-	for _, sourcelang := range m.Source.SourceInformation.Languages {
-		lang, err := ConvertSourceSerieLanguage(sourcelang)
-		if err != nil {
-			continue
-		}
+	ratings, err := m.contentRatings(context, filter.ContentRatings)
+	if err != nil {
+		return source_types.SourcePaginatedSmallSerie{}, err
+	}
+
+	for _, rating := range ratings {
+		q.Add("contentRating[]", string(rating))
+	}
 
+	for _, lang := range m.languages() {
 		q.Add("availableTranslatedLanguage[]", string(lang))
 	}
 
@@ -169,19 +217,17 @@ func (m *mangadex) FetchSearchSerie(context context.Context, page int, filter so
 		}
 	}
 
-	// Need to fetch authors and artists first
+	for _, author := range people.authors {
+		q.Add("authors[]", author)
+	}
 
-	// if filter.Artists != nil {
-	// 	for _, artist := range filter.Artists {
-	// 		q.Add("authorOrArtist", artist)
-	// 	}
-	// }
+	for _, artist := range people.artists {
+		q.Add("artists[]", artist)
+	}
 
-	// if filter.Authors != nil {
-	// 	for _, author := range filter.Authors {
-	// 		q.Add("authorOrArtist", author)
-	// 	}
-	// }
+	if people.authorOrArtist != "" {
+		q.Set("authorOrArtist", people.authorOrArtist)
+	}
 
 	url.RawQuery = q.Encode()
 
@@ -190,6 +236,8 @@ func (m *mangadex) FetchSearchSerie(context context.Context, page int, filter so
 		return source_types.SourcePaginatedSmallSerie{}, errors.Join(source_types.ErrBuildingRequest, err, fmt.Errorf("failed to build request: %s", url.String()))
 	}
 
+	req.Header = m.SourceAPIInformation.Headers.Clone()
+
 	m.logger.Info("Fetching search serie", "url", url.String())
 
 	resp, err := m.httpClient.Do(req)
@@ -239,6 +287,70 @@ func (m *mangadex) ParseFetchSearchSerie(html io.Reader) (source_types.SourcePag
 }
 
 func (m *mangadex) FetchSerieDetail(context context.Context, serieID source_types.SourceSerieID) (source_types.SourceSerie, error) {
+	serie, err := m.fetchSerieMetadata(context, serieID)
+	if err != nil {
+		return source_types.SourceSerie{}, err
+	}
+
+	langs := m.languages()
+
+	var chapters []serieDetailChapterDetailResponse
+	offset := 0
+
+	// TODO(AzSiAz): Add some point there will be a need add a check to see if the chapter is already in the list, since the total could change if another chapter is added
+	for {
+		parsedChapters, total, err := m.fetchSerieFeed(context, serieID, langs, offset, time.Time{})
+		if err != nil {
+			return source_types.SourceSerie{}, err
+		}
+
+		chapters = append(chapters, parsedChapters...)
+
+		offset += feedLimit
+		if offset >= total {
+			break
+		}
+	}
+
+	serie.Volumes = m.convertMangadexChapters(chapters)
+
+	return serie, nil
+}
+
+// FetchSerieMetadata only fetches the manga, without its feed
+func (m *mangadex) FetchSerieMetadata(context context.Context, serieID source_types.SourceSerieID) (source_types.SourceSerie, error) {
+	serie, err := m.fetchSerieMetadata(context, serieID)
+	if err != nil {
+		return source_types.SourceSerie{}, err
+	}
+
+	serie.Volumes = []source_types.SourceSerieVolume{}
+
+	return serie, nil
+}
+
+// FetchSerieChapters fetches a single page of the feed in the languages of the source, the since date filters on the chapter creation
+func (m *mangadex) FetchSerieChapters(context context.Context, serieID source_types.SourceSerieID, filter source_types.FetchSerieChaptersFilter) (source_types.SourcePaginatedSerieVolumes, error) {
+	page := max(filter.Page, 1)
+	offset := (page - 1) * feedLimit
+
+	chapters, total, err := m.fetchSerieFeed(context, serieID, m.languages(), offset, filter.Since)
+	if err != nil {
+		return source_types.SourcePaginatedSerieVolumes{}, err
+	}
+
+	volumes := m.convertMangadexChapters(chapters)
+	if volumes == nil {
+		volumes = []source_types.SourceSerieVolume{}
+	}
+
+	return source_types.SourcePaginatedSerieVolumes{
+		HasNextPage: offset+feedLimit < total,
+		Volumes:     volumes,
+	}, nil
+}
+
+func (m *mangadex) fetchSerieMetadata(context context.Context, serieID source_types.SourceSerieID) (source_types.SourceSerie, error) {
 	serieURL := m.Source.SourceAPIInformation.APIURL.JoinPath("manga", string(serieID))
 
 	q := serieURL.Query()
@@ -252,6 +364,8 @@ func (m *mangadex) FetchSerieDetail(context context.Context, serieID source_type
 		return source_types.SourceSerie{}, errors.Join(source_types.ErrBuildingRequest, err, fmt.Errorf("failed to build request: %s", serieURL.String()))
 	}
 
+	req.Header = m.SourceAPIInformation.Headers.Clone()
+
 	m.logger.Info("Fetching serie detail", "url", serieURL.String())
 
 	resp, err := m.httpClient.Do(req)
@@ -262,98 +376,91 @@ func (m *mangadex) FetchSerieDetail(context context.Context, serieID source_type
 
 	m.logger.Debug("Fetched serie detail", "status", resp.Status, "header", resp.Header)
 
-	serie, lang, err := m.ParseFetchSerieDetail(resp.Body)
+	serie, err := m.ParseFetchSerieDetail(resp.Body)
 	if err != nil {
 		return source_types.SourceSerie{}, errors.Join(source_types.ErrExtractingData, err, fmt.Errorf("failed to extract data from response"))
 	}
 
-	var chapters []serieDetailChapterDetailResponse
-	offset := 0
-	limit := 500
-	continueFetching := true
-
-	volumesURL := m.Source.SourceAPIInformation.APIURL.JoinPath("manga", string(serieID), "feed")
-	q = volumesURL.Query()
-	q.Set("order[volume]", "desc")
-	q.Set("order[chapter]", "desc")
-	q.Set("limit", strconv.Itoa(limit))
-	for _, lang := range lang {
-		q.Add("translatedLanguage[]", string(lang))
-	}
-	volumesURL.RawQuery = q.Encode()
-
-	// TODO(AzSiAz): Add some point there will be a need add a check to see if the chapter is already in the list, since the total could change if another chapter is added
-	for {
-		q := volumesURL.Query()
-		q.Set("offset", strconv.Itoa(offset))
-		volumesURL.RawQuery = q.Encode()
+	return serie, nil
+}
 
-		req, err := http.NewRequestWithContext(context, http.MethodGet, volumesURL.String(), nil)
+// languages are the languages of the source in the MangaDex format, the search and the feed both use them
+// so the chapters of a serie are the same whether they are fetched at once or page by page
+func (m *mangadex) languages() []MangadexLanguage {
+	var langs []MangadexLanguage
+	for _, sourcelang := range m.Source.SourceInformation.Languages {
+		lang, err := ConvertSourceSerieLanguage(sourcelang)
 		if err != nil {
-			return source_types.SourceSerie{}, errors.Join(source_types.ErrBuildingRequest, err, fmt.Errorf("failed to build request: %s", volumesURL.String()))
+			continue
 		}
 
-		m.logger.Info("Fetching serie volumes", "url", volumesURL.String())
+		langs = append(langs, lang)
+	}
 
-		resp, err := m.httpClient.Do(req)
-		if err != nil {
-			return source_types.SourceSerie{}, errors.Join(source_types.ErrHTTPRequestFailed, err, fmt.Errorf("failed to fetch data: %s", volumesURL.String()))
-		}
-		defer resp.Body.Close()
+	return langs
+}
 
-		if resp.StatusCode != http.StatusOK {
-			break
-		}
+// fetchSerieFeed fetches feedLimit chapters starting at offset, and returns the total number of chapters
+func (m *mangadex) fetchSerieFeed(context context.Context, serieID source_types.SourceSerieID, langs []MangadexLanguage, offset int, since time.Time) ([]serieDetailChapterDetailResponse, int, error) {
+	feedURL := m.Source.SourceAPIInformation.APIURL.JoinPath("manga", string(serieID), "feed")
 
-		m.logger.Debug("Fetched serie volumes", "status", resp.Status, "header", resp.Header)
+	q := feedURL.Query()
+	q.Set("order[volume]", "desc")
+	q.Set("order[chapter]", "desc")
+	q.Set("limit", strconv.Itoa(feedLimit))
+	q.Set("offset", strconv.Itoa(offset))
+	q.Add("includes[]", "scanlation_group")
+	for _, lang := range langs {
+		q.Add("translatedLanguage[]", string(lang))
+	}
+	if !since.IsZero() {
+		q.Set("createdAtSince", since.UTC().Format(feedDateFormat))
+	}
+	feedURL.RawQuery = q.Encode()
 
-		parsedChapters, total, err := m.ParseFetchSerieDetailVolume(resp.Body)
-		if err != nil {
-			return source_types.SourceSerie{}, errors.Join(source_types.ErrExtractingData, err, fmt.Errorf("failed to extract data from response"))
-		}
+	req, err := http.NewRequestWithContext(context, http.MethodGet, feedURL.String(), nil)
+	if err != nil {
+		return nil, 0, errors.Join(source_types.ErrBuildingRequest, err, fmt.Errorf("failed to build request: %s", feedURL.String()))
+	}
 
-		chapters = append(chapters, parsedChapters...)
+	req.Header = m.SourceAPIInformation.Headers.Clone()
 
-		continueFetching = limit+offset < total
-		offset += limit
+	m.logger.Info("Fetching serie volumes", "url", feedURL.String())
 
-		if !continueFetching {
-			break
-		}
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, errors.Join(source_types.ErrHTTPRequestFailed, err, fmt.Errorf("failed to fetch data: %s", feedURL.String()))
+	}
+	defer resp.Body.Close()
 
-		time.Sleep(500 * time.Millisecond)
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, errors.Join(source_types.ErrHTTPRequestFailed, fmt.Errorf("unexpected status %s: %s", resp.Status, feedURL.String()))
 	}
 
-	serie.Volumes = m.convertMangadexChapters(chapters)
+	m.logger.Debug("Fetched serie volumes", "status", resp.Status, "header", resp.Header)
 
-	return serie, nil
+	chapters, total, err := m.ParseFetchSerieDetailVolume(resp.Body)
+	if err != nil {
+		return nil, 0, errors.Join(source_types.ErrExtractingData, err, fmt.Errorf("failed to extract data from response"))
+	}
+
+	return chapters, total, nil
 }
 
-func (m *mangadex) ParseFetchSerieDetail(html io.Reader) (source_types.SourceSerie, []MangadexLanguage, error) {
+func (m *mangadex) ParseFetchSerieDetail(html io.Reader) (source_types.SourceSerie, error) {
 	data, err := io.ReadAll(html)
 	if err != nil {
-		return source_types.SourceSerie{}, nil, errors.Join(source_types.ErrParsingHTML, err, fmt.Errorf("failed to read response"))
+		return source_types.SourceSerie{}, errors.Join(source_types.ErrParsingHTML, err, fmt.Errorf("failed to read response"))
 	}
 
 	var sdr serieDetailResponse
 	err = json.Unmarshal(data, &sdr)
 	if err != nil {
-		return source_types.SourceSerie{}, nil, errors.Join(source_types.ErrParsingJSON, err, fmt.Errorf("failed to parse response"))
+		return source_types.SourceSerie{}, errors.Join(source_types.ErrParsingJSON, err, fmt.Errorf("failed to parse response"))
 	}
 
 	if sdr.Result != "ok" {
-		return source_types.SourceSerie{}, nil, errors.Join(source_types.ErrHTTPRequestFailed, fmt.Errorf("response not ok"))
-	}
-
-	// Only use supported languages to fetch the chapters
-	var langs []MangadexLanguage
-	for _, lang := range sdr.Data.Attributes.AvailableTranslatedLanguages {
-		pl, err := NewMangadexLanguage(lang)
-		if err != nil {
-			continue
-		}
-
-		langs = append(langs, pl)
+		return source_types.SourceSerie{}, errors.Join(source_types.ErrHTTPRequestFailed, fmt.Errorf("response not ok"))
 	}
 
 	id := source_types.SourceSerieID(sdr.Data.ID)
@@ -370,6 +477,11 @@ func (m *mangadex) ParseFetchSerieDetail(html io.Reader) (source_types.SourceSer
 	}
 
 	genres := m.getGenres(sdr.Data.Attributes.Tags)
+	links := sdr.Data.Attributes.Links
+
+	// Both are empty or a number, the serie is not finished when they are empty
+	finalChapter, _ := strconv.ParseFloat(sdr.Data.Attributes.LastChapter, 64)
+	finalVolume, _ := strconv.ParseFloat(sdr.Data.Attributes.LastVolume, 64)
 
 	return source_types.SourceSerie{
 		ID:                id,
@@ -382,7 +494,26 @@ func (m *mangadex) ParseFetchSerieDetail(html io.Reader) (source_types.SourceSer
 		Genres:            genres,
 		Authors:           m.getAuthors(sdr.Data.Relationships),
 		Artists:           m.getArtists(sdr.Data.Relationships),
-	}, langs, nil
+		ExternalLinks: source_types.SourceSerieExternalLinks{
+			AniList:         links.Al,
+			MyAnimeList:     links.Mal,
+			AnimePlanet:     links.Ap,
+			Kitsu:           links.Kt,
+			MangaUpdates:    links.Mu,
+			NovelUpdates:    links.Nu,
+			BookWalker:      links.Bw,
+			Amazon:          links.Amz,
+			EBookJapan:      links.Ebj,
+			Raw:             links.Raw,
+			OfficialEnglish: links.Engtl,
+		},
+		Year:             sdr.Data.Attributes.Year,
+		Demographic:      source_types.NewSourceSerieDemographic(sdr.Data.Attributes.PublicationDemographic),
+		ContentRating:    MANGADEX_TO_SOURCE_CONTENT_RATING[MangadexContentRating(sdr.Data.Attributes.ContentRating)],
+		OriginalLanguage: m.getOriginalLanguage(sdr.Data.Attributes.OriginalLanguage),
+		FinalChapter:     finalChapter,
+		FinalVolume:      finalVolume,
+	}, nil
 }
 
 func (m *mangadex) ParseFetchSerieDetailVolume(html io.Reader) ([]serieDetailChapterDetailResponse, int, error) {
@@ -407,6 +538,15 @@ func (m *mangadex) ParseFetchSerieDetailVolume(html io.Reader) ([]serieDetailCha
 }
 
 func (m *mangadex) FetchChapterData(context context.Context, serieID source_types.SourceSerieID, volumeID source_types.SourceSerieVolumeID, chapterID source_types.SourceSerieVolumeChapterID) (source_types.SourceSerieVolumeChapterData, error) {
+	return m.FetchChapterDataQuality(context, serieID, volumeID, chapterID, m.SourceAPIInformation.ImageQuality)
+}
+
+// FetchChapterDataQuality returns the original pages or the compressed pages of the data saver
+func (m *mangadex) FetchChapterDataQuality(context context.Context, serieID source_types.SourceSerieID, volumeID source_types.SourceSerieVolumeID, chapterID source_types.SourceSerieVolumeChapterID, quality source_types.SourceImageQuality) (source_types.SourceSerieVolumeChapterData, error) {
+	if !slices.Contains(m.SourceInformation.ImageQualities, quality) {
+		return source_types.SourceSerieVolumeChapterData{}, errors.Join(source_types.ErrInvalidImageQuality, fmt.Errorf("quality %q is not supported", quality))
+	}
+
 	url := m.Source.SourceAPIInformation.APIURL.JoinPath("at-home/server", string(chapterID))
 
 	q := url.Query()
@@ -418,7 +558,9 @@ func (m *mangadex) FetchChapterData(context context.Context, serieID source_type
 		return source_types.SourceSerieVolumeChapterData{}, errors.Join(source_types.ErrBuildingRequest, err, fmt.Errorf("failed to build request: %s", url.String()))
 	}
 
-	m.logger.Info("Fetching chapter data", "url", url.String())
+	req.Header = m.SourceAPIInformation.Headers.Clone()
+
+	m.logger.Info("Fetching chapter data", "url", url.String(), "quality", quality)
 
 	resp, err := m.httpClient.Do(req)
 	if err != nil {
@@ -428,10 +570,10 @@ func (m *mangadex) FetchChapterData(context context.Context, serieID source_type
 
 	m.logger.Debug("Fetched chapter data", "status", resp.Status, "header", resp.Header)
 
-	return m.ParseFetchChapterData(resp.Body)
+	return m.ParseFetchChapterData(resp.Body, quality)
 }
 
-func (m *mangadex) ParseFetchChapterData(html io.Reader) (source_types.SourceSerieVolumeChapterData, error) {
+func (m *mangadex) ParseFetchChapterData(html io.Reader, quality source_types.SourceImageQuality) (source_types.SourceSerieVolumeChapterData, error) {
 	raw, err := io.ReadAll(html)
 	if err != nil {
 		return source_types.SourceSerieVolumeChapterData{}, errors.Join(source_types.ErrParsingHTML, err, fmt.Errorf("failed to read response"))
@@ -449,17 +591,30 @@ func (m *mangadex) ParseFetchChapterData(html io.Reader) (source_types.SourceSer
 		return source_types.SourceSerieVolumeChapterData{}, errors.Join(source_types.ErrParsingURL, err, fmt.Errorf("failed to parse base url"))
 	}
 
-	images := make([]source_types.SourceSerieVolumeChapterImage, len(data.Chapter.Data))
-	for i, img := range data.Chapter.Data {
+	// The data saver serves compressed copies of the pages under their own file names
+	files, dir := data.Chapter.Data, "data"
+	switch quality {
+	case source_types.QUALITY_ORIGINAL:
+	case source_types.QUALITY_DATA_SAVER:
+		files, dir = data.Chapter.DataSaver, "data-saver"
+	default:
+		return source_types.SourceSerieVolumeChapterData{}, errors.Join(source_types.ErrInvalidImageQuality, fmt.Errorf("quality %q is not supported", quality))
+	}
+
+	images := make([]source_types.SourceSerieVolumeChapterImage, len(files))
+	for i, img := range files {
 		images[i] = source_types.SourceSerieVolumeChapterImage{
-			Index: i + 1,
-			URL:   baseUrl.JoinPath("data", hash, img).String(),
+			ID:       source_types.SourceSerieVolumeChapterImageID(img),
+			Index:    i + 1,
+			URL:      baseUrl.JoinPath(dir, hash, img).String(),
+			MimeType: mime.TypeByExtension(path.Ext(img)),
 		}
 	}
 
 	return source_types.SourceSerieVolumeChapterData{
-		Type:   source_types.IMAGE,
-		Images: images,
+		Type:    source_types.IMAGE,
+		Quality: quality,
+		Images:  images,
 	}, nil
 }
 
@@ -472,6 +627,86 @@ func (m *mangadex) SerieUrl(serieID source_types.SourceSerieID) (*url.URL, error
 	return url.JoinPath("title", string(serieID)), nil
 }
 
+// ChapterUrl returns the page of the chapter in the MangaDex reader
+func (m *mangadex) ChapterUrl(serieID source_types.SourceSerieID, volumeID source_types.SourceSerieVolumeID, chapterID source_types.SourceSerieVolumeChapterID) (*url.URL, error) {
+	url, err := url.Parse("https://mangadex.org")
+	if err != nil {
+		return nil, errors.Join(source_types.ErrBuildingURL, err, fmt.Errorf("failed to build URL: %s", m.SourceInformation.URL))
+	}
+
+	return url.JoinPath("chapter", string(chapterID)), nil
+}
+
+func (m *mangadex) FetchRandomSerie(context context.Context) (source_types.SourceSmallSerie, error) {
+	randomURL := m.Source.SourceAPIInformation.APIURL.JoinPath("manga", "random")
+
+	q := randomURL.Query()
+	q.Add("includes[]", "cover_art")
+
+	ratings, err := m.contentRatings(context, nil)
+	if err != nil {
+		return source_types.SourceSmallSerie{}, err
+	}
+
+	for _, rating := range ratings {
+		q.Add("contentRating[]", string(rating))
+	}
+
+	randomURL.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(context, http.MethodGet, randomURL.String(), nil)
+	if err != nil {
+		return source_types.SourceSmallSerie{}, errors.Join(source_types.ErrBuildingRequest, err, fmt.Errorf("failed to build request: %s", randomURL.String()))
+	}
+
+	req.Header = m.SourceAPIInformation.Headers.Clone()
+
+	m.logger.Info("Fetching random serie", "url", randomURL.String())
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return source_types.SourceSmallSerie{}, errors.Join(source_types.ErrHTTPRequestFailed, err, fmt.Errorf("failed to fetch data: %s", randomURL.String()))
+	}
+	defer resp.Body.Close()
+
+	m.logger.Debug("Fetched random serie", "status", resp.Status, "header", resp.Header)
+
+	// The random manga has the shape of a manga detail
+	serie, err := m.ParseFetchSerieDetail(resp.Body)
+	if err != nil {
+		return source_types.SourceSmallSerie{}, errors.Join(source_types.ErrExtractingData, err, fmt.Errorf("failed to extract data from response"))
+	}
+
+	return source_types.SourceSmallSerie{
+		ID:    serie.ID,
+		Title: serie.Title,
+		Cover: serie.Cover,
+	}, nil
+}
+
+// contentRatings converts the ratings of the filter, falls back on the ratings of the context then on the default ones of the source
+func (m *mangadex) contentRatings(context context.Context, ratings []source_types.SourceContentRating) ([]MangadexContentRating, error) {
+	if len(ratings) == 0 {
+		ratings = source_types.ContentRatingsFromContext(context)
+	}
+
+	if len(ratings) == 0 {
+		ratings = m.SourceAPIInformation.ContentRatings
+	}
+
+	mangadexRatings := make([]MangadexContentRating, 0, len(ratings))
+	for _, rating := range ratings {
+		mangadexRating, err := ConvertSourceContentRating(rating)
+		if err != nil {
+			return nil, errors.Join(source_types.ErrInvalidSearchQuery, source_types.ErrInvalidContentRating, err)
+		}
+
+		mangadexRatings = append(mangadexRatings, mangadexRating)
+	}
+
+	return mangadexRatings, nil
+}
+
 func (m *mangadex) getSerieTitle(title langField) source_types.MultiLanguageString {
 	return source_types.MultiLanguageString{
 		EN:    title.En,
@@ -585,6 +820,33 @@ func (m *mangadex) getArtists(relationship []serieDetailRelationship) []string {
 	return artists
 }
 
+// getOriginalLanguage returns an empty language when it is not a SourceLanguage
+func (m *mangadex) getOriginalLanguage(originalLang string) source_types.SourceLanguage {
+	lang, err := NewMangadexLanguage(originalLang)
+	if err != nil {
+		return ""
+	}
+
+	sourceLang, err := ConvertMangadexLanguage(lang)
+	if err != nil {
+		return ""
+	}
+
+	return sourceLang
+}
+
+func (m *mangadex) getGroups(relationship []serieDetailRelationship) []source_types.SourceScanlationGroup {
+	var groups []source_types.SourceScanlationGroup
+
+	for _, r := range relationship {
+		if r.Type == "scanlation_group" {
+			groups = append(groups, source_types.SourceScanlationGroup{ID: r.ID, Name: r.Attributes.Name})
+		}
+	}
+
+	return groups
+}
+
 func (m *mangadex) getType(originalLang string, genres []source_types.SourceSerieGenre) source_types.SourceSerieType {
 	isLongStrip := false
 	isDoujinshi := false
@@ -669,6 +931,7 @@ func (m *mangadex) convertMangadexChapters(chapters []serieDetailChapterDetailRe
 			Language:      chapterLang,
 			DateUpload:    c.Attributes.CreatedAt,
 			ExternalURL:   c.Attributes.ExternalURL,
+			Groups:        m.getGroups(c.Relationships),
 		})
 	}
 
@@ -0,0 +1,287 @@
+package mangadex_test
+
+import (
+	"context"
+	"dokusho/pkg/sources/scrapers/mangadex"
+	"dokusho/pkg/sources/source_types"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+var source = mangadex.NewMangadex()
+
+func TestMangadexParseFetchSerieDetail(t *testing.T) {
+	t.Parallel()
+
+	serie, err := source.ParseFetchSerieDetail(strings.NewReader(serieHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if serie.Title.EN == "" {
+		t.Error("Serie title should not be empty")
+	}
+
+	if serie.ExternalLinks.AniList != "105398" {
+		t.Errorf("AniList link should be 105398, got %q", serie.ExternalLinks.AniList)
+	}
+
+	if serie.ExternalLinks.MyAnimeList != "121496" {
+		t.Errorf("MyAnimeList link should be 121496, got %q", serie.ExternalLinks.MyAnimeList)
+	}
+
+	expectedLinks := source_types.SourceSerieExternalLinks{
+		AniList:         "105398",
+		MyAnimeList:     "121496",
+		AnimePlanet:     "solo-leveling",
+		Kitsu:           "54114",
+		MangaUpdates:    "151349",
+		NovelUpdates:    "i-alone-level-up",
+		BookWalker:      "series/248341/list",
+		Amazon:          "https://www.amazon.co.jp/dp/B08LT33JHT",
+		EBookJapan:      "https://ebookjapan.yahoo.co.jp/books/584614/",
+		Raw:             "https://page.kakao.com/home?seriesId=50866481",
+		OfficialEnglish: "https://www.tappytoon.com/en/comics/solo-leveling-official",
+	}
+	if serie.ExternalLinks != expectedLinks {
+		t.Errorf("expected links %+v, got %+v", expectedLinks, serie.ExternalLinks)
+	}
+
+	if serie.Year != 2018 || serie.ContentRating != source_types.CONTENT_RATING_SAFE || serie.OriginalLanguage != source_types.KO || serie.Demographic != source_types.DEMOGRAPHIC_UNKNOWN {
+		t.Errorf("unexpected metadata: year %d, content rating %q, original language %q, demographic %q", serie.Year, serie.ContentRating, serie.OriginalLanguage, serie.Demographic)
+	}
+
+	if serie.FinalChapter != 200 || serie.FinalVolume != 3 {
+		t.Errorf("expected final chapter 200 of volume 3, got %v of %v", serie.FinalChapter, serie.FinalVolume)
+	}
+}
+
+func TestMangadexParseFetchSerieDetailVolume(t *testing.T) {
+	t.Parallel()
+
+	chapters, total, err := source.ParseFetchSerieDetailVolume(strings.NewReader(serieVolume))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != 2318 || len(chapters) != 100 {
+		t.Fatalf("expected 100 of 2318 chapters, got %d of %d", len(chapters), total)
+	}
+
+	groups := 0
+	for _, r := range chapters[0].Relationships {
+		if r.Type == "scanlation_group" {
+			groups++
+			if r.ID != "dafe60d9-e06a-4ed8-8e97-3937efe6a118" {
+				t.Errorf("unexpected scanlation group %q", r.ID)
+			}
+		}
+	}
+	if groups != 1 {
+		t.Errorf("expected 1 scanlation group, got %d", groups)
+	}
+}
+
+func TestMangadexParseFetchChapterData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		quality  source_types.SourceImageQuality
+		expected source_types.SourceSerieVolumeChapterImage
+		err      error
+	}{
+		{
+			name:    "original",
+			quality: source_types.QUALITY_ORIGINAL,
+			expected: source_types.SourceSerieVolumeChapterImage{
+				ID:       "1-433e18916aaed6d80b6e9055bfbffafa19acba1a1ae44fd8afa09662c497ac27.jpg",
+				Index:    1,
+				URL:      "https://cmdxd98sb0x3yprd.mangadex.network/data/110ba656bc89ee7dbbc2e6e66b2a3614/1-433e18916aaed6d80b6e9055bfbffafa19acba1a1ae44fd8afa09662c497ac27.jpg",
+				MimeType: "image/jpeg",
+			},
+		},
+		{
+			name:    "data saver",
+			quality: source_types.QUALITY_DATA_SAVER,
+			expected: source_types.SourceSerieVolumeChapterImage{
+				ID:       "1-9d026858ba9e5dd4c6ac37d9527202051aa0d35e83813b82142b76555448bbf9.jpg",
+				Index:    1,
+				URL:      "https://cmdxd98sb0x3yprd.mangadex.network/data-saver/110ba656bc89ee7dbbc2e6e66b2a3614/1-9d026858ba9e5dd4c6ac37d9527202051aa0d35e83813b82142b76555448bbf9.jpg",
+				MimeType: "image/jpeg",
+			},
+		},
+		{
+			name:    "unknown quality",
+			quality: "low",
+			err:     source_types.ErrInvalidImageQuality,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := source.ParseFetchChapterData(strings.NewReader(chapterImagesJSON), tc.quality)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+			if tc.err != nil {
+				return
+			}
+
+			if data.Quality != tc.quality || len(data.Images) != 89 {
+				t.Fatalf("expected 89 images in %s, got %d in %s", tc.quality, len(data.Images), data.Quality)
+			}
+
+			if data.Images[0] != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, data.Images[0])
+			}
+		})
+	}
+}
+
+func TestMangadexParseResolveAuthor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		author   string
+		expected string
+		err      error
+	}{
+		{name: "exact match", author: "Fujimoto Tatsuki", expected: "f2b2e1a0-6a4b-4d2f-9c55-3f1f0b9d2a10"},
+		{name: "exact match ignoring case", author: " nohda tatsuki ", expected: "c4d5e6f7-0819-4a2b-8c3d-4e5f60718293"},
+		{name: "homonyms", author: "Tatsuki", err: mangadex.ErrAmbiguousAuthor},
+		{name: "single partial match", author: "Nohda", err: mangadex.ErrAmbiguousAuthor},
+		{name: "ambiguous partial match", author: "Tatsu", err: mangadex.ErrAmbiguousAuthor},
+		{name: "unknown author", author: "Oda Eiichiro", err: mangadex.ErrUnknownAuthor},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			id, err := source.ParseResolveAuthor(strings.NewReader(authorList), tc.author)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected %v, got %v", tc.err, err)
+			}
+
+			if id != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, id)
+			}
+		})
+	}
+}
+
+func TestMangadexResolveAuthorCache(t *testing.T) {
+	t.Parallel()
+
+	var authorRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/author":
+			authorRequests.Add(1)
+			w.Write([]byte(authorList))
+		case "/manga":
+			w.Write([]byte(searchSerie))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	src := mangadex.NewMangadex()
+	apiURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.SourceAPIInformation.APIURL = apiURL
+
+	// Known and unknown names are both fetched once
+	for _, author := range []string{"Fujimoto Tatsuki", "fujimoto tatsuki", "Oda Eiichiro", "Oda Eiichiro"} {
+		series, err := src.FetchAuthorSeries(context.Background(), author, 1)
+		if err != nil {
+			t.Fatalf("%s: %v", author, err)
+		}
+
+		if strings.HasPrefix(author, "Oda") && len(series.Series) != 0 {
+			t.Errorf("expected no serie for an unknown author, got %d", len(series.Series))
+		}
+	}
+
+	if authorRequests.Load() != 2 {
+		t.Errorf("expected 2 author requests, got %d", authorRequests.Load())
+	}
+
+	// Ambiguous names are not cached, the user has to pick an author
+	for range 2 {
+		_, err := src.FetchAuthorSeries(context.Background(), "Tatsuki", 1)
+		if !errors.Is(err, mangadex.ErrAmbiguousAuthor) {
+			t.Fatalf("expected %v, got %v", mangadex.ErrAmbiguousAuthor, err)
+		}
+	}
+
+	if authorRequests.Load() != 4 {
+		t.Errorf("expected 4 author requests, got %d", authorRequests.Load())
+	}
+}
+
+func TestMangadexFeedLanguages(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var feedLanguages [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/feed"):
+			mu.Lock()
+			feedLanguages = append(feedLanguages, r.URL.Query()["translatedLanguage[]"])
+			mu.Unlock()
+			w.Write([]byte(serieVolume))
+		case strings.HasPrefix(r.URL.Path, "/manga/"):
+			w.Write([]byte(serieHTML))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	src := mangadex.NewMangadex()
+	apiURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.SourceAPIInformation.APIURL = apiURL
+
+	_, err = src.FetchSerieDetail(context.Background(), "32d76d19-8a05-4db0-9fc2-e0b0648fe9d0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = src.FetchSerieChapters(context.Background(), "32d76d19-8a05-4db0-9fc2-e0b0648fe9d0", source_types.FetchSerieChaptersFilter{Page: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every page of the detail and the single page of the chapters
+	if len(feedLanguages) != 6 {
+		t.Fatalf("expected 6 feed requests, got %d", len(feedLanguages))
+	}
+
+	// The serie is translated in many more languages, only the ones of the source are fetched by both
+	expected := []string{"en", "fr", "ja", "ko", "zh", "zh-hk"}
+	for _, languages := range feedLanguages {
+		slices.Sort(languages)
+		if !slices.Equal(languages, expected) {
+			t.Errorf("expected the feed in %v, got %v", expected, languages)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package mangadex
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"dokusho/pkg/http_utils"
+)
+
+// Classes of endpoints with their own limit, on top of the global limit of every request
+const (
+	classAtHome = "at-home"
+	classRandom = "random"
+)
+
+// transport is shared by every client of the process, MangaDex limits the requests by IP and bans the ones going over.
+// The limits are the documented ones: 5 requests per second, 40 per minute for at-home servers and 60 per minute for random manga
+var transport = http_utils.NewRateLimitedTransport(
+	http.DefaultTransport,
+	http_utils.RateLimit{Requests: 5, Per: time.Second, Burst: 5},
+	map[string]http_utils.RateLimit{
+		classAtHome: {Requests: 40, Per: time.Minute, Burst: 10},
+		classRandom: {Requests: 60, Per: time.Minute, Burst: 10},
+	},
+	classifyRequest,
+)
+
+func classifyRequest(req *http.Request) string {
+	switch {
+	case strings.HasPrefix(req.URL.Path, "/at-home/"):
+		return classAtHome
+	case req.URL.Path == "/manga/random":
+		return classRandom
+	default:
+		return ""
+	}
+}
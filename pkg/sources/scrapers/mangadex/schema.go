@@ -86,7 +86,7 @@ type serieDetailResponse struct {
 			OriginalLanguage               string           `json:"originalLanguage"`
 			LastVolume                     string           `json:"lastVolume"`
 			LastChapter                    string           `json:"lastChapter"`
-			PublicationDemographic         any              `json:"publicationDemographic"`
+			PublicationDemographic         string           `json:"publicationDemographic"`
 			Status                         string           `json:"status"`
 			Year                           int              `json:"year"`
 			ContentRating                  string           `json:"contentRating"`
@@ -128,6 +128,7 @@ type serieDetailChapterDetailResponse struct {
 		Pages              int       `json:"pages"`
 		Version            int       `json:"version"`
 	} `json:"attributes"`
+	Relationships []serieDetailRelationship `json:"relationships"`
 }
 
 type chapterImagesResponse struct {
@@ -173,3 +174,24 @@ type searchResponse struct {
 	Offset int `json:"offset,omitempty"`
 	Total  int `json:"total,omitempty"`
 }
+
+type authorResponse struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Attributes struct {
+		Name string `json:"name"`
+	} `json:"attributes"`
+	Relationships []struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	} `json:"relationships"`
+}
+
+type authorListResponse struct {
+	Result   string           `json:"result"`
+	Response string           `json:"response"`
+	Data     []authorResponse `json:"data"`
+	Limit    int              `json:"limit"`
+	Offset   int              `json:"offset"`
+	Total    int              `json:"total"`
+}
@@ -292,6 +292,42 @@ func ConvertSourceSerieStatuses(statuses []source_types.SourceSerieStatus) ([]Ma
 	return mangadexStatuses, error
 }
 
+type MangadexContentRating string
+
+const (
+	SAFE         MangadexContentRating = "safe"
+	SUGGESTIVE   MangadexContentRating = "suggestive"
+	EROTICA      MangadexContentRating = "erotica"
+	PORNOGRAPHIC MangadexContentRating = "pornographic"
+)
+
+var MANGADEX_TO_SOURCE_CONTENT_RATING = map[MangadexContentRating]source_types.SourceContentRating{
+	SAFE:         source_types.CONTENT_RATING_SAFE,
+	SUGGESTIVE:   source_types.CONTENT_RATING_SUGGESTIVE,
+	EROTICA:      source_types.CONTENT_RATING_EROTICA,
+	PORNOGRAPHIC: source_types.CONTENT_RATING_PORNOGRAPHIC,
+}
+
+// GetSearchableContentRatings returns the ratings from the safest to the most explicit
+func GetSearchableContentRatings() []source_types.SourceContentRating {
+	return []source_types.SourceContentRating{
+		source_types.CONTENT_RATING_SAFE,
+		source_types.CONTENT_RATING_SUGGESTIVE,
+		source_types.CONTENT_RATING_EROTICA,
+		source_types.CONTENT_RATING_PORNOGRAPHIC,
+	}
+}
+
+func ConvertSourceContentRating(rating source_types.SourceContentRating) (MangadexContentRating, error) {
+	for k, v := range MANGADEX_TO_SOURCE_CONTENT_RATING {
+		if v == rating {
+			return k, nil
+		}
+	}
+
+	return "", fmt.Errorf("Content rating %s is not a valid Source content rating for Mangadex: %w", rating, ErrInvalidContentRating)
+}
+
 type MangadexSort string
 
 const (
@@ -0,0 +1,9 @@
+package mangaplus
+
+import "errors"
+
+var (
+	ErrInvalidResponse      = errors.New("invalid mangaplus response")
+	ErrAPIError             = errors.New("mangaplus returned an error")
+	ErrInvalidEncryptionKey = errors.New("invalid encryption key")
+)
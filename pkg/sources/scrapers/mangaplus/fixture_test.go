@@ -0,0 +1,23 @@
+package mangaplus_test
+
+import (
+	_ "embed"
+)
+
+//go:embed fixtures/ranking.bin
+var rankingProto []byte
+
+//go:embed fixtures/web_home.bin
+var webHomeProto []byte
+
+//go:embed fixtures/all_titles.bin
+var allTitlesProto []byte
+
+//go:embed fixtures/title_detail.bin
+var titleDetailProto []byte
+
+//go:embed fixtures/manga_viewer.bin
+var mangaViewerProto []byte
+
+//go:embed fixtures/error.bin
+var errorProto []byte
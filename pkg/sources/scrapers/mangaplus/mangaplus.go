@@ -0,0 +1,485 @@
+package mangaplus
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"dokusho/pkg/sources/chapterutils"
+	"dokusho/pkg/sources/source_types"
+)
+
+const (
+	pageSize = 20
+	// Upper bound of an API response, the list of every title is the biggest one
+	maxResponseSize = 16 << 20
+)
+
+type mangaPlus struct {
+	source_types.Source
+
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+func NewMangaPlus() *mangaPlus {
+	timeout := 10 * time.Second
+
+	client := &http.Client{Timeout: timeout}
+
+	return &mangaPlus{
+		httpClient: client,
+		logger:     slog.Default().WithGroup("mangaplus"),
+		Source: source_types.Source{
+			HTTPClient: client,
+			SourceInformation: source_types.SourceInformation{
+				ID:        "mangaplus",
+				Name:      "MANGA Plus",
+				URL:       "https://mangaplus.shueisha.co.jp",
+				Icon:      "https://mangaplus.shueisha.co.jp/favicon.ico",
+				Version:   "1.0.0",
+				Languages: []source_types.SourceLanguage{source_types.EN},
+				UpdatedAt: time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC),
+				NSFW:      false,
+				SearchFilters: source_types.SupportedFilters{
+					Query:   true,
+					Artists: false,
+					Authors: true,
+					Orders:  []source_types.FetchSearchSerieFilterOrder{},
+					Sorts:   []source_types.FetchSearchSerieFilterSort{},
+					Types:   []source_types.SourceSerieType{},
+					Status:  []source_types.SourceSerieStatus{},
+					Genres: source_types.SupportedFiltersGenres{
+						Included:       false,
+						Excluded:       false,
+						PossibleValues: []source_types.SourceSerieGenre{},
+					},
+				},
+			},
+			SourceAPIInformation: source_types.SourceAPIInformation{
+				APIURL:                &url.URL{Scheme: "https", Host: "jumpg-webapi.tokyo-cdn.com", Path: "/api"},
+				MinimumUpdateInterval: 5 * time.Minute,
+				Timeout:               timeout,
+				Headers: http.Header{
+					"User-Agent": []string{"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:71.0) Gecko/20100101 Firefox/77.0"},
+					"Origin":     []string{"https://mangaplus.shueisha.co.jp"},
+					"Referer":    []string{"https://mangaplus.shueisha.co.jp/"},
+				},
+				CanBlockScraping: false,
+				ImageHosts:       []string{"*.tokyo-cdn.com", "mangaplus.shueisha.co.jp"},
+			},
+		},
+	}
+}
+
+func (m *mangaPlus) GetInformation() source_types.SourceInformation {
+	return m.Source.SourceInformation
+}
+
+func (m *mangaPlus) GetAPIInformation() source_types.SourceAPIInformation {
+	return m.Source.SourceAPIInformation
+}
+
+// FetchPopularSerie returns the hottest titles, the ranking has a single page
+func (m *mangaPlus) FetchPopularSerie(ctx context.Context, page int) (source_types.SourcePaginatedSmallSerie, error) {
+	if page > 1 {
+		return source_types.SourcePaginatedSmallSerie{Series: []source_types.SourceSmallSerie{}}, nil
+	}
+
+	u := m.SourceAPIInformation.APIURL.JoinPath("title_list", "ranking")
+	q := languageQuery()
+	q.Set("type", "hottest")
+	u.RawQuery = q.Encode()
+
+	data, err := m.get(ctx, u)
+	if err != nil {
+		return source_types.SourcePaginatedSmallSerie{}, errors.Join(err, fmt.Errorf("failed to fetch popular titles"))
+	}
+
+	return m.ParseFetchPopularSerie(data)
+}
+
+func (m *mangaPlus) ParseFetchPopularSerie(data []byte) (source_types.SourcePaginatedSmallSerie, error) {
+	success, err := decodeSuccess(data)
+	if err != nil {
+		return source_types.SourcePaginatedSmallSerie{}, err
+	}
+
+	if success.titleRankingView == nil {
+		return source_types.SourcePaginatedSmallSerie{}, errors.Join(ErrInvalidResponse, fmt.Errorf("missing title ranking"))
+	}
+
+	return source_types.SourcePaginatedSmallSerie{Series: convertTitles(success.titleRankingView.titles)}, nil
+}
+
+// FetchLatestUpdates returns the titles updated recently, the home page has a single page
+func (m *mangaPlus) FetchLatestUpdates(ctx context.Context, page int) (source_types.SourcePaginatedSmallSerie, error) {
+	if page > 1 {
+		return source_types.SourcePaginatedSmallSerie{Series: []source_types.SourceSmallSerie{}}, nil
+	}
+
+	u := m.SourceAPIInformation.APIURL.JoinPath("web", "web_homeV3")
+	u.RawQuery = languageQuery().Encode()
+
+	data, err := m.get(ctx, u)
+	if err != nil {
+		return source_types.SourcePaginatedSmallSerie{}, errors.Join(err, fmt.Errorf("failed to fetch latest titles"))
+	}
+
+	return m.ParseFetchLatestUpdates(data)
+}
+
+func (m *mangaPlus) ParseFetchLatestUpdates(data []byte) (source_types.SourcePaginatedSmallSerie, error) {
+	success, err := decodeSuccess(data)
+	if err != nil {
+		return source_types.SourcePaginatedSmallSerie{}, err
+	}
+
+	if success.webHomeViewV3 == nil {
+		return source_types.SourcePaginatedSmallSerie{}, errors.Join(ErrInvalidResponse, fmt.Errorf("missing home view"))
+	}
+
+	titles := []title{}
+	seen := map[int]bool{}
+	for _, group := range success.webHomeViewV3.groups {
+		for _, titleGroup := range group.titleGroups {
+			for _, t := range titleGroup.titles {
+				if !seen[t.titleID] {
+					seen[t.titleID] = true
+					titles = append(titles, t)
+				}
+			}
+		}
+	}
+
+	return source_types.SourcePaginatedSmallSerie{Series: convertTitles(titles)}, nil
+}
+
+// FetchSearchSerie filters the list of every title, MangaPlus has no search endpoint
+func (m *mangaPlus) FetchSearchSerie(ctx context.Context, page int, filter source_types.FetchSearchSerieFilter) (source_types.SourcePaginatedSmallSerie, error) {
+	u := m.SourceAPIInformation.APIURL.JoinPath("title_list", "allV2")
+	u.RawQuery = languageQuery().Encode()
+
+	data, err := m.get(ctx, u)
+	if err != nil {
+		return source_types.SourcePaginatedSmallSerie{}, errors.Join(err, fmt.Errorf("failed to fetch every title"))
+	}
+
+	return m.ParseFetchSearchSerie(data, page, filter)
+}
+
+func (m *mangaPlus) ParseFetchSearchSerie(data []byte, page int, filter source_types.FetchSearchSerieFilter) (source_types.SourcePaginatedSmallSerie, error) {
+	success, err := decodeSuccess(data)
+	if err != nil {
+		return source_types.SourcePaginatedSmallSerie{}, err
+	}
+
+	if success.allTitlesViewV2 == nil {
+		return source_types.SourcePaginatedSmallSerie{}, errors.Join(ErrInvalidResponse, fmt.Errorf("missing title list"))
+	}
+
+	query := strings.ToLower(filter.Query)
+
+	titles := []title{}
+	for _, group := range success.allTitlesViewV2.groups {
+		for _, t := range group.titles {
+			if t.language != ENGLISH {
+				continue
+			}
+
+			if query != "" && !strings.Contains(strings.ToLower(group.theTitle), query) && !strings.Contains(strings.ToLower(t.name), query) {
+				continue
+			}
+
+			if !matchAuthors(t.author, filter.Authors) {
+				continue
+			}
+
+			titles = append(titles, t)
+		}
+	}
+
+	start := min(max(page-1, 0)*pageSize, len(titles))
+	end := min(start+pageSize, len(titles))
+
+	return source_types.SourcePaginatedSmallSerie{
+		HasNextPage: end < len(titles),
+		Series:      convertTitles(titles[start:end]),
+	}, nil
+}
+
+// matchAuthors reports whether every author is credited, the credits are a single "Writer / Artist" string
+func matchAuthors(credits string, authors []string) bool {
+	credits = strings.ToLower(credits)
+
+	for _, author := range authors {
+		author = strings.TrimSpace(author)
+		if author != "" && !strings.Contains(credits, strings.ToLower(author)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *mangaPlus) FetchSerieDetail(ctx context.Context, serieID source_types.SourceSerieID) (source_types.SourceSerie, error) {
+	if _, err := strconv.Atoi(string(serieID)); err != nil {
+		return source_types.SourceSerie{}, errors.Join(source_types.ErrInvalidSerieID, err, fmt.Errorf("serie id must be a number: %s", serieID))
+	}
+
+	u := m.SourceAPIInformation.APIURL.JoinPath("title_detailV3")
+	q := languageQuery()
+	q.Set("title_id", string(serieID))
+	u.RawQuery = q.Encode()
+
+	data, err := m.get(ctx, u)
+	if err != nil {
+		return source_types.SourceSerie{}, errors.Join(err, fmt.Errorf("failed to fetch serie %s", serieID))
+	}
+
+	return m.ParseFetchSerieDetail(data)
+}
+
+func (m *mangaPlus) ParseFetchSerieDetail(data []byte) (source_types.SourceSerie, error) {
+	success, err := decodeSuccess(data)
+	if err != nil {
+		return source_types.SourceSerie{}, err
+	}
+
+	detail := success.titleDetailView
+	if detail == nil {
+		return source_types.SourceSerie{}, errors.Join(ErrInvalidResponse, fmt.Errorf("missing title detail"))
+	}
+
+	// The expired chapters of the middle can't be read, they are reported as missing
+	readable := []chapter{}
+	for _, group := range detail.chapterListGroups {
+		readable = append(readable, group.firstChapterList...)
+		readable = append(readable, group.lastChapterList...)
+	}
+
+	if len(detail.chapterListGroups) == 0 {
+		readable = append(readable, detail.firstChapterList...)
+		readable = append(readable, detail.lastChapterList...)
+	}
+
+	chapters := make([]source_types.SourceSerieVolumeChapter, len(readable))
+	chapterNumbers := make([]float64, 0, len(readable))
+	for i, c := range readable {
+		number, err := strconv.ParseFloat(strings.TrimPrefix(c.name, "#"), 64)
+		if err != nil {
+			m.logger.Debug("Chapter without number", "name", c.name)
+		} else {
+			chapterNumbers = append(chapterNumbers, number)
+		}
+
+		name := c.subTitle
+		if name == "" {
+			name = c.name
+		}
+
+		chapters[i] = source_types.SourceSerieVolumeChapter{
+			ID:            source_types.SourceSerieVolumeChapterID(strconv.Itoa(c.chapterID)),
+			Name:          name,
+			ChapterNumber: number,
+			Language:      source_types.EN,
+			DateUpload:    time.Unix(c.startTimeStamp, 0).UTC(),
+		}
+	}
+
+	volume := source_types.SourceSerieVolume{
+		ID:              "volume-1",
+		Name:            "Volume 1",
+		VolumeNumber:    1,
+		Chapters:        chapters,
+		MissingChapters: chapterutils.CalculateMissingChapters(chapterNumbers),
+	}
+
+	authors := []string{}
+	for _, author := range strings.Split(detail.title.author, "/") {
+		author = strings.TrimSpace(author)
+		if author != "" {
+			authors = append(authors, author)
+		}
+	}
+
+	return source_types.SourceSerie{
+		ID:                source_types.SourceSerieID(strconv.Itoa(detail.title.titleID)),
+		Title:             source_types.MultiLanguageString{EN: detail.title.name},
+		AlternativeTitles: []source_types.MultiLanguageString{},
+		Cover:             detail.title.portraitImageURL,
+		Synopsis:          source_types.MultiLanguageString{EN: detail.overview},
+		Type:              source_types.TYPE_MANGA,
+		Genres:            []source_types.SourceSerieGenre{},
+		Status:            []source_types.SourceSerieStatus{ConvertTitleStatus(detail.titleLabels.releaseSchedule, detail.nonAppearanceInfo)},
+		Authors:           authors,
+		Artists:           []string{},
+		Volumes:           []source_types.SourceSerieVolume{volume},
+	}, nil
+}
+
+// FetchChapterData returns the encrypted pages of the chapter, the image proxy decrypts them
+func (m *mangaPlus) FetchChapterData(ctx context.Context, serieID source_types.SourceSerieID, volumeID source_types.SourceSerieVolumeID, chapterID source_types.SourceSerieVolumeChapterID) (source_types.SourceSerieVolumeChapterData, error) {
+	u := m.SourceAPIInformation.APIURL.JoinPath("manga_viewer")
+	q := languageQuery()
+	q.Set("chapter_id", string(chapterID))
+	q.Set("split", "yes")
+	q.Set("img_quality", "super_high")
+	u.RawQuery = q.Encode()
+
+	data, err := m.get(ctx, u)
+	if err != nil {
+		return source_types.SourceSerieVolumeChapterData{}, errors.Join(err, fmt.Errorf("failed to fetch chapter %s", chapterID))
+	}
+
+	return m.ParseFetchChapterData(data)
+}
+
+func (m *mangaPlus) ParseFetchChapterData(data []byte) (source_types.SourceSerieVolumeChapterData, error) {
+	success, err := decodeSuccess(data)
+	if err != nil {
+		return source_types.SourceSerieVolumeChapterData{}, err
+	}
+
+	if success.mangaViewer == nil {
+		return source_types.SourceSerieVolumeChapterData{}, errors.Join(ErrInvalidResponse, fmt.Errorf("missing manga viewer"))
+	}
+
+	images := make([]source_types.SourceSerieVolumeChapterImage, len(success.mangaViewer.pages))
+	for i, page := range success.mangaViewer.pages {
+		images[i] = source_types.SourceSerieVolumeChapterImage{
+			Index:           i + 1,
+			URL:             page.imageURL,
+			Width:           page.width,
+			Height:          page.height,
+			NeedsProcessing: page.encryptionKey != "",
+			EncryptionKey:   page.encryptionKey,
+		}
+	}
+
+	return source_types.SourceSerieVolumeChapterData{Images: images, Type: source_types.IMAGE}, nil
+}
+
+// DecryptImage xors the image with the hex encoded key of the page
+func (m *mangaPlus) DecryptImage(r io.Reader, key string) (io.Reader, error) {
+	k, err := hex.DecodeString(key)
+	if err != nil || len(k) == 0 {
+		return nil, errors.Join(ErrInvalidEncryptionKey, err)
+	}
+
+	return &xorReader{r: r, key: k}, nil
+}
+
+type xorReader struct {
+	r      io.Reader
+	key    []byte
+	offset int
+}
+
+func (x *xorReader) Read(p []byte) (int, error) {
+	n, err := x.r.Read(p)
+	for i := range n {
+		p[i] ^= x.key[x.offset%len(x.key)]
+		x.offset++
+	}
+
+	return n, err
+}
+
+func (m *mangaPlus) SerieUrl(serieID source_types.SourceSerieID) (*url.URL, error) {
+	u, err := url.Parse(m.SourceInformation.URL)
+	if err != nil {
+		return nil, errors.Join(source_types.ErrBuildingURL, err, fmt.Errorf("failed to build URL: %s", m.SourceInformation.URL))
+	}
+
+	return u.JoinPath("titles", string(serieID)), nil
+}
+
+// ChapterUrl returns the page of the chapter in the MangaPlus viewer
+func (m *mangaPlus) ChapterUrl(serieID source_types.SourceSerieID, volumeID source_types.SourceSerieVolumeID, chapterID source_types.SourceSerieVolumeChapterID) (*url.URL, error) {
+	u, err := url.Parse(m.SourceInformation.URL)
+	if err != nil {
+		return nil, errors.Join(source_types.ErrBuildingURL, err, fmt.Errorf("failed to build URL: %s", m.SourceInformation.URL))
+	}
+
+	return u.JoinPath("viewer", string(chapterID)), nil
+}
+
+// get returns the protobuf payload of the API
+func (m *mangaPlus) get(ctx context.Context, u *url.URL) ([]byte, error) {
+	m.logger.Info("Fetching mangaplus api", "url", u.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Join(source_types.ErrBuildingRequest, err, fmt.Errorf("failed to build request: %s", u))
+	}
+	req.Header = m.SourceAPIInformation.Headers.Clone()
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Join(source_types.ErrHTTPRequestFailed, err, fmt.Errorf("failed to fetch %s", u))
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, errors.Join(source_types.ErrHTTPRequestFailed, err, fmt.Errorf("failed to read %s", u))
+	}
+
+	// Errors are also protobuf payloads, they are decoded to report the message
+	if resp.StatusCode != http.StatusOK {
+		_, err := decodeSuccess(data)
+		return nil, errors.Join(source_types.ErrHTTPRequestFailed, err, fmt.Errorf("unexpected status %s for %s", resp.Status, u))
+	}
+
+	return data, nil
+}
+
+// languageQuery returns the language parameters of every request
+func languageQuery() url.Values {
+	q := url.Values{}
+	q.Set("lang", "eng")
+	q.Set("clang", "eng")
+
+	return q
+}
+
+// decodeSuccess decodes the response, an error result is returned as an error
+func decodeSuccess(data []byte) (*successResult, error) {
+	var r response
+	err := decodeResponse(&r, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.err != nil {
+		return nil, errors.Join(ErrAPIError, fmt.Errorf("%s: %s", r.err.englishPopup.subject, r.err.englishPopup.body))
+	}
+
+	if r.success == nil {
+		return nil, errors.Join(ErrInvalidResponse, fmt.Errorf("empty response"))
+	}
+
+	return r.success, nil
+}
+
+func convertTitles(titles []title) []source_types.SourceSmallSerie {
+	series := make([]source_types.SourceSmallSerie, len(titles))
+	for i, t := range titles {
+		series[i] = source_types.SourceSmallSerie{
+			ID:    source_types.SourceSerieID(strconv.Itoa(t.titleID)),
+			Title: source_types.MultiLanguageString{EN: t.name},
+			Cover: t.portraitImageURL,
+		}
+	}
+
+	return series
+}
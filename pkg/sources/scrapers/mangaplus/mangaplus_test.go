@@ -0,0 +1,234 @@
+package mangaplus_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"dokusho/pkg/sources/scrapers/mangaplus"
+	"dokusho/pkg/sources/source_types"
+)
+
+var source = mangaplus.NewMangaPlus()
+
+func TestMangaPlusParseFetchPopularSerie(t *testing.T) {
+	t.Parallel()
+
+	result, err := source.ParseFetchPopularSerie(rankingProto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.HasNextPage || len(result.Series) != 3 {
+		t.Fatalf("expected 3 series without a next page, got %+v", result)
+	}
+
+	expected := source_types.SourceSmallSerie{
+		ID:    "100020",
+		Title: source_types.MultiLanguageString{EN: "One Piece"},
+		Cover: "https://jumpg-assets.tokyo-cdn.com/secure/title/100020/title_thumbnail_portrait_list/300060.jpg",
+	}
+	if result.Series[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, result.Series[0])
+	}
+}
+
+func TestMangaPlusParseFetchLatestUpdates(t *testing.T) {
+	t.Parallel()
+
+	result, err := source.ParseFetchLatestUpdates(webHomeProto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []source_types.SourceSerieID{}
+	for _, serie := range result.Series {
+		ids = append(ids, serie.ID)
+	}
+
+	expected := []source_types.SourceSerieID{"100020", "100191", "100269"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected the updated series %v once, got %v", expected, ids)
+	}
+}
+
+func TestMangaPlusParseFetchSearchSerie(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		page        int
+		filter      source_types.FetchSearchSerieFilter
+		expected    int
+		hasNextPage bool
+		first       source_types.SourceSerieID
+	}{
+		{name: "first page", page: 1, expected: 20, hasNextPage: true, first: "100171"},
+		{name: "last page", page: 2, expected: 4, hasNextPage: false, first: "100516"},
+		{name: "after the last page", page: 3, expected: 0, hasNextPage: false},
+		{name: "query", page: 1, filter: source_types.FetchSearchSerieFilter{Query: "one piece"}, expected: 1, first: "100020"},
+		{name: "author", page: 1, filter: source_types.FetchSearchSerieFilter{Authors: []string{"kouji miura"}}, expected: 1, first: "100171"},
+		{name: "no match", page: 1, filter: source_types.FetchSearchSerieFilter{Query: "naruto"}, expected: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := source.ParseFetchSearchSerie(allTitlesProto, tc.page, tc.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(result.Series) != tc.expected || result.HasNextPage != tc.hasNextPage {
+				t.Fatalf("expected %d series and next page %t, got %d and %t", tc.expected, tc.hasNextPage, len(result.Series), result.HasNextPage)
+			}
+
+			if tc.expected > 0 && result.Series[0].ID != tc.first {
+				t.Errorf("expected %s first, got %s", tc.first, result.Series[0].ID)
+			}
+		})
+	}
+}
+
+func TestMangaPlusParseFetchSerieDetail(t *testing.T) {
+	t.Parallel()
+
+	serie, err := source.ParseFetchSerieDetail(titleDetailProto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if serie.ID != "100191" || serie.Title.EN != "Kagurabachi" || serie.Type != source_types.TYPE_MANGA {
+		t.Errorf("unexpected serie %+v", serie)
+	}
+
+	if !strings.HasPrefix(serie.Synopsis.EN, "Chihiro trains daily") {
+		t.Errorf("unexpected synopsis %q", serie.Synopsis.EN)
+	}
+
+	if !reflect.DeepEqual(serie.Authors, []string{"Takeru Hokazono"}) {
+		t.Errorf("unexpected authors %v", serie.Authors)
+	}
+
+	if !reflect.DeepEqual(serie.Status, []source_types.SourceSerieStatus{source_types.STATUS_ONGOING}) {
+		t.Errorf("unexpected status %v", serie.Status)
+	}
+
+	if len(serie.Volumes) != 1 || len(serie.Volumes[0].Chapters) != 3 {
+		t.Fatalf("expected the 3 readable chapters, got %+v", serie.Volumes)
+	}
+
+	volume := serie.Volumes[0]
+	if !reflect.DeepEqual(volume.MissingChapters, []float64{2, 3, 4}) {
+		t.Errorf("expected the expired chapters to be missing, got %v", volume.MissingChapters)
+	}
+
+	expected := source_types.SourceSerieVolumeChapter{
+		ID:            "1019455",
+		Name:          "Chapter 5: Kagura",
+		ChapterNumber: 5,
+		Language:      source_types.EN,
+		DateUpload:    time.Date(2023, time.October, 29, 15, 0, 0, 0, time.UTC),
+	}
+	if chapter := volume.Chapters[1]; chapter.ID != expected.ID || chapter.Name != expected.Name || chapter.ChapterNumber != expected.ChapterNumber || !chapter.DateUpload.Equal(expected.DateUpload) {
+		t.Errorf("expected chapter %+v, got %+v", expected, chapter)
+	}
+}
+
+func TestMangaPlusParseFetchChapterData(t *testing.T) {
+	t.Parallel()
+
+	data, err := source.ParseFetchChapterData(mangaViewerProto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Type != source_types.IMAGE || len(data.Images) != 3 {
+		t.Fatalf("expected 3 pages without the banner, got %+v", data)
+	}
+
+	image := data.Images[2]
+	if image.Index != 3 || !strings.HasPrefix(image.URL, "https://mangaplus.shueisha.co.jp/drm/title/100191/chapter/1019451/manga_page/super_high/13280003.jpg") || image.EncryptionKey == "" {
+		t.Errorf("unexpected image %+v", image)
+	}
+
+	if !image.NeedsProcessing || image.Width == 0 || image.Height == 0 {
+		t.Errorf("expected an encrypted image with its dimensions, got %+v", image)
+	}
+
+	_, err = source.DecryptImage(strings.NewReader(""), image.EncryptionKey)
+	if err != nil {
+		t.Errorf("expected the key of the page to be valid, got %v", err)
+	}
+}
+
+func TestMangaPlusErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	_, err := source.ParseFetchChapterData(errorProto)
+	if !errors.Is(err, mangaplus.ErrAPIError) || !strings.Contains(err.Error(), "This chapter is not available.") {
+		t.Errorf("expected ErrAPIError with the message of the popup, got %v", err)
+	}
+
+	_, err = source.ParseFetchSerieDetail(mangaViewerProto)
+	if !errors.Is(err, mangaplus.ErrInvalidResponse) {
+		t.Errorf("expected ErrInvalidResponse without a title detail, got %v", err)
+	}
+
+	_, err = source.ParseFetchSerieDetail(titleDetailProto[:len(titleDetailProto)-10])
+	if !errors.Is(err, mangaplus.ErrInvalidResponse) {
+		t.Errorf("expected ErrInvalidResponse with a truncated payload, got %v", err)
+	}
+}
+
+func TestMangaPlusDecryptImage(t *testing.T) {
+	t.Parallel()
+
+	image := bytes.Repeat([]byte("\x89PNG\r\n\x1a\n page content "), 100)
+	key := []byte{0x5b, 0x0b, 0x72, 0xe6, 0xac}
+
+	encrypted := make([]byte, len(image))
+	for i := range image {
+		encrypted[i] = image[i] ^ key[i%len(key)]
+	}
+
+	r, err := source.DecryptImage(io.MultiReader(bytes.NewReader(encrypted[:7]), bytes.NewReader(encrypted[7:])), hex.EncodeToString(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(decrypted, image) {
+		t.Error("decrypted image doesn't match the original")
+	}
+
+	for _, invalid := range []string{"", "zz", "abc"} {
+		_, err := source.DecryptImage(bytes.NewReader(encrypted), invalid)
+		if !errors.Is(err, mangaplus.ErrInvalidEncryptionKey) {
+			t.Errorf("expected ErrInvalidEncryptionKey for %q, got %v", invalid, err)
+		}
+	}
+}
+
+func TestMangaPlusSerieUrl(t *testing.T) {
+	t.Parallel()
+
+	u, err := source.SerieUrl("100191")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.String() != "https://mangaplus.shueisha.co.jp/titles/100191" {
+		t.Errorf("unexpected serie url %s", u)
+	}
+}
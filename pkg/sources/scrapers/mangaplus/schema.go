@@ -0,0 +1,433 @@
+package mangaplus
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Messages of the MangaPlus protobuf API, only the used fields are decoded.
+// The field numbers come from the schema used by the official web reader.
+
+type response struct {
+	success *successResult
+	err     *errorResult
+}
+
+type errorResult struct {
+	englishPopup popup
+}
+
+type popup struct {
+	subject string
+	body    string
+}
+
+type successResult struct {
+	titleRankingView *titleRankingView
+	titleDetailView  *titleDetailView
+	mangaViewer      *mangaViewer
+	allTitlesViewV2  *allTitlesViewV2
+	webHomeViewV3    *webHomeViewV3
+}
+
+type titleRankingView struct {
+	titles []title
+}
+
+type allTitlesViewV2 struct {
+	groups []allTitlesGroup
+}
+
+// allTitlesGroup is a title in every language it is published in
+type allTitlesGroup struct {
+	theTitle string
+	titles   []title
+}
+
+type webHomeViewV3 struct {
+	groups []updatedTitleGroup
+}
+
+// updatedTitleGroup are the titles updated the same day
+type updatedTitleGroup struct {
+	groupName   string
+	titleGroups []originalTitleGroup
+}
+
+type originalTitleGroup struct {
+	theTitle string
+	titles   []title
+}
+
+type title struct {
+	titleID          int
+	name             string
+	author           string
+	portraitImageURL string
+	language         Language
+}
+
+type titleDetailView struct {
+	title             title
+	titleImageURL     string
+	overview          string
+	nonAppearanceInfo string
+	firstChapterList  []chapter
+	lastChapterList   []chapter
+	isSimulReleased   bool
+	chapterListGroups []chapterListGroup
+	titleLabels       titleLabels
+}
+
+type titleLabels struct {
+	releaseSchedule ReleaseSchedule
+	isSimulpub      bool
+}
+
+// chapterListGroup only lists the readable chapters in first and last, the middle ones are expired
+type chapterListGroup struct {
+	chapterNumbers   string
+	firstChapterList []chapter
+	midChapterList   []chapter
+	lastChapterList  []chapter
+}
+
+type chapter struct {
+	titleID        int
+	chapterID      int
+	name           string
+	subTitle       string
+	startTimeStamp int64
+}
+
+type mangaViewer struct {
+	pages []mangaPlusPage
+}
+
+type mangaPlusPage struct {
+	imageURL      string
+	width         int
+	height        int
+	encryptionKey string
+}
+
+// decodeMessage calls field with every field of the message, values are only set for varint and length delimited fields
+func decodeMessage(b []byte, field func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return errors.Join(ErrInvalidResponse, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		var v uint64
+		var raw []byte
+		switch typ {
+		case protowire.VarintType:
+			v, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			raw, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return errors.Join(ErrInvalidResponse, protowire.ParseError(n), fmt.Errorf("invalid field %d", num))
+		}
+		b = b[n:]
+
+		err := field(num, typ, v, raw)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeRepeated appends the decoded message to the list
+func decodeRepeated[T any](list *[]T, raw []byte, decode func(*T, []byte) error) error {
+	var item T
+	err := decode(&item, raw)
+	if err != nil {
+		return err
+	}
+
+	*list = append(*list, item)
+
+	return nil
+}
+
+// decodeOptional sets the decoded message, the fields of a message repeated in the payload are merged
+func decodeOptional[T any](message **T, raw []byte, decode func(*T, []byte) error) error {
+	if *message == nil {
+		*message = new(T)
+	}
+
+	return decode(*message, raw)
+}
+
+func decodeResponse(r *response, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return decodeOptional(&r.success, raw, decodeSuccessResult)
+		case num == 2 && typ == protowire.BytesType:
+			return decodeOptional(&r.err, raw, decodeErrorResult)
+		}
+
+		return nil
+	})
+}
+
+func decodeErrorResult(e *errorResult, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		if num == 2 && typ == protowire.BytesType {
+			return decodeMessage(raw, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					e.englishPopup.subject = string(raw)
+				case num == 2 && typ == protowire.BytesType:
+					e.englishPopup.body = string(raw)
+				}
+
+				return nil
+			})
+		}
+
+		return nil
+	})
+}
+
+func decodeSuccessResult(s *successResult, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+
+		switch num {
+		case 6:
+			return decodeOptional(&s.titleRankingView, raw, decodeTitleRankingView)
+		case 8:
+			return decodeOptional(&s.titleDetailView, raw, decodeTitleDetailView)
+		case 10:
+			return decodeOptional(&s.mangaViewer, raw, decodeMangaViewer)
+		case 25:
+			return decodeOptional(&s.allTitlesViewV2, raw, decodeAllTitlesViewV2)
+		case 31:
+			return decodeOptional(&s.webHomeViewV3, raw, decodeWebHomeViewV3)
+		}
+
+		return nil
+	})
+}
+
+func decodeTitleRankingView(t *titleRankingView, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		if num == 1 && typ == protowire.BytesType {
+			return decodeRepeated(&t.titles, raw, decodeTitle)
+		}
+
+		return nil
+	})
+}
+
+func decodeAllTitlesViewV2(a *allTitlesViewV2, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		if num == 1 && typ == protowire.BytesType {
+			return decodeRepeated(&a.groups, raw, decodeAllTitlesGroup)
+		}
+
+		return nil
+	})
+}
+
+func decodeAllTitlesGroup(g *allTitlesGroup, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			g.theTitle = string(raw)
+		case num == 2 && typ == protowire.BytesType:
+			return decodeRepeated(&g.titles, raw, decodeTitle)
+		}
+
+		return nil
+	})
+}
+
+func decodeWebHomeViewV3(w *webHomeViewV3, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		if num == 2 && typ == protowire.BytesType {
+			return decodeRepeated(&w.groups, raw, decodeUpdatedTitleGroup)
+		}
+
+		return nil
+	})
+}
+
+func decodeUpdatedTitleGroup(g *updatedTitleGroup, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			g.groupName = string(raw)
+		case num == 2 && typ == protowire.BytesType:
+			return decodeRepeated(&g.titleGroups, raw, decodeOriginalTitleGroup)
+		}
+
+		return nil
+	})
+}
+
+func decodeOriginalTitleGroup(g *originalTitleGroup, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			g.theTitle = string(raw)
+		case num == 3 && typ == protowire.BytesType:
+			// UpdatedTitle only wraps the title
+			return decodeMessage(raw, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+				if num == 1 && typ == protowire.BytesType {
+					return decodeRepeated(&g.titles, raw, decodeTitle)
+				}
+
+				return nil
+			})
+		}
+
+		return nil
+	})
+}
+
+func decodeTitle(t *title, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			t.titleID = int(v)
+		case num == 2 && typ == protowire.BytesType:
+			t.name = string(raw)
+		case num == 3 && typ == protowire.BytesType:
+			t.author = string(raw)
+		case num == 4 && typ == protowire.BytesType:
+			t.portraitImageURL = string(raw)
+		case num == 7 && typ == protowire.VarintType:
+			t.language = Language(v)
+		}
+
+		return nil
+	})
+}
+
+func decodeTitleDetailView(t *titleDetailView, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return decodeTitle(&t.title, raw)
+		case num == 2 && typ == protowire.BytesType:
+			t.titleImageURL = string(raw)
+		case num == 3 && typ == protowire.BytesType:
+			t.overview = string(raw)
+		case num == 8 && typ == protowire.BytesType:
+			t.nonAppearanceInfo = string(raw)
+		case num == 9 && typ == protowire.BytesType:
+			return decodeRepeated(&t.firstChapterList, raw, decodeChapter)
+		case num == 10 && typ == protowire.BytesType:
+			return decodeRepeated(&t.lastChapterList, raw, decodeChapter)
+		case num == 14 && typ == protowire.VarintType:
+			t.isSimulReleased = v != 0
+		case num == 28 && typ == protowire.BytesType:
+			return decodeRepeated(&t.chapterListGroups, raw, decodeChapterListGroup)
+		case num == 32 && typ == protowire.BytesType:
+			return decodeTitleLabels(&t.titleLabels, raw)
+		}
+
+		return nil
+	})
+}
+
+func decodeTitleLabels(l *titleLabels, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			l.releaseSchedule = ReleaseSchedule(v)
+		case num == 2 && typ == protowire.VarintType:
+			l.isSimulpub = v != 0
+		}
+
+		return nil
+	})
+}
+
+func decodeChapterListGroup(g *chapterListGroup, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+
+		switch num {
+		case 1:
+			g.chapterNumbers = string(raw)
+		case 2:
+			return decodeRepeated(&g.firstChapterList, raw, decodeChapter)
+		case 3:
+			return decodeRepeated(&g.midChapterList, raw, decodeChapter)
+		case 4:
+			return decodeRepeated(&g.lastChapterList, raw, decodeChapter)
+		}
+
+		return nil
+	})
+}
+
+func decodeChapter(c *chapter, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			c.titleID = int(v)
+		case num == 2 && typ == protowire.VarintType:
+			c.chapterID = int(v)
+		case num == 3 && typ == protowire.BytesType:
+			c.name = string(raw)
+		case num == 4 && typ == protowire.BytesType:
+			c.subTitle = string(raw)
+		case num == 6 && typ == protowire.VarintType:
+			c.startTimeStamp = int64(v)
+		}
+
+		return nil
+	})
+}
+
+func decodeMangaViewer(m *mangaViewer, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+
+		// MangaPage is a oneof, the other kinds of pages are ads and banners
+		return decodeMessage(raw, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+			if num == 1 && typ == protowire.BytesType {
+				return decodeRepeated(&m.pages, raw, decodeMangaPlusPage)
+			}
+
+			return nil
+		})
+	})
+}
+
+func decodeMangaPlusPage(p *mangaPlusPage, b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, v uint64, raw []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			p.imageURL = string(raw)
+		case num == 2 && typ == protowire.VarintType:
+			p.width = int(v)
+		case num == 3 && typ == protowire.VarintType:
+			p.height = int(v)
+		case num == 5 && typ == protowire.BytesType:
+			p.encryptionKey = string(raw)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,47 @@
+package mangaplus
+
+import (
+	"strings"
+
+	"dokusho/pkg/sources/source_types"
+)
+
+// Language of a title, the enum of the protobuf API
+type Language int
+
+const (
+	ENGLISH       Language = 0
+	SPANISH       Language = 1
+	FRENCH        Language = 2
+	INDONESIAN    Language = 3
+	PORTUGUESE_BR Language = 4
+	RUSSIAN       Language = 5
+	THAI          Language = 6
+)
+
+// ReleaseSchedule of a title, the enum of the protobuf API
+type ReleaseSchedule int
+
+const (
+	SCHEDULE_DISABLED   ReleaseSchedule = 0
+	SCHEDULE_EVERYDAY   ReleaseSchedule = 1
+	SCHEDULE_WEEKLY     ReleaseSchedule = 2
+	SCHEDULE_BIWEEKLY   ReleaseSchedule = 3
+	SCHEDULE_MONTHLY    ReleaseSchedule = 4
+	SCHEDULE_BIMONTHLY  ReleaseSchedule = 5
+	SCHEDULE_TRIMONTHLY ReleaseSchedule = 6
+	SCHEDULE_OTHER      ReleaseSchedule = 7
+	SCHEDULE_COMPLETED  ReleaseSchedule = 8
+)
+
+// ConvertTitleStatus guesses the status from the release schedule, MangaPlus has no status
+func ConvertTitleStatus(schedule ReleaseSchedule, nonAppearanceInfo string) source_types.SourceSerieStatus {
+	switch {
+	case schedule == SCHEDULE_COMPLETED || schedule == SCHEDULE_DISABLED:
+		return source_types.STATUS_COMPLETED
+	case strings.Contains(strings.ToLower(nonAppearanceInfo), "hiatus"):
+		return source_types.STATUS_HIATUS
+	default:
+		return source_types.STATUS_ONGOING
+	}
+}
@@ -0,0 +1,11 @@
+package royalroad
+
+import "errors"
+
+var (
+	ErrInvalidStatus  = errors.New("invalid status")
+	ErrInvalidSort    = errors.New("invalid sort")
+	ErrInvalidOrder   = errors.New("invalid order")
+	ErrInvalidGenre   = errors.New("invalid genre")
+	ErrMissingContent = errors.New("missing chapter content")
+)
@@ -0,0 +1,17 @@
+package royalroad_test
+
+import (
+	_ "embed"
+)
+
+//go:embed fixtures/search.html
+var searchHTML string
+
+//go:embed fixtures/serie.html
+var serieHTML string
+
+//go:embed fixtures/serie_arcs.html
+var serieArcsHTML string
+
+//go:embed fixtures/chapter.html
+var chapterHTML string
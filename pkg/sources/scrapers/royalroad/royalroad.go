@@ -0,0 +1,512 @@
+package royalroad
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"dokusho/pkg/sources/chapterutils"
+	sources "dokusho/pkg/sources/source_types"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	fictionIDRegex = regexp.MustCompile(`/fiction/(\d+)`)
+	chaptersRegex  = regexp.MustCompile(`(?m)window\.chapters\s*=\s*(\[.*\]);\s*$`)
+	volumesRegex   = regexp.MustCompile(`(?m)window\.volumes\s*=\s*(\[.*\]);\s*$`)
+	// Arcs and books announced in the chapter titles, "Arc 2 - Chapter 5" or "Book 1: Prologue"
+	arcRegex = regexp.MustCompile(`(?i)^\s*(arc|book|volume|vol\.)\s*(\d+)`)
+	// Classes hidden by the stylesheet of the chapter, they hide the anti theft notices
+	hiddenClassRegex = regexp.MustCompile(`\.([\w-]+)\s*\{[^}]*display\s*:\s*none`)
+)
+
+type royalRoad struct {
+	sources.Source
+
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+func NewRoyalRoad() *royalRoad {
+	timeout := 10 * time.Second
+
+	client := &http.Client{Timeout: timeout}
+
+	return &royalRoad{
+		httpClient: client,
+		logger:     slog.Default().WithGroup("royalroad"),
+		Source: sources.Source{
+			HTTPClient: client,
+			SourceInformation: sources.SourceInformation{
+				ID:        "royalroad",
+				Name:      "Royal Road",
+				URL:       "https://www.royalroad.com",
+				Icon:      "https://www.royalroad.com/icons/favicon-32x32.png",
+				Version:   "1.0.0",
+				Languages: []sources.SourceLanguage{sources.EN},
+				UpdatedAt: time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC),
+				NSFW:      false,
+				SearchFilters: sources.SupportedFilters{
+					Query:   true,
+					Artists: false,
+					Authors: false,
+					Orders:  GetSearchableOrders(),
+					Sorts:   GetSearchableSorts(),
+					Types:   []sources.SourceSerieType{},
+					Status:  GetSearchableStatus(),
+					Genres: sources.SupportedFiltersGenres{
+						Included:       true,
+						Excluded:       true,
+						PossibleValues: GetSearchableGenres(),
+					},
+				},
+			},
+			SourceAPIInformation: sources.SourceAPIInformation{
+				APIURL:                &url.URL{Scheme: "https", Host: "www.royalroad.com"},
+				MinimumUpdateInterval: 5 * time.Minute,
+				Timeout:               timeout,
+				Headers: http.Header{
+					"User-Agent": []string{"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:71.0) Gecko/20100101 Firefox/77.0"},
+				},
+				CanBlockScraping: true,
+				ImageHosts:       []string{"www.royalroadcdn.com"},
+			},
+		},
+	}
+}
+
+func (r *royalRoad) GetInformation() sources.SourceInformation {
+	return r.Source.SourceInformation
+}
+
+func (r *royalRoad) GetAPIInformation() sources.SourceAPIInformation {
+	return r.Source.SourceAPIInformation
+}
+
+func (r *royalRoad) FetchPopularSerie(ctx context.Context, page int) (sources.SourcePaginatedSmallSerie, error) {
+	return r.fetchList(ctx, "best-rated", page)
+}
+
+func (r *royalRoad) FetchLatestUpdates(ctx context.Context, page int) (sources.SourcePaginatedSmallSerie, error) {
+	return r.fetchList(ctx, "latest-updates", page)
+}
+
+// fetchList fetches a list of fictions, the lists share the markup of the search
+func (r *royalRoad) fetchList(ctx context.Context, list string, page int) (sources.SourcePaginatedSmallSerie, error) {
+	u := r.SourceAPIInformation.APIURL.JoinPath("fictions", list)
+	q := u.Query()
+	q.Set("page", strconv.Itoa(max(page, 1)))
+	u.RawQuery = q.Encode()
+
+	resp, err := r.get(ctx, u)
+	if err != nil {
+		return sources.SourcePaginatedSmallSerie{}, errors.Join(err, fmt.Errorf("failed to fetch %s fictions", list))
+	}
+	defer resp.Body.Close()
+
+	return r.ParseFetchSearchSerie(resp.Body)
+}
+
+func (r *royalRoad) FetchSearchSerie(ctx context.Context, page int, filter sources.FetchSearchSerieFilter) (sources.SourcePaginatedSmallSerie, error) {
+	u := r.SourceAPIInformation.APIURL.JoinPath("fictions", "search")
+	q := u.Query()
+	q.Set("page", strconv.Itoa(max(page, 1)))
+
+	if filter.Query != "" {
+		q.Set("title", filter.Query)
+	}
+
+	if filter.Sort != "" {
+		sort, err := ConvertSourceSerieSort(filter.Sort)
+		if err != nil {
+			return sources.SourcePaginatedSmallSerie{}, errors.Join(sources.ErrInvalidSearchSort, err, fmt.Errorf("invalid sort: %s", filter.Sort))
+		}
+		q.Set("orderBy", string(sort))
+	}
+
+	if filter.Order != "" {
+		order, err := ConvertSourceSerieOrder(filter.Order)
+		if err != nil {
+			return sources.SourcePaginatedSmallSerie{}, errors.Join(sources.ErrInvalidSearchOrder, err, fmt.Errorf("invalid order: %s", filter.Order))
+		}
+		q.Set("dir", string(order))
+	}
+
+	if filter.Status != nil {
+		statuses, err := ConvertSourceSerieStatuses(filter.Status)
+		if err != nil {
+			return sources.SourcePaginatedSmallSerie{}, errors.Join(sources.ErrInvalidSearchStatus, err, fmt.Errorf("invalid status: %s", filter.Status))
+		}
+
+		for _, status := range statuses {
+			q.Add("status", string(status))
+		}
+	}
+
+	include, err := ConvertSourceSerieGenres(filter.Genres.Include)
+	if err != nil {
+		return sources.SourcePaginatedSmallSerie{}, errors.Join(sources.ErrInvalidSearchGenres, err, fmt.Errorf("invalid genres: %s", filter.Genres.Include))
+	}
+
+	exclude, err := ConvertSourceSerieGenres(filter.Genres.Exclude)
+	if err != nil {
+		return sources.SourcePaginatedSmallSerie{}, errors.Join(sources.ErrInvalidSearchGenres, err, fmt.Errorf("invalid genres: %s", filter.Genres.Exclude))
+	}
+
+	for _, tag := range include {
+		q.Add("tagsAdd", string(tag))
+	}
+
+	for _, tag := range exclude {
+		q.Add("tagsRemove", string(tag))
+	}
+
+	u.RawQuery = q.Encode()
+
+	resp, err := r.get(ctx, u)
+	if err != nil {
+		return sources.SourcePaginatedSmallSerie{}, errors.Join(err, fmt.Errorf("failed to search fictions"))
+	}
+	defer resp.Body.Close()
+
+	return r.ParseFetchSearchSerie(resp.Body)
+}
+
+func (r *royalRoad) ParseFetchSearchSerie(html io.Reader) (sources.SourcePaginatedSmallSerie, error) {
+	doc, err := goquery.NewDocumentFromReader(html)
+	if err != nil {
+		return sources.SourcePaginatedSmallSerie{}, errors.Join(sources.ErrParsingHTML, err)
+	}
+
+	series := []sources.SourceSmallSerie{}
+	doc.Find("div.fiction-list-item").Each(func(i int, item *goquery.Selection) {
+		link := item.Find(".fiction-title a").First()
+		id := fictionID(link.AttrOr("href", ""))
+		if id == "" {
+			r.logger.Debug("Fiction without id", "title", link.Text())
+			return
+		}
+
+		series = append(series, sources.SourceSmallSerie{
+			ID:    id,
+			Title: sources.MultiLanguageString{EN: strings.TrimSpace(link.Text())},
+			Cover: r.absoluteURL(item.Find("img").First().AttrOr("src", "")),
+		})
+	})
+
+	hasNextPage := false
+	doc.Find("ul.pagination a").EachWithBreak(func(i int, a *goquery.Selection) bool {
+		hasNextPage = strings.Contains(strings.ToLower(a.Text()), "next")
+		return !hasNextPage
+	})
+
+	return sources.SourcePaginatedSmallSerie{HasNextPage: hasNextPage, Series: series}, nil
+}
+
+func (r *royalRoad) FetchSerieDetail(ctx context.Context, serieID sources.SourceSerieID) (sources.SourceSerie, error) {
+	u, err := r.SerieUrl(serieID)
+	if err != nil {
+		return sources.SourceSerie{}, err
+	}
+
+	resp, err := r.get(ctx, u)
+	if err != nil {
+		return sources.SourceSerie{}, errors.Join(err, fmt.Errorf("failed to fetch fiction %s", serieID))
+	}
+	defer resp.Body.Close()
+
+	return r.ParseFetchSerieDetail(serieID, resp.Body)
+}
+
+type chapterScript struct {
+	ID       int       `json:"id"`
+	VolumeID *int      `json:"volumeId"`
+	Title    string    `json:"title"`
+	Date     time.Time `json:"date"`
+	Order    int       `json:"order"`
+}
+
+type volumeScript struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	Order int    `json:"order"`
+}
+
+func (r *royalRoad) ParseFetchSerieDetail(serieID sources.SourceSerieID, html io.Reader) (sources.SourceSerie, error) {
+	doc, err := goquery.NewDocumentFromReader(html)
+	if err != nil {
+		return sources.SourceSerie{}, errors.Join(sources.ErrParsingHTML, err)
+	}
+
+	title := strings.TrimSpace(doc.Find("div.fic-title h1").First().Text())
+	if title == "" {
+		return sources.SourceSerie{}, errors.Join(sources.ErrExtractingData, fmt.Errorf("missing title of fiction %s", serieID))
+	}
+
+	synopsis := []string{}
+	if description := doc.Find("div.description").First(); description.Length() > 0 {
+		synopsis = paragraphs(description.Nodes[0])
+	}
+
+	status := []sources.SourceSerieStatus{}
+	serieType := sources.TYPE_NOVEL
+	doc.Find("div.fiction-info span.label").Each(func(i int, label *goquery.Selection) {
+		text := strings.TrimSpace(label.Text())
+		if s, err := ConvertRoyalRoadStatus(text); err == nil {
+			status = append(status, s)
+		}
+
+		if strings.Contains(strings.ToLower(text), "light novel") {
+			serieType = sources.TYPE_LIGHTNOVEL
+		}
+	})
+
+	genres := []sources.SourceSerieGenre{}
+	doc.Find("span.tags a.fiction-tag").Each(func(i int, tag *goquery.Selection) {
+		text := strings.TrimSpace(tag.Text())
+		if strings.EqualFold(text, "light novel") {
+			serieType = sources.TYPE_LIGHTNOVEL
+		}
+
+		genre, err := ConvertRoyalRoadGenre(text)
+		if err != nil {
+			r.logger.Debug("Unknown tag", "tag", text)
+			return
+		}
+
+		if !slices.Contains(genres, genre) {
+			genres = append(genres, genre)
+		}
+	})
+
+	authors := []string{}
+	if author := strings.TrimSpace(doc.Find("div.fic-title h4 a").First().Text()); author != "" {
+		authors = append(authors, author)
+	}
+
+	volumes, err := r.parseVolumes(doc)
+	if err != nil {
+		return sources.SourceSerie{}, errors.Join(err, fmt.Errorf("failed to parse chapters of fiction %s", serieID))
+	}
+
+	return sources.SourceSerie{
+		ID:                serieID,
+		Title:             sources.MultiLanguageString{EN: title},
+		AlternativeTitles: []sources.MultiLanguageString{},
+		Cover:             r.absoluteURL(doc.Find("div.cover-art-container img").First().AttrOr("src", "")),
+		Synopsis:          sources.MultiLanguageString{EN: strings.Join(synopsis, "\n")},
+		Type:              serieType,
+		Genres:            genres,
+		Status:            status,
+		Authors:           authors,
+		Artists:           []string{},
+		Volumes:           volumes,
+	}, nil
+}
+
+// parseVolumes groups the chapters of the page script by book, or by the arcs of their titles when the fiction has no books
+func (r *royalRoad) parseVolumes(doc *goquery.Document) ([]sources.SourceSerieVolume, error) {
+	var chapters []chapterScript
+	var books []volumeScript
+
+	scripts := doc.Find("script").Text()
+	if match := chaptersRegex.FindStringSubmatch(scripts); match != nil {
+		err := json.Unmarshal([]byte(match[1]), &chapters)
+		if err != nil {
+			return nil, errors.Join(sources.ErrParsingJSON, err)
+		}
+	}
+
+	if match := volumesRegex.FindStringSubmatch(scripts); match != nil {
+		err := json.Unmarshal([]byte(match[1]), &books)
+		if err != nil {
+			return nil, errors.Join(sources.ErrParsingJSON, err)
+		}
+	}
+
+	slices.SortStableFunc(chapters, func(a, b chapterScript) int { return a.Order - b.Order })
+	slices.SortStableFunc(books, func(a, b volumeScript) int { return a.Order - b.Order })
+
+	volumes := []sources.SourceSerieVolume{}
+	index := map[sources.SourceSerieVolumeID]int{}
+	add := func(id sources.SourceSerieVolumeID, name string, number float64, chapter sources.SourceSerieVolumeChapter) {
+		i, ok := index[id]
+		if !ok {
+			i = len(volumes)
+			index[id] = i
+			volumes = append(volumes, sources.SourceSerieVolume{ID: id, Name: name, VolumeNumber: number, Chapters: []sources.SourceSerieVolumeChapter{}})
+		}
+
+		volumes[i].Chapters = append(volumes[i].Chapters, chapter)
+	}
+
+	for i, book := range books {
+		index[sources.SourceSerieVolumeID(strconv.Itoa(book.ID))] = len(volumes)
+		volumes = append(volumes, sources.SourceSerieVolume{
+			ID:           sources.SourceSerieVolumeID(strconv.Itoa(book.ID)),
+			Name:         book.Title,
+			VolumeNumber: float64(i + 1),
+			Chapters:     []sources.SourceSerieVolumeChapter{},
+		})
+	}
+
+	// Without books, the chapters before the first arc are in the volume 0
+	arcID, arcName, arcNumber := sources.SourceSerieVolumeID("volume-0"), "Chapters", 0.0
+	hasArcs := slices.ContainsFunc(chapters, func(c chapterScript) bool { return arcRegex.MatchString(c.Title) })
+	if !hasArcs {
+		arcID, arcName, arcNumber = "volume-1", "Volume 1", 1
+	}
+
+	for i, c := range chapters {
+		chapter := sources.SourceSerieVolumeChapter{
+			ID:            sources.SourceSerieVolumeChapterID(strconv.Itoa(c.ID)),
+			Name:          c.Title,
+			ChapterNumber: float64(i + 1),
+			Language:      sources.EN,
+			DateUpload:    c.Date,
+		}
+
+		if len(books) > 0 {
+			if c.VolumeID != nil {
+				if _, ok := index[sources.SourceSerieVolumeID(strconv.Itoa(*c.VolumeID))]; ok {
+					add(sources.SourceSerieVolumeID(strconv.Itoa(*c.VolumeID)), "", 0, chapter)
+					continue
+				}
+			}
+
+			add("volume-0", "Chapters", 0, chapter)
+			continue
+		}
+
+		if match := arcRegex.FindStringSubmatch(c.Title); match != nil {
+			arcNumber, _ = strconv.ParseFloat(match[2], 64)
+			arcID = sources.SourceSerieVolumeID("volume-" + match[2])
+			arcName = strings.ToUpper(match[1][:1]) + strings.ToLower(match[1][1:]) + " " + match[2]
+		}
+
+		add(arcID, arcName, arcNumber, chapter)
+	}
+
+	// Books without visible chapters are dropped
+	volumes = slices.DeleteFunc(volumes, func(v sources.SourceSerieVolume) bool { return len(v.Chapters) == 0 })
+
+	for i, volume := range volumes {
+		numbers := make([]float64, len(volume.Chapters))
+		for j, chapter := range volume.Chapters {
+			numbers[j] = chapter.ChapterNumber
+		}
+		volumes[i].MissingChapters = chapterutils.CalculateMissingChapters(numbers)
+	}
+
+	return volumes, nil
+}
+
+func (r *royalRoad) FetchChapterData(ctx context.Context, serieID sources.SourceSerieID, volumeID sources.SourceSerieVolumeID, chapterID sources.SourceSerieVolumeChapterID) (sources.SourceSerieVolumeChapterData, error) {
+	// The chapter URL redirects to the URL with the slugs
+	u := r.SourceAPIInformation.APIURL.JoinPath("fiction", "chapter", string(chapterID))
+
+	resp, err := r.get(ctx, u)
+	if err != nil {
+		return sources.SourceSerieVolumeChapterData{}, errors.Join(err, fmt.Errorf("failed to fetch chapter %s", chapterID))
+	}
+	defer resp.Body.Close()
+
+	if id := fictionID(resp.Request.URL.Path); id != "" && id != serieID {
+		return sources.SourceSerieVolumeChapterData{}, errors.Join(sources.ErrInvalidSerieID, fmt.Errorf("chapter %s isn't in fiction %s", chapterID, serieID))
+	}
+
+	return r.ParseFetchChapterData(resp.Body)
+}
+
+// ParseFetchChapterData returns the paragraphs of the chapter, the paragraphs hidden by the stylesheet are dropped
+func (r *royalRoad) ParseFetchChapterData(html io.Reader) (sources.SourceSerieVolumeChapterData, error) {
+	doc, err := goquery.NewDocumentFromReader(html)
+	if err != nil {
+		return sources.SourceSerieVolumeChapterData{}, errors.Join(sources.ErrParsingHTML, err)
+	}
+
+	content := doc.Find("div.chapter-content").First()
+	if content.Length() == 0 {
+		return sources.SourceSerieVolumeChapterData{}, errors.Join(sources.ErrExtractingData, ErrMissingContent)
+	}
+
+	for _, match := range hiddenClassRegex.FindAllStringSubmatch(doc.Find("style").Text(), -1) {
+		content.Find("." + match[1]).Remove()
+	}
+
+	texts := []sources.SourceSerieVolumeChapterText{}
+	for i, text := range paragraphs(content.Nodes[0]) {
+		texts = append(texts, sources.SourceSerieVolumeChapterText{Index: i + 1, Text: text})
+	}
+
+	return sources.SourceSerieVolumeChapterData{Type: sources.TEXT, Texts: texts}, nil
+}
+
+func (r *royalRoad) SerieUrl(serieID sources.SourceSerieID) (*url.URL, error) {
+	if _, err := strconv.Atoi(string(serieID)); err != nil {
+		return nil, errors.Join(sources.ErrInvalidSerieID, err, fmt.Errorf("fiction id must be a number: %s", serieID))
+	}
+
+	return r.SourceAPIInformation.APIURL.JoinPath("fiction", string(serieID)), nil
+}
+
+// ChapterUrl returns the page of the chapter, Royal Road redirects it to the URL with the fiction and chapter slugs
+func (r *royalRoad) ChapterUrl(serieID sources.SourceSerieID, volumeID sources.SourceSerieVolumeID, chapterID sources.SourceSerieVolumeChapterID) (*url.URL, error) {
+	if _, err := strconv.Atoi(string(chapterID)); err != nil {
+		return nil, errors.Join(sources.ErrInvalidSerieID, err, fmt.Errorf("chapter id must be a number: %s", chapterID))
+	}
+
+	return r.SourceAPIInformation.APIURL.JoinPath("fiction", "chapter", string(chapterID)), nil
+}
+
+func (r *royalRoad) get(ctx context.Context, u *url.URL) (*http.Response, error) {
+	r.logger.Info("Fetching royal road page", "url", u.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Join(sources.ErrBuildingRequest, err, fmt.Errorf("failed to build request: %s", u))
+	}
+	req.Header = r.SourceAPIInformation.Headers.Clone()
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Join(sources.ErrHTTPRequestFailed, err, fmt.Errorf("failed to fetch %s", u))
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Join(sources.ErrHTTPRequestFailed, fmt.Errorf("unexpected status %s for %s", resp.Status, u))
+	}
+
+	return resp, nil
+}
+
+func (r *royalRoad) absoluteURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || raw == "" {
+		return ""
+	}
+
+	return r.SourceAPIInformation.APIURL.ResolveReference(u).String()
+}
+
+// fictionID returns the id of a fiction URL, /fiction/21220/mother-of-learning is 21220
+func fictionID(raw string) sources.SourceSerieID {
+	match := fictionIDRegex.FindStringSubmatch(raw)
+	if match == nil {
+		return ""
+	}
+
+	return sources.SourceSerieID(match[1])
+}
@@ -0,0 +1,188 @@
+package royalroad_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"dokusho/pkg/sources/scrapers/royalroad"
+	"dokusho/pkg/sources/source_types"
+)
+
+var source = royalroad.NewRoyalRoad()
+
+func TestRoyalRoadParseFetchSearchSerie(t *testing.T) {
+	t.Parallel()
+
+	result, err := source.ParseFetchSearchSerie(strings.NewReader(searchHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.HasNextPage || len(result.Series) != 2 {
+		t.Fatalf("expected 2 series and a next page, got %+v", result)
+	}
+
+	expected := []source_types.SourceSmallSerie{
+		{
+			ID:    "21220",
+			Title: source_types.MultiLanguageString{EN: "Mother of Learning"},
+			Cover: "https://www.royalroadcdn.com/public/covers-large/21220-mother-of-learning.jpg?time=1637247458",
+		},
+		{
+			ID:    "25137",
+			Title: source_types.MultiLanguageString{EN: "The Wandering Inn"},
+			Cover: "https://www.royalroad.com/dist/img/nocover-new-min.png",
+		},
+	}
+	if !reflect.DeepEqual(result.Series, expected) {
+		t.Errorf("expected %+v, got %+v", expected, result.Series)
+	}
+}
+
+func TestRoyalRoadParseFetchSerieDetail(t *testing.T) {
+	t.Parallel()
+
+	serie, err := source.ParseFetchSerieDetail("21220", strings.NewReader(serieHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if serie.Title.EN != "Mother of Learning" || serie.Type != source_types.TYPE_NOVEL || !reflect.DeepEqual(serie.Authors, []string{"nobody103"}) {
+		t.Errorf("unexpected serie %+v", serie)
+	}
+
+	if !reflect.DeepEqual(serie.Status, []source_types.SourceSerieStatus{source_types.STATUS_COMPLETED}) {
+		t.Errorf("unexpected status %v", serie.Status)
+	}
+
+	expectedGenres := []source_types.SourceSerieGenre{source_types.ADVENTURE, source_types.FANTASY, source_types.MAGIC, source_types.TIME_TRAVEL, source_types.ISEKAI}
+	if !reflect.DeepEqual(serie.Genres, expectedGenres) {
+		t.Errorf("expected genres %v, got %v", expectedGenres, serie.Genres)
+	}
+
+	if strings.Count(serie.Synopsis.EN, "\n") != 1 || !strings.HasPrefix(serie.Synopsis.EN, "Zorian is a teenage mage") {
+		t.Errorf("expected 2 paragraphs of synopsis, got %q", serie.Synopsis.EN)
+	}
+
+	type volume struct {
+		id       source_types.SourceSerieVolumeID
+		name     string
+		number   float64
+		chapters []source_types.SourceSerieVolumeChapterID
+	}
+	expected := []volume{
+		{id: "5001", name: "Book 1: Stillness", number: 1, chapters: []source_types.SourceSerieVolumeChapterID{"301778", "301779", "301780"}},
+		{id: "5002", name: "Book 2: Ripples", number: 2, chapters: []source_types.SourceSerieVolumeChapterID{"301795"}},
+		{id: "volume-0", name: "Chapters", number: 0, chapters: []source_types.SourceSerieVolumeChapterID{"330000"}},
+	}
+
+	got := []volume{}
+	for _, v := range serie.Volumes {
+		ids := []source_types.SourceSerieVolumeChapterID{}
+		for _, c := range v.Chapters {
+			ids = append(ids, c.ID)
+		}
+		got = append(got, volume{id: v.ID, name: v.Name, number: v.VolumeNumber, chapters: ids})
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected volumes %+v, got %+v", expected, got)
+	}
+
+	chapter := serie.Volumes[0].Chapters[2]
+	if chapter.Name != "3. Cyoria" || chapter.ChapterNumber != 3 || !chapter.DateUpload.Equal(time.Date(2017, time.October, 24, 1, 12, 40, 0, time.UTC)) {
+		t.Errorf("unexpected chapter %+v", chapter)
+	}
+}
+
+func TestRoyalRoadParseFetchSerieDetailArcs(t *testing.T) {
+	t.Parallel()
+
+	serie, err := source.ParseFetchSerieDetail("25137", strings.NewReader(serieArcsHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if serie.Type != source_types.TYPE_LIGHTNOVEL {
+		t.Errorf("expected a light novel, got %s", serie.Type)
+	}
+
+	if !reflect.DeepEqual(serie.Status, []source_types.SourceSerieStatus{source_types.STATUS_ONGOING}) {
+		t.Errorf("unexpected status %v", serie.Status)
+	}
+
+	names := []string{}
+	counts := []int{}
+	for _, v := range serie.Volumes {
+		names = append(names, v.Name)
+		counts = append(counts, len(v.Chapters))
+	}
+
+	if !reflect.DeepEqual(names, []string{"Chapters", "Arc 1", "Arc 2"}) || !reflect.DeepEqual(counts, []int{1, 2, 2}) {
+		t.Errorf("expected the chapters grouped by arc, got %v with %v chapters", names, counts)
+	}
+}
+
+func TestRoyalRoadParseFetchChapterData(t *testing.T) {
+	t.Parallel()
+
+	data, err := source.ParseFetchChapterData(strings.NewReader(chapterHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Type != source_types.TEXT || len(data.Images) != 0 {
+		t.Fatalf("expected a text chapter, got %+v", data)
+	}
+
+	expected := []string{
+		"Zorian's eyes abruptly shot open as a sharp pain erupted from his stomach.",
+		"His whole body convulsed, buckling against the object that fell on him, and suddenly he was wide awake, not a trace of drowsiness in his mind.",
+		"<b>“Good morning, brother!”</b> an annoyingly cheerful voice sounded right on top of him. <i>“Morning, morning, <u>MORNING</u>!!!”</i>",
+		"5 &lt; 6 &amp; click",
+		"Nested paragraph",
+		"Loose text",
+		"Level 3 <b>Mage</b>",
+		"Line one<br>Line two",
+	}
+
+	texts := []string{}
+	for i, text := range data.Texts {
+		if text.Index != i+1 {
+			t.Errorf("expected index %d, got %d", i+1, text.Index)
+		}
+		texts = append(texts, text.Text)
+	}
+
+	if !reflect.DeepEqual(texts, expected) {
+		t.Errorf("expected paragraphs\n%q\ngot\n%q", expected, texts)
+	}
+}
+
+func TestRoyalRoadParseFetchChapterDataMissingContent(t *testing.T) {
+	t.Parallel()
+
+	_, err := source.ParseFetchChapterData(strings.NewReader(serieHTML))
+	if err == nil {
+		t.Error("expected an error without chapter content")
+	}
+}
+
+func TestRoyalRoadSerieUrl(t *testing.T) {
+	t.Parallel()
+
+	u, err := source.SerieUrl("21220")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.String() != "https://www.royalroad.com/fiction/21220" {
+		t.Errorf("unexpected serie url %s", u)
+	}
+
+	_, err = source.SerieUrl("../admin")
+	if err == nil {
+		t.Error("expected an error with an invalid fiction id")
+	}
+}
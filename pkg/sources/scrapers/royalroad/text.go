@@ -0,0 +1,137 @@
+package royalroad
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var whitespaceRegex = regexp.MustCompile(`[ \t\r\n\f]+`)
+
+// Escapes the text of the paragraphs, quotes are kept since the paragraphs have no attributes
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Inline formatting kept in the paragraphs, the synonyms are normalized to a single tag
+var inlineTags = map[atom.Atom]string{
+	atom.B:      "b",
+	atom.Strong: "b",
+	atom.I:      "i",
+	atom.Em:     "i",
+	atom.U:      "u",
+	atom.S:      "s",
+	atom.Strike: "s",
+	atom.Del:    "s",
+	atom.Sub:    "sub",
+	atom.Sup:    "sup",
+}
+
+// Elements starting a new paragraph
+var blockTags = map[atom.Atom]bool{
+	atom.P:          true,
+	atom.Div:        true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Blockquote: true,
+	atom.Ul:         true,
+	atom.Ol:         true,
+	atom.Li:         true,
+	atom.Pre:        true,
+	atom.Table:      true,
+	atom.Tbody:      true,
+	atom.Thead:      true,
+	atom.Tr:         true,
+	atom.Td:         true,
+	atom.Th:         true,
+	atom.Section:    true,
+	atom.Article:    true,
+	atom.Hr:         true,
+}
+
+// Elements dropped with their content
+var droppedTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Iframe:   true,
+	atom.Img:      true,
+	atom.Button:   true,
+	atom.Form:     true,
+}
+
+type paragraph struct {
+	html  strings.Builder
+	plain strings.Builder
+}
+
+// paragraphs returns the paragraphs of the node, the only markup kept is the inline formatting and the line breaks
+func paragraphs(root *html.Node) []string {
+	result := []string{}
+	current := &paragraph{}
+
+	flush := func() {
+		text := strings.TrimSpace(current.html.String())
+		for {
+			trimmed := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(text, "<br>"), "<br>"))
+			if trimmed == text {
+				break
+			}
+			text = trimmed
+		}
+
+		if strings.TrimSpace(current.plain.String()) != "" {
+			result = append(result, text)
+		}
+		current = &paragraph{}
+	}
+
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && blockTags[c.DataAtom] {
+			flush()
+			result = append(result, paragraphs(c)...)
+			continue
+		}
+
+		current.write(c)
+	}
+	flush()
+
+	return result
+}
+
+func (p *paragraph) write(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		text := whitespaceRegex.ReplaceAllString(n.Data, " ")
+		p.html.WriteString(textEscaper.Replace(text))
+		p.plain.WriteString(text)
+	case html.ElementNode:
+		if droppedTags[n.DataAtom] {
+			return
+		}
+
+		if n.DataAtom == atom.Br {
+			p.html.WriteString("<br>")
+			p.plain.WriteString(" ")
+			return
+		}
+
+		tag, inline := inlineTags[n.DataAtom]
+		if inline {
+			p.html.WriteString("<" + tag + ">")
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			p.write(c)
+		}
+
+		if inline {
+			p.html.WriteString("</" + tag + ">")
+		}
+	}
+}
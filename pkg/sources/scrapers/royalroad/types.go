@@ -0,0 +1,210 @@
+package royalroad
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	sources "dokusho/pkg/sources/source_types"
+)
+
+// RoyalRoadSort is the orderBy parameter of the search
+type RoyalRoadSort string
+
+const (
+	SORT_RELEVANCE   RoyalRoadSort = "relevance"
+	SORT_POPULARITY  RoyalRoadSort = "popularity"
+	SORT_LAST_UPDATE RoyalRoadSort = "last_update"
+	SORT_TITLE       RoyalRoadSort = "title"
+)
+
+var SOURCE_SERIE_SORT_TO_ROYALROAD = map[sources.FetchSearchSerieFilterSort]RoyalRoadSort{
+	sources.RELEVANCE:  SORT_RELEVANCE,
+	sources.POPULARITY: SORT_POPULARITY,
+	sources.LATEST:     SORT_LAST_UPDATE,
+	sources.ALPHABETIC: SORT_TITLE,
+}
+
+func GetSearchableSorts() []sources.FetchSearchSerieFilterSort {
+	return []sources.FetchSearchSerieFilterSort{sources.RELEVANCE, sources.POPULARITY, sources.LATEST, sources.ALPHABETIC}
+}
+
+func ConvertSourceSerieSort(sort sources.FetchSearchSerieFilterSort) (RoyalRoadSort, error) {
+	s, ok := SOURCE_SERIE_SORT_TO_ROYALROAD[sort]
+	if !ok {
+		return "", errors.Join(ErrInvalidSort, fmt.Errorf("sort not supported: %s", sort))
+	}
+
+	return s, nil
+}
+
+// RoyalRoadOrder is the dir parameter of the search
+type RoyalRoadOrder string
+
+const (
+	ORDER_ASC  RoyalRoadOrder = "asc"
+	ORDER_DESC RoyalRoadOrder = "desc"
+)
+
+var SOURCE_SERIE_ORDER_TO_ROYALROAD = map[sources.FetchSearchSerieFilterOrder]RoyalRoadOrder{
+	sources.ASC:  ORDER_ASC,
+	sources.DESC: ORDER_DESC,
+}
+
+func GetSearchableOrders() []sources.FetchSearchSerieFilterOrder {
+	return []sources.FetchSearchSerieFilterOrder{sources.ASC, sources.DESC}
+}
+
+func ConvertSourceSerieOrder(order sources.FetchSearchSerieFilterOrder) (RoyalRoadOrder, error) {
+	o, ok := SOURCE_SERIE_ORDER_TO_ROYALROAD[order]
+	if !ok {
+		return "", errors.Join(ErrInvalidOrder, fmt.Errorf("order not supported: %s", order))
+	}
+
+	return o, nil
+}
+
+// RoyalRoadStatus is the status parameter of the search and the status label of the fictions
+type RoyalRoadStatus string
+
+const (
+	STATUS_ONGOING   RoyalRoadStatus = "ONGOING"
+	STATUS_COMPLETED RoyalRoadStatus = "COMPLETED"
+	STATUS_HIATUS    RoyalRoadStatus = "HIATUS"
+	STATUS_DROPPED   RoyalRoadStatus = "DROPPED"
+	// The author removed most of the chapters, usually after a publication
+	STATUS_STUB RoyalRoadStatus = "STUB"
+)
+
+var ROYALROAD_TO_SOURCE_SERIE_STATUS = map[RoyalRoadStatus]sources.SourceSerieStatus{
+	STATUS_ONGOING:   sources.STATUS_ONGOING,
+	STATUS_COMPLETED: sources.STATUS_COMPLETED,
+	STATUS_HIATUS:    sources.STATUS_HIATUS,
+	STATUS_DROPPED:   sources.STATUS_CANCELED,
+	STATUS_STUB:      sources.STATUS_CANCELED,
+}
+
+var SOURCE_SERIE_STATUS_TO_ROYALROAD = map[sources.SourceSerieStatus]RoyalRoadStatus{
+	sources.STATUS_ONGOING:   STATUS_ONGOING,
+	sources.STATUS_COMPLETED: STATUS_COMPLETED,
+	sources.STATUS_HIATUS:    STATUS_HIATUS,
+	sources.STATUS_CANCELED:  STATUS_DROPPED,
+}
+
+func GetSearchableStatus() []sources.SourceSerieStatus {
+	return []sources.SourceSerieStatus{sources.STATUS_ONGOING, sources.STATUS_COMPLETED, sources.STATUS_HIATUS, sources.STATUS_CANCELED}
+}
+
+func ConvertRoyalRoadStatus(label string) (sources.SourceSerieStatus, error) {
+	status, ok := ROYALROAD_TO_SOURCE_SERIE_STATUS[RoyalRoadStatus(strings.ToUpper(strings.TrimSpace(label)))]
+	if !ok {
+		return sources.STATUS_UNKNOWN, errors.Join(ErrInvalidStatus, fmt.Errorf("unknown status: %s", label))
+	}
+
+	return status, nil
+}
+
+func ConvertSourceSerieStatuses(statuses []sources.SourceSerieStatus) ([]RoyalRoadStatus, error) {
+	converted := make([]RoyalRoadStatus, len(statuses))
+	for i, status := range statuses {
+		s, ok := SOURCE_SERIE_STATUS_TO_ROYALROAD[status]
+		if !ok {
+			return nil, errors.Join(ErrInvalidStatus, fmt.Errorf("status not supported: %s", status))
+		}
+
+		converted[i] = s
+	}
+
+	return converted, nil
+}
+
+// RoyalRoadTag is the slug of a tag, used by the tagsAdd and tagsRemove parameters of the search
+type RoyalRoadTag string
+
+var ROYALROAD_TO_SOURCE_SERIE_GENRE = map[RoyalRoadTag]sources.SourceSerieGenre{
+	"action":           sources.ACTION,
+	"adventure":        sources.ADVENTURE,
+	"comedy":           sources.COMEDY,
+	"drama":            sources.DRAMA,
+	"fantasy":          sources.FANTASY,
+	"gender_bender":    sources.GENDER_BENDER,
+	"harem":            sources.HAREM,
+	"historical":       sources.HISTORICAL,
+	"horror":           sources.HORROR,
+	"magic":            sources.MAGIC,
+	"martial_arts":     sources.MARTIAL_ARTS,
+	"military":         sources.MILITARY,
+	"mystery":          sources.MYSTERY,
+	"one_shot":         sources.ONE_SHOT,
+	"post_apocalyptic": sources.POST_APOCALYPTIC,
+	"psychological":    sources.PSYCHOLOGICAL,
+	"reincarnation":    sources.REINCARNATION,
+	"romance":          sources.ROMANCE,
+	"school_life":      sources.SCHOOL_LIFE,
+	"sci_fi":           sources.SCI_FI,
+	"slice_of_life":    sources.SLICE_OF_LIFE,
+	"sports":           sources.SPORTS,
+	"summoned_hero":    sources.ISEKAI,
+	"super_heroes":     sources.SUPERHERO,
+	"supernatural":     sources.SUPERNATURAL,
+	"time_travel":      sources.TIME_TRAVEL,
+	"tragedy":          sources.TRAGEDY,
+	"virtual_reality":  sources.VIRTUAL_REALITY,
+	"wuxia":            sources.WUXIA,
+}
+
+// Names of the tags displayed on the fiction pages, lower cased
+var ROYALROAD_LABEL_TO_SOURCE_SERIE_GENRE = map[string]sources.SourceSerieGenre{
+	"portal fantasy / isekai": sources.ISEKAI,
+	"super heroes":            sources.SUPERHERO,
+	"sci-fi":                  sources.SCI_FI,
+	"one shot":                sources.ONE_SHOT,
+}
+
+func GetSearchableGenres() []sources.SourceSerieGenre {
+	genres := make([]sources.SourceSerieGenre, 0, len(ROYALROAD_TO_SOURCE_SERIE_GENRE))
+	for _, genre := range sources.ALL_GENRES {
+		for _, g := range ROYALROAD_TO_SOURCE_SERIE_GENRE {
+			if g == genre {
+				genres = append(genres, genre)
+				break
+			}
+		}
+	}
+
+	return genres
+}
+
+func ConvertSourceSerieGenres(genres []sources.SourceSerieGenre) ([]RoyalRoadTag, error) {
+	converted := make([]RoyalRoadTag, len(genres))
+	for i, genre := range genres {
+		found := false
+		for tag, g := range ROYALROAD_TO_SOURCE_SERIE_GENRE {
+			if g == genre {
+				converted[i] = tag
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, errors.Join(ErrInvalidGenre, fmt.Errorf("genre not supported: %s", genre))
+		}
+	}
+
+	return converted, nil
+}
+
+// ConvertRoyalRoadGenre converts the name of a tag displayed on a fiction page
+func ConvertRoyalRoadGenre(label string) (sources.SourceSerieGenre, error) {
+	key := strings.ToLower(strings.TrimSpace(label))
+	if genre, ok := ROYALROAD_LABEL_TO_SOURCE_SERIE_GENRE[key]; ok {
+		return genre, nil
+	}
+
+	if genre, ok := ROYALROAD_TO_SOURCE_SERIE_GENRE[RoyalRoadTag(strings.ReplaceAll(key, " ", "_"))]; ok {
+		return genre, nil
+	}
+
+	return sources.UNKNOWN, errors.Join(ErrInvalidGenre, fmt.Errorf("unknown genre: %s", label))
+}
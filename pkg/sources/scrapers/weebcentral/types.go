@@ -168,6 +168,14 @@ var WEEBCENTRAL_TO_SOURCE_SERIE_TYPE = map[WeebCentralType]sources.SourceSerieTy
 	OEL:    sources.TYPE_OEL,
 }
 
+// WEEBCENTRAL_TYPE_TO_ORIGINAL_LANGUAGE is the language a serie of the type is first published in
+var WEEBCENTRAL_TYPE_TO_ORIGINAL_LANGUAGE = map[WeebCentralType]sources.SourceLanguage{
+	MANGA:  sources.JP,
+	MANHWA: sources.KO,
+	MANHUA: sources.ZH,
+	OEL:    sources.EN,
+}
+
 func GetSearchableTypes() []sources.SourceSerieType {
 	var types []sources.SourceSerieType
 
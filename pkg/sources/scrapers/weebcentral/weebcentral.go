@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"mime"
 	"net/http"
 	"net/url"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
@@ -41,6 +43,7 @@ func NewWeebCentral() *weebCentral {
 		httpClient: &client,
 		logger:     logger,
 		Source: sources.Source{
+			HTTPClient: &client,
 			SourceInformation: sources.SourceInformation{
 				ID:        "weebcentral",
 				Name:      "WeebCentral",
@@ -73,6 +76,7 @@ func NewWeebCentral() *weebCentral {
 					"User-Agent": []string{"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:71.0) Gecko/20100101 Firefox/77.0"},
 				},
 				CanBlockScraping: true,
+				ImageHosts:       []string{"*.lastation.us", "*.compsci88.com"},
 			},
 		},
 	}
@@ -295,47 +299,122 @@ func (w *weebCentral) FetchSerieDetail(context context.Context, serieID sources.
 
 	w.logger.Info("Fetching serie detail", "serie_url", serieURL.String(), "chapters_url", chaptersURL.String())
 
-	serieReq, err := http.NewRequest("GET", serieURL.String(), nil)
+	serieResp, err := w.get(context, serieURL)
 	if err != nil {
-		return sources.SourceSerie{}, errors.Join(sources.ErrBuildingRequest, err, fmt.Errorf("failed to build serie request: %s", serieURL))
+		return sources.SourceSerie{}, errors.Join(err, fmt.Errorf("failed to fetch serie detail data"))
 	}
+	defer serieResp.Body.Close()
+
+	w.logger.Debug("Fetched serie detail", "status", serieResp.Status, "header", serieResp.Header)
 
-	chaptersReq, err := http.NewRequest("GET", chaptersURL.String(), nil)
+	chaptersResp, err := w.get(context, chaptersURL)
 	if err != nil {
-		return sources.SourceSerie{}, errors.Join(sources.ErrBuildingRequest, err, fmt.Errorf("failed to build chapters request: %s", chaptersURL))
+		return sources.SourceSerie{}, errors.Join(err, fmt.Errorf("failed to fetch chapters list data"))
 	}
+	defer chaptersResp.Body.Close()
 
-	serieReq.WithContext(context)
-	chaptersReq.WithContext(context)
-	serieReq.Header = w.SourceAPIInformation.Headers
-	chaptersReq.Header = w.SourceAPIInformation.Headers
+	w.logger.Debug("Fetched chapters list", "status", chaptersResp.Status, "header", chaptersResp.Header)
+
+	return w.ParseFetchSerieDetail(serieID, chaptersResp.Body, serieResp.Body)
+}
 
-	serieResp, err := w.httpClient.Do(serieReq)
-	if err != nil || serieResp.StatusCode != http.StatusOK {
-		return sources.SourceSerie{}, errors.Join(sources.ErrHTTPRequestFailed, err, fmt.Errorf("failed to fetch serie detail data: %+v", serieResp))
+// FetchSerieMetadata only fetches the serie page, without the full chapter list
+func (w *weebCentral) FetchSerieMetadata(context context.Context, serieID sources.SourceSerieID) (sources.SourceSerie, error) {
+	serieURL, err := w.SerieUrl(serieID)
+	if err != nil {
+		return sources.SourceSerie{}, errors.Join(sources.ErrBuildingURL, err, fmt.Errorf("failed to build serie URL: %s", serieID))
 	}
-	defer serieResp.Body.Close()
 
-	w.logger.Debug("Fetched serie detail", "status", serieResp.Status, "header", serieResp.Header)
+	w.logger.Info("Fetching serie metadata", "serie_url", serieURL.String())
 
-	chaptersResp, err := w.httpClient.Do(chaptersReq)
-	if err != nil || chaptersResp.StatusCode != http.StatusOK {
-		return sources.SourceSerie{}, errors.Join(sources.ErrHTTPRequestFailed, err, fmt.Errorf("failed to fetch chapters list data: %+v", chaptersResp))
+	resp, err := w.get(context, serieURL)
+	if err != nil {
+		return sources.SourceSerie{}, errors.Join(err, fmt.Errorf("failed to fetch serie detail data"))
 	}
-	defer chaptersResp.Body.Close()
+	defer resp.Body.Close()
 
-	w.logger.Debug("Fetched chapters list", "status", chaptersResp.Status, "header", chaptersResp.Header)
+	w.logger.Debug("Fetched serie metadata", "status", resp.Status, "header", resp.Header)
 
-	return w.ParseFetchSerieDetail(serieID, chaptersResp.Body, serieResp.Body)
+	return w.ParseFetchSerieMetadata(serieID, resp.Body)
+}
+
+// FetchSerieChapters returns the full chapter list in the first page, the site does not paginate it
+func (w *weebCentral) FetchSerieChapters(context context.Context, serieID sources.SourceSerieID, filter sources.FetchSerieChaptersFilter) (sources.SourcePaginatedSerieVolumes, error) {
+	if filter.Page > 1 {
+		return sources.SourcePaginatedSerieVolumes{Volumes: []sources.SourceSerieVolume{}}, nil
+	}
+
+	serieURL, err := w.SerieUrl(serieID)
+	if err != nil {
+		return sources.SourcePaginatedSerieVolumes{}, errors.Join(sources.ErrBuildingURL, err, fmt.Errorf("failed to build serie URL: %s", serieID))
+	}
+
+	chaptersURL := serieURL.JoinPath("full-chapter-list")
+
+	w.logger.Info("Fetching serie chapters", "chapters_url", chaptersURL.String())
+
+	resp, err := w.get(context, chaptersURL)
+	if err != nil {
+		return sources.SourcePaginatedSerieVolumes{}, errors.Join(err, fmt.Errorf("failed to fetch chapters list data"))
+	}
+	defer resp.Body.Close()
+
+	w.logger.Debug("Fetched chapters list", "status", resp.Status, "header", resp.Header)
+
+	chapters, err := w.ParseFetchSerieChapters(resp.Body)
+	if err != nil {
+		return sources.SourcePaginatedSerieVolumes{}, err
+	}
+
+	return sources.SourcePaginatedSerieVolumes{
+		Volumes: sources.FilterVolumesSince([]sources.SourceSerieVolume{chaptersVolume(chapters)}, filter.Since),
+	}, nil
+}
+
+func (w *weebCentral) get(context context.Context, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(context, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Join(sources.ErrBuildingRequest, err, fmt.Errorf("failed to build request: %s", u))
+	}
+
+	req.Header = w.SourceAPIInformation.Headers.Clone()
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Join(sources.ErrHTTPRequestFailed, err, fmt.Errorf("failed to fetch data: %s", u))
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Join(sources.ErrHTTPRequestFailed, fmt.Errorf("unexpected status %s: %s", resp.Status, u))
+	}
+
+	return resp, nil
 }
 
 func (w *weebCentral) ParseFetchSerieDetail(serieID sources.SourceSerieID, chaptersHTML io.Reader, serieHTML io.Reader) (sources.SourceSerie, error) {
+	chapters, err := w.ParseFetchSerieChapters(chaptersHTML)
+	if err != nil {
+		return sources.SourceSerie{}, err
+	}
+
+	serie, err := w.ParseFetchSerieMetadata(serieID, serieHTML)
+	if err != nil {
+		return sources.SourceSerie{}, err
+	}
+
+	serie.Volumes = []sources.SourceSerieVolume{chaptersVolume(chapters)}
+
+	return serie, nil
+}
+
+func (w *weebCentral) ParseFetchSerieChapters(chaptersHTML io.Reader) ([]sources.SourceSerieVolumeChapter, error) {
 	chaptersDoc, err := goquery.NewDocumentFromReader(chaptersHTML)
 	if err != nil {
 		html, errRead := io.ReadAll(chaptersHTML)
 		w.logger.Debug("Weird chapters HTML received", "html", html, "error_read", errRead)
 
-		return sources.SourceSerie{}, errors.Join(sources.ErrParsingHTML, err, fmt.Errorf("failed to parse chapters list html: %s", html))
+		return nil, errors.Join(sources.ErrParsingHTML, err, fmt.Errorf("failed to parse chapters list html: %s", html))
 	}
 
 	cm := chaptersDoc.Find("body").Children().ChildrenFiltered("a.flex")
@@ -377,13 +456,10 @@ func (w *weebCentral) ParseFetchSerieDetail(serieID sources.SourceSerieID, chapt
 
 	w.logger.Debug("Parsed chapters", "count", len(chapters))
 
-	var chapterNumbers []float64
-	for _, ch := range chapters {
-		chapterNumbers = append(chapterNumbers, ch.ChapterNumber)
-	}
-	missingChapters := chapterutils.CalculateMissingChapters(chapterNumbers)
-	w.logger.Debug("Missing chapters detected", "missing", missingChapters)
+	return chapters, nil
+}
 
+func (w *weebCentral) ParseFetchSerieMetadata(serieID sources.SourceSerieID, serieHTML io.Reader) (sources.SourceSerie, error) {
 	serieDoc, err := goquery.NewDocumentFromReader(serieHTML)
 	if err != nil {
 		html, errRead := io.ReadAll(serieHTML)
@@ -408,8 +484,15 @@ func (w *weebCentral) ParseFetchSerieDetail(serieID sources.SourceSerieID, chapt
 
 	rawGenres := sm.Find("li:has(strong:contains(Tags)) > span > a")
 	genres := make([]sources.SourceSerieGenre, rawGenres.Length())
+	demographic := sources.DEMOGRAPHIC_UNKNOWN
 	for i := range genres {
 		rawGenre := rawGenres.Eq(i).Text()
+
+		// The demographic is one of the tags
+		if demographic == sources.DEMOGRAPHIC_UNKNOWN {
+			demographic = sources.NewSourceSerieDemographic(rawGenre)
+		}
+
 		genre, err := ConvertWeebCentralGenre(WeebCentralGenre(rawGenre))
 		if err != nil {
 			w.logger.Warn("Failed to parse genre", "raw_genre", rawGenre, "error", err)
@@ -431,16 +514,13 @@ func (w *weebCentral) ParseFetchSerieDetail(serieID sources.SourceSerieID, chapt
 	synopsis := sm.Find("li:has(strong:contains(Description)) > p").First().Text()
 	authors := sm.Find("li:has(strong:contains(Author)) > span > a").Map(func(i int, s *goquery.Selection) string { return s.Text() })
 
-	// Wrap all chapters into a single volume.
-	volume := sources.SourceSerieVolume{
-		ID:              "volume-1",
-		Name:            "Volume 1",
-		VolumeNumber:    1,
-		Chapters:        chapters,
-		MissingChapters: missingChapters,
+	rawYear := strings.TrimSpace(sm.Find("li:has(strong:contains(Released)) > span").First().Text())
+	year, err := strconv.Atoi(rawYear)
+	if err != nil {
+		w.logger.Warn("Failed to parse release year", "raw_year", rawYear, "error", err)
 	}
 
-	// create final serie object using parsed details and add the volume
+	// create final serie object using parsed details, the chapters are added by ParseFetchSerieDetail
 	serie := sources.SourceSerie{
 		ID:                serieID,
 		Title:             sources.MultiLanguageString{EN: title},
@@ -452,12 +532,67 @@ func (w *weebCentral) ParseFetchSerieDetail(serieID sources.SourceSerieID, chapt
 		Artists:           []string{},
 		AlternativeTitles: []sources.MultiLanguageString{},
 		Genres:            genres,
-		Volumes:           []sources.SourceSerieVolume{volume},
+		Volumes:           []sources.SourceSerieVolume{},
+		ExternalLinks:     w.getExternalLinks(sm),
+		Year:              year,
+		Demographic:       demographic,
+		OriginalLanguage:  WEEBCENTRAL_TYPE_TO_ORIGINAL_LANGUAGE[WeebCentralType(rawType)],
 	}
 
 	return serie, nil
 }
 
+// getExternalLinks reads the serie ID from the URLs of the trackers, the ID is the path segment after the kind of media
+func (w *weebCentral) getExternalLinks(sm *goquery.Selection) sources.SourceSerieExternalLinks {
+	var links sources.SourceSerieExternalLinks
+
+	sm.Find("li:has(strong:contains(Track)) a").Each(func(_ int, s *goquery.Selection) {
+		rawURL := s.AttrOr("href", "")
+		trackerURL, err := url.Parse(rawURL)
+		if err != nil {
+			w.logger.Warn("Failed to parse tracker URL", "raw_url", rawURL, "error", err)
+			return
+		}
+
+		segments := strings.Split(strings.Trim(trackerURL.Path, "/"), "/")
+		if len(segments) < 2 {
+			return
+		}
+		id := segments[1]
+
+		switch strings.TrimPrefix(trackerURL.Hostname(), "www.") {
+		case "anilist.co":
+			links.AniList = id
+		case "myanimelist.net":
+			links.MyAnimeList = id
+		case "mangaupdates.com":
+			links.MangaUpdates = id
+		case "kitsu.app", "kitsu.io":
+			links.Kitsu = id
+		case "anime-planet.com":
+			links.AnimePlanet = id
+		}
+	})
+
+	return links
+}
+
+// chaptersVolume wraps all chapters into a single volume
+func chaptersVolume(chapters []sources.SourceSerieVolumeChapter) sources.SourceSerieVolume {
+	var chapterNumbers []float64
+	for _, ch := range chapters {
+		chapterNumbers = append(chapterNumbers, ch.ChapterNumber)
+	}
+
+	return sources.SourceSerieVolume{
+		ID:              "volume-1",
+		Name:            "Volume 1",
+		VolumeNumber:    1,
+		Chapters:        chapters,
+		MissingChapters: chapterutils.CalculateMissingChapters(chapterNumbers),
+	}
+}
+
 func (w *weebCentral) FetchChapterData(ctx context.Context, serieID sources.SourceSerieID, volumeID sources.SourceSerieVolumeID, chapterID sources.SourceSerieVolumeChapterID) (sources.SourceSerieVolumeChapterData, error) {
 	chapterDataURL := w.SourceAPIInformation.APIURL.JoinPath(fmt.Sprintf("/chapters/%s/images", chapterID))
 
@@ -509,9 +644,16 @@ func (w *weebCentral) ParseFetchChapterData(html io.Reader) (sources.SourceSerie
 			w.logger.Warn("Failed to parse image URL", "index", i, "src", rawURL, "error", error)
 		}
 
+		width, _ := strconv.Atoi(s.AttrOr("width", ""))
+		height, _ := strconv.Atoi(s.AttrOr("height", ""))
+
 		images = append(images, sources.SourceSerieVolumeChapterImage{
-			Index: i + 1,
-			URL:   u.String(),
+			Index:    i + 1,
+			URL:      u.String(),
+			Headers:  sources.SourceSerieVolumeChapterImageHeaders{Referer: w.SourceInformation.URL + "/"},
+			Width:    width,
+			Height:   height,
+			MimeType: mime.TypeByExtension(path.Ext(u.Path)),
 		})
 	})
 
@@ -526,3 +668,13 @@ func (w *weebCentral) SerieUrl(serieID sources.SourceSerieID) (*url.URL, error)
 
 	return url.JoinPath("series", string(serieID)), nil
 }
+
+// ChapterUrl returns the page of the chapter in the WeebCentral reader
+func (w *weebCentral) ChapterUrl(serieID sources.SourceSerieID, volumeID sources.SourceSerieVolumeID, chapterID sources.SourceSerieVolumeChapterID) (*url.URL, error) {
+	url, err := url.Parse(w.SourceInformation.URL)
+	if err != nil {
+		return nil, errors.Join(source_types.ErrBuildingURL, err, fmt.Errorf("failed to build URL: %s", w.SourceInformation.URL))
+	}
+
+	return url.JoinPath("chapters", string(chapterID)), nil
+}
@@ -64,6 +64,54 @@ func TestWeebCentralParseFetchSerieDetail(t *testing.T) {
 	t.Log(serie.Type)
 }
 
+func TestWeebCentralParseFetchSerieMetadata(t *testing.T) {
+	t.Parallel()
+
+	serie, err := source.ParseFetchSerieMetadata("01J76XYGC5B3EH5D5XDR7M490Q", strings.NewReader(serieHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if serie.Title.EN == "" {
+		t.Error("Serie title should not be empty")
+	}
+
+	if len(serie.Volumes) != 0 {
+		t.Errorf("Serie metadata should not have volumes, got %d", len(serie.Volumes))
+	}
+
+	if serie.Year != 2021 || serie.Demographic != source_types.DEMOGRAPHIC_SEINEN || serie.OriginalLanguage != source_types.JP {
+		t.Errorf("unexpected metadata: year %d, demographic %q, original language %q", serie.Year, serie.Demographic, serie.OriginalLanguage)
+	}
+
+	expectedLinks := source_types.SourceSerieExternalLinks{AniList: "147160", MangaUpdates: "ocpdlih"}
+	if serie.ExternalLinks != expectedLinks {
+		t.Errorf("expected links %+v, got %+v", expectedLinks, serie.ExternalLinks)
+	}
+}
+
+func TestWeebCentralParseFetchSerieChapters(t *testing.T) {
+	t.Parallel()
+
+	chapters, err := source.ParseFetchSerieChapters(strings.NewReader(chaptersListHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serie, err := source.ParseFetchSerieDetail("01J76XYGC5B3EH5D5XDR7M490Q", strings.NewReader(chaptersListHTML), strings.NewReader(serieHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(chapters) == 0 {
+		t.Fatal("Chapters should not be empty")
+	}
+
+	if len(serie.Volumes) != 1 || len(serie.Volumes[0].Chapters) != len(chapters) {
+		t.Errorf("Serie detail should have the %d chapters in a single volume", len(chapters))
+	}
+}
+
 func TestWeebCentralParseFetchChapterData(t *testing.T) {
 	t.Parallel()
 
@@ -85,6 +133,18 @@ func TestWeebCentralParseFetchChapterData(t *testing.T) {
 		t.Error("Chapter for this source should only have type Image")
 	}
 
+	expected := source_types.SourceSerieVolumeChapterImage{
+		Index:    1,
+		URL:      "https://scans.lastation.us/manga/Sono-Munou-Jitsuha-Sekai-Saikyou-No-Mahoutsukai/0064-001.png",
+		Headers:  source_types.SourceSerieVolumeChapterImageHeaders{Referer: "https://weebcentral.com/"},
+		Width:    1125,
+		Height:   1600,
+		MimeType: "image/png",
+	}
+	if len(chapter.Images) > 0 && chapter.Images[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, chapter.Images[0])
+	}
+
 	for _, image := range chapter.Images {
 		t.Log(image)
 	}
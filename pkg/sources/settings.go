@@ -0,0 +1,98 @@
+package sources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"dokusho/pkg/sources/source_types"
+)
+
+// settingsFile is the settings file format, keyed by source ID:
+//
+//	{"weebcentral": {"enabled": false}, "mangadex": {"timeout": "15s", "languages": ["en", "fr"], "headers": {"User-Agent": "dokusho"}, "imageQuality": "data_saver",
+//	 "contentRatings": ["safe", "suggestive"]},
+//	 "komga": {"credentials": {"apiKey": "..."}}}
+type settingsFile map[source_types.SourceID]struct {
+	Enabled        *bool             `json:"enabled"`
+	Timeout        string            `json:"timeout"`
+	Headers        map[string]string `json:"headers"`
+	Languages      []string          `json:"languages"`
+	NSFW           *bool             `json:"nsfw"`
+	ImageQuality   string            `json:"imageQuality"`
+	ContentRatings []string          `json:"contentRatings"`
+	Credentials    struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		APIKey   string `json:"apiKey"`
+	} `json:"credentials"`
+}
+
+// LoadSettings reads the settings of the sources from a JSON file, no path means no settings
+func LoadSettings(path string) (map[source_types.SourceID]source_types.SourceSettings, error) {
+	settings := map[source_types.SourceID]source_types.SourceSettings{}
+	if path == "" {
+		return settings, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Join(ErrReadingSettings, err)
+	}
+
+	var file settingsFile
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, errors.Join(ErrReadingSettings, err, fmt.Errorf("failed to parse %s", path))
+	}
+
+	for id, raw := range file {
+		s := source_types.SourceSettings{
+			Enabled:     raw.Enabled,
+			NSFW:        raw.NSFW,
+			Credentials: source_types.SourceCredentials(raw.Credentials),
+		}
+
+		if raw.Timeout != "" {
+			s.Timeout, err = time.ParseDuration(raw.Timeout)
+			if err != nil || s.Timeout <= 0 {
+				return nil, errors.Join(ErrInvalidSettings, err, fmt.Errorf("invalid timeout %q for source %s", raw.Timeout, id))
+			}
+		}
+
+		if len(raw.Headers) > 0 {
+			s.Headers = http.Header{}
+			for name, value := range raw.Headers {
+				s.Headers.Set(name, value)
+			}
+		}
+
+		if raw.ImageQuality != "" {
+			s.ImageQuality, err = source_types.NewSourceImageQuality(raw.ImageQuality)
+			if err != nil {
+				return nil, errors.Join(ErrInvalidSettings, err, fmt.Errorf("invalid image quality %q for source %s", raw.ImageQuality, id))
+			}
+		}
+
+		for _, rating := range raw.ContentRatings {
+			contentRating, err := source_types.NewSourceContentRating(rating)
+			if err != nil {
+				return nil, errors.Join(ErrInvalidSettings, err, fmt.Errorf("invalid content rating %q for source %s", rating, id))
+			}
+
+			s.ContentRatings = append(s.ContentRatings, contentRating)
+		}
+
+		// Kept as is, Configure refuses the languages the source doesn't support. NewSourceLanguage would turn an unknown one into english
+		for _, language := range raw.Languages {
+			s.Languages = append(s.Languages, source_types.SourceLanguage(language))
+		}
+
+		settings[id] = s
+	}
+
+	return settings, nil
+}
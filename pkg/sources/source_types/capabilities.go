@@ -0,0 +1,108 @@
+package source_types
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// SourceCapability is an optional feature of a source, detected from the interfaces it implements
+type SourceCapability string
+
+const (
+	CAPABILITY_SETTINGS         SourceCapability = "settings"
+	CAPABILITY_SERIE_CHAPTERS   SourceCapability = "serie_chapters"
+	CAPABILITY_CHAPTER_URL      SourceCapability = "chapter_url"
+	CAPABILITY_LOGIN            SourceCapability = "login"
+	CAPABILITY_IMAGE_HEADERS    SourceCapability = "image_headers"
+	CAPABILITY_IMAGE_DECRYPTION SourceCapability = "image_decryption"
+	CAPABILITY_IMAGE_QUALITY    SourceCapability = "image_quality"
+	CAPABILITY_HOME_SECTIONS    SourceCapability = "home_sections"
+	CAPABILITY_AUTHOR_SERIES    SourceCapability = "author_series"
+	CAPABILITY_RANDOM_SERIE     SourceCapability = "random_serie"
+)
+
+func (c SourceCapability) String() string {
+	return string(c)
+}
+
+// ChapterURLSourceAPI is a source linking to the page of a chapter on its website
+type ChapterURLSourceAPI interface {
+	SourceAPI
+	ChapterUrl(serieID SourceSerieID, volumeID SourceSerieVolumeID, chapterID SourceSerieVolumeChapterID) (*url.URL, error)
+}
+
+// LoginSourceAPI is a source accepting credentials at runtime, they replace the credentials of the settings
+type LoginSourceAPI interface {
+	SourceAPI
+	Login(context context.Context, credentials SourceCredentials) error
+}
+
+// ImageHeadersSourceAPI is a source needing extra headers to fetch an image, the image proxy sends them with the headers of the source
+type ImageHeadersSourceAPI interface {
+	SourceAPI
+	ImageHeaders(imageURL *url.URL) http.Header
+}
+
+// SourceHomeSection is a list of series displayed on the home page of the source
+type SourceHomeSection struct {
+	ID     string             `json:"id"`
+	Title  string             `json:"title"`
+	Series []SourceSmallSerie `json:"series"`
+}
+
+// HomeSectionsSourceAPI is a source with a home page made of several lists of series
+type HomeSectionsSourceAPI interface {
+	SourceAPI
+	FetchHomeSections(context context.Context) ([]SourceHomeSection, error)
+}
+
+// AuthorSeriesSourceAPI is a source listing the series of an author
+type AuthorSeriesSourceAPI interface {
+	SourceAPI
+	FetchAuthorSeries(context context.Context, author string, page int) (SourcePaginatedSmallSerie, error)
+}
+
+// RandomSerieSourceAPI is a source picking a serie at random
+type RandomSerieSourceAPI interface {
+	SourceAPI
+	FetchRandomSerie(context context.Context) (SourceSmallSerie, error)
+}
+
+// DetectCapabilities lists the optional interfaces implemented by the source
+func DetectCapabilities(source SourceAPI) []SourceCapability {
+	capabilities := []SourceCapability{}
+
+	if _, ok := source.(ConfigurableSourceAPI); ok {
+		capabilities = append(capabilities, CAPABILITY_SETTINGS)
+	}
+	if _, ok := source.(SerieChaptersSourceAPI); ok {
+		capabilities = append(capabilities, CAPABILITY_SERIE_CHAPTERS)
+	}
+	if _, ok := source.(ChapterURLSourceAPI); ok {
+		capabilities = append(capabilities, CAPABILITY_CHAPTER_URL)
+	}
+	if _, ok := source.(LoginSourceAPI); ok {
+		capabilities = append(capabilities, CAPABILITY_LOGIN)
+	}
+	if _, ok := source.(ImageHeadersSourceAPI); ok {
+		capabilities = append(capabilities, CAPABILITY_IMAGE_HEADERS)
+	}
+	if _, ok := source.(ImageDecrypter); ok {
+		capabilities = append(capabilities, CAPABILITY_IMAGE_DECRYPTION)
+	}
+	if _, ok := source.(ImageQualitySourceAPI); ok {
+		capabilities = append(capabilities, CAPABILITY_IMAGE_QUALITY)
+	}
+	if _, ok := source.(HomeSectionsSourceAPI); ok {
+		capabilities = append(capabilities, CAPABILITY_HOME_SECTIONS)
+	}
+	if _, ok := source.(AuthorSeriesSourceAPI); ok {
+		capabilities = append(capabilities, CAPABILITY_AUTHOR_SERIES)
+	}
+	if _, ok := source.(RandomSerieSourceAPI); ok {
+		capabilities = append(capabilities, CAPABILITY_RANDOM_SERIE)
+	}
+
+	return capabilities
+}
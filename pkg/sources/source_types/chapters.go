@@ -0,0 +1,88 @@
+package source_types
+
+import (
+	"context"
+	"time"
+)
+
+type FetchSerieChaptersFilter struct {
+	// Page of chapters, starting at 1
+	Page int `json:"page"`
+	// Only keep the chapters uploaded after this date, zero keeps every chapter
+	Since time.Time `json:"since"`
+}
+
+// SourcePaginatedSerieVolumes holds a page of chapters grouped by volume, a volume can span several pages
+type SourcePaginatedSerieVolumes struct {
+	HasNextPage bool                `json:"hasNextPage"`
+	Volumes     []SourceSerieVolume `json:"volumes"`
+}
+
+// SerieChaptersSourceAPI is a source fetching the metadata of a serie without its chapters, and its chapters page by page
+type SerieChaptersSourceAPI interface {
+	SourceAPI
+	FetchSerieMetadata(context context.Context, serieID SourceSerieID) (SourceSerie, error)
+	FetchSerieChapters(context context.Context, serieID SourceSerieID, filter FetchSerieChaptersFilter) (SourcePaginatedSerieVolumes, error)
+}
+
+// FetchSerieMetadata returns the serie without its volumes, falls back on FetchSerieDetail when the source can't split them
+func FetchSerieMetadata(context context.Context, source SourceAPI, serieID SourceSerieID) (SourceSerie, error) {
+	if s, ok := source.(SerieChaptersSourceAPI); ok {
+		return s.FetchSerieMetadata(context, serieID)
+	}
+
+	serie, err := source.FetchSerieDetail(context, serieID)
+	if err != nil {
+		return SourceSerie{}, err
+	}
+
+	serie.Volumes = []SourceSerieVolume{}
+
+	return serie, nil
+}
+
+// FetchSerieChapters returns a page of chapters, falls back on FetchSerieDetail with every chapter in the first page when the source can't paginate them
+func FetchSerieChapters(context context.Context, source SourceAPI, serieID SourceSerieID, filter FetchSerieChaptersFilter) (SourcePaginatedSerieVolumes, error) {
+	if s, ok := source.(SerieChaptersSourceAPI); ok {
+		return s.FetchSerieChapters(context, serieID, filter)
+	}
+
+	if filter.Page > 1 {
+		return SourcePaginatedSerieVolumes{Volumes: []SourceSerieVolume{}}, nil
+	}
+
+	serie, err := source.FetchSerieDetail(context, serieID)
+	if err != nil {
+		return SourcePaginatedSerieVolumes{}, err
+	}
+
+	return SourcePaginatedSerieVolumes{
+		Volumes: FilterVolumesSince(serie.Volumes, filter.Since),
+	}, nil
+}
+
+// FilterVolumesSince keeps the chapters uploaded after since and drops the volumes left empty, a zero since keeps everything
+func FilterVolumesSince(volumes []SourceSerieVolume, since time.Time) []SourceSerieVolume {
+	if since.IsZero() {
+		return volumes
+	}
+
+	filtered := []SourceSerieVolume{}
+	for _, volume := range volumes {
+		var chapters []SourceSerieVolumeChapter
+		for _, chapter := range volume.Chapters {
+			if chapter.DateUpload.After(since) {
+				chapters = append(chapters, chapter)
+			}
+		}
+
+		if len(chapters) == 0 {
+			continue
+		}
+
+		volume.Chapters = chapters
+		filtered = append(filtered, volume)
+	}
+
+	return filtered
+}
@@ -0,0 +1,61 @@
+package source_types_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dokusho/pkg/sources/mock"
+	"dokusho/pkg/sources/source_types"
+)
+
+func TestFetchSerieMetadataFallback(t *testing.T) {
+	t.Parallel()
+
+	serie, err := source_types.FetchSerieMetadata(context.Background(), mock.NewMockSource(), "ID")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if serie.Title.EN == "" {
+		t.Error("Serie title should not be empty")
+	}
+
+	if len(serie.Volumes) != 0 {
+		t.Errorf("Serie metadata should not have volumes, got %d", len(serie.Volumes))
+	}
+}
+
+func TestFetchSerieChaptersFallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		filter  source_types.FetchSerieChaptersFilter
+		volumes int
+	}{
+		{name: "first page", filter: source_types.FetchSerieChaptersFilter{Page: 1}, volumes: 2},
+		{name: "second page", filter: source_types.FetchSerieChaptersFilter{Page: 2}, volumes: 0},
+		{name: "since past", filter: source_types.FetchSerieChaptersFilter{Page: 1, Since: time.Now().Add(-time.Hour)}, volumes: 2},
+		{name: "since future", filter: source_types.FetchSerieChaptersFilter{Page: 1, Since: time.Now().Add(time.Hour)}, volumes: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			chapters, err := source_types.FetchSerieChapters(context.Background(), mock.NewMockSource(), "ID", tc.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if chapters.HasNextPage {
+				t.Error("Fallback should not have a next page")
+			}
+
+			if len(chapters.Volumes) != tc.volumes {
+				t.Errorf("Expected %d volumes, got %d", tc.volumes, len(chapters.Volumes))
+			}
+		})
+	}
+}
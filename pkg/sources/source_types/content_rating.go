@@ -0,0 +1,56 @@
+package source_types
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SourceContentRating is how explicit a serie is, sources map it to their own ratings
+type SourceContentRating string
+
+const (
+	CONTENT_RATING_SAFE         SourceContentRating = "safe"
+	CONTENT_RATING_SUGGESTIVE   SourceContentRating = "suggestive"
+	CONTENT_RATING_EROTICA      SourceContentRating = "erotica"
+	CONTENT_RATING_PORNOGRAPHIC SourceContentRating = "pornographic"
+)
+
+func (c SourceContentRating) String() string {
+	return string(c)
+}
+
+// IsNSFW reports whether the rating is only shown when NSFW content is allowed
+func (c SourceContentRating) IsNSFW() bool {
+	return c == CONTENT_RATING_EROTICA || c == CONTENT_RATING_PORNOGRAPHIC
+}
+
+func NewSourceContentRating(c string) (SourceContentRating, error) {
+	c = strings.TrimSpace(c)
+
+	switch c {
+	case "safe":
+		return CONTENT_RATING_SAFE, nil
+	case "suggestive":
+		return CONTENT_RATING_SUGGESTIVE, nil
+	case "erotica":
+		return CONTENT_RATING_EROTICA, nil
+	case "pornographic":
+		return CONTENT_RATING_PORNOGRAPHIC, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidContentRating, c)
+	}
+}
+
+type contentRatingsKey struct{}
+
+// WithContentRatings restricts the series listed with the context, for the feeds without a search filter
+func WithContentRatings(ctx context.Context, ratings []SourceContentRating) context.Context {
+	return context.WithValue(ctx, contentRatingsKey{}, ratings)
+}
+
+// ContentRatingsFromContext returns the ratings set with WithContentRatings, nil when none were set
+func ContentRatingsFromContext(ctx context.Context) []SourceContentRating {
+	ratings, _ := ctx.Value(contentRatingsKey{}).([]SourceContentRating)
+	return ratings
+}
@@ -0,0 +1,35 @@
+package source_types
+
+import "strings"
+
+// SourceSerieDemographic is the readership a serie is published for
+type SourceSerieDemographic string
+
+const (
+	DEMOGRAPHIC_SHOUNEN SourceSerieDemographic = "shounen"
+	DEMOGRAPHIC_SHOUJO  SourceSerieDemographic = "shoujo"
+	DEMOGRAPHIC_SEINEN  SourceSerieDemographic = "seinen"
+	DEMOGRAPHIC_JOSEI   SourceSerieDemographic = "josei"
+	DEMOGRAPHIC_UNKNOWN SourceSerieDemographic = ""
+)
+
+func (d SourceSerieDemographic) String() string {
+	return string(d)
+}
+
+func NewSourceSerieDemographic(d string) SourceSerieDemographic {
+	d = strings.ToLower(strings.TrimSpace(d))
+
+	switch d {
+	case "shounen", "shonen":
+		return DEMOGRAPHIC_SHOUNEN
+	case "shoujo", "shojo":
+		return DEMOGRAPHIC_SHOUJO
+	case "seinen":
+		return DEMOGRAPHIC_SEINEN
+	case "josei":
+		return DEMOGRAPHIC_JOSEI
+	default:
+		return DEMOGRAPHIC_UNKNOWN
+	}
+}
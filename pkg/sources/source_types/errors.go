@@ -10,17 +10,21 @@ var (
 	ErrInvalidSearchGenres = errors.New("invalid search genres")
 	ErrInvalidSearchStatus = errors.New("invalid search status")
 	ErrInvalidLanguage     = errors.New("invalid language")
+	ErrInvalidImageQuality = errors.New("invalid image quality")
+
+	ErrInvalidContentRating = errors.New("invalid content rating")
 
 	ErrInvalidSerieID = errors.New("invalid serie id")
 	ErrInvalidCover   = errors.New("invalid cover")
 
-	ErrBuildingRequest   = errors.New("error building request")
-	ErrHTTPRequestFailed = errors.New("http request failed")
-	ErrParsingHTML       = errors.New("error parsing html")
-	ErrParsingJSON       = errors.New("error parsing json")
-	ErrParsingURL        = errors.New("error parsing url")
-	ErrExtractingData    = errors.New("error extracting data")
-	ErrTimeout           = errors.New("timeout")
+	ErrBuildingRequest    = errors.New("error building request")
+	ErrHTTPRequestFailed  = errors.New("http request failed")
+	ErrParsingHTML        = errors.New("error parsing html")
+	ErrParsingJSON        = errors.New("error parsing json")
+	ErrParsingURL         = errors.New("error parsing url")
+	ErrExtractingData     = errors.New("error extracting data")
+	ErrTimeout            = errors.New("timeout")
+	ErrInvalidCredentials = errors.New("invalid credentials")
 
 	ErrBuildingURL = errors.New("error building url")
 )
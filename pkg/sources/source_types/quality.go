@@ -0,0 +1,52 @@
+package source_types
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SourceImageQuality is the quality of the chapter images served by a source
+type SourceImageQuality string
+
+const (
+	QUALITY_ORIGINAL   SourceImageQuality = "original"
+	QUALITY_DATA_SAVER SourceImageQuality = "data_saver"
+)
+
+func (q SourceImageQuality) String() string {
+	return string(q)
+}
+
+func NewSourceImageQuality(q string) (SourceImageQuality, error) {
+	q = strings.TrimSpace(q)
+
+	switch q {
+	case "original":
+		return QUALITY_ORIGINAL, nil
+	case "data_saver":
+		return QUALITY_DATA_SAVER, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidImageQuality, q)
+	}
+}
+
+// ImageQualitySourceAPI is a source serving the chapter images in the qualities of SourceInformation.ImageQualities
+type ImageQualitySourceAPI interface {
+	SourceAPI
+	FetchChapterDataQuality(context context.Context, serieID SourceSerieID, volumeID SourceSerieVolumeID, chapterID SourceSerieVolumeChapterID, quality SourceImageQuality) (SourceSerieVolumeChapterData, error)
+}
+
+// FetchChapterDataQuality returns the chapter images in the quality, falls back on FetchChapterData when the source has a single quality.
+// A source with a single quality serves its original images, any other quality is refused.
+func FetchChapterDataQuality(context context.Context, source SourceAPI, serieID SourceSerieID, volumeID SourceSerieVolumeID, chapterID SourceSerieVolumeChapterID, quality SourceImageQuality) (SourceSerieVolumeChapterData, error) {
+	if s, ok := source.(ImageQualitySourceAPI); ok {
+		return s.FetchChapterDataQuality(context, serieID, volumeID, chapterID, quality)
+	}
+
+	if quality != "" && quality != QUALITY_ORIGINAL {
+		return SourceSerieVolumeChapterData{}, fmt.Errorf("%w: %s has a single quality", ErrInvalidImageQuality, source.GetInformation().ID)
+	}
+
+	return source.FetchChapterData(context, serieID, volumeID, chapterID)
+}
@@ -0,0 +1,39 @@
+package source_types_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"dokusho/pkg/sources/mock"
+	"dokusho/pkg/sources/source_types"
+)
+
+func TestFetchChapterDataQualityFallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		quality  source_types.SourceImageQuality
+		expected error
+	}{
+		{name: "default quality", quality: "", expected: nil},
+		{name: "original", quality: source_types.QUALITY_ORIGINAL, expected: nil},
+		{name: "unsupported quality", quality: source_types.QUALITY_DATA_SAVER, expected: source_types.ErrInvalidImageQuality},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := source_types.FetchChapterDataQuality(context.Background(), mock.NewMockSource(), "ID", "volume", "chapter", tc.quality)
+			if !errors.Is(err, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, err)
+			}
+
+			if err == nil && data.Quality != "" {
+				t.Errorf("expected no quality for a source with a single quality, got %s", data.Quality)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package source_types
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"dokusho/pkg/http_utils"
+)
+
+// SourceSettings overrides the defaults of a source, zero values keep the default of the source
+type SourceSettings struct {
+	Enabled *bool
+	Timeout time.Duration
+	// Merged into the default headers, replacing headers with the same name
+	Headers http.Header
+	// Restricts the languages of the source, they must be supported by the source
+	Languages []SourceLanguage
+	NSFW      *bool
+	// Default quality of the chapter images, it must be one of the qualities of the source
+	ImageQuality SourceImageQuality
+	// Default ratings of the listed series, they must be supported by the search filters of the source
+	ContentRatings []SourceContentRating
+	// Credentials of the self-hosted servers, the other sources ignore them
+	Credentials SourceCredentials
+}
+
+// SourceCredentials authenticates to a server, either with a username and a password or with an API key
+type SourceCredentials struct {
+	Username string
+	Password string
+	APIKey   string
+}
+
+// ConfigurableSourceAPI is a source accepting settings, settings are applied once before the source is used
+type ConfigurableSourceAPI interface {
+	SourceAPI
+	Configure(settings SourceSettings) error
+}
+
+// Configure applies the settings to the information of the source and the timeout to its http client
+func (s *Source) Configure(settings SourceSettings) error {
+	if settings.Timeout > 0 {
+		s.SourceAPIInformation.Timeout = settings.Timeout
+	}
+
+	if len(settings.Headers) > 0 {
+		headers := s.SourceAPIInformation.Headers.Clone()
+		if headers == nil {
+			headers = http.Header{}
+		}
+
+		for name, values := range settings.Headers {
+			headers[http.CanonicalHeaderKey(name)] = slices.Clone(values)
+		}
+
+		s.SourceAPIInformation.Headers = headers
+	}
+
+	if len(settings.Languages) > 0 {
+		for _, language := range settings.Languages {
+			if !slices.Contains(s.SourceInformation.Languages, language) {
+				return ErrInvalidLanguage
+			}
+		}
+
+		s.SourceInformation.Languages = slices.Clone(settings.Languages)
+	}
+
+	if settings.NSFW != nil {
+		s.SourceInformation.NSFW = *settings.NSFW
+	}
+
+	if settings.ImageQuality != "" {
+		if !slices.Contains(s.SourceInformation.ImageQualities, settings.ImageQuality) {
+			return ErrInvalidImageQuality
+		}
+
+		s.SourceAPIInformation.ImageQuality = settings.ImageQuality
+	}
+
+	if len(settings.ContentRatings) > 0 {
+		for _, rating := range settings.ContentRatings {
+			if !slices.Contains(s.SourceInformation.SearchFilters.ContentRatings, rating) {
+				return ErrInvalidContentRating
+			}
+		}
+
+		s.SourceAPIInformation.ContentRatings = slices.Clone(settings.ContentRatings)
+	}
+
+	if s.HTTPClient != nil {
+		http_utils.SetClientTimeout(s.HTTPClient, s.SourceAPIInformation.Timeout)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package source_types_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"dokusho/pkg/http_utils"
+	"dokusho/pkg/sources/source_types"
+)
+
+func TestConfigureTimeout(t *testing.T) {
+	t.Parallel()
+
+	limited := http_utils.NewRateLimitedTransport(http.DefaultTransport, http_utils.RateLimit{Requests: 1, Per: time.Second}, nil, func(req *http.Request) string { return "" })
+
+	tests := []struct {
+		name     string
+		client   *http.Client
+		expected time.Duration
+	}{
+		{name: "whole request", client: &http.Client{Timeout: time.Second}, expected: 3 * time.Second},
+		// The timeout of a rate limited client is applied to each attempt by its transport
+		{name: "rate limited", client: &http.Client{Transport: limited}, expected: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			source := source_types.Source{
+				SourceAPIInformation: source_types.SourceAPIInformation{Timeout: time.Second},
+				HTTPClient:           tc.client,
+			}
+
+			err := source.Configure(source_types.SourceSettings{Timeout: 3 * time.Second})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if source.SourceAPIInformation.Timeout != 3*time.Second {
+				t.Errorf("expected source timeout 3s, got %s", source.SourceAPIInformation.Timeout)
+			}
+			if tc.client.Timeout != tc.expected {
+				t.Errorf("expected client timeout %s, got %s", tc.expected, tc.client.Timeout)
+			}
+		})
+	}
+}
@@ -2,14 +2,33 @@ package source_types
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/url"
 	"time"
 )
 
+// SourceSerieVolumeChapterImageHeaders are the headers the image host checks, empty ones are not sent
+type SourceSerieVolumeChapterImageHeaders struct {
+	Referer string `json:"referer,omitempty"`
+	Cookie  string `json:"cookie,omitempty"`
+}
+
 type SourceSerieVolumeChapterImage struct {
-	Index int    `json:"index"`
-	URL   string `json:"url"`
+	ID    SourceSerieVolumeChapterImageID `json:"id,omitempty"`
+	Index int                             `json:"index"`
+	URL   string                          `json:"url"`
+	// Headers to send with the request of this image
+	Headers SourceSerieVolumeChapterImageHeaders `json:"headers,omitzero"`
+	// Expected dimensions in pixels, zero when the source doesn't know them
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// MIME type of the image, empty when the source doesn't know it
+	MimeType string `json:"mimeType,omitempty"`
+	// The image can't be displayed as fetched, it must be descrambled or decrypted first
+	NeedsProcessing bool `json:"needsProcessing,omitempty"`
+	// Key of an encrypted image, the image proxy decrypts it when the source is an ImageDecrypter
+	EncryptionKey string `json:"encryptionKey,omitempty"`
 }
 
 type SourceSerieVolumeChapterText struct {
@@ -25,9 +44,17 @@ const (
 )
 
 type SourceSerieVolumeChapterData struct {
-	Type   SourceSerieVolumeChapterDataType `json:"type"`
-	Images []SourceSerieVolumeChapterImage  `json:"images,omitempty"`
-	Texts  []SourceSerieVolumeChapterText   `json:"texts,omitempty"`
+	Type SourceSerieVolumeChapterDataType `json:"type"`
+	// Quality of the images, empty when the source has a single quality
+	Quality SourceImageQuality              `json:"quality,omitempty"`
+	Images  []SourceSerieVolumeChapterImage `json:"images,omitempty"`
+	Texts   []SourceSerieVolumeChapterText  `json:"texts,omitempty"`
+}
+
+// SourceScanlationGroup is a group translating the chapters of a serie, the name can be empty when the source only knows the ID
+type SourceScanlationGroup struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
 }
 
 type SourceSerieVolumeChapter struct {
@@ -37,6 +64,8 @@ type SourceSerieVolumeChapter struct {
 	Language      SourceLanguage             `json:"language"`
 	DateUpload    time.Time                  `json:"dateUpload"`
 	ExternalURL   string                     `json:"externalURL,omitempty"`
+	// Groups releasing the chapter, empty when the source doesn't know them
+	Groups []SourceScanlationGroup `json:"groups,omitempty"`
 }
 
 type SourceSerieVolume struct {
@@ -48,17 +77,43 @@ type SourceSerieVolume struct {
 }
 
 type SourceSerie struct {
-	ID                SourceSerieID         `json:"id"`
-	Title             MultiLanguageString   `json:"title"`
-	AlternativeTitles []MultiLanguageString `json:"alternativeTitles,omitempty"`
-	Cover             string                `json:"cover"`
-	Synopsis          MultiLanguageString   `json:"synopsis"`
-	Type              SourceSerieType       `json:"type"`
-	Genres            []SourceSerieGenre    `json:"genres"`
-	Status            []SourceSerieStatus   `json:"status"`
-	Authors           []string              `json:"authors"`
-	Artists           []string              `json:"artists"`
-	Volumes           []SourceSerieVolume   `json:"volumes"`
+	ID                SourceSerieID            `json:"id"`
+	Title             MultiLanguageString      `json:"title"`
+	AlternativeTitles []MultiLanguageString    `json:"alternativeTitles,omitempty"`
+	Cover             string                   `json:"cover"`
+	Synopsis          MultiLanguageString      `json:"synopsis"`
+	Type              SourceSerieType          `json:"type"`
+	Genres            []SourceSerieGenre       `json:"genres"`
+	Status            []SourceSerieStatus      `json:"status"`
+	Authors           []string                 `json:"authors"`
+	Artists           []string                 `json:"artists"`
+	Volumes           []SourceSerieVolume      `json:"volumes"`
+	ExternalLinks     SourceSerieExternalLinks `json:"externalLinks"`
+	// Year of the first publication, zero when unknown
+	Year          int                    `json:"year,omitempty"`
+	Demographic   SourceSerieDemographic `json:"demographic,omitempty"`
+	ContentRating SourceContentRating    `json:"contentRating,omitempty"`
+	// Language the serie was first published in, empty when unknown or not a SourceLanguage
+	OriginalLanguage SourceLanguage `json:"originalLanguage,omitempty"`
+	// Last chapter and volume of the finished serie, zero when the source doesn't know them
+	FinalChapter float64 `json:"finalChapter,omitempty"`
+	FinalVolume  float64 `json:"finalVolume,omitempty"`
+}
+
+// SourceSerieExternalLinks holds the serie ID or slug on trackers and the URL of the stores, when the source knows them
+type SourceSerieExternalLinks struct {
+	AniList      string `json:"anilist,omitempty"`
+	MyAnimeList  string `json:"myanimelist,omitempty"`
+	AnimePlanet  string `json:"animeplanet,omitempty"`
+	Kitsu        string `json:"kitsu,omitempty"`
+	MangaUpdates string `json:"mangaupdates,omitempty"`
+	NovelUpdates string `json:"novelupdates,omitempty"`
+	// Path of the serie on https://bookwalker.jp, like series/248341
+	BookWalker      string `json:"bookwalker,omitempty"`
+	Amazon          string `json:"amazon,omitempty"`
+	EBookJapan      string `json:"ebookjapan,omitempty"`
+	Raw             string `json:"raw,omitempty"`
+	OfficialEnglish string `json:"officialEnglish,omitempty"`
 }
 
 type SourceSmallSerie struct {
@@ -86,6 +141,8 @@ type FetchSearchSerieFilter struct {
 	Genres  FetchSearchSerieFilterGenres `json:"genres"`
 	Types   []SourceSerieType            `json:"types"`
 	Status  []SourceSerieStatus          `json:"status"`
+	// Ratings of the series to list, empty uses the default ratings of the source
+	ContentRatings []SourceContentRating `json:"contentRatings"`
 }
 
 type SupportedFiltersGenres struct {
@@ -103,6 +160,8 @@ type SupportedFilters struct {
 	Types   []SourceSerieType             `json:"types"`
 	Genres  SupportedFiltersGenres        `json:"genres"`
 	Status  []SourceSerieStatus           `json:"status"`
+	// Ratings the source can filter on, empty when it can't
+	ContentRatings []SourceContentRating `json:"contentRatings"`
 }
 
 type SourceInformation struct {
@@ -115,6 +174,10 @@ type SourceInformation struct {
 	Version       string           `json:"version"`
 	NSFW          bool             `json:"nsfw"`
 	SearchFilters SupportedFilters `json:"supportedFilters"`
+	// Qualities of the chapter images, empty when the source has a single quality
+	ImageQualities []SourceImageQuality `json:"imageQualities,omitempty"`
+	// Optional features of the source, filled by the registry with DetectCapabilities
+	Capabilities []SourceCapability `json:"capabilities"`
 }
 
 type SourceAPIInformation struct {
@@ -123,11 +186,21 @@ type SourceAPIInformation struct {
 	MinimumUpdateInterval time.Duration `json:"minimumUpdateInterval"`
 	Timeout               time.Duration `json:"timeout"`
 	CanBlockScraping      bool          `json:"canBlockScraping"`
+	// Hosts serving the source images, allowed to go through the image proxy. "*.example.com" matches every subdomain
+	ImageHosts []string `json:"imageHosts"`
+	// The source is a server of the operator, the image proxy can reach its image hosts on private addresses
+	SelfHosted bool `json:"selfHosted"`
+	// Quality of the chapter images when the request doesn't choose one
+	ImageQuality SourceImageQuality `json:"imageQuality,omitempty"`
+	// Ratings of the listed series when the request doesn't choose them
+	ContentRatings []SourceContentRating `json:"contentRatings,omitempty"`
 }
 
 type Source struct {
 	SourceInformation
 	SourceAPIInformation
+	// Client of the source, Configure applies the timeout to it
+	HTTPClient *http.Client
 }
 
 type SourceAPI interface {
@@ -141,3 +214,8 @@ type SourceAPI interface {
 	FetchChapterData(context context.Context, serieID SourceSerieID, volumeID SourceSerieVolumeID, chapterID SourceSerieVolumeChapterID) (SourceSerieVolumeChapterData, error)
 	SerieUrl(serieID SourceSerieID) (*url.URL, error)
 }
+
+// ImageDecrypter is a source serving encrypted images, the image proxy decrypts them with the key of the image
+type ImageDecrypter interface {
+	DecryptImage(r io.Reader, key string) (io.Reader, error)
+}
@@ -2,21 +2,70 @@ package sources
 
 import (
 	"dokusho/pkg/config"
+	"dokusho/pkg/sources/declarative"
+	"dokusho/pkg/sources/javascript"
 	"dokusho/pkg/sources/mock"
+	"dokusho/pkg/sources/scrapers/komga"
 	"dokusho/pkg/sources/scrapers/mangadex"
+	"dokusho/pkg/sources/scrapers/mangaplus"
+	"dokusho/pkg/sources/scrapers/royalroad"
 	"dokusho/pkg/sources/scrapers/weebcentral"
 	"dokusho/pkg/sources/source_types"
+	"dokusho/pkg/sources/wasm"
 )
 
-func BuildSources(cfg *config.SourceBaseConfig) []source_types.SourceAPI {
+func BuildSources(cfg *config.SourceBaseConfig) (*Registry, error) {
 	sources := []source_types.SourceAPI{
 		weebcentral.NewWeebCentral(),
 		mangadex.NewMangadex(),
+		mangaplus.NewMangaPlus(),
+		royalroad.NewRoyalRoad(),
 	}
 
 	if cfg.SourceUseMock {
 		sources = append(sources, mock.NewMockSource())
 	}
 
-	return sources
+	if cfg.SourceDefinitionsDir != "" {
+		definitions, err := declarative.LoadDirectory(cfg.SourceDefinitionsDir)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, definitions...)
+	}
+
+	if cfg.SourceWasmDir != "" {
+		modules, err := wasm.LoadDirectory(cfg.SourceWasmDir)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, modules...)
+	}
+
+	if cfg.SourceJSDir != "" {
+		scripts, err := javascript.LoadDirectory(cfg.SourceJSDir)
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, scripts...)
+	}
+
+	if cfg.SourceKomgaURL != "" {
+		server, err := komga.NewKomga(komga.Config{URL: cfg.SourceKomgaURL, Libraries: cfg.SourceKomgaLibraries})
+		if err != nil {
+			return nil, err
+		}
+
+		sources = append(sources, server)
+	}
+
+	settings, err := LoadSettings(cfg.SourceSettingsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRegistry(settings, sources...)
 }
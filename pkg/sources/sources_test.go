@@ -9,7 +9,12 @@ import (
 func TestGetSources(t *testing.T) {
 	t.Parallel()
 
-	sources := sources.BuildSources(&config.SourceBaseConfig{SourceUseMock: true})
+	registry, err := sources.BuildSources(&config.SourceBaseConfig{SourceUseMock: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := registry.All()
 
 	if len(sources) == 0 {
 		t.Error("No sources found")
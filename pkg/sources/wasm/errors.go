@@ -0,0 +1,9 @@
+package wasm
+
+import "errors"
+
+var (
+	ErrReadingModule = errors.New("error reading wasm module")
+	ErrInvalidModule = errors.New("invalid wasm module")
+	ErrCallingModule = errors.New("error calling wasm module")
+)
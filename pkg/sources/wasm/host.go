@@ -0,0 +1,214 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"dokusho/pkg/sources/extension"
+	"dokusho/pkg/sources/source_types"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// Modules exchange JSON with the host. A module exports its memory, "alloc(size i32) i32" used by the host
+// to write in it and the operations "(ptr i32, len i32) i64". The request of an operation is at ptr, its
+// result is returned as the pointer in the high 32 bits and the length in the low 32 bits.
+// Host functions are imported from the "dokusho" module with the same signature.
+// Results and host responses are either {"result": ...} or {"error": "..."}.
+const hostModule = "dokusho"
+
+type envelope struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type htmlSelectRequest struct {
+	HTML     string `json:"html"`
+	Selector string `json:"selector"`
+}
+
+type htmlElement struct {
+	Text  string            `json:"text"`
+	HTML  string            `json:"html"`
+	Attrs map[string]string `json:"attrs"`
+}
+
+type jsonQueryRequest struct {
+	JSON string `json:"json"`
+	// Dot separated keys and array indexes, "data.0.id". The whole document when empty
+	Path string `json:"path"`
+}
+
+type logRequest struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+func (s *wasmSource) instantiateHost(ctx context.Context) error {
+	_, err := s.runtime.NewHostModuleBuilder(hostModule).
+		NewFunctionBuilder().WithFunc(hostFunction(s.fetch)).Export("fetch").
+		NewFunctionBuilder().WithFunc(hostFunction(htmlSelect)).Export("html_select").
+		NewFunctionBuilder().WithFunc(hostFunction(jsonQuery)).Export("json_query").
+		NewFunctionBuilder().WithFunc(hostFunction(s.log)).Export("log").
+		Instantiate(ctx)
+
+	return err
+}
+
+// hostFunction decodes the request of the module and writes the response of the handler in its memory
+func hostFunction[Req any, Res any](handler func(ctx context.Context, req Req) (Res, error)) func(ctx context.Context, mod api.Module, ptr, size uint32) uint64 {
+	return func(ctx context.Context, mod api.Module, ptr, size uint32) uint64 {
+		var response envelope
+
+		var req Req
+		err := readJSON(mod, ptr, size, &req)
+		if err == nil {
+			var res Res
+			res, err = handler(ctx, req)
+			if err == nil {
+				response.Result, err = json.Marshal(res)
+			}
+		}
+		if err != nil {
+			response.Error = err.Error()
+		}
+
+		packed, err := writeJSON(ctx, mod, response)
+		if err != nil {
+			// Aborts the call of the module
+			panic(err)
+		}
+
+		return packed
+	}
+}
+
+func readJSON(mod api.Module, ptr, size uint32, v any) error {
+	data, ok := mod.Memory().Read(ptr, size)
+	if !ok {
+		return errors.Join(ErrCallingModule, fmt.Errorf("out of range memory read at %d of %d bytes", ptr, size))
+	}
+
+	err := json.Unmarshal(data, v)
+	if err != nil {
+		return errors.Join(ErrCallingModule, err, fmt.Errorf("invalid json from the module"))
+	}
+
+	return nil
+}
+
+// writeJSON allocates the value in the memory of the module and returns its packed pointer and length
+func writeJSON(ctx context.Context, mod api.Module, v any) (uint64, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, errors.Join(ErrCallingModule, err, fmt.Errorf("failed to encode json for the module"))
+	}
+
+	res, err := mod.ExportedFunction("alloc").Call(ctx, uint64(len(data)))
+	if err != nil {
+		return 0, errors.Join(ErrCallingModule, err, fmt.Errorf("failed to allocate %d bytes", len(data)))
+	}
+
+	ptr := uint32(res[0])
+	if !mod.Memory().Write(ptr, data) {
+		return 0, errors.Join(ErrCallingModule, fmt.Errorf("out of range memory write at %d of %d bytes", ptr, len(data)))
+	}
+
+	return uint64(ptr)<<32 | uint64(len(data)), nil
+}
+
+// fetch sends the request with the current headers of the source
+func (s *wasmSource) fetch(ctx context.Context, req extension.FetchRequest) (extension.FetchResponse, error) {
+	return s.fetcher.Fetch(ctx, s.SourceAPIInformation.Headers, req)
+}
+
+func htmlSelect(_ context.Context, req htmlSelectRequest) ([]htmlElement, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(req.HTML))
+	if err != nil {
+		return nil, errors.Join(source_types.ErrParsingHTML, err)
+	}
+
+	selector, err := cascadia.Compile(req.Selector)
+	if err != nil {
+		return nil, errors.Join(source_types.ErrParsingHTML, err, fmt.Errorf("invalid selector %q", req.Selector))
+	}
+
+	selection := doc.FindMatcher(selector)
+
+	elements := make([]htmlElement, 0, selection.Length())
+	selection.Each(func(_ int, s *goquery.Selection) {
+		html, _ := s.Html()
+
+		attrs := map[string]string{}
+		for _, attr := range s.Nodes[0].Attr {
+			attrs[attr.Key] = attr.Val
+		}
+
+		elements = append(elements, htmlElement{Text: strings.TrimSpace(s.Text()), HTML: html, Attrs: attrs})
+	})
+
+	return elements, nil
+}
+
+func jsonQuery(_ context.Context, req jsonQueryRequest) (json.RawMessage, error) {
+	value := json.RawMessage(req.JSON)
+	if req.Path == "" {
+		return value, nil
+	}
+
+	for _, key := range strings.Split(req.Path, ".") {
+		trimmed := bytes.TrimSpace(value)
+
+		if len(trimmed) > 0 && trimmed[0] == '[' {
+			var array []json.RawMessage
+			err := json.Unmarshal(trimmed, &array)
+			if err != nil {
+				return nil, errors.Join(source_types.ErrParsingJSON, err)
+			}
+
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(array) {
+				return nil, errors.Join(source_types.ErrParsingJSON, fmt.Errorf("invalid index %q in %q", key, req.Path))
+			}
+
+			value = array[index]
+			continue
+		}
+
+		var object map[string]json.RawMessage
+		err := json.Unmarshal(trimmed, &object)
+		if err != nil {
+			return nil, errors.Join(source_types.ErrParsingJSON, err, fmt.Errorf("can't read %q in %q", key, req.Path))
+		}
+
+		v, ok := object[key]
+		if !ok {
+			return json.RawMessage("null"), nil
+		}
+		value = v
+	}
+
+	return value, nil
+}
+
+func (s *wasmSource) log(_ context.Context, req logRequest) (struct{}, error) {
+	switch req.Level {
+	case "debug":
+		s.logger.Debug(req.Message)
+	case "warn":
+		s.logger.Warn(req.Message)
+	case "error":
+		s.logger.Error(req.Message)
+	default:
+		s.logger.Info(req.Message)
+	}
+
+	return struct{}{}, nil
+}
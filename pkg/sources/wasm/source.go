@@ -0,0 +1,279 @@
+package wasm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"dokusho/pkg/sources/extension"
+	"dokusho/pkg/sources/source_types"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// Operations exported by the modules, one per SourceAPI method
+var operations = []string{
+	"fetch_popular_serie",
+	"fetch_latest_updates",
+	"fetch_search_serie",
+	"fetch_serie_detail",
+	"fetch_chapter_data",
+	"serie_url",
+}
+
+// operationRequest is given to the operations, only the fields of the operation are set
+type operationRequest struct {
+	BaseURL   string                                  `json:"baseURL"`
+	Page      int                                     `json:"page,omitempty"`
+	Filter    *source_types.FetchSearchSerieFilter    `json:"filter,omitempty"`
+	SerieID   source_types.SourceSerieID              `json:"serieID,omitempty"`
+	VolumeID  source_types.SourceSerieVolumeID        `json:"volumeID,omitempty"`
+	ChapterID source_types.SourceSerieVolumeChapterID `json:"chapterID,omitempty"`
+}
+
+type wasmSource struct {
+	source_types.Source
+
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	fetcher  *extension.Fetcher
+	logger   *slog.Logger
+}
+
+// NewSource compiles the module and checks it implements the operations, every call runs in a new instance
+func NewSource(manifest extension.Manifest, module []byte) (*wasmSource, error) {
+	err := manifest.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	s := &wasmSource{
+		Source: manifest.Source(),
+		runtime: wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+			// 64 KiB pages
+			WithMemoryLimitPages(uint32(manifest.MemoryLimitBytes()>>16)).
+			WithCloseOnContextDone(true)),
+		fetcher: extension.NewFetcher(manifest),
+		logger:  slog.Default().WithGroup(string(manifest.ID)),
+	}
+	s.HTTPClient = s.fetcher.Client
+
+	err = s.compile(ctx, module)
+	if err != nil {
+		s.runtime.Close(ctx)
+		return nil, errors.Join(err, fmt.Errorf("invalid module %s", manifest.ID))
+	}
+
+	return s, nil
+}
+
+func (s *wasmSource) compile(ctx context.Context, module []byte) error {
+	_, err := wasi_snapshot_preview1.Instantiate(ctx, s.runtime)
+	if err != nil {
+		return errors.Join(ErrInvalidModule, err, fmt.Errorf("failed to instantiate wasi"))
+	}
+
+	err = s.instantiateHost(ctx)
+	if err != nil {
+		return errors.Join(ErrInvalidModule, err, fmt.Errorf("failed to instantiate host functions"))
+	}
+
+	s.compiled, err = s.runtime.CompileModule(ctx, module)
+	if err != nil {
+		return errors.Join(ErrInvalidModule, err, fmt.Errorf("failed to compile module"))
+	}
+
+	for _, imported := range s.compiled.ImportedFunctions() {
+		moduleName, _, _ := imported.Import()
+		if moduleName != hostModule && moduleName != wasi_snapshot_preview1.ModuleName {
+			return errors.Join(ErrInvalidModule, fmt.Errorf("unknown import module %s", moduleName))
+		}
+	}
+
+	if _, ok := s.compiled.ExportedMemories()["memory"]; !ok {
+		return errors.Join(ErrInvalidModule, fmt.Errorf("memory isn't exported"))
+	}
+
+	exports := s.compiled.ExportedFunctions()
+	expected := map[string][]api.ValueType{"alloc": {api.ValueTypeI32}}
+	for _, operation := range operations {
+		expected[operation] = []api.ValueType{api.ValueTypeI64}
+	}
+
+	for name, results := range expected {
+		fn, ok := exports[name]
+		if !ok {
+			return errors.Join(ErrInvalidModule, fmt.Errorf("%s isn't exported", name))
+		}
+
+		params := []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}
+		if name == "alloc" {
+			params = params[:1]
+		}
+
+		if !slices.Equal(fn.ParamTypes(), params) || !slices.Equal(fn.ResultTypes(), results) {
+			return errors.Join(ErrInvalidModule, fmt.Errorf("invalid signature for %s", name))
+		}
+	}
+
+	return nil
+}
+
+// call runs the operation in a new instance of the module, the instance is closed when the call times out
+func (s *wasmSource) call(ctx context.Context, operation string, req operationRequest, result any) error {
+	ctx, cancel := context.WithTimeout(ctx, s.SourceAPIInformation.Timeout)
+	defer cancel()
+
+	mod, err := s.runtime.InstantiateModule(ctx, s.compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize").
+		WithRandSource(rand.Reader).
+		WithSysWalltime().
+		WithSysNanotime())
+	if err != nil {
+		return errors.Join(ErrCallingModule, err, fmt.Errorf("failed to instantiate module for %s", operation))
+	}
+	defer mod.Close(context.Background())
+
+	req.BaseURL = s.SourceInformation.URL
+	packed, err := writeJSON(ctx, mod, req)
+	if err != nil {
+		return err
+	}
+
+	res, err := mod.ExportedFunction(operation).Call(ctx, packed>>32, packed&0xffffffff)
+	if err != nil {
+		if ctx.Err() != nil {
+			return errors.Join(source_types.ErrTimeout, ErrCallingModule, err, fmt.Errorf("%s timed out", operation))
+		}
+
+		return errors.Join(ErrCallingModule, err, fmt.Errorf("%s failed", operation))
+	}
+
+	var response envelope
+	err = readJSON(mod, uint32(res[0]>>32), uint32(res[0]), &response)
+	if err != nil {
+		return err
+	}
+
+	if response.Error != "" {
+		return errors.Join(ErrCallingModule, fmt.Errorf("%s failed: %s", operation, response.Error))
+	}
+
+	err = json.Unmarshal(response.Result, result)
+	if err != nil {
+		return errors.Join(ErrCallingModule, source_types.ErrParsingJSON, err, fmt.Errorf("invalid result for %s", operation))
+	}
+
+	return nil
+}
+
+func (s *wasmSource) GetInformation() source_types.SourceInformation {
+	return s.Source.SourceInformation
+}
+
+func (s *wasmSource) GetAPIInformation() source_types.SourceAPIInformation {
+	return s.Source.SourceAPIInformation
+}
+
+func (s *wasmSource) FetchPopularSerie(ctx context.Context, page int) (source_types.SourcePaginatedSmallSerie, error) {
+	var result source_types.SourcePaginatedSmallSerie
+	err := s.call(ctx, "fetch_popular_serie", operationRequest{Page: page}, &result)
+
+	return result, err
+}
+
+func (s *wasmSource) FetchLatestUpdates(ctx context.Context, page int) (source_types.SourcePaginatedSmallSerie, error) {
+	var result source_types.SourcePaginatedSmallSerie
+	err := s.call(ctx, "fetch_latest_updates", operationRequest{Page: page}, &result)
+
+	return result, err
+}
+
+func (s *wasmSource) FetchSearchSerie(ctx context.Context, page int, filter source_types.FetchSearchSerieFilter) (source_types.SourcePaginatedSmallSerie, error) {
+	var result source_types.SourcePaginatedSmallSerie
+	err := s.call(ctx, "fetch_search_serie", operationRequest{Page: page, Filter: &filter}, &result)
+
+	return result, err
+}
+
+func (s *wasmSource) FetchSerieDetail(ctx context.Context, serieID source_types.SourceSerieID) (source_types.SourceSerie, error) {
+	var result source_types.SourceSerie
+	err := s.call(ctx, "fetch_serie_detail", operationRequest{SerieID: serieID}, &result)
+
+	return result, err
+}
+
+func (s *wasmSource) FetchChapterData(ctx context.Context, serieID source_types.SourceSerieID, volumeID source_types.SourceSerieVolumeID, chapterID source_types.SourceSerieVolumeChapterID) (source_types.SourceSerieVolumeChapterData, error) {
+	var result source_types.SourceSerieVolumeChapterData
+	err := s.call(ctx, "fetch_chapter_data", operationRequest{SerieID: serieID, VolumeID: volumeID, ChapterID: chapterID}, &result)
+
+	return result, err
+}
+
+func (s *wasmSource) SerieUrl(serieID source_types.SourceSerieID) (*url.URL, error) {
+	var raw string
+	err := s.call(context.Background(), "serie_url", operationRequest{SerieID: serieID}, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, errors.Join(source_types.ErrBuildingURL, err, fmt.Errorf("invalid serie url: %s", raw))
+	}
+
+	return u, nil
+}
+
+// LoadDirectory builds a source for every module of the directory, "name.wasm" is described by "name.json"
+func LoadDirectory(dir string) ([]source_types.SourceAPI, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Join(ErrReadingModule, err, fmt.Errorf("failed to read modules directory %s", dir))
+	}
+
+	apis := []source_types.SourceAPI{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".wasm" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		manifest, err := extension.LoadManifest(strings.TrimSuffix(path, ".wasm") + ".json")
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("failed to load manifest of %s", path))
+		}
+
+		module, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Join(ErrReadingModule, err, fmt.Errorf("failed to read module %s", path))
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, errors.Join(ErrReadingModule, err, fmt.Errorf("failed to stat module %s", path))
+		}
+		manifest.UpdatedAt = info.ModTime().UTC()
+
+		api, err := NewSource(manifest, module)
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("failed to build source from %s", path))
+		}
+
+		apis = append(apis, api)
+	}
+
+	return apis, nil
+}
@@ -0,0 +1,270 @@
+// Guest module used by the tests, built with GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"unsafe"
+)
+
+type request struct {
+	BaseURL   string `json:"baseURL"`
+	Page      int    `json:"page"`
+	SerieID   string `json:"serieID"`
+	ChapterID string `json:"chapterID"`
+	Filter    struct {
+		Query string `json:"query"`
+	} `json:"filter"`
+}
+
+type envelope struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type smallSerie struct {
+	ID    string            `json:"id"`
+	Title map[string]string `json:"title"`
+	Cover string            `json:"cover"`
+}
+
+type paginated struct {
+	HasNextPage bool         `json:"hasNextPage"`
+	Series      []smallSerie `json:"series"`
+}
+
+type element struct {
+	Text  string            `json:"text"`
+	HTML  string            `json:"html"`
+	Attrs map[string]string `json:"attrs"`
+}
+
+type fetchResponse struct {
+	Status int    `json:"status"`
+	Body   string `json:"body"`
+}
+
+// Keeps the allocated buffers alive, an instance only serves one call
+var buffers [][]byte
+
+//go:wasmexport alloc
+func alloc(size uint32) uint32 {
+	buf := make([]byte, max(size, 1))
+	buffers = append(buffers, buf)
+
+	return uint32(uintptr(unsafe.Pointer(&buf[0])))
+}
+
+//go:wasmimport dokusho fetch
+func hostFetch(ptr, size uint32) uint64
+
+//go:wasmimport dokusho html_select
+func hostHTMLSelect(ptr, size uint32) uint64
+
+//go:wasmimport dokusho json_query
+func hostJSONQuery(ptr, size uint32) uint64
+
+//go:wasmimport dokusho log
+func hostLog(ptr, size uint32) uint64
+
+func read(packed uint64) []byte {
+	return unsafe.Slice((*byte)(unsafe.Pointer(uintptr(packed>>32))), uint32(packed))
+}
+
+func write(data []byte) uint64 {
+	ptr := alloc(uint32(len(data)))
+	copy(unsafe.Slice((*byte)(unsafe.Pointer(uintptr(ptr))), len(data)), data)
+
+	return uint64(ptr)<<32 | uint64(len(data))
+}
+
+func host(fn func(ptr, size uint32) uint64, req any, result any) error {
+	data, _ := json.Marshal(req)
+	packed := write(data)
+
+	var response envelope
+	err := json.Unmarshal(read(fn(uint32(packed>>32), uint32(packed))), &response)
+	if err != nil {
+		return err
+	}
+
+	if response.Error != "" {
+		return errors.New(response.Error)
+	}
+
+	return json.Unmarshal(response.Result, result)
+}
+
+func fetch(u string) (string, error) {
+	var res fetchResponse
+	err := host(hostFetch, map[string]string{"url": u}, &res)
+	if err != nil {
+		return "", err
+	}
+
+	if res.Status != 200 {
+		return "", errors.New("unexpected status " + strconv.Itoa(res.Status))
+	}
+
+	return res.Body, nil
+}
+
+func selectHTML(html, selector string) ([]element, error) {
+	var elements []element
+	err := host(hostHTMLSelect, map[string]string{"html": html, "selector": selector}, &elements)
+
+	return elements, err
+}
+
+// operation decodes the request and encodes the result of handler
+func operation(ptr, size uint32, handler func(req request) (any, error)) uint64 {
+	var response envelope
+
+	var req request
+	err := json.Unmarshal(read(uint64(ptr)<<32|uint64(size)), &req)
+	if err == nil {
+		var result any
+		result, err = handler(req)
+		if err == nil {
+			response.Result, err = json.Marshal(result)
+		}
+	}
+	if err != nil {
+		response.Error = err.Error()
+	}
+
+	data, _ := json.Marshal(response)
+	return write(data)
+}
+
+//go:wasmexport fetch_popular_serie
+func fetchPopularSerie(ptr, size uint32) uint64 {
+	return operation(ptr, size, func(req request) (any, error) {
+		html, err := fetch(req.BaseURL + "/popular?page=" + strconv.Itoa(req.Page))
+		if err != nil {
+			return nil, err
+		}
+
+		items, err := selectHTML(html, "div.serie")
+		if err != nil {
+			return nil, err
+		}
+
+		result := paginated{Series: []smallSerie{}}
+		for _, item := range items {
+			covers, err := selectHTML(item.HTML, "img")
+			if err != nil || len(covers) == 0 {
+				return nil, errors.New("missing cover")
+			}
+
+			result.Series = append(result.Series, smallSerie{
+				ID:    item.Attrs["data-id"],
+				Title: map[string]string{"en": item.Text},
+				Cover: req.BaseURL + covers[0].Attrs["src"],
+			})
+		}
+
+		next, err := selectHTML(html, "a.next")
+		result.HasNextPage = err == nil && len(next) > 0
+
+		return result, nil
+	})
+}
+
+// Page 1 fails, page 2 never returns and page 3 fetches a host that isn't allowed
+//
+//go:wasmexport fetch_latest_updates
+func fetchLatestUpdates(ptr, size uint32) uint64 {
+	return operation(ptr, size, func(req request) (any, error) {
+		switch req.Page {
+		case 2:
+			for {
+			}
+		case 3:
+			_, err := fetch("https://forbidden.example.com/latest")
+			return nil, err
+		default:
+			return nil, errors.New("latest updates aren't available")
+		}
+	})
+}
+
+//go:wasmexport fetch_search_serie
+func fetchSearchSerie(ptr, size uint32) uint64 {
+	return operation(ptr, size, func(req request) (any, error) {
+		body, err := fetch(req.BaseURL + "/search?q=" + url.QueryEscape(req.Filter.Query))
+		if err != nil {
+			return nil, err
+		}
+
+		var title string
+		err = host(hostJSONQuery, map[string]string{"json": body, "path": "data.0.attributes.title"}, &title)
+		if err != nil {
+			return nil, err
+		}
+
+		var id string
+		err = host(hostJSONQuery, map[string]string{"json": body, "path": "data.0.id"}, &id)
+		if err != nil {
+			return nil, err
+		}
+
+		var empty struct{}
+		_ = host(hostLog, map[string]string{"level": "debug", "message": "found " + id}, &empty)
+
+		return paginated{Series: []smallSerie{{ID: id, Title: map[string]string{"en": title}}}}, nil
+	})
+}
+
+//go:wasmexport fetch_serie_detail
+func fetchSerieDetail(ptr, size uint32) uint64 {
+	return operation(ptr, size, func(req request) (any, error) {
+		body, err := fetch(req.BaseURL + "/series/" + url.PathEscape(req.SerieID))
+		if err != nil {
+			return nil, err
+		}
+
+		// The site already returns the dokusho format
+		return json.RawMessage(body), nil
+	})
+}
+
+//go:wasmexport fetch_chapter_data
+func fetchChapterData(ptr, size uint32) uint64 {
+	return operation(ptr, size, func(req request) (any, error) {
+		html, err := fetch(req.BaseURL + "/chapters/" + url.PathEscape(req.ChapterID))
+		if err != nil {
+			return nil, err
+		}
+
+		images, err := selectHTML(html, "img")
+		if err != nil {
+			return nil, err
+		}
+
+		type image struct {
+			Index int    `json:"index"`
+			URL   string `json:"url"`
+		}
+		result := struct {
+			Type   string  `json:"type"`
+			Images []image `json:"images"`
+		}{Type: "image"}
+		for i, img := range images {
+			result.Images = append(result.Images, image{Index: i + 1, URL: img.Attrs["src"]})
+		}
+
+		return result, nil
+	})
+}
+
+//go:wasmexport serie_url
+func serieURL(ptr, size uint32) uint64 {
+	return operation(ptr, size, func(req request) (any, error) {
+		return req.BaseURL + "/series/" + url.PathEscape(req.SerieID), nil
+	})
+}
+
+func main() {}
@@ -0,0 +1,303 @@
+package wasm_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"dokusho/pkg/sources/extension"
+	"dokusho/pkg/sources/source_types"
+	"dokusho/pkg/sources/wasm"
+)
+
+var (
+	guestModule []byte
+	server      *httptest.Server
+	source      source_types.SourceAPI
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	dir, err := os.MkdirTemp("", "wasm_test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, "guest.wasm")
+	cmd := exec.Command("go", "build", "-buildmode=c-shared", "-o", output, "./testdata/guest")
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to build the guest module:", err)
+		return 1
+	}
+
+	guestModule, err = os.ReadFile(output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /popular", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Source") != "guest" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		fmt.Fprintf(w, `<html><body>
+			<div class="serie" data-id="one-piece"><img src="/covers/one-piece.jpg">One Piece</div>
+			<div class="serie" data-id="frieren"><img src="/covers/frieren.jpg">Frieren</div>
+			%s
+		</body></html>`, map[bool]string{true: `<a class="next" href="/popular?page=2">Next</a>`}[r.URL.Query().Get("page") == "1"])
+	})
+	mux.HandleFunc("GET /search", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data": [{"id": "frieren", "attributes": {"title": %q}}]}`, "Frieren: "+r.URL.Query().Get("q"))
+	})
+	mux.HandleFunc("GET /series/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id": %q, "title": {"en": "Frieren"}, "type": "manga", "volumes": [{"id": "volume-1", "chapters": [{"id": "1", "chapterNumber": 1}]}]}`, r.PathValue("id"))
+	})
+	mux.HandleFunc("GET /chapters/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><img src="https://cdn.example.com/1.png"><img src="https://cdn.example.com/2.png"></body></html>`)
+	})
+
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	source, err = wasm.NewSource(newManifest(), guestModule)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return m.Run()
+}
+
+func newManifest() extension.Manifest {
+	manifest := extension.Manifest{
+		ID:        "guest",
+		Name:      "Guest",
+		URL:       server.URL,
+		Languages: []string{"en"},
+		Headers:   map[string]string{"X-Source": "guest"},
+		Timeout:   "2s",
+	}
+	manifest.Filters.Query = true
+	manifest.Filters.Sorts = []string{"Latest", "Popularity"}
+	manifest.Filters.Genres.Included = true
+	manifest.Filters.Genres.Values = []string{"Action", "Fantasy"}
+
+	return manifest
+}
+
+func TestInformation(t *testing.T) {
+	t.Parallel()
+
+	info := source.GetInformation()
+	if info.ID != "guest" || !info.SearchFilters.Query || len(info.SearchFilters.Sorts) != 2 || len(info.SearchFilters.Genres.PossibleValues) != 2 {
+		t.Errorf("unexpected information %+v", info)
+	}
+}
+
+func TestFetchPopularSerie(t *testing.T) {
+	t.Parallel()
+
+	result, err := source.FetchPopularSerie(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.HasNextPage || len(result.Series) != 2 {
+		t.Fatalf("expected 2 series and a next page, got %+v", result)
+	}
+
+	expected := source_types.SourceSmallSerie{
+		ID:    "one-piece",
+		Title: source_types.MultiLanguageString{EN: "One Piece"},
+		Cover: server.URL + "/covers/one-piece.jpg",
+	}
+	if result.Series[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, result.Series[0])
+	}
+}
+
+func TestFetchSearchSerie(t *testing.T) {
+	t.Parallel()
+
+	result, err := source.FetchSearchSerie(context.Background(), 1, source_types.FetchSearchSerieFilter{Query: "beyond journey's end"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Series) != 1 || result.Series[0].ID != "frieren" || result.Series[0].Title.EN != "Frieren: beyond journey's end" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestFetchSerieDetail(t *testing.T) {
+	t.Parallel()
+
+	serie, err := source.FetchSerieDetail(context.Background(), "frieren")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if serie.ID != "frieren" || serie.Type != source_types.TYPE_MANGA || len(serie.Volumes) != 1 || len(serie.Volumes[0].Chapters) != 1 {
+		t.Errorf("unexpected serie %+v", serie)
+	}
+}
+
+func TestFetchChapterData(t *testing.T) {
+	t.Parallel()
+
+	data, err := source.FetchChapterData(context.Background(), "frieren", "volume-1", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Type != source_types.IMAGE || len(data.Images) != 2 || data.Images[1].URL != "https://cdn.example.com/2.png" {
+		t.Errorf("unexpected chapter data %+v", data)
+	}
+}
+
+func TestSerieUrl(t *testing.T) {
+	t.Parallel()
+
+	u, err := source.SerieUrl("frieren")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.String() != server.URL+"/series/frieren" {
+		t.Errorf("unexpected serie url %s", u)
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		page     int
+		expected error
+	}{
+		{name: "module error", page: 1, expected: wasm.ErrCallingModule},
+		{name: "timeout", page: 2, expected: source_types.ErrTimeout},
+		{name: "host not allowed", page: 3, expected: wasm.ErrCallingModule},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := source.FetchLatestUpdates(context.Background(), tc.page)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestInvalidModule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		manifest func() extension.Manifest
+		module   []byte
+		expected error
+	}{
+		{
+			name:     "not wasm",
+			manifest: newManifest,
+			module:   []byte("not wasm"),
+			expected: wasm.ErrInvalidModule,
+		},
+		{
+			// Empty module with only the wasm header
+			name:     "missing operations",
+			manifest: newManifest,
+			module:   []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+			expected: wasm.ErrInvalidModule,
+		},
+		{
+			name: "unknown genre",
+			manifest: func() extension.Manifest {
+				m := newManifest()
+				m.Filters.Genres.Values = []string{"Space Opera"}
+				return m
+			},
+			module:   guestModule,
+			expected: extension.ErrInvalidManifest,
+		},
+		{
+			name: "unknown language",
+			manifest: func() extension.Manifest {
+				m := newManifest()
+				m.Languages = []string{"de"}
+				return m
+			},
+			module:   guestModule,
+			expected: source_types.ErrInvalidLanguage,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := wasm.NewSource(tc.manifest(), tc.module)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestLoadDirectory(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"guest.wasm": string(guestModule),
+		"guest.json": fmt.Sprintf(`{"id": "guest", "name": "Guest", "url": %q, "languages": ["en"], "filters": {"sorts": ["Latest"]}}`, server.URL),
+		"README.md":  "ignored",
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	apis, err := wasm.LoadDirectory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(apis) != 1 || apis[0].GetInformation().ID != "guest" || apis[0].GetInformation().UpdatedAt.IsZero() {
+		t.Fatalf("expected the guest source, got %d sources", len(apis))
+	}
+
+	err = os.Remove(filepath.Join(dir, "guest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = wasm.LoadDirectory(dir)
+	if !errors.Is(err, extension.ErrReadingManifest) {
+		t.Errorf("expected ErrReadingManifest without manifest, got %v", err)
+	}
+}
@@ -0,0 +1,8 @@
+package stats
+
+import "errors"
+
+var (
+	ErrDatabaseQuery   = errors.New("database query failed")
+	ErrRefreshingStats = errors.New("error refreshing statistics")
+)
@@ -0,0 +1,49 @@
+package stats
+
+import (
+	"context"
+	"time"
+
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+)
+
+// RefreshStatsArgs refreshes the materialized statistics
+type RefreshStatsArgs struct{}
+
+func (RefreshStatsArgs) Kind() string { return "stats_refresh" }
+
+func (RefreshStatsArgs) InsertOpts() river.InsertOpts {
+	// A refresh already waiting or running covers the reads recorded before it, River requires these states at least
+	return river.InsertOpts{UniqueOpts: river.UniqueOpts{ByState: []rivertype.JobState{
+		rivertype.JobStateAvailable,
+		rivertype.JobStatePending,
+		rivertype.JobStateRunning,
+		rivertype.JobStateScheduled,
+	}}}
+}
+
+type RefreshStatsWorker struct {
+	river.WorkerDefaults[RefreshStatsArgs]
+
+	store *Store
+}
+
+func (w *RefreshStatsWorker) Work(ctx context.Context, _ *river.Job[RefreshStatsArgs]) error {
+	return w.store.Refresh(ctx)
+}
+
+func AddWorkers(workers *river.Workers, store *Store) {
+	river.AddWorker(workers, &RefreshStatsWorker{store: store})
+}
+
+// RefreshPeriodically schedules a refresh every interval, and one when the client starts
+func RefreshPeriodically(bundle *river.PeriodicJobBundle, interval time.Duration) {
+	bundle.Add(river.NewPeriodicJob(
+		river.PeriodicInterval(interval),
+		func() (river.JobArgs, *river.InsertOpts) {
+			return RefreshStatsArgs{}, nil
+		},
+		&river.PeriodicJobOpts{RunOnStart: true},
+	))
+}
@@ -0,0 +1,141 @@
+package stats
+
+import (
+	"slices"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+type DayStat struct {
+	Day             string `json:"day"`
+	Chapters        int    `json:"chapters"`
+	Pages           int    `json:"pages"`
+	DurationSeconds int    `json:"durationSeconds"`
+}
+
+// WeekStat is an ISO week, starting on monday
+type WeekStat struct {
+	Year            int    `json:"year"`
+	Week            int    `json:"week"`
+	Start           string `json:"start"`
+	Chapters        int    `json:"chapters"`
+	Pages           int    `json:"pages"`
+	DurationSeconds int    `json:"durationSeconds"`
+}
+
+// HeatmapCell is the activity for an hour of a weekday, Weekday is 1 for monday to 7 for sunday
+type HeatmapCell struct {
+	Weekday  int `json:"weekday"`
+	Hour     int `json:"hour"`
+	Chapters int `json:"chapters"`
+	Pages    int `json:"pages"`
+}
+
+type RankedStat struct {
+	Name     string `json:"name"`
+	Chapters int    `json:"chapters"`
+	Pages    int    `json:"pages"`
+}
+
+// Completion covers the series read during the period, a serie is completed once all its known chapters are read
+type Completion struct {
+	Started         int     `json:"started"`
+	Completed       int     `json:"completed"`
+	Rate            float64 `json:"rate"`
+	AverageProgress float64 `json:"averageProgress"`
+}
+
+type Streaks struct {
+	Current      int    `json:"current"`
+	Longest      int    `json:"longest"`
+	LongestStart string `json:"longestStart,omitempty"`
+	LongestEnd   string `json:"longestEnd,omitempty"`
+}
+
+type Summary struct {
+	From            string        `json:"from"`
+	To              string        `json:"to"`
+	Timezone        string        `json:"timezone"`
+	Chapters        int           `json:"chapters"`
+	Pages           int           `json:"pages"`
+	DurationSeconds int           `json:"durationSeconds"`
+	ActiveDays      int           `json:"activeDays"`
+	Daily           []DayStat     `json:"daily"`
+	Weekly          []WeekStat    `json:"weekly"`
+	Heatmap         []HeatmapCell `json:"heatmap"`
+	TopGenres       []RankedStat  `json:"topGenres"`
+	TopAuthors      []RankedStat  `json:"topAuthors"`
+	Completion      Completion    `json:"completion"`
+	Streaks         Streaks       `json:"streaks"`
+}
+
+// Date truncates t to its day in loc, returned as midnight UTC so days can be compared and subtracted safely
+func Date(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// WeeklyFromDaily groups the days by ISO week, days must be sorted
+func WeeklyFromDaily(days []DayStat) []WeekStat {
+	weeks := []WeekStat{}
+
+	for _, day := range days {
+		date, err := time.Parse(dateLayout, day.Day)
+		if err != nil {
+			continue
+		}
+
+		year, week := date.ISOWeek()
+		if len(weeks) == 0 || weeks[len(weeks)-1].Year != year || weeks[len(weeks)-1].Week != week {
+			// Days since monday
+			offset := (int(date.Weekday()) + 6) % 7
+			weeks = append(weeks, WeekStat{
+				Year:  year,
+				Week:  week,
+				Start: date.AddDate(0, 0, -offset).Format(dateLayout),
+			})
+		}
+
+		w := &weeks[len(weeks)-1]
+		w.Chapters += day.Chapters
+		w.Pages += day.Pages
+		w.DurationSeconds += day.DurationSeconds
+	}
+
+	return weeks
+}
+
+// ComputeStreaks returns the longest run of consecutive active days, and the current one ending today or yesterday,
+// so a streak isn't lost before the day is over. days are dates as returned by Date, in any order.
+func ComputeStreaks(days []time.Time, today time.Time) Streaks {
+	if len(days) == 0 {
+		return Streaks{}
+	}
+
+	sorted := slices.Clone(days)
+	slices.SortFunc(sorted, func(a, b time.Time) int { return a.Compare(b) })
+	sorted = slices.CompactFunc(sorted, func(a, b time.Time) bool { return a.Equal(b) })
+
+	var streaks Streaks
+	runStart := 0
+
+	for i := range sorted {
+		if i > 0 && !sorted[i-1].AddDate(0, 0, 1).Equal(sorted[i]) {
+			runStart = i
+		}
+
+		if length := i - runStart + 1; length > streaks.Longest {
+			streaks.Longest = length
+			streaks.LongestStart = sorted[runStart].Format(dateLayout)
+			streaks.LongestEnd = sorted[i].Format(dateLayout)
+		}
+	}
+
+	last := sorted[len(sorted)-1]
+	if last.Equal(today) || last.Equal(today.AddDate(0, 0, -1)) {
+		streaks.Current = len(sorted) - runStart
+	}
+
+	return streaks
+}
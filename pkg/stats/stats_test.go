@@ -0,0 +1,112 @@
+package stats_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"dokusho/pkg/stats"
+)
+
+func day(value string) time.Time {
+	d, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func TestComputeStreaks(t *testing.T) {
+	t.Parallel()
+
+	today := day("2025-03-10")
+
+	tests := []struct {
+		name     string
+		days     []time.Time
+		expected stats.Streaks
+	}{
+		{
+			name:     "no activity",
+			days:     nil,
+			expected: stats.Streaks{},
+		},
+		{
+			name:     "current streak ending today",
+			days:     []time.Time{day("2025-03-08"), day("2025-03-09"), day("2025-03-10")},
+			expected: stats.Streaks{Current: 3, Longest: 3, LongestStart: "2025-03-08", LongestEnd: "2025-03-10"},
+		},
+		{
+			name:     "current streak ending yesterday",
+			days:     []time.Time{day("2025-03-08"), day("2025-03-09")},
+			expected: stats.Streaks{Current: 2, Longest: 2, LongestStart: "2025-03-08", LongestEnd: "2025-03-09"},
+		},
+		{
+			name:     "broken streak",
+			days:     []time.Time{day("2025-03-01"), day("2025-03-02"), day("2025-03-03"), day("2025-03-07")},
+			expected: stats.Streaks{Current: 0, Longest: 3, LongestStart: "2025-03-01", LongestEnd: "2025-03-03"},
+		},
+		{
+			name:     "unsorted with duplicates",
+			days:     []time.Time{day("2025-03-10"), day("2025-03-05"), day("2025-03-09"), day("2025-03-10"), day("2025-03-04")},
+			expected: stats.Streaks{Current: 2, Longest: 2, LongestStart: "2025-03-04", LongestEnd: "2025-03-05"},
+		},
+		{
+			name:     "across months",
+			days:     []time.Time{day("2025-02-27"), day("2025-02-28"), day("2025-03-01")},
+			expected: stats.Streaks{Current: 0, Longest: 3, LongestStart: "2025-02-27", LongestEnd: "2025-03-01"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			streaks := stats.ComputeStreaks(tc.days, today)
+			if streaks != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, streaks)
+			}
+		})
+	}
+}
+
+func TestWeeklyFromDaily(t *testing.T) {
+	t.Parallel()
+
+	days := []stats.DayStat{
+		// Sunday belongs to the week starting the previous monday
+		{Day: "2025-03-02", Chapters: 1, Pages: 10, DurationSeconds: 60},
+		{Day: "2025-03-03", Chapters: 2, Pages: 20, DurationSeconds: 120},
+		{Day: "2025-03-09", Chapters: 3, Pages: 30, DurationSeconds: 180},
+		// ISO week 1 of 2026 starts in 2025
+		{Day: "2025-12-29", Chapters: 4, Pages: 40, DurationSeconds: 240},
+		{Day: "2026-01-01", Chapters: 5, Pages: 50, DurationSeconds: 300},
+	}
+
+	expected := []stats.WeekStat{
+		{Year: 2025, Week: 9, Start: "2025-02-24", Chapters: 1, Pages: 10, DurationSeconds: 60},
+		{Year: 2025, Week: 10, Start: "2025-03-03", Chapters: 5, Pages: 50, DurationSeconds: 300},
+		{Year: 2026, Week: 1, Start: "2025-12-29", Chapters: 9, Pages: 90, DurationSeconds: 540},
+	}
+
+	weeks := stats.WeeklyFromDaily(days)
+	if !reflect.DeepEqual(weeks, expected) {
+		t.Errorf("expected %+v, got %+v", expected, weeks)
+	}
+}
+
+func TestDate(t *testing.T) {
+	t.Parallel()
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 20:00 UTC is already the next day in Tokyo
+	d := stats.Date(time.Date(2025, time.March, 9, 20, 0, 0, 0, time.UTC), tokyo)
+	if !d.Equal(day("2025-03-10")) {
+		t.Errorf("expected 2025-03-10, got %s", d)
+	}
+}
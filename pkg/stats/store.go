@@ -0,0 +1,250 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReadEvent is recorded by the reader every time pages of a chapter are read
+type ReadEvent struct {
+	UserID           string    `json:"-"`
+	LibrarySerieID   uuid.UUID `json:"librarySerieId"`
+	ChapterID        string    `json:"chapterId"`
+	PagesRead        int       `json:"pagesRead"`
+	ChapterCompleted bool      `json:"chapterCompleted"`
+	DurationSeconds  int       `json:"durationSeconds"`
+	ReadAt           time.Time `json:"readAt,omitzero"`
+}
+
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{pool: pool}
+}
+
+func (s *Store) RecordRead(ctx context.Context, event ReadEvent) error {
+	if event.ReadAt.IsZero() {
+		event.ReadAt = time.Now()
+	}
+
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO read_events (user_id, library_serie_id, chapter_id, pages_read, chapter_completed, duration_seconds, read_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		event.UserID, event.LibrarySerieID, event.ChapterID, event.PagesRead, event.ChapterCompleted, event.DurationSeconds, event.ReadAt,
+	)
+	if err != nil {
+		return errors.Join(ErrDatabaseQuery, err, fmt.Errorf("failed to record read event"))
+	}
+
+	return nil
+}
+
+// Refresh recomputes the materialized statistics, reads stay available while refreshing
+func (s *Store) Refresh(ctx context.Context) error {
+	for _, view := range []string{"read_stats_hourly", "read_stats_series"} {
+		_, err := s.pool.Exec(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY "+view)
+		if err != nil {
+			return errors.Join(ErrRefreshingStats, err, fmt.Errorf("failed to refresh %s", view))
+		}
+	}
+
+	return nil
+}
+
+// Summary computes the statistics of the user from the day from to the day to included, days are in loc.
+// Buckets are hourly, so timezones with a non whole hour offset can shift some reads to the adjacent hour.
+func (s *Store) Summary(ctx context.Context, userID string, from, to time.Time, loc *time.Location, limit int) (Summary, error) {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	tz := loc.String()
+
+	summary := Summary{
+		From:     from.Format(dateLayout),
+		To:       to.Format(dateLayout),
+		Timezone: tz,
+	}
+
+	var err error
+
+	summary.Daily, err = s.daily(ctx, userID, start, end, tz)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	for _, day := range summary.Daily {
+		summary.Chapters += day.Chapters
+		summary.Pages += day.Pages
+		summary.DurationSeconds += day.DurationSeconds
+	}
+	summary.ActiveDays = len(summary.Daily)
+	summary.Weekly = WeeklyFromDaily(summary.Daily)
+
+	summary.Heatmap, err = s.heatmap(ctx, userID, start, end, tz)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	summary.TopGenres, err = s.ranked(ctx, "genres", userID, start, end, limit)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	summary.TopAuthors, err = s.ranked(ctx, "authors", userID, start, end, limit)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	summary.Completion, err = s.completion(ctx, userID, start, end)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	summary.Streaks, err = s.streaks(ctx, userID, start, end, loc)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	return summary, nil
+}
+
+func (s *Store) daily(ctx context.Context, userID string, start, end time.Time, tz string) ([]DayStat, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT to_char((bucket AT TIME ZONE $2)::date, 'YYYY-MM-DD'), sum(chapters_read)::bigint, sum(pages_read)::bigint, sum(duration_seconds)::bigint
+		FROM read_stats_hourly
+		WHERE user_id = $1 AND bucket >= $3 AND bucket < $4
+		GROUP BY 1
+		ORDER BY 1`,
+		userID, tz, start, end,
+	)
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	days, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (DayStat, error) {
+		var day DayStat
+		err := row.Scan(&day.Day, &day.Chapters, &day.Pages, &day.DurationSeconds)
+		return day, err
+	})
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return days, nil
+}
+
+func (s *Store) heatmap(ctx context.Context, userID string, start, end time.Time, tz string) ([]HeatmapCell, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT extract(isodow FROM bucket AT TIME ZONE $2)::int, extract(hour FROM bucket AT TIME ZONE $2)::int, sum(chapters_read)::bigint, sum(pages_read)::bigint
+		FROM read_stats_hourly
+		WHERE user_id = $1 AND bucket >= $3 AND bucket < $4
+		GROUP BY 1, 2
+		ORDER BY 1, 2`,
+		userID, tz, start, end,
+	)
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	cells, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (HeatmapCell, error) {
+		var cell HeatmapCell
+		err := row.Scan(&cell.Weekday, &cell.Hour, &cell.Chapters, &cell.Pages)
+		return cell, err
+	})
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return cells, nil
+}
+
+// ranked ranks the values of a text array column of library_series by chapters read, column is never user input
+func (s *Store) ranked(ctx context.Context, column string, userID string, start, end time.Time, limit int) ([]RankedStat, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT value, sum(h.chapters_read)::bigint AS chapters, sum(h.pages_read)::bigint AS pages
+		FROM read_stats_hourly h
+		JOIN library_series l ON l.id = h.library_serie_id
+		CROSS JOIN unnest(l.`+column+`) AS value
+		WHERE h.user_id = $1 AND h.bucket >= $2 AND h.bucket < $3
+		GROUP BY value
+		ORDER BY chapters DESC, pages DESC, value
+		LIMIT $4`,
+		userID, start, end, limit,
+	)
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	ranked, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (RankedStat, error) {
+		var stat RankedStat
+		err := row.Scan(&stat.Name, &stat.Chapters, &stat.Pages)
+		return stat, err
+	})
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return ranked, nil
+}
+
+func (s *Store) completion(ctx context.Context, userID string, start, end time.Time) (Completion, error) {
+	var c Completion
+
+	err := s.pool.QueryRow(ctx, `
+		SELECT
+			count(*),
+			count(*) FILTER (WHERE l.chapter_count > 0 AND s.chapters_read >= l.chapter_count),
+			coalesce(avg(least(s.chapters_read::float8 / nullif(l.chapter_count, 0), 1)), 0)
+		FROM read_stats_series s
+		JOIN library_series l ON l.id = s.library_serie_id
+		WHERE s.user_id = $1 AND s.last_read_at >= $2 AND s.first_read_at < $3`,
+		userID, start, end,
+	).Scan(&c.Started, &c.Completed, &c.AverageProgress)
+	if err != nil {
+		return Completion{}, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	if c.Started > 0 {
+		c.Rate = float64(c.Completed) / float64(c.Started)
+	}
+
+	return c, nil
+}
+
+// streaks computes the longest streak in the period, the current streak is always computed as of today
+func (s *Store) streaks(ctx context.Context, userID string, start, end time.Time, loc *time.Location) (Streaks, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT DISTINCT (bucket AT TIME ZONE $2)::date
+		FROM read_stats_hourly
+		WHERE user_id = $1
+		ORDER BY 1`,
+		userID, loc.String(),
+	)
+	if err != nil {
+		return Streaks{}, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	days, err := pgx.CollectRows(rows, pgx.RowTo[time.Time])
+	if err != nil {
+		return Streaks{}, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	var period []time.Time
+	for _, day := range days {
+		if !day.Before(Date(start, loc)) && day.Before(Date(end, loc)) {
+			period = append(period, day)
+		}
+	}
+
+	streaks := ComputeStreaks(period, Date(time.Now(), loc))
+	streaks.Current = ComputeStreaks(days, Date(time.Now(), loc)).Current
+
+	return streaks, nil
+}
@@ -0,0 +1,329 @@
+package anilist
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"dokusho/pkg/trackers"
+)
+
+const (
+	defaultAPIURL  = "https://graphql.anilist.co"
+	defaultAuthURL = "https://anilist.co/api/v2/oauth"
+)
+
+type anilist struct {
+	cfg        trackers.ClientConfig
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+// NewAniList returns an AniList client, AniList tokens are valid for a year and can't be refreshed
+func NewAniList(cfg trackers.ClientConfig) *anilist {
+	if cfg.APIURL == "" {
+		cfg.APIURL = defaultAPIURL
+	}
+
+	if cfg.AuthURL == "" {
+		cfg.AuthURL = defaultAuthURL
+	}
+
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	return &anilist{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		logger:     slog.Default().WithGroup("anilist"),
+	}
+}
+
+func (a *anilist) ID() trackers.TrackerID {
+	return trackers.TRACKER_ANILIST
+}
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+	Status  int    `json:"status"`
+}
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []graphQLError  `json:"errors"`
+}
+
+type media struct {
+	ID    int `json:"id"`
+	Title struct {
+		Romaji  string `json:"romaji"`
+		English string `json:"english"`
+	} `json:"title"`
+	Chapters       int        `json:"chapters"`
+	Volumes        int        `json:"volumes"`
+	SiteURL        string     `json:"siteUrl"`
+	MediaListEntry *listEntry `json:"mediaListEntry"`
+}
+
+type listEntry struct {
+	MediaID         int    `json:"mediaId"`
+	Status          string `json:"status"`
+	Progress        int    `json:"progress"`
+	ProgressVolumes int    `json:"progressVolumes"`
+}
+
+const searchQuery = `query ($search: String) {
+  Page(perPage: 10) {
+    media(search: $search, type: MANGA) { id title { romaji english } chapters volumes siteUrl }
+  }
+}`
+
+func (a *anilist) SearchMedia(ctx context.Context, query string) ([]trackers.Media, error) {
+	var data struct {
+		Page struct {
+			Media []media `json:"media"`
+		} `json:"Page"`
+	}
+
+	err := a.do(ctx, searchQuery, map[string]any{"search": query}, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]trackers.Media, 0, len(data.Page.Media))
+	for _, m := range data.Page.Media {
+		title := m.Title.English
+		if title == "" {
+			title = m.Title.Romaji
+		}
+
+		results = append(results, trackers.Media{
+			ID:       strconv.Itoa(m.ID),
+			Title:    title,
+			Chapters: m.Chapters,
+			Volumes:  m.Volumes,
+			URL:      m.SiteURL,
+		})
+	}
+
+	return results, nil
+}
+
+const entryQuery = `query ($id: Int) {
+  Media(id: $id, type: MANGA) { id mediaListEntry { mediaId status progress progressVolumes } }
+}`
+
+func (a *anilist) GetEntry(ctx context.Context, mediaID string) (trackers.Entry, error) {
+	id, err := strconv.Atoi(mediaID)
+	if err != nil {
+		return trackers.Entry{}, errors.Join(trackers.ErrBuildingRequest, fmt.Errorf("invalid anilist media id: %s", mediaID))
+	}
+
+	var data struct {
+		Media media `json:"Media"`
+	}
+
+	err = a.do(ctx, entryQuery, map[string]any{"id": id}, &data)
+	if err != nil {
+		return trackers.Entry{}, err
+	}
+
+	if data.Media.MediaListEntry == nil {
+		return trackers.Entry{}, trackers.ErrEntryNotFound
+	}
+
+	return convertEntry(*data.Media.MediaListEntry)
+}
+
+const saveEntryMutation = `mutation ($mediaId: Int, $status: MediaListStatus, $progress: Int, $progressVolumes: Int) {
+  SaveMediaListEntry(mediaId: $mediaId, status: $status, progress: $progress, progressVolumes: $progressVolumes) { mediaId status progress progressVolumes }
+}`
+
+func (a *anilist) UpdateEntry(ctx context.Context, entry trackers.Entry) (trackers.Entry, error) {
+	id, err := strconv.Atoi(entry.MediaID)
+	if err != nil {
+		return trackers.Entry{}, errors.Join(trackers.ErrBuildingRequest, fmt.Errorf("invalid anilist media id: %s", entry.MediaID))
+	}
+
+	variables := map[string]any{
+		"mediaId":         id,
+		"progress":        entry.Progress,
+		"progressVolumes": entry.ProgressVolumes,
+	}
+
+	if entry.Status != "" {
+		status, err := ConvertToAniListStatus(entry.Status)
+		if err != nil {
+			return trackers.Entry{}, err
+		}
+
+		variables["status"] = status
+	}
+
+	var data struct {
+		SaveMediaListEntry listEntry `json:"SaveMediaListEntry"`
+	}
+
+	err = a.do(ctx, saveEntryMutation, variables, &data)
+	if err != nil {
+		return trackers.Entry{}, err
+	}
+
+	return convertEntry(data.SaveMediaListEntry)
+}
+
+func (a *anilist) AuthorizeURL(state, _ string) string {
+	u, _ := url.Parse(a.cfg.AuthURL)
+	u = u.JoinPath("authorize")
+
+	q := u.Query()
+	q.Set("client_id", a.cfg.ClientID)
+	q.Set("redirect_uri", a.cfg.RedirectURL)
+	q.Set("response_type", "code")
+	q.Set("state", state)
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+func (a *anilist) ExchangeCode(ctx context.Context, code, _ string) (trackers.Token, error) {
+	tokenURL, err := url.JoinPath(a.cfg.AuthURL, "token")
+	if err != nil {
+		return trackers.Token{}, errors.Join(trackers.ErrExchangingCode, err)
+	}
+
+	body, _ := json.Marshal(map[string]string{
+		"grant_type":    "authorization_code",
+		"client_id":     a.cfg.ClientID,
+		"client_secret": a.cfg.ClientSecret,
+		"redirect_uri":  a.cfg.RedirectURL,
+		"code":          code,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, bytes.NewReader(body))
+	if err != nil {
+		return trackers.Token{}, errors.Join(trackers.ErrExchangingCode, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return trackers.Token{}, errors.Join(trackers.ErrExchangingCode, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return trackers.Token{}, errors.Join(trackers.ErrExchangingCode, fmt.Errorf("unexpected status: %s", resp.Status))
+	}
+
+	var tr struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&tr)
+	if err != nil {
+		return trackers.Token{}, errors.Join(trackers.ErrParsingResponse, err)
+	}
+
+	token := trackers.Token{AccessToken: tr.AccessToken, RefreshToken: tr.RefreshToken}
+	if tr.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
+
+func (a *anilist) do(ctx context.Context, query string, variables map[string]any, out any) error {
+	body, err := json.Marshal(graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return errors.Join(trackers.ErrBuildingRequest, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.cfg.APIURL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Join(trackers.ErrBuildingRequest, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if a.cfg.Token.AccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+a.cfg.Token.AccessToken)
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return errors.Join(trackers.ErrRequestFailed, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return trackers.ErrUnauthorized
+	case http.StatusTooManyRequests:
+		return errors.Join(trackers.ErrRateLimited, fmt.Errorf("retry after %s", resp.Header.Get("Retry-After")))
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Join(trackers.ErrRequestFailed, err)
+	}
+
+	var gr graphQLResponse
+	err = json.Unmarshal(data, &gr)
+	if err != nil {
+		return errors.Join(trackers.ErrParsingResponse, err, fmt.Errorf("status %s", resp.Status))
+	}
+
+	if len(gr.Errors) > 0 {
+		return convertGraphQLError(gr.Errors[0])
+	}
+
+	err = json.Unmarshal(gr.Data, out)
+	if err != nil {
+		return errors.Join(trackers.ErrParsingResponse, err)
+	}
+
+	return nil
+}
+
+func convertGraphQLError(e graphQLError) error {
+	switch e.Status {
+	case http.StatusNotFound:
+		return errors.Join(trackers.ErrEntryNotFound, errors.New(e.Message))
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errors.Join(trackers.ErrUnauthorized, errors.New(e.Message))
+	case http.StatusTooManyRequests:
+		return errors.Join(trackers.ErrRateLimited, errors.New(e.Message))
+	}
+
+	return errors.Join(trackers.ErrRequestFailed, fmt.Errorf("anilist error %d: %s", e.Status, e.Message))
+}
+
+func convertEntry(e listEntry) (trackers.Entry, error) {
+	status, err := ConvertAniListStatus(e.Status)
+	if err != nil {
+		return trackers.Entry{}, err
+	}
+
+	return trackers.Entry{
+		MediaID:         strconv.Itoa(e.MediaID),
+		Status:          status,
+		Progress:        e.Progress,
+		ProgressVolumes: e.ProgressVolumes,
+	}, nil
+}
@@ -0,0 +1,152 @@
+package anilist_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dokusho/pkg/trackers"
+	"dokusho/pkg/trackers/anilist"
+)
+
+// fakeAniList answers the queries used by the client, entries are kept by media id
+type fakeAniList struct {
+	token   string
+	entries map[int]map[string]any
+}
+
+func (f *fakeAniList) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+f.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var data any
+
+	switch {
+	case strings.Contains(req.Query, "Page("):
+		data = map[string]any{"Page": map[string]any{"media": []any{
+			map[string]any{"id": 105398, "title": map[string]any{"romaji": "Na Honjaman Level Up", "english": "Solo Leveling"}, "chapters": 201, "volumes": 14, "siteUrl": "https://anilist.co/manga/105398"},
+			map[string]any{"id": 1, "title": map[string]any{"romaji": "Romaji Only"}, "siteUrl": "https://anilist.co/manga/1"},
+		}}}
+	case strings.Contains(req.Query, "SaveMediaListEntry"):
+		id := int(req.Variables["mediaId"].(float64))
+		entry := map[string]any{
+			"mediaId":         id,
+			"status":          req.Variables["status"],
+			"progress":        req.Variables["progress"],
+			"progressVolumes": req.Variables["progressVolumes"],
+		}
+		f.entries[id] = entry
+		data = map[string]any{"SaveMediaListEntry": entry}
+	case strings.Contains(req.Query, "mediaListEntry"):
+		id := int(req.Variables["id"].(float64))
+		if id == 404 {
+			json.NewEncoder(w).Encode(map[string]any{"data": nil, "errors": []any{map[string]any{"message": "Not Found.", "status": 404}}})
+			return
+		}
+
+		var entry any
+		if e, ok := f.entries[id]; ok {
+			entry = e
+		}
+		data = map[string]any{"Media": map[string]any{"id": id, "mediaListEntry": entry}}
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func newClient(t *testing.T, token string) (trackers.Tracker, *fakeAniList) {
+	fake := &fakeAniList{token: "valid-token", entries: map[int]map[string]any{
+		105398: {"mediaId": 105398, "status": "CURRENT", "progress": 10, "progressVolumes": 1},
+	}}
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return anilist.NewAniList(trackers.ClientConfig{APIURL: server.URL, Token: trackers.Token{AccessToken: token}}), fake
+}
+
+func TestAniListSearchMedia(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newClient(t, "valid-token")
+
+	media, err := client.SearchMedia(t.Context(), "solo leveling")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(media) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(media))
+	}
+
+	if media[0].ID != "105398" || media[0].Title != "Solo Leveling" || media[0].Chapters != 201 {
+		t.Errorf("Unexpected first result %+v", media[0])
+	}
+
+	if media[1].Title != "Romaji Only" {
+		t.Errorf("Title should fallback to romaji, got %s", media[1].Title)
+	}
+}
+
+func TestAniListEntries(t *testing.T) {
+	t.Parallel()
+
+	client, fake := newClient(t, "valid-token")
+
+	entry, err := client.GetEntry(t.Context(), "105398")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Status != trackers.STATUS_READING || entry.Progress != 10 || entry.ProgressVolumes != 1 {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+
+	_, err = client.GetEntry(t.Context(), "7")
+	if !errors.Is(err, trackers.ErrEntryNotFound) {
+		t.Errorf("Media not in list should return ErrEntryNotFound, got %v", err)
+	}
+
+	_, err = client.GetEntry(t.Context(), "404")
+	if !errors.Is(err, trackers.ErrEntryNotFound) {
+		t.Errorf("Unknown media should return ErrEntryNotFound, got %v", err)
+	}
+
+	entry, err = trackers.Sync(t.Context(), client, trackers.Entry{MediaID: "7", Progress: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Status != trackers.STATUS_READING || entry.Progress != 3 {
+		t.Errorf("Unexpected synced entry %+v", entry)
+	}
+
+	if fake.entries[7]["status"] != "CURRENT" {
+		t.Errorf("Status should be sent as CURRENT, got %v", fake.entries[7]["status"])
+	}
+}
+
+func TestAniListUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	client, _ := newClient(t, "revoked-token")
+
+	_, err := client.GetEntry(t.Context(), "105398")
+	if !errors.Is(err, trackers.ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package anilist
+
+import (
+	"fmt"
+
+	"dokusho/pkg/trackers"
+)
+
+type AniListStatus string
+
+const (
+	CURRENT   AniListStatus = "CURRENT"
+	PLANNING  AniListStatus = "PLANNING"
+	COMPLETED AniListStatus = "COMPLETED"
+	DROPPED   AniListStatus = "DROPPED"
+	PAUSED    AniListStatus = "PAUSED"
+	REPEATING AniListStatus = "REPEATING"
+)
+
+func ConvertAniListStatus(status string) (trackers.ReadingStatus, error) {
+	switch AniListStatus(status) {
+	case CURRENT:
+		return trackers.STATUS_READING, nil
+	case PLANNING:
+		return trackers.STATUS_PLANNING, nil
+	case COMPLETED:
+		return trackers.STATUS_COMPLETED, nil
+	case DROPPED:
+		return trackers.STATUS_DROPPED, nil
+	case PAUSED:
+		return trackers.STATUS_PAUSED, nil
+	case REPEATING:
+		return trackers.STATUS_REREADING, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", trackers.ErrInvalidStatus, status)
+}
+
+func ConvertToAniListStatus(status trackers.ReadingStatus) (AniListStatus, error) {
+	switch status {
+	case trackers.STATUS_READING:
+		return CURRENT, nil
+	case trackers.STATUS_PLANNING:
+		return PLANNING, nil
+	case trackers.STATUS_COMPLETED:
+		return COMPLETED, nil
+	case trackers.STATUS_DROPPED:
+		return DROPPED, nil
+	case trackers.STATUS_PAUSED:
+		return PAUSED, nil
+	case trackers.STATUS_REREADING:
+		return REPEATING, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", trackers.ErrInvalidStatus, status)
+}
@@ -0,0 +1,19 @@
+package trackers
+
+import "errors"
+
+var (
+	ErrUnknownTracker  = errors.New("unknown tracker")
+	ErrInvalidStatus   = errors.New("invalid status")
+	ErrEntryNotFound   = errors.New("entry not found")
+	ErrAccountNotFound = errors.New("tracker account not found")
+	ErrLinkNotFound    = errors.New("tracker link not found")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrRateLimited     = errors.New("rate limited")
+	ErrBuildingRequest = errors.New("error building request")
+	ErrRequestFailed   = errors.New("tracker request failed")
+	ErrParsingResponse = errors.New("error parsing tracker response")
+	ErrRefreshingToken = errors.New("error refreshing token")
+	ErrExchangingCode  = errors.New("error exchanging authorization code")
+	ErrDatabaseQuery   = errors.New("database query failed")
+)
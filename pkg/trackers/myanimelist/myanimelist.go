@@ -0,0 +1,364 @@
+package myanimelist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"dokusho/pkg/trackers"
+)
+
+const (
+	defaultAPIURL  = "https://api.myanimelist.net/v2"
+	defaultAuthURL = "https://myanimelist.net/v1/oauth2"
+)
+
+type myanimelist struct {
+	cfg        trackers.ClientConfig
+	httpClient *http.Client
+	logger     *slog.Logger
+
+	// Guards cfg.Token, which is replaced on refresh
+	mu sync.Mutex
+}
+
+func NewMyAnimeList(cfg trackers.ClientConfig) *myanimelist {
+	if cfg.APIURL == "" {
+		cfg.APIURL = defaultAPIURL
+	}
+
+	if cfg.AuthURL == "" {
+		cfg.AuthURL = defaultAuthURL
+	}
+
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	return &myanimelist{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		logger:     slog.Default().WithGroup("myanimelist"),
+	}
+}
+
+func (m *myanimelist) ID() trackers.TrackerID {
+	return trackers.TRACKER_MYANIMELIST
+}
+
+type manga struct {
+	ID           int         `json:"id"`
+	Title        string      `json:"title"`
+	NumChapters  int         `json:"num_chapters"`
+	NumVolumes   int         `json:"num_volumes"`
+	MyListStatus *listStatus `json:"my_list_status"`
+}
+
+type listStatus struct {
+	Status          string `json:"status"`
+	IsRereading     bool   `json:"is_rereading"`
+	NumChaptersRead int    `json:"num_chapters_read"`
+	NumVolumesRead  int    `json:"num_volumes_read"`
+}
+
+func (m *myanimelist) SearchMedia(ctx context.Context, query string) ([]trackers.Media, error) {
+	q := url.Values{}
+	q.Set("q", query)
+	q.Set("limit", "10")
+	q.Set("fields", "num_chapters,num_volumes")
+
+	var data struct {
+		Data []struct {
+			Node manga `json:"node"`
+		} `json:"data"`
+	}
+
+	err := m.do(ctx, http.MethodGet, "/manga", q, nil, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]trackers.Media, 0, len(data.Data))
+	for _, d := range data.Data {
+		results = append(results, trackers.Media{
+			ID:       strconv.Itoa(d.Node.ID),
+			Title:    d.Node.Title,
+			Chapters: d.Node.NumChapters,
+			Volumes:  d.Node.NumVolumes,
+			URL:      fmt.Sprintf("https://myanimelist.net/manga/%d", d.Node.ID),
+		})
+	}
+
+	return results, nil
+}
+
+func (m *myanimelist) GetEntry(ctx context.Context, mediaID string) (trackers.Entry, error) {
+	q := url.Values{}
+	q.Set("fields", "my_list_status")
+
+	var data manga
+	err := m.do(ctx, http.MethodGet, "/manga/"+url.PathEscape(mediaID), q, nil, &data)
+	if err != nil {
+		return trackers.Entry{}, err
+	}
+
+	if data.MyListStatus == nil {
+		return trackers.Entry{}, trackers.ErrEntryNotFound
+	}
+
+	return convertListStatus(mediaID, *data.MyListStatus)
+}
+
+func (m *myanimelist) UpdateEntry(ctx context.Context, entry trackers.Entry) (trackers.Entry, error) {
+	form := url.Values{}
+	form.Set("num_chapters_read", strconv.Itoa(entry.Progress))
+	form.Set("num_volumes_read", strconv.Itoa(entry.ProgressVolumes))
+
+	if entry.Status != "" {
+		status, rereading, err := ConvertToMyAnimeListStatus(entry.Status)
+		if err != nil {
+			return trackers.Entry{}, err
+		}
+
+		form.Set("status", string(status))
+		form.Set("is_rereading", strconv.FormatBool(rereading))
+	}
+
+	var data listStatus
+	err := m.do(ctx, http.MethodPatch, "/manga/"+url.PathEscape(entry.MediaID)+"/my_list_status", nil, form, &data)
+	if err != nil {
+		return trackers.Entry{}, err
+	}
+
+	return convertListStatus(entry.MediaID, data)
+}
+
+// AuthorizeURL uses PKCE with the plain method, the only one MyAnimeList supports
+func (m *myanimelist) AuthorizeURL(state, codeVerifier string) string {
+	u, _ := url.Parse(m.cfg.AuthURL)
+	u = u.JoinPath("authorize")
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", m.cfg.ClientID)
+	q.Set("redirect_uri", m.cfg.RedirectURL)
+	q.Set("state", state)
+	q.Set("code_challenge", codeVerifier)
+	q.Set("code_challenge_method", "plain")
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
+
+func (m *myanimelist) ExchangeCode(ctx context.Context, code, codeVerifier string) (trackers.Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("redirect_uri", m.cfg.RedirectURL)
+
+	token, err := m.requestToken(ctx, form)
+	if err != nil {
+		return trackers.Token{}, errors.Join(trackers.ErrExchangingCode, err)
+	}
+
+	m.mu.Lock()
+	m.cfg.Token = token
+	m.mu.Unlock()
+
+	return token, nil
+}
+
+// RefreshToken replaces the current token, the new one is passed to OnTokenRefresh
+func (m *myanimelist) RefreshToken(ctx context.Context) (trackers.Token, error) {
+	m.mu.Lock()
+	refreshToken := m.cfg.Token.RefreshToken
+	m.mu.Unlock()
+
+	if refreshToken == "" {
+		return trackers.Token{}, errors.Join(trackers.ErrRefreshingToken, trackers.ErrUnauthorized)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+
+	token, err := m.requestToken(ctx, form)
+	if err != nil {
+		return trackers.Token{}, errors.Join(trackers.ErrRefreshingToken, err)
+	}
+
+	m.mu.Lock()
+	m.cfg.Token = token
+	m.mu.Unlock()
+
+	if m.cfg.OnTokenRefresh != nil {
+		err = m.cfg.OnTokenRefresh(ctx, token)
+		if err != nil {
+			m.logger.Error("Failed to persist refreshed token", "error", err)
+		}
+	}
+
+	return token, nil
+}
+
+func (m *myanimelist) requestToken(ctx context.Context, form url.Values) (trackers.Token, error) {
+	tokenURL, err := url.JoinPath(m.cfg.AuthURL, "token")
+	if err != nil {
+		return trackers.Token{}, errors.Join(trackers.ErrBuildingRequest, err)
+	}
+
+	form.Set("client_id", m.cfg.ClientID)
+	if m.cfg.ClientSecret != "" {
+		form.Set("client_secret", m.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return trackers.Token{}, errors.Join(trackers.ErrBuildingRequest, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return trackers.Token{}, errors.Join(trackers.ErrRequestFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return trackers.Token{}, errors.Join(trackers.ErrRequestFailed, fmt.Errorf("unexpected status: %s", resp.Status))
+	}
+
+	var tr struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&tr)
+	if err != nil {
+		return trackers.Token{}, errors.Join(trackers.ErrParsingResponse, err)
+	}
+
+	return trackers.Token{
+		AccessToken:  tr.AccessToken,
+		RefreshToken: tr.RefreshToken,
+		ExpiresAt:    time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// do sends an authenticated request, the token is refreshed when it's expired or refused once
+func (m *myanimelist) do(ctx context.Context, method, path string, query url.Values, form url.Values, out any) error {
+	m.mu.Lock()
+	token := m.cfg.Token
+	m.mu.Unlock()
+
+	if token.Expired(time.Now()) {
+		refreshed, err := m.RefreshToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		token = refreshed
+	}
+
+	resp, err := m.request(ctx, method, path, query, form, token)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && token.RefreshToken != "" {
+		resp.Body.Close()
+
+		refreshed, err := m.RefreshToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		resp, err = m.request(ctx, method, path, query, form, refreshed)
+		if err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return trackers.ErrUnauthorized
+	case http.StatusNotFound:
+		return trackers.ErrEntryNotFound
+	case http.StatusTooManyRequests:
+		return errors.Join(trackers.ErrRateLimited, fmt.Errorf("retry after %s", resp.Header.Get("Retry-After")))
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Join(trackers.ErrRequestFailed, fmt.Errorf("unexpected status %s: %s", resp.Status, body))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return errors.Join(trackers.ErrParsingResponse, err)
+	}
+
+	return nil
+}
+
+func (m *myanimelist) request(ctx context.Context, method, path string, query url.Values, form url.Values, token trackers.Token) (*http.Response, error) {
+	u, err := url.Parse(m.cfg.APIURL + path)
+	if err != nil {
+		return nil, errors.Join(trackers.ErrBuildingRequest, err)
+	}
+	u.RawQuery = query.Encode()
+
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, errors.Join(trackers.ErrBuildingRequest, err)
+	}
+
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	if token.AccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	} else {
+		// Public endpoints like search only need the client id
+		req.Header.Set("X-MAL-CLIENT-ID", m.cfg.ClientID)
+	}
+
+	m.logger.Debug("Requesting myanimelist", "method", method, "url", u.String())
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Join(trackers.ErrRequestFailed, err)
+	}
+
+	return resp, nil
+}
+
+func convertListStatus(mediaID string, s listStatus) (trackers.Entry, error) {
+	status, err := ConvertMyAnimeListStatus(s.Status, s.IsRereading)
+	if err != nil {
+		return trackers.Entry{}, err
+	}
+
+	return trackers.Entry{
+		MediaID:         mediaID,
+		Status:          status,
+		Progress:        s.NumChaptersRead,
+		ProgressVolumes: s.NumVolumesRead,
+	}, nil
+}
@@ -0,0 +1,227 @@
+package myanimelist_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"dokusho/pkg/trackers"
+	"dokusho/pkg/trackers/myanimelist"
+)
+
+// fakeMyAnimeList serves the REST API and the OAuth2 token endpoint, refreshing replaces the valid token
+type fakeMyAnimeList struct {
+	mu           sync.Mutex
+	accessToken  string
+	refreshToken string
+	refreshes    int
+	statuses     map[string]map[string]any
+}
+
+func (f *fakeMyAnimeList) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") != f.refreshToken || r.FormValue("client_id") != "client-id" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		f.refreshes++
+		f.accessToken = "access-" + strconv.Itoa(f.refreshes)
+		f.refreshToken = "refresh-" + strconv.Itoa(f.refreshes)
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"token_type":    "Bearer",
+			"expires_in":    3600,
+			"access_token":  f.accessToken,
+			"refresh_token": f.refreshToken,
+		})
+	})
+
+	mux.HandleFunc("GET /api/manga", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-MAL-CLIENT-ID") != "client-id" && !f.authorized(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{"data": []any{
+			map[string]any{"node": map[string]any{"id": 121496, "title": "Solo Leveling", "num_chapters": 201, "num_volumes": 14}},
+		}})
+	})
+
+	mux.HandleFunc("GET /api/manga/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if !f.authorized(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		manga := map[string]any{"id": id, "title": "Solo Leveling"}
+
+		f.mu.Lock()
+		if status, ok := f.statuses[r.PathValue("id")]; ok {
+			manga["my_list_status"] = status
+		}
+		f.mu.Unlock()
+
+		json.NewEncoder(w).Encode(manga)
+	})
+
+	mux.HandleFunc("PATCH /api/manga/{id}/my_list_status", func(w http.ResponseWriter, r *http.Request) {
+		if !f.authorized(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		chapters, _ := strconv.Atoi(r.FormValue("num_chapters_read"))
+		volumes, _ := strconv.Atoi(r.FormValue("num_volumes_read"))
+		status := map[string]any{
+			"status":            r.FormValue("status"),
+			"is_rereading":      r.FormValue("is_rereading") == "true",
+			"num_chapters_read": chapters,
+			"num_volumes_read":  volumes,
+		}
+
+		f.mu.Lock()
+		f.statuses[r.PathValue("id")] = status
+		f.mu.Unlock()
+
+		json.NewEncoder(w).Encode(status)
+	})
+
+	return mux
+}
+
+func (f *fakeMyAnimeList) authorized(r *http.Request) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return r.Header.Get("Authorization") == "Bearer "+f.accessToken
+}
+
+func newFake() *fakeMyAnimeList {
+	return &fakeMyAnimeList{
+		accessToken:  "access-0",
+		refreshToken: "refresh-0",
+		statuses: map[string]map[string]any{
+			"121496": {"status": "reading", "is_rereading": false, "num_chapters_read": 10, "num_volumes_read": 1},
+		},
+	}
+}
+
+func setup(t *testing.T, token trackers.Token) (trackers.Tracker, *fakeMyAnimeList, *[]trackers.Token) {
+	t.Helper()
+
+	fake := newFake()
+	server := httptest.NewServer(fake.handler())
+	t.Cleanup(server.Close)
+
+	var refreshed []trackers.Token
+	client := myanimelist.NewMyAnimeList(trackers.ClientConfig{
+		APIURL:   server.URL + "/api",
+		AuthURL:  server.URL + "/oauth2",
+		ClientID: "client-id",
+		Token:    token,
+		OnTokenRefresh: func(_ context.Context, token trackers.Token) error {
+			refreshed = append(refreshed, token)
+			return nil
+		},
+	})
+
+	return client, fake, &refreshed
+}
+
+func TestMyAnimeListSearchMedia(t *testing.T) {
+	t.Parallel()
+
+	client, _, _ := setup(t, trackers.Token{})
+
+	media, err := client.SearchMedia(t.Context(), "solo leveling")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(media) != 1 || media[0].ID != "121496" || media[0].Chapters != 201 || media[0].URL != "https://myanimelist.net/manga/121496" {
+		t.Errorf("Unexpected results %+v", media)
+	}
+}
+
+func TestMyAnimeListEntries(t *testing.T) {
+	t.Parallel()
+
+	client, fake, _ := setup(t, trackers.Token{AccessToken: "access-0", RefreshToken: "refresh-0", ExpiresAt: time.Now().Add(time.Hour)})
+
+	entry, err := client.GetEntry(t.Context(), "121496")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Status != trackers.STATUS_READING || entry.Progress != 10 {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+
+	_, err = client.GetEntry(t.Context(), "2")
+	if !errors.Is(err, trackers.ErrEntryNotFound) {
+		t.Errorf("Media not in list should return ErrEntryNotFound, got %v", err)
+	}
+
+	entry, err = client.UpdateEntry(t.Context(), trackers.Entry{MediaID: "2", Status: trackers.STATUS_REREADING, Progress: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Status != trackers.STATUS_REREADING || entry.Progress != 4 {
+		t.Errorf("Unexpected updated entry %+v", entry)
+	}
+
+	if fake.statuses["2"]["status"] != "completed" || fake.statuses["2"]["is_rereading"] != true {
+		t.Errorf("Rereading should be sent as completed with is_rereading, got %v", fake.statuses["2"])
+	}
+}
+
+func TestMyAnimeListRefreshExpiredToken(t *testing.T) {
+	t.Parallel()
+
+	client, fake, refreshed := setup(t, trackers.Token{AccessToken: "access-0", RefreshToken: "refresh-0", ExpiresAt: time.Now().Add(-time.Hour)})
+
+	_, err := client.GetEntry(t.Context(), "121496")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fake.refreshes != 1 || len(*refreshed) != 1 || (*refreshed)[0].AccessToken != "access-1" {
+		t.Errorf("Expired token should be refreshed once and persisted, got %d refreshes and %+v", fake.refreshes, *refreshed)
+	}
+}
+
+func TestMyAnimeListRefreshRevokedToken(t *testing.T) {
+	t.Parallel()
+
+	client, fake, _ := setup(t, trackers.Token{AccessToken: "revoked", RefreshToken: "refresh-0", ExpiresAt: time.Now().Add(time.Hour)})
+
+	_, err := client.GetEntry(t.Context(), "121496")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fake.refreshes != 1 {
+		t.Errorf("Refused token should be refreshed, got %d refreshes", fake.refreshes)
+	}
+
+	client, _, _ = setup(t, trackers.Token{AccessToken: "revoked"})
+
+	_, err = client.GetEntry(t.Context(), "121496")
+	if !errors.Is(err, trackers.ErrUnauthorized) {
+		t.Errorf("Without refresh token, expected ErrUnauthorized, got %v", err)
+	}
+}
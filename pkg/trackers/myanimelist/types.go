@@ -0,0 +1,58 @@
+package myanimelist
+
+import (
+	"fmt"
+
+	"dokusho/pkg/trackers"
+)
+
+type MyAnimeListStatus string
+
+const (
+	READING      MyAnimeListStatus = "reading"
+	COMPLETED    MyAnimeListStatus = "completed"
+	ON_HOLD      MyAnimeListStatus = "on_hold"
+	DROPPED      MyAnimeListStatus = "dropped"
+	PLAN_TO_READ MyAnimeListStatus = "plan_to_read"
+)
+
+// ConvertMyAnimeListStatus converts the list status, MyAnimeList has no rereading status but a separate flag
+func ConvertMyAnimeListStatus(status string, rereading bool) (trackers.ReadingStatus, error) {
+	if rereading {
+		return trackers.STATUS_REREADING, nil
+	}
+
+	switch MyAnimeListStatus(status) {
+	case READING:
+		return trackers.STATUS_READING, nil
+	case COMPLETED:
+		return trackers.STATUS_COMPLETED, nil
+	case ON_HOLD:
+		return trackers.STATUS_PAUSED, nil
+	case DROPPED:
+		return trackers.STATUS_DROPPED, nil
+	case PLAN_TO_READ:
+		return trackers.STATUS_PLANNING, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", trackers.ErrInvalidStatus, status)
+}
+
+func ConvertToMyAnimeListStatus(status trackers.ReadingStatus) (MyAnimeListStatus, bool, error) {
+	switch status {
+	case trackers.STATUS_READING:
+		return READING, false, nil
+	case trackers.STATUS_REREADING:
+		return COMPLETED, true, nil
+	case trackers.STATUS_COMPLETED:
+		return COMPLETED, false, nil
+	case trackers.STATUS_PAUSED:
+		return ON_HOLD, false, nil
+	case trackers.STATUS_DROPPED:
+		return DROPPED, false, nil
+	case trackers.STATUS_PLANNING:
+		return PLAN_TO_READ, false, nil
+	}
+
+	return "", false, fmt.Errorf("%w: %s", trackers.ErrInvalidStatus, status)
+}
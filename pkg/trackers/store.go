@@ -0,0 +1,194 @@
+package trackers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Link ties a library serie to a media on a tracker, Entry is the last state pushed to the tracker
+type Link struct {
+	LibrarySerieID uuid.UUID  `json:"librarySerieId"`
+	Tracker        TrackerID  `json:"tracker"`
+	Entry          Entry      `json:"entry"`
+	SyncedAt       *time.Time `json:"syncedAt"`
+	LastError      string     `json:"lastError,omitempty"`
+}
+
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+func NewStore(pool *pgxpool.Pool) *Store {
+	return &Store{pool: pool}
+}
+
+// SaveAccount links the tracker account of the user, replacing the one already linked
+func (s *Store) SaveAccount(ctx context.Context, userID string, tracker TrackerID, token Token) error {
+	var expiresAt *time.Time
+	if !token.ExpiresAt.IsZero() {
+		expiresAt = &token.ExpiresAt
+	}
+
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO tracker_accounts (user_id, tracker, access_token, refresh_token, expires_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, tracker) DO UPDATE SET
+			access_token = EXCLUDED.access_token,
+			refresh_token = EXCLUDED.refresh_token,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = now()`,
+		userID, tracker, token.AccessToken, token.RefreshToken, expiresAt,
+	)
+	if err != nil {
+		return errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return nil
+}
+
+func (s *Store) GetAccount(ctx context.Context, userID string, tracker TrackerID) (Token, error) {
+	var token Token
+	var expiresAt *time.Time
+
+	err := s.pool.QueryRow(ctx, `SELECT access_token, refresh_token, expires_at FROM tracker_accounts WHERE user_id = $1 AND tracker = $2`, userID, tracker).
+		Scan(&token.AccessToken, &token.RefreshToken, &expiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Token{}, ErrAccountNotFound
+	}
+	if err != nil {
+		return Token{}, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	if expiresAt != nil {
+		token.ExpiresAt = *expiresAt
+	}
+
+	return token, nil
+}
+
+// ListAccounts returns the trackers the user linked an account of
+func (s *Store) ListAccounts(ctx context.Context, userID string) ([]TrackerID, error) {
+	rows, err := s.pool.Query(ctx, `SELECT tracker FROM tracker_accounts WHERE user_id = $1 ORDER BY tracker`, userID)
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[TrackerID])
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return ids, nil
+}
+
+func (s *Store) DeleteAccount(ctx context.Context, userID string, tracker TrackerID) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM tracker_accounts WHERE user_id = $1 AND tracker = $2`, userID, tracker)
+	if err != nil {
+		return errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return nil
+}
+
+// LinkSerie links the serie to the media, relinking to another media resets the synced state
+func (s *Store) LinkSerie(ctx context.Context, librarySerieID uuid.UUID, tracker TrackerID, mediaID string) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO tracker_links (library_serie_id, tracker, media_id) VALUES ($1, $2, $3)
+		ON CONFLICT (library_serie_id, tracker) DO UPDATE SET
+			media_id = EXCLUDED.media_id,
+			status = CASE WHEN tracker_links.media_id = EXCLUDED.media_id THEN tracker_links.status ELSE '' END,
+			progress = CASE WHEN tracker_links.media_id = EXCLUDED.media_id THEN tracker_links.progress ELSE 0 END,
+			progress_volumes = CASE WHEN tracker_links.media_id = EXCLUDED.media_id THEN tracker_links.progress_volumes ELSE 0 END,
+			synced_at = CASE WHEN tracker_links.media_id = EXCLUDED.media_id THEN tracker_links.synced_at ELSE NULL END,
+			last_error = ''`,
+		librarySerieID, tracker, mediaID,
+	)
+	if err != nil {
+		return errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return nil
+}
+
+// LinkSerieIfMissing only links the serie when it's not linked yet, used to prefill links without overriding user choices
+func (s *Store) LinkSerieIfMissing(ctx context.Context, librarySerieID uuid.UUID, tracker TrackerID, mediaID string) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO tracker_links (library_serie_id, tracker, media_id) VALUES ($1, $2, $3)
+		ON CONFLICT (library_serie_id, tracker) DO NOTHING`,
+		librarySerieID, tracker, mediaID,
+	)
+	if err != nil {
+		return errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return nil
+}
+
+func (s *Store) UnlinkSerie(ctx context.Context, librarySerieID uuid.UUID, tracker TrackerID) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM tracker_links WHERE library_serie_id = $1 AND tracker = $2`, librarySerieID, tracker)
+	if err != nil {
+		return errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return nil
+}
+
+const linkColumns = `library_serie_id, tracker, media_id, status, progress, progress_volumes, synced_at, last_error`
+
+func scanLink(row pgx.Row) (Link, error) {
+	var link Link
+	err := row.Scan(&link.LibrarySerieID, &link.Tracker, &link.Entry.MediaID, &link.Entry.Status, &link.Entry.Progress, &link.Entry.ProgressVolumes, &link.SyncedAt, &link.LastError)
+	return link, err
+}
+
+func (s *Store) GetLink(ctx context.Context, librarySerieID uuid.UUID, tracker TrackerID) (Link, error) {
+	link, err := scanLink(s.pool.QueryRow(ctx, `SELECT `+linkColumns+` FROM tracker_links WHERE library_serie_id = $1 AND tracker = $2`, librarySerieID, tracker))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Link{}, ErrLinkNotFound
+	}
+	if err != nil {
+		return Link{}, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return link, nil
+}
+
+func (s *Store) GetLinks(ctx context.Context, librarySerieID uuid.UUID) ([]Link, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+linkColumns+` FROM tracker_links WHERE library_serie_id = $1 ORDER BY tracker`, librarySerieID)
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	links, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Link, error) {
+		return scanLink(row)
+	})
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return links, nil
+}
+
+// SaveSync records the entry returned by the tracker after a sync, or the error when the sync failed
+func (s *Store) SaveSync(ctx context.Context, librarySerieID uuid.UUID, tracker TrackerID, entry Entry, syncErr error) error {
+	var err error
+
+	if syncErr != nil {
+		_, err = s.pool.Exec(ctx, `UPDATE tracker_links SET last_error = $3 WHERE library_serie_id = $1 AND tracker = $2`,
+			librarySerieID, tracker, syncErr.Error())
+	} else {
+		_, err = s.pool.Exec(ctx, `
+			UPDATE tracker_links SET status = $4, progress = $5, progress_volumes = $6, synced_at = now(), last_error = ''
+			WHERE library_serie_id = $1 AND tracker = $2 AND media_id = $3`,
+			librarySerieID, tracker, entry.MediaID, entry.Status, entry.Progress, entry.ProgressVolumes)
+	}
+	if err != nil {
+		return errors.Join(ErrDatabaseQuery, err)
+	}
+
+	return nil
+}
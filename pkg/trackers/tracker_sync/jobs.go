@@ -0,0 +1,151 @@
+package tracker_sync
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"dokusho/pkg/trackers"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/riverqueue/river"
+)
+
+const QueueTrackers = "trackers"
+
+// SyncEntryArgs pushes the read progress and status of a library serie to one of its linked trackers, with the account of the user
+type SyncEntryArgs struct {
+	UserID          string                 `json:"user_id"`
+	LibrarySerieID  uuid.UUID              `json:"library_serie_id"`
+	Tracker         trackers.TrackerID     `json:"tracker"`
+	Progress        int                    `json:"progress"`
+	ProgressVolumes int                    `json:"progress_volumes"`
+	Status          trackers.ReadingStatus `json:"status,omitempty"`
+}
+
+func (SyncEntryArgs) Kind() string { return "tracker_sync_entry" }
+
+func (SyncEntryArgs) InsertOpts() river.InsertOpts {
+	return river.InsertOpts{Queue: QueueTrackers, MaxAttempts: 8}
+}
+
+type SyncEntryWorker struct {
+	river.WorkerDefaults[SyncEntryArgs]
+
+	store    *trackers.Store
+	registry *Registry
+	logger   *slog.Logger
+}
+
+func NewSyncEntryWorker(store *trackers.Store, registry *Registry) *SyncEntryWorker {
+	return &SyncEntryWorker{
+		store:    store,
+		registry: registry,
+		logger:   slog.Default().WithGroup("tracker_sync"),
+	}
+}
+
+func (w *SyncEntryWorker) Work(ctx context.Context, job *river.Job[SyncEntryArgs]) error {
+	args := job.Args
+
+	link, err := w.store.GetLink(ctx, args.LibrarySerieID, args.Tracker)
+	if errors.Is(err, trackers.ErrLinkNotFound) {
+		// Unlinked since the job was enqueued
+		return river.JobCancel(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := w.store.GetAccount(ctx, args.UserID, args.Tracker)
+	if errors.Is(err, trackers.ErrAccountNotFound) {
+		return river.JobCancel(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	tracker, err := w.registry.New(args.Tracker, token, func(ctx context.Context, token trackers.Token) error {
+		return w.store.SaveAccount(ctx, args.UserID, args.Tracker, token)
+	})
+	if err != nil {
+		return river.JobCancel(err)
+	}
+
+	entry, err := trackers.Sync(ctx, tracker, trackers.Entry{
+		MediaID:         link.Entry.MediaID,
+		Status:          args.Status,
+		Progress:        args.Progress,
+		ProgressVolumes: args.ProgressVolumes,
+	})
+	if err != nil {
+		w.logger.Warn("Failed to sync tracker entry", "tracker", args.Tracker, "media_id", link.Entry.MediaID, "attempt", job.Attempt, "error", err)
+
+		serr := w.store.SaveSync(ctx, args.LibrarySerieID, args.Tracker, trackers.Entry{}, err)
+		if serr != nil {
+			w.logger.Error("Failed to save sync error", "error", serr)
+		}
+
+		switch {
+		case errors.Is(err, trackers.ErrUnauthorized):
+			// Retrying won't help until the account is linked again
+			return river.JobCancel(err)
+		case errors.Is(err, trackers.ErrRateLimited):
+			return river.JobSnooze(time.Minute)
+		}
+
+		return err
+	}
+
+	w.logger.Info("Synced tracker entry", "tracker", args.Tracker, "media_id", entry.MediaID, "progress", entry.Progress, "status", entry.Status)
+
+	return w.store.SaveSync(ctx, args.LibrarySerieID, args.Tracker, entry, nil)
+}
+
+func AddWorkers(workers *river.Workers, store *trackers.Store, registry *Registry) {
+	river.AddWorker(workers, NewSyncEntryWorker(store, registry))
+}
+
+// EnqueueSync enqueues a sync job for every tracker linked to the library serie, the trackers the user has no account of are skipped.
+// It returns the number of jobs enqueued.
+func EnqueueSync(ctx context.Context, client *river.Client[pgx.Tx], store *trackers.Store, userID string, librarySerieID uuid.UUID, progress int, progressVolumes int, status trackers.ReadingStatus) (int, error) {
+	links, err := store.GetLinks(ctx, librarySerieID)
+	if err != nil {
+		return 0, err
+	}
+
+	accounts, err := store.ListAccounts(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	params := make([]river.InsertManyParams, 0, len(links))
+	for _, link := range links {
+		if !slices.Contains(accounts, link.Tracker) {
+			continue
+		}
+
+		params = append(params, river.InsertManyParams{Args: SyncEntryArgs{
+			UserID:          userID,
+			LibrarySerieID:  librarySerieID,
+			Tracker:         link.Tracker,
+			Progress:        progress,
+			ProgressVolumes: progressVolumes,
+			Status:          status,
+		}})
+	}
+
+	if len(params) == 0 {
+		return 0, nil
+	}
+
+	_, err = client.InsertMany(ctx, params)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(params), nil
+}
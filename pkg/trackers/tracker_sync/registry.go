@@ -0,0 +1,80 @@
+package tracker_sync
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"dokusho/pkg/config"
+	"dokusho/pkg/trackers"
+	"dokusho/pkg/trackers/anilist"
+	"dokusho/pkg/trackers/myanimelist"
+)
+
+// Client is a tracker able to authenticate a user
+type Client interface {
+	trackers.Tracker
+	trackers.Authenticator
+}
+
+// Registry builds tracker clients for the trackers configured with a client id
+type Registry struct {
+	cfg        config.TrackerBaseConfig
+	backendURL string
+}
+
+func NewRegistry(cfg config.TrackerBaseConfig, backendURL string) *Registry {
+	return &Registry{cfg: cfg, backendURL: backendURL}
+}
+
+// Enabled returns the trackers configured with a client id
+func (r *Registry) Enabled() []trackers.TrackerID {
+	var ids []trackers.TrackerID
+
+	if r.cfg.AniListClientID != "" {
+		ids = append(ids, trackers.TRACKER_ANILIST)
+	}
+
+	if r.cfg.MyAnimeListClientID != "" {
+		ids = append(ids, trackers.TRACKER_MYANIMELIST)
+	}
+
+	return ids
+}
+
+// New returns a client of the tracker authenticated with the token, onRefresh is called when the token is refreshed
+func (r *Registry) New(id trackers.TrackerID, token trackers.Token, onRefresh func(ctx context.Context, token trackers.Token) error) (Client, error) {
+	redirectURL, err := url.JoinPath(r.backendURL, "/api/v1/trackers", string(id), "callback")
+	if err != nil {
+		return nil, err
+	}
+
+	switch id {
+	case trackers.TRACKER_ANILIST:
+		if r.cfg.AniListClientID == "" {
+			break
+		}
+
+		return anilist.NewAniList(trackers.ClientConfig{
+			ClientID:       r.cfg.AniListClientID,
+			ClientSecret:   r.cfg.AniListClientSecret,
+			RedirectURL:    redirectURL,
+			Token:          token,
+			OnTokenRefresh: onRefresh,
+		}), nil
+	case trackers.TRACKER_MYANIMELIST:
+		if r.cfg.MyAnimeListClientID == "" {
+			break
+		}
+
+		return myanimelist.NewMyAnimeList(trackers.ClientConfig{
+			ClientID:       r.cfg.MyAnimeListClientID,
+			ClientSecret:   r.cfg.MyAnimeListClientSecret,
+			RedirectURL:    redirectURL,
+			Token:          token,
+			OnTokenRefresh: onRefresh,
+		}), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s is not configured", trackers.ErrUnknownTracker, id)
+}
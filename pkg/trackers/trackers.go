@@ -0,0 +1,162 @@
+package trackers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"dokusho/pkg/sources/source_types"
+)
+
+type TrackerID string
+
+const (
+	TRACKER_ANILIST     TrackerID = "anilist"
+	TRACKER_MYANIMELIST TrackerID = "myanimelist"
+)
+
+func NewTrackerID(id string) (TrackerID, error) {
+	switch TrackerID(id) {
+	case TRACKER_ANILIST, TRACKER_MYANIMELIST:
+		return TrackerID(id), nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrUnknownTracker, id)
+}
+
+// ReadingStatus is the tracker agnostic reading status, every tracker converts it to its own values
+type ReadingStatus string
+
+const (
+	STATUS_READING   ReadingStatus = "reading"
+	STATUS_COMPLETED ReadingStatus = "completed"
+	STATUS_PAUSED    ReadingStatus = "paused"
+	STATUS_DROPPED   ReadingStatus = "dropped"
+	STATUS_PLANNING  ReadingStatus = "planning"
+	STATUS_REREADING ReadingStatus = "rereading"
+)
+
+func NewReadingStatus(status string) (ReadingStatus, error) {
+	switch ReadingStatus(status) {
+	case STATUS_READING, STATUS_COMPLETED, STATUS_PAUSED, STATUS_DROPPED, STATUS_PLANNING, STATUS_REREADING:
+		return ReadingStatus(status), nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrInvalidStatus, status)
+}
+
+// Media is a serie on a tracker
+type Media struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Chapters int    `json:"chapters,omitempty"`
+	Volumes  int    `json:"volumes,omitempty"`
+	URL      string `json:"url"`
+}
+
+// Entry is the user list entry of a media on a tracker
+type Entry struct {
+	MediaID         string        `json:"mediaId"`
+	Status          ReadingStatus `json:"status"`
+	Progress        int           `json:"progress"`
+	ProgressVolumes int           `json:"progressVolumes"`
+}
+
+type Token struct {
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	ExpiresAt    time.Time `json:"expiresAt,omitzero"`
+}
+
+// Expired reports whether the token is expired or about to, a zero ExpiresAt never expires
+func (t Token) Expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && now.Add(time.Minute).After(t.ExpiresAt)
+}
+
+type Tracker interface {
+	ID() TrackerID
+	SearchMedia(ctx context.Context, query string) ([]Media, error)
+	// GetEntry returns ErrEntryNotFound when the media is not in the user list
+	GetEntry(ctx context.Context, mediaID string) (Entry, error)
+	UpdateEntry(ctx context.Context, entry Entry) (Entry, error)
+}
+
+// Authenticator is implemented by trackers using the OAuth2 authorization code flow
+type Authenticator interface {
+	AuthorizeURL(state, codeVerifier string) string
+	ExchangeCode(ctx context.Context, code, codeVerifier string) (Token, error)
+}
+
+// LinksFromExternal returns the tracker media IDs known by the source for a serie
+func LinksFromExternal(links source_types.SourceSerieExternalLinks) map[TrackerID]string {
+	ids := map[TrackerID]string{}
+
+	if links.AniList != "" {
+		ids[TRACKER_ANILIST] = links.AniList
+	}
+
+	if links.MyAnimeList != "" {
+		ids[TRACKER_MYANIMELIST] = links.MyAnimeList
+	}
+
+	return ids
+}
+
+// MergeEntry merges the local update into the tracker entry, progress never goes backward
+// so reading on another device or an out of order job doesn't undo progress on the tracker.
+// changed is false when nothing needs to be pushed.
+func MergeEntry(remote Entry, update Entry) (merged Entry, changed bool) {
+	merged = remote
+	merged.MediaID = update.MediaID
+
+	if update.Progress > merged.Progress {
+		merged.Progress = update.Progress
+		changed = true
+	}
+
+	if update.ProgressVolumes > merged.ProgressVolumes {
+		merged.ProgressVolumes = update.ProgressVolumes
+		changed = true
+	}
+
+	if update.Status != "" && update.Status != merged.Status {
+		merged.Status = update.Status
+		changed = true
+	}
+
+	// Reading a chapter of something planned means it is being read
+	if changed && update.Status == "" && (merged.Status == "" || merged.Status == STATUS_PLANNING) {
+		merged.Status = STATUS_READING
+	}
+
+	return merged, changed
+}
+
+// Sync pushes the update to the tracker, the entry is created when it's not yet in the user list
+func Sync(ctx context.Context, tracker Tracker, update Entry) (Entry, error) {
+	remote, err := tracker.GetEntry(ctx, update.MediaID)
+	if err != nil && !errors.Is(err, ErrEntryNotFound) {
+		return Entry{}, err
+	}
+
+	merged, changed := MergeEntry(remote, update)
+	if !changed {
+		return remote, nil
+	}
+
+	return tracker.UpdateEntry(ctx, merged)
+}
+
+// ClientConfig configures a tracker client, empty URLs default to the public tracker endpoints
+type ClientConfig struct {
+	APIURL       string
+	AuthURL      string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Token        Token
+	// OnTokenRefresh is called with the new token after a refresh, so it can be persisted
+	OnTokenRefresh func(ctx context.Context, token Token) error
+	Timeout        time.Duration
+}
@@ -0,0 +1,139 @@
+package trackers_test
+
+import (
+	"context"
+	"testing"
+
+	"dokusho/pkg/trackers"
+)
+
+type fakeTracker struct {
+	entries map[string]trackers.Entry
+	updates int
+}
+
+func (f *fakeTracker) ID() trackers.TrackerID { return trackers.TRACKER_ANILIST }
+
+func (f *fakeTracker) SearchMedia(context.Context, string) ([]trackers.Media, error) {
+	return nil, nil
+}
+
+func (f *fakeTracker) GetEntry(_ context.Context, mediaID string) (trackers.Entry, error) {
+	entry, ok := f.entries[mediaID]
+	if !ok {
+		return trackers.Entry{}, trackers.ErrEntryNotFound
+	}
+
+	return entry, nil
+}
+
+func (f *fakeTracker) UpdateEntry(_ context.Context, entry trackers.Entry) (trackers.Entry, error) {
+	f.updates++
+	f.entries[entry.MediaID] = entry
+
+	return entry, nil
+}
+
+func TestMergeEntry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		remote   trackers.Entry
+		update   trackers.Entry
+		expected trackers.Entry
+		changed  bool
+	}{
+		{
+			name:     "progress forward",
+			remote:   trackers.Entry{MediaID: "1", Status: trackers.STATUS_READING, Progress: 10},
+			update:   trackers.Entry{MediaID: "1", Progress: 12},
+			expected: trackers.Entry{MediaID: "1", Status: trackers.STATUS_READING, Progress: 12},
+			changed:  true,
+		},
+		{
+			name:     "progress never goes backward",
+			remote:   trackers.Entry{MediaID: "1", Status: trackers.STATUS_READING, Progress: 20, ProgressVolumes: 3},
+			update:   trackers.Entry{MediaID: "1", Progress: 12, ProgressVolumes: 2},
+			expected: trackers.Entry{MediaID: "1", Status: trackers.STATUS_READING, Progress: 20, ProgressVolumes: 3},
+			changed:  false,
+		},
+		{
+			name:     "planned becomes reading",
+			remote:   trackers.Entry{MediaID: "1", Status: trackers.STATUS_PLANNING},
+			update:   trackers.Entry{MediaID: "1", Progress: 1},
+			expected: trackers.Entry{MediaID: "1", Status: trackers.STATUS_READING, Progress: 1},
+			changed:  true,
+		},
+		{
+			name:     "new entry",
+			remote:   trackers.Entry{},
+			update:   trackers.Entry{MediaID: "1", Progress: 3},
+			expected: trackers.Entry{MediaID: "1", Status: trackers.STATUS_READING, Progress: 3},
+			changed:  true,
+		},
+		{
+			name:     "status change only",
+			remote:   trackers.Entry{MediaID: "1", Status: trackers.STATUS_READING, Progress: 50},
+			update:   trackers.Entry{MediaID: "1", Status: trackers.STATUS_COMPLETED, Progress: 50},
+			expected: trackers.Entry{MediaID: "1", Status: trackers.STATUS_COMPLETED, Progress: 50},
+			changed:  true,
+		},
+		{
+			name:     "paused stays paused",
+			remote:   trackers.Entry{MediaID: "1", Status: trackers.STATUS_PAUSED, Progress: 5},
+			update:   trackers.Entry{MediaID: "1", Progress: 6},
+			expected: trackers.Entry{MediaID: "1", Status: trackers.STATUS_PAUSED, Progress: 6},
+			changed:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			merged, changed := trackers.MergeEntry(tc.remote, tc.update)
+			if merged != tc.expected || changed != tc.changed {
+				t.Errorf("MergeEntry() = %+v, %v, expected %+v, %v", merged, changed, tc.expected, tc.changed)
+			}
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	tracker := &fakeTracker{entries: map[string]trackers.Entry{
+		"1": {MediaID: "1", Status: trackers.STATUS_READING, Progress: 10},
+	}}
+
+	entry, err := trackers.Sync(context.Background(), tracker, trackers.Entry{MediaID: "1", Progress: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tracker.updates != 0 || entry.Progress != 10 {
+		t.Errorf("Older progress should not be pushed, got %d updates and progress %d", tracker.updates, entry.Progress)
+	}
+
+	entry, err = trackers.Sync(context.Background(), tracker, trackers.Entry{MediaID: "2", Progress: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tracker.updates != 1 || entry.Progress != 4 || entry.Status != trackers.STATUS_READING {
+		t.Errorf("Missing entry should be created as reading, got %+v", entry)
+	}
+}
+
+func TestNewTrackerID(t *testing.T) {
+	t.Parallel()
+
+	if _, err := trackers.NewTrackerID("kitsu"); err == nil {
+		t.Error("Unknown tracker should be refused")
+	}
+
+	if id, err := trackers.NewTrackerID("anilist"); err != nil || id != trackers.TRACKER_ANILIST {
+		t.Errorf("NewTrackerID(anilist) = %s, %v", id, err)
+	}
+}